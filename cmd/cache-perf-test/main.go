@@ -12,6 +12,7 @@ import (
 
 	"gocreator/internal/interfaces"
 	"gocreator/internal/services"
+	"gocreator/internal/timeline"
 
 	"github.com/openai/openai-go/v3"
 	"github.com/spf13/afero"
@@ -34,7 +35,7 @@ func NewMockOpenAIClient(translationDelay, ttsDelay time.Duration) *MockOpenAICl
 	}
 }
 
-func (m *MockOpenAIClient) ChatCompletion(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion) (string, error) {
+func (m *MockOpenAIClient) ChatCompletion(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion, options interfaces.ChatOptions) (string, error) {
 	m.CallCount.Translation++
 	time.Sleep(m.TranslationDelay)
 	
@@ -42,7 +43,7 @@ func (m *MockOpenAIClient) ChatCompletion(ctx context.Context, messages []openai
 	return "Mock translation", nil
 }
 
-func (m *MockOpenAIClient) GenerateSpeech(ctx context.Context, text string) (io.ReadCloser, error) {
+func (m *MockOpenAIClient) GenerateSpeech(ctx context.Context, text string, voice interfaces.Voice) (io.ReadCloser, error) {
 	m.CallCount.TTS++
 	time.Sleep(m.TTSDelay)
 	
@@ -213,11 +214,12 @@ func runScenario(scenario Scenario) []PerformanceMetrics {
 		slideService := services.NewSlideService(fs, logger)
 		
 		// Configure transitions
+		transition := services.TransitionConfig{Type: services.TransitionNone}
 		if scenario.TransitionEnabled {
-			videoService.SetTransition(services.TransitionConfig{
+			transition = services.TransitionConfig{
 				Type:     services.TransitionFade,
 				Duration: 0.5,
-			})
+			}
 		}
 		
 		// Create video creator
@@ -287,12 +289,18 @@ func runScenario(scenario Scenario) []PerformanceMetrics {
 			}
 			
 			// Generate audio
-			audioPaths, _ := audioService.GenerateBatch(ctx, texts, audioDir)
+			audioPaths, _ := audioService.GenerateBatch(ctx, texts, nil, audioDir)
 			
 			// Generate video
 			outputDir := filepath.Join(dataDir, "out")
 			outputPath := filepath.Join(outputDir, fmt.Sprintf("output-%s.mp4", lang))
-			if err := videoService.GenerateFromSlides(ctx, slides, audioPaths, outputPath); err != nil {
+			tl, err := timeline.FromSlides(slides, audioPaths)
+			if err != nil {
+				log.Printf("Warning: failed to build timeline: %v", err)
+				continue
+			}
+			transition.ApplyTo(&tl)
+			if err := videoService.GenerateFromTimeline(ctx, tl, outputPath); err != nil {
 				log.Printf("Warning: failed to generate video: %v", err)
 			}
 		}
@@ -221,7 +221,7 @@ func runRealAPITests(ctx context.Context, fs afero.Fs, dataDir string, results *
 	// Measure audio generation
 	start = time.Now()
 	audioDir := filepath.Join(dataDir, "cache", "es", "audio")
-	audioPaths, err := audioService.GenerateBatch(ctx, translatedTexts, audioDir)
+	audioPaths, err := audioService.GenerateBatch(ctx, translatedTexts, nil, audioDir)
 	audioDur := time.Since(start)
 	if err != nil {
 		fmt.Printf("  Audio generation error: %v\n", err)
@@ -265,7 +265,7 @@ func runRealAPITests(ctx context.Context, fs afero.Fs, dataDir string, results *
 
 	// Measure audio generation with cache
 	start = time.Now()
-	cachedAudioPaths, err := audioService.GenerateBatch(ctx, translatedTexts, audioDir)
+	cachedAudioPaths, err := audioService.GenerateBatch(ctx, translatedTexts, nil, audioDir)
 	cachedAudioDur := time.Since(start)
 	if err != nil {
 		fmt.Printf("  Audio generation error: %v\n", err)
@@ -0,0 +1,74 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DeepL API endpoints: keys of the free plan end in ":fx" and use the free endpoint
+const (
+	deepLURL     = "https://api.deepl.com"
+	deepLFreeURL = "https://api-free.deepl.com"
+)
+
+// DeepLAdapter translates with a DeepL-compatible REST API
+type DeepLAdapter struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewDeepLAdapter creates a DeepL adapter for the API at baseURL, or DeepL's own API matching apiKey when empty
+func NewDeepLAdapter(baseURL, apiKey string) *DeepLAdapter {
+	if baseURL == "" {
+		baseURL = deepLURL
+		if strings.HasSuffix(apiKey, ":fx") {
+			baseURL = deepLFreeURL
+		}
+	}
+	return &DeepLAdapter{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: httpTimeout},
+	}
+}
+
+type deepLRequest struct {
+	Text       []string `json:"text"`
+	TargetLang string   `json:"target_lang"`
+}
+
+type deepLResponse struct {
+	Translations []struct {
+		Text string `json:"text"`
+	} `json:"translations"`
+	Message string `json:"message"`
+}
+
+// Translate translates text to targetLang, detecting the source language
+func (a *DeepLAdapter) Translate(ctx context.Context, text, targetLang string) (string, error) {
+	// DeepL language codes are upper case, with the variant: EN-GB, PT-BR
+	body, err := json.Marshal(deepLRequest{Text: []string{text}, TargetLang: strings.ToUpper(targetLang)})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/v2/translate", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "DeepL-Auth-Key "+a.apiKey)
+
+	var resp deepLResponse
+	if err := doJSON(a.client, req, &resp, func() string { return resp.Message }); err != nil {
+		return "", fmt.Errorf("deepl: %w", err)
+	}
+	if len(resp.Translations) != 1 {
+		return "", fmt.Errorf("deepl: expected 1 translation, got %d", len(resp.Translations))
+	}
+	return resp.Translations[0].Text, nil
+}
@@ -0,0 +1,61 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeepLAdapter_Translate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("translates", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "/v2/translate", r.URL.Path)
+			assert.Equal(t, "DeepL-Auth-Key secret", r.Header.Get("Authorization"))
+			var req deepLRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, deepLRequest{Text: []string{"Hello ⟦1⟧"}, TargetLang: "PT-BR"}, req)
+			_, _ = w.Write([]byte(`{"translations":[{"detected_source_language":"EN","text":"Olá ⟦1⟧"}]}`))
+		}))
+		defer server.Close()
+
+		translated, err := NewDeepLAdapter(server.URL+"/", "secret").Translate(ctx, "Hello ⟦1⟧", "pt-br")
+		require.NoError(t, err)
+		assert.Equal(t, "Olá ⟦1⟧", translated)
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(456)
+			_, _ = w.Write([]byte(`{"message":"Quota exceeded"}`))
+		}))
+		defer server.Close()
+
+		_, err := NewDeepLAdapter(server.URL, "secret").Translate(ctx, "Hello", "fr")
+		require.Error(t, err)
+		assert.Equal(t, "deepl: status 456: Quota exceeded", err.Error())
+	})
+
+	t.Run("error status without a body", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		_, err := NewDeepLAdapter(server.URL, "wrong").Translate(ctx, "Hello", "fr")
+		assert.EqualError(t, err, "deepl: 403 Forbidden")
+	})
+}
+
+func TestNewDeepLAdapter_URL(t *testing.T) {
+	assert.Equal(t, "https://api.deepl.com", NewDeepLAdapter("", "key").baseURL)
+	assert.Equal(t, "https://api-free.deepl.com", NewDeepLAdapter("", "key:fx").baseURL)
+	assert.Equal(t, "http://localhost:8080", NewDeepLAdapter("http://localhost:8080/", "key:fx").baseURL)
+}
@@ -0,0 +1,42 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// httpTimeout bounds one request of the HTTP translation APIs
+const httpTimeout = 60 * time.Second
+
+// doJSON sends req and decodes its JSON response into out. A response with an error status
+// is an error, with the message errorMessage reads from out when the body has one.
+func doJSON(client *http.Client, req *http.Request, out any, errorMessage func() string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	decodeErr := json.Unmarshal(data, out)
+	if resp.StatusCode != http.StatusOK {
+		status := fmt.Sprintf("status %d", resp.StatusCode)
+		if text := http.StatusText(resp.StatusCode); text != "" {
+			status = fmt.Sprintf("%d %s", resp.StatusCode, text)
+		}
+		if message := errorMessage(); decodeErr == nil && message != "" {
+			return fmt.Errorf("%s: %s", status, message)
+		}
+		return fmt.Errorf("%s", status)
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("invalid response: %w", decodeErr)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// LibreTranslateAdapter translates with a LibreTranslate-compatible REST API
+type LibreTranslateAdapter struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewLibreTranslateAdapter creates a LibreTranslate adapter for the API at baseURL.
+// The API key is optional: self-hosted instances usually need none.
+func NewLibreTranslateAdapter(baseURL, apiKey string) *LibreTranslateAdapter {
+	return &LibreTranslateAdapter{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: httpTimeout},
+	}
+}
+
+type libreTranslateRequest struct {
+	Q      string `json:"q"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Format string `json:"format"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type libreTranslateResponse struct {
+	TranslatedText string `json:"translatedText"`
+	Error          string `json:"error"`
+}
+
+// Translate translates text to targetLang, detecting the source language
+func (a *LibreTranslateAdapter) Translate(ctx context.Context, text, targetLang string) (string, error) {
+	body, err := json.Marshal(libreTranslateRequest{Q: text, Source: "auto", Target: targetLang, Format: "text", APIKey: a.apiKey})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/translate", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var resp libreTranslateResponse
+	if err := doJSON(a.client, req, &resp, func() string { return resp.Error }); err != nil {
+		return "", fmt.Errorf("libretranslate: %w", err)
+	}
+	return resp.TranslatedText, nil
+}
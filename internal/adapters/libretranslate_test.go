@@ -0,0 +1,53 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibreTranslateAdapter_Translate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("translates", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/translate", r.URL.Path)
+			var req libreTranslateRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, libreTranslateRequest{Q: "Hello", Source: "auto", Target: "fr", Format: "text", APIKey: "key"}, req)
+			_, _ = w.Write([]byte(`{"translatedText":"Bonjour"}`))
+		}))
+		defer server.Close()
+
+		translated, err := NewLibreTranslateAdapter(server.URL, "key").Translate(ctx, "Hello", "fr")
+		require.NoError(t, err)
+		assert.Equal(t, "Bonjour", translated)
+	})
+
+	t.Run("error message", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"xx is not supported"}`))
+		}))
+		defer server.Close()
+
+		_, err := NewLibreTranslateAdapter(server.URL, "").Translate(ctx, "Hello", "xx")
+		assert.EqualError(t, err, "libretranslate: 400 Bad Request: xx is not supported")
+	})
+
+	t.Run("invalid response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`<html>`))
+		}))
+		defer server.Close()
+
+		_, err := NewLibreTranslateAdapter(server.URL, "").Translate(ctx, "Hello", "fr")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "libretranslate: invalid response")
+	})
+}
@@ -2,8 +2,11 @@ package adapters
 
 import (
 	"context"
+	"fmt"
 	"io"
 
+	"gocreator/internal/interfaces"
+
 	"github.com/openai/openai-go/v3"
 )
 
@@ -17,32 +20,68 @@ func NewOpenAIAdapter(client openai.Client) *OpenAIAdapter {
 	return &OpenAIAdapter{client: client}
 }
 
-// ChatCompletion sends a chat completion request
-func (a *OpenAIAdapter) ChatCompletion(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion) (string, error) {
-	resp, err := a.client.Chat.Completions.New(
-		ctx,
-		openai.ChatCompletionNewParams{
-			Model:    openai.ChatModelGPT4oMini,
-			Messages: messages,
-		},
-	)
+// ChatCompletion sends a chat completion request with the model and sampling of options. An answer
+// constrained by the schema of options uses the strict structured outputs of the API.
+func (a *OpenAIAdapter) ChatCompletion(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion, options interfaces.ChatOptions) (string, error) {
+	resp, err := a.client.Chat.Completions.New(ctx, chatParams(messages, options))
 	if err != nil {
 		return "", err
 	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no choices in response")
+	}
+	if refusal := resp.Choices[0].Message.Refusal; refusal != "" {
+		return "", fmt.Errorf("request refused: %s", refusal)
+	}
 	return resp.Choices[0].Message.Content, nil
 }
 
-// GenerateSpeech generates speech from text
-func (a *OpenAIAdapter) GenerateSpeech(ctx context.Context, text string) (io.ReadCloser, error) {
-	response, err := a.client.Audio.Speech.New(
-		ctx,
-		openai.AudioSpeechNewParams{
-			Model:          openai.SpeechModelTTS1HD,
-			Input:          text,
-			Voice:          "onyx",
-			ResponseFormat: openai.AudioSpeechNewParamsResponseFormatMP3,
-		},
-	)
+// chatParams returns the parameters of a chat completion request sending messages, with the
+// default model unless options name another, and a free text answer unless they set a schema
+func chatParams(messages []openai.ChatCompletionMessageParamUnion, options interfaces.ChatOptions) openai.ChatCompletionNewParams {
+	params := openai.ChatCompletionNewParams{
+		Model:    interfaces.DefaultChatModel,
+		Messages: messages,
+	}
+	if options.Model != "" {
+		params.Model = options.Model
+	}
+	if options.Temperature != nil {
+		params.Temperature = openai.Float(*options.Temperature)
+	}
+	if options.Schema != nil {
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{
+				JSONSchema: openai.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:   options.Schema.Name,
+					Schema: options.Schema.Schema,
+					Strict: openai.Bool(true),
+				},
+			},
+		}
+	}
+	return params
+}
+
+// defaultVoice is the voice speech is generated with unless another is requested
+const defaultVoice = "onyx"
+
+// GenerateSpeech generates speech from text with the given voice and speed
+func (a *OpenAIAdapter) GenerateSpeech(ctx context.Context, text string, voice interfaces.Voice) (io.ReadCloser, error) {
+	params := openai.AudioSpeechNewParams{
+		Model:          openai.SpeechModelTTS1HD,
+		Input:          text,
+		Voice:          defaultVoice,
+		ResponseFormat: openai.AudioSpeechNewParamsResponseFormatMP3,
+	}
+	if voice.Name != "" {
+		params.Voice = openai.AudioSpeechNewParamsVoice(voice.Name)
+	}
+	if voice.Speed != 0 {
+		params.Speed = openai.Float(voice.Speed)
+	}
+
+	response, err := a.client.Audio.Speech.New(ctx, params)
 	if err != nil {
 		return nil, err
 	}
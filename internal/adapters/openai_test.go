@@ -0,0 +1,53 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gocreator/internal/interfaces"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAIAdapter_ChatCompletion(t *testing.T) {
+	ctx := context.Background()
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"Bonjour"}}]}`))
+	}))
+	defer server.Close()
+	adapter := NewOpenAIAdapter(openai.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("key"), option.WithMaxRetries(0)))
+	messages := []openai.ChatCompletionMessageParamUnion{openai.UserMessage("Translate 'Hello' to fr")}
+
+	answer, err := adapter.ChatCompletion(ctx, messages, interfaces.ChatOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", answer)
+	temperature := 0.0
+	_, err = adapter.ChatCompletion(ctx, messages, interfaces.ChatOptions{Model: "gpt-4o", Temperature: &temperature})
+	require.NoError(t, err)
+	schema := interfaces.JSONSchema{Name: "answer", Schema: map[string]any{"type": "object"}}
+	_, err = adapter.ChatCompletion(ctx, messages, interfaces.ChatOptions{Schema: &schema})
+	require.NoError(t, err)
+
+	require.Len(t, requests, 3)
+	assert.Equal(t, "gpt-4o-mini", requests[0]["model"])
+	assert.NotContains(t, requests[0], "temperature")
+	assert.NotContains(t, requests[0], "response_format")
+	assert.Equal(t, "gpt-4o", requests[1]["model"])
+	assert.Equal(t, 0.0, requests[1]["temperature"])
+	assert.Equal(t, map[string]any{
+		"type":        "json_schema",
+		"json_schema": map[string]any{"name": "answer", "schema": map[string]any{"type": "object"}, "strict": true},
+	}, requests[2]["response_format"])
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"gocreator/internal/config"
+	"gocreator/internal/interfaces"
+	"gocreator/internal/services"
+	"gocreator/internal/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// buildAllOptions holds the flags of the build-all command
+type buildAllOptions struct {
+	noProgress     bool
+	jobs           int
+	apiConcurrency int
+	resume         bool
+	keepGoing      bool
+	reportPath     string
+}
+
+// NewBuildAllCommand creates the build-all command
+func NewBuildAllCommand() *cobra.Command {
+	var opts buildAllOptions
+
+	cmd := &cobra.Command{
+		Use:   "build-all [workspace-file]",
+		Short: "Create the videos of every project listed in a workspace file",
+		Long: `Create the videos of every project listed in a workspace file (default: ` + config.DefaultWorkspaceFile + `).
+Projects are built concurrently, sharing one limit on ffmpeg jobs and API calls and one translation cache,
+and a combined report of every project is written next to the workspace file.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspacePath := config.DefaultWorkspaceFile
+			if len(args) == 1 {
+				workspacePath = args[0]
+			}
+			return runBuildAll(workspacePath, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.noProgress, "no-progress", false, "Disable progress UI")
+	cmd.Flags().IntVarP(&opts.jobs, "jobs", "j", 0, "Maximum concurrent ffmpeg jobs across all projects (overrides workspace file, default: number of CPUs)")
+	cmd.Flags().IntVar(&opts.apiConcurrency, "api-concurrency", 0, "Maximum concurrent OpenAI API calls across all projects (overrides workspace file, default: 4)")
+	cmd.Flags().BoolVar(&opts.resume, "resume", false, "Skip languages the build manifest of each project records as complete")
+	cmd.Flags().BoolVar(&opts.keepGoing, "keep-going", false, "Finish every project and language possible instead of stopping at the first failure")
+	cmd.Flags().StringVar(&opts.reportPath, "report", "", "Path of the combined JSON report (default: build-report.json next to the workspace file)")
+
+	return cmd
+}
+
+func runBuildAll(workspacePath string, opts buildAllOptions) error {
+	fs := afero.NewOsFs()
+
+	ws, err := config.LoadWorkspace(fs, workspacePath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Loaded workspace %s with %d projects\n", workspacePath, len(ws.Projects))
+
+	// Setup logging
+	slogger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	logger := &interfaces.SlogLogger{Logger: slogger}
+
+	// Load every configuration first, so a broken one fails before anything is generated
+	configs := make([]*config.Config, len(ws.Projects))
+	paths := make([]string, len(ws.Projects))
+	for i, project := range ws.Projects {
+		cfg, _, err := ws.ProjectConfig(fs, project)
+		if err != nil {
+			return fmt.Errorf("project %s: %w", project.Path, err)
+		}
+		if err := applyCreateFlags(cfg, createOptions{}); err != nil {
+			return fmt.Errorf("project %s: %w", project.Path, err)
+		}
+		configs[i] = cfg
+		paths[i] = project.Path
+	}
+
+	// Ctrl-C and SIGTERM cancel every project, as for create
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// Initialize progress UI if enabled
+	var prog *tea.Program
+	var progress interfaces.ProgressCallback = &interfaces.NoOpProgressCallback{}
+	if !opts.noProgress {
+		progressModel := ui.NewProgressModel()
+		prog = tea.NewProgram(progressModel, tea.WithContext(ctx))
+		progress = ui.NewProgressAdapter(prog)
+
+		go func() {
+			if _, err := prog.Run(); err != nil && ctx.Err() == nil {
+				logger.Error("Progress UI error", "error", err)
+			}
+			// The UI reads Ctrl-C as a key press, forward it to the run
+			if progressModel.Interrupted() {
+				cancel()
+			}
+		}()
+	}
+
+	// Every project shares one scheduler and one translation cache
+	concurrency := ws.Concurrency
+	if opts.jobs > 0 {
+		concurrency.Jobs = opts.jobs
+	}
+	if opts.apiConcurrency > 0 {
+		concurrency.API = opts.apiConcurrency
+	}
+	shared := newSharedServices(fs, concurrency, filepath.Join(ws.CacheDir(), "translations"), logger)
+
+	createOpts := createOptions{resume: opts.resume, keepGoing: opts.keepGoing}
+	report, runErr := services.BuildProjects(ctx, paths, opts.keepGoing, func(ctx context.Context, i int) (*services.RunReport, error) {
+		project := ws.Projects[i]
+		projectLogger := logger.With("project", project.Path)
+		p, err := newCreatePipeline(fs, ws.ProjectDir(project), configs[i], createOpts, nil,
+			&projectProgress{project: project.Path, progress: progress}, projectLogger, shared)
+		if err != nil {
+			return nil, err
+		}
+		runReport, err := p.creator.Run(ctx, p.config)
+		p.saveReport(fs, runReport, projectLogger)
+		return runReport, err
+	})
+
+	// Complete progress
+	if prog != nil {
+		prog.Send(ui.CompleteMsg{})
+		prog.Wait()
+	}
+
+	reportPath := opts.reportPath
+	if reportPath == "" {
+		reportPath = filepath.Join(filepath.Dir(workspacePath), "build-report.json")
+	}
+	if err := report.Save(fs, reportPath); err != nil {
+		logger.Warn("Failed to save build report", "path", reportPath, "error", err)
+	}
+	fmt.Println()
+	if err := report.WriteSummary(os.Stdout); err != nil {
+		logger.Warn("Failed to print build summary", "error", err)
+	}
+	fmt.Printf("\nBuild report: %s\n", reportPath)
+
+	if ctx.Err() != nil {
+		fmt.Println("✗ Interrupted: running ffmpeg jobs were stopped and their partial files removed")
+		return fmt.Errorf("build interrupted (resume with --resume)")
+	}
+	if runErr != nil {
+		return fmt.Errorf("build failed (resume with --resume): %w", runErr)
+	}
+	if opts.noProgress {
+		fmt.Printf("✓ All %d projects built successfully!\n", len(paths))
+	}
+	return nil
+}
+
+// projectProgress prefixes the items of a project with its path, so projects
+// running at once can be told apart in the progress UI
+type projectProgress struct {
+	project  string
+	progress interfaces.ProgressCallback
+}
+
+func (p *projectProgress) item(item string) string {
+	return p.project + "/" + item
+}
+
+func (p *projectProgress) message(message string) string {
+	return p.project + ": " + message
+}
+
+func (p *projectProgress) OnStageStart(stage string) {
+	p.progress.OnStageStart(stage)
+}
+
+func (p *projectProgress) OnStageProgress(stage string, progress int, message string) {
+	p.progress.OnStageProgress(stage, progress, p.message(message))
+}
+
+func (p *projectProgress) OnStageComplete(stage string, success bool, message string) {
+	p.progress.OnStageComplete(stage, success, p.message(message))
+}
+
+func (p *projectProgress) OnItemStart(stage string, item string) {
+	p.progress.OnItemStart(stage, p.item(item))
+}
+
+func (p *projectProgress) OnItemProgress(stage string, item string, progress int, message string) {
+	p.progress.OnItemProgress(stage, p.item(item), progress, message)
+}
+
+func (p *projectProgress) OnItemComplete(stage string, item string, success bool, message string) {
+	p.progress.OnItemComplete(stage, p.item(item), success, message)
+}
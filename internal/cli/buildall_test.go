@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"testing"
+
+	"gocreator/internal/interfaces"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBuildAllCommand(t *testing.T) {
+	cmd := NewBuildAllCommand()
+
+	assert.Equal(t, "build-all [workspace-file]", cmd.Use)
+	assert.NotEmpty(t, cmd.Long)
+	assert.NoError(t, cmd.Args(cmd, []string{}))
+	assert.NoError(t, cmd.Args(cmd, []string{"courses.yaml"}))
+	assert.Error(t, cmd.Args(cmd, []string{"a.yaml", "b.yaml"}))
+
+	for _, name := range []string{"no-progress", "jobs", "api-concurrency", "resume", "keep-going", "report"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "flag %s", name)
+	}
+	assert.Nil(t, cmd.Flags().Lookup("slides"))
+}
+
+// recordingProgress records the items and messages it receives
+type recordingProgress struct {
+	interfaces.NoOpProgressCallback
+	items    []string
+	messages []string
+}
+
+func (r *recordingProgress) OnStageProgress(stage string, progress int, message string) {
+	r.messages = append(r.messages, message)
+}
+
+func (r *recordingProgress) OnItemStart(stage string, item string) {
+	r.items = append(r.items, item)
+}
+
+func TestProjectProgress(t *testing.T) {
+	rec := &recordingProgress{}
+	p := &projectProgress{project: "course-a", progress: rec}
+
+	p.OnItemStart("Generating audio", "fr")
+	p.OnStageProgress("Loading", 50, "Loaded 3 slides")
+
+	assert.Equal(t, []string{"course-a/fr"}, rec.items)
+	assert.Equal(t, []string{"course-a: Loaded 3 slides"}, rec.messages)
+}
@@ -5,8 +5,13 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 
 	"gocreator/internal/adapters"
 	"gocreator/internal/config"
@@ -20,33 +25,54 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// createOptions holds the flags of the create command
+type createOptions struct {
+	inputLang      string
+	outputLangs    string
+	googleSlidesID string
+	configFile     string
+	noProgress     bool
+	jobs           int
+	apiConcurrency int
+	resume         bool
+	keepGoing      bool
+	reportPath     string
+	dryRun         bool
+	slides         string
+	langs          string
+}
+
 // NewCreateCommand creates the create command
 func NewCreateCommand() *cobra.Command {
-	var inputLang string
-	var outputLangs string
-	var googleSlidesID string
-	var configFile string
-	var noProgress bool
+	var opts createOptions
 
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create videos with translations",
 		Long:  `Create videos by processing text files, generating translations, audio, and combining with slides.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runCreate(inputLang, outputLangs, googleSlidesID, configFile, noProgress)
+			return runCreate(opts)
 		},
 	}
 
-	cmd.Flags().StringVarP(&inputLang, "lang", "l", "", "Language of the text input (overrides config file)")
-	cmd.Flags().StringVarP(&outputLangs, "langs-out", "o", "", "Comma-separated list of output languages (overrides config file)")
-	cmd.Flags().StringVar(&googleSlidesID, "google-slides", "", "Google Slides presentation ID (overrides config file)")
-	cmd.Flags().StringVarP(&configFile, "config", "c", "", "Config file path (default: looks for gocreator.yaml in current and parent directories)")
-	cmd.Flags().BoolVar(&noProgress, "no-progress", false, "Disable progress UI")
+	cmd.Flags().StringVarP(&opts.inputLang, "lang", "l", "", "Language of the text input (overrides config file)")
+	cmd.Flags().StringVarP(&opts.outputLangs, "langs-out", "o", "", "Comma-separated list of output languages (overrides config file)")
+	cmd.Flags().StringVar(&opts.googleSlidesID, "google-slides", "", "Google Slides presentation ID (overrides config file)")
+	cmd.Flags().StringVarP(&opts.configFile, "config", "c", "", "Config file path (default: looks for gocreator.yaml in current and parent directories)")
+	cmd.Flags().BoolVar(&opts.noProgress, "no-progress", false, "Disable progress UI")
+	cmd.Flags().IntVarP(&opts.jobs, "jobs", "j", 0, "Maximum concurrent ffmpeg jobs (overrides config file, default: number of CPUs)")
+	cmd.Flags().IntVar(&opts.apiConcurrency, "api-concurrency", 0, "Maximum concurrent OpenAI API calls (overrides config file, default: 4)")
+	cmd.Flags().BoolVar(&opts.resume, "resume", false, "Skip languages the build manifest of the previous run records as complete")
+	cmd.Flags().BoolVar(&opts.keepGoing, "keep-going", false, "Finish every language possible instead of stopping at the first failure")
+	cmd.Flags().StringVar(&opts.reportPath, "report", "", "Path of the JSON run report (default: report.json in the output directory)")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Print what would be regenerated and the estimated API cost, without generating anything")
+	cmd.Flags().StringVar(&opts.slides, "slides", "", "Render a preview of only these slides, e.g. 12-15 or 1,3,5-7, to data/out/preview")
+	cmd.Flags().StringVar(&opts.langs, "langs", "", "Comma-separated subset of the output languages to render, e.g. fr")
 
 	return cmd
 }
 
-func runCreate(inputLang, outputLangs, googleSlidesID, configFile string, noProgress bool) error {
+func runCreate(opts createOptions) error {
 	// Get working directory
 	rootDir, err := os.Getwd()
 	if err != nil {
@@ -57,44 +83,193 @@ func runCreate(inputLang, outputLangs, googleSlidesID, configFile string, noProg
 	fs := afero.NewOsFs()
 
 	// Load configuration
+	cfg, configPath, err := loadCreateConfig(fs, rootDir, opts)
+	if err != nil {
+		return err
+	}
+	printConfigSource(configPath)
+
+	var slides []int
+	if opts.slides != "" {
+		slides, err = parseSlideSelection(opts.slides)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Setup logging
+	slogger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	logger := &interfaces.SlogLogger{Logger: slogger}
+
+	// Check the local inputs before spending anything on them, Google Slides are fetched later
+	if !(cfg.Input.Source == "google-slides" && cfg.Input.PresentationID != "") {
+		diags, err := validateProject(context.Background(), fs, rootDir, logger, os.Stderr)
+		if err != nil {
+			return err
+		}
+		if err := validationError(diags); err != nil {
+			return err
+		}
+	}
+
+	// Ctrl-C and SIGTERM cancel the run, stopping API calls and killing ffmpeg.
+	// Once the run is canceled the default handlers are restored, so a second Ctrl-C exits at once.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// Initialize progress UI if enabled
+	var prog *tea.Program
+	var progressAdapter *ui.ProgressAdapter
+	if !opts.noProgress && !opts.dryRun {
+		progressModel := ui.NewProgressModel()
+		prog = tea.NewProgram(progressModel, tea.WithContext(ctx))
+		progressAdapter = ui.NewProgressAdapter(prog)
+		
+		// Run progress UI in background
+		go func() {
+			if _, err := prog.Run(); err != nil && ctx.Err() == nil {
+				logger.Error("Progress UI error", "error", err)
+			}
+			// The UI reads Ctrl-C as a key press, forward it to the run
+			if progressModel.Interrupted() {
+				cancel()
+			}
+		}()
+	}
+
+	// Create video creator configuration with progress callback
+	var progressCallback interfaces.ProgressCallback
+	if progressAdapter != nil {
+		progressCallback = progressAdapter
+	} else {
+		progressCallback = &interfaces.NoOpProgressCallback{}
+	}
+
+	shared := newSharedServices(fs, cfg.Concurrency, filepath.Join(rootDir, cfg.Cache.Directory, "translations"), logger)
+	p, err := newCreatePipeline(fs, rootDir, cfg, opts, slides, progressCallback, logger, shared)
+	if err != nil {
+		return err
+	}
+
+	if opts.dryRun {
+		// Plan against the local copy of the slides, Google Slides are not fetched
+		planner := services.NewPlanner(
+			fs,
+			p.textService,
+			services.NewSlideService(fs, logger),
+			p.translationService,
+			p.audioService,
+			p.videoService,
+			logger,
+		)
+		plan, err := planner.Plan(ctx, p.config)
+		if err != nil {
+			return fmt.Errorf("dry run failed: %w", err)
+		}
+		fmt.Println()
+		return plan.WriteSummary(os.Stdout)
+	}
+
+	// Run video creation
+	report, runErr := p.creator.Run(ctx, p.config)
+
+	// Complete progress
+	if prog != nil {
+		prog.Send(ui.CompleteMsg{})
+		prog.Wait()
+	}
+
+	// Report every language, including the ones that succeeded
+	reportPath := p.saveReport(fs, report, logger)
+	if len(report.Languages) > 0 {
+		fmt.Println()
+		if err := report.WriteSummary(os.Stdout); err != nil {
+			logger.Warn("Failed to print run summary", "error", err)
+		}
+		fmt.Printf("\nRun report: %s\n", reportPath)
+	}
+
+	if ctx.Err() != nil {
+		fmt.Println("✗ Interrupted: running ffmpeg jobs were stopped and their partial files removed")
+		return fmt.Errorf("video creation interrupted (resume with --resume, manifest: %s)", p.manifestPath)
+	}
+	if runErr != nil {
+		return fmt.Errorf("video creation failed (resume with --resume, manifest: %s): %w", p.manifestPath, runErr)
+	}
+
+	if p.config.IsPreview() {
+		fmt.Printf("✓ Preview of %d slides created in %s\n", len(slides), filepath.Join(rootDir, cfg.Output.Directory, "preview"))
+	} else if opts.noProgress {
+		fmt.Println("✓ All videos created successfully!")
+	}
+	return nil
+}
+
+// loadCreateConfig loads the configuration file given by opts, or found from rootDir,
+// and applies the flags of opts over it.
+// It also returns the path of the file loaded, empty when the default configuration is used.
+func loadCreateConfig(fs afero.Fs, rootDir string, opts createOptions) (*config.Config, string, error) {
 	var cfg *config.Config
-	if configFile != "" {
+	var configPath string
+	var err error
+	if opts.configFile != "" {
 		// Use specified config file
-		cfg, err = config.LoadConfig(fs, configFile)
+		cfg, err = config.LoadConfig(fs, opts.configFile)
 		if err != nil {
-			return fmt.Errorf("failed to load config file %s: %w", configFile, err)
+			return nil, "", fmt.Errorf("failed to load config file %s: %w", opts.configFile, err)
 		}
-		fmt.Printf("✓ Loaded config from %s\n", configFile)
+		configPath = opts.configFile
 	} else {
 		// Try to find config file
-		foundPath, err := config.FindConfigFile(fs)
+		foundPath, err := config.FindConfigFileFrom(fs, rootDir)
 		if err != nil {
-			return fmt.Errorf("error searching for config file: %w", err)
+			return nil, "", fmt.Errorf("error searching for config file: %w", err)
 		}
 		
 		if foundPath != "" {
 			cfg, err = config.LoadConfig(fs, foundPath)
 			if err != nil {
-				return fmt.Errorf("failed to load config file %s: %w", foundPath, err)
+				return nil, "", fmt.Errorf("failed to load config file %s: %w", foundPath, err)
 			}
-			fmt.Printf("✓ Loaded config from %s\n", foundPath)
+			configPath = foundPath
 		} else {
 			// Use default config
 			cfg = config.DefaultConfig()
-			fmt.Println("ℹ Using default configuration (no config file found)")
 		}
 	}
 
+	if err := applyCreateFlags(cfg, opts); err != nil {
+		return nil, "", err
+	}
+	return cfg, configPath, nil
+}
+
+// applyCreateFlags applies the flags of opts over cfg
+func applyCreateFlags(cfg *config.Config, opts createOptions) error {
+	var err error
+
 	// Override config with command-line flags
-	if inputLang != "" {
-		cfg.Input.Lang = inputLang
+	if opts.inputLang != "" {
+		cfg.Input.Lang = opts.inputLang
 	}
-	if outputLangs != "" {
-		cfg.Output.Languages = parseLanguages(outputLangs, cfg.Input.Lang)
+	if opts.outputLangs != "" {
+		cfg.Output.Languages = parseLanguages(opts.outputLangs, cfg.Input.Lang)
 	}
-	if googleSlidesID != "" {
+	if opts.googleSlidesID != "" {
 		cfg.Input.Source = "google-slides"
-		cfg.Input.PresentationID = googleSlidesID
+		cfg.Input.PresentationID = opts.googleSlidesID
+	}
+	if opts.jobs > 0 {
+		cfg.Concurrency.Jobs = opts.jobs
+	}
+	if opts.apiConcurrency > 0 {
+		cfg.Concurrency.API = opts.apiConcurrency
 	}
 
 	// Ensure input language is in output languages
@@ -103,39 +278,157 @@ func runCreate(inputLang, outputLangs, googleSlidesID, configFile string, noProg
 	}
 	cfg.Output.Languages = ensureInputLanguageFirst(cfg.Output.Languages, cfg.Input.Lang)
 
-	// Setup logging
-	slogger := slog.New(slog.NewTextHandler(os.Stderr, nil))
-	logger := &interfaces.SlogLogger{Logger: slogger}
+	// Narrow the run down to the selected languages
+	if opts.langs != "" {
+		cfg.Output.Languages, err = selectLanguages(cfg.Output.Languages, opts.langs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	// Initialize progress UI if enabled
-	var prog *tea.Program
-	var progressAdapter *ui.ProgressAdapter
-	if !noProgress {
-		progressModel := ui.NewProgressModel()
-		prog = tea.NewProgram(progressModel)
-		progressAdapter = ui.NewProgressAdapter(prog)
-		
-		// Run progress UI in background
-		go func() {
-			if _, err := prog.Run(); err != nil {
-				logger.Error("Progress UI error", "error", err)
-			}
-		}()
+// printConfigSource tells where the configuration was loaded from
+func printConfigSource(configPath string) {
+	if configPath != "" {
+		fmt.Printf("✓ Loaded config from %s\n", configPath)
+	} else {
+		fmt.Println("ℹ Using default configuration (no config file found)")
 	}
+}
+
+// createPipeline holds the services of one create run, wired from the configuration
+type createPipeline struct {
+	textService        *services.TextService
+	translationService *services.TranslationService
+	audioService       *services.AudioService
+	videoService       *services.VideoService
+	creator            *services.VideoCreator
+	config             services.VideoCreatorConfig
+	manifestPath       string
+	reportPath         string
+}
 
+// sharedServices are the services shared by every project of a run
+type sharedServices struct {
+	openai             interfaces.OpenAIClient
+	scheduler          *services.Scheduler
+	translationService *services.TranslationService
+
+	fs         afero.Fs
+	memoryPath string
+	memoryOnce sync.Once
+	memory     *services.TranslationMemory
+	memoryErr  error
+}
+
+// translationMemory returns the translation memory kept with the translation cache, opened
+// by the first project using it
+func (s *sharedServices) translationMemory() (*services.TranslationMemory, error) {
+	s.memoryOnce.Do(func() {
+		s.memory, s.memoryErr = services.OpenTranslationMemory(s.fs, s.memoryPath)
+	})
+	return s.memory, s.memoryErr
+}
+
+// newSharedServices creates the shared services of a run limited by concurrency,
+// caching translations in translationCacheDir
+func newSharedServices(fs afero.Fs, concurrency config.ConcurrencyConfig, translationCacheDir string, logger interfaces.Logger) *sharedServices {
 	// Initialize OpenAI client
 	openaiClient := openai.NewClient()
 	openaiAdapter := adapters.NewOpenAIAdapter(openaiClient)
 
-	// Create services with dependency injection
-	textService := services.NewTextService(fs, logger)
-	
+	// One scheduler bounds ffmpeg jobs and API calls across all languages
+	scheduler := services.NewScheduler(concurrency.Jobs, concurrency.API)
+	logger.Info("Concurrency limits", "jobs", scheduler.Jobs(), "api", scheduler.APIConcurrency())
+
 	// Create translation service with disk cache
-	translationCacheDir := filepath.Join(rootDir, cfg.Cache.Directory, "translations")
 	translationService := services.NewTranslationServiceWithCache(openaiAdapter, logger, fs, translationCacheDir)
+	translationService.SetScheduler(scheduler)
+
+	return &sharedServices{
+		openai:             openaiAdapter,
+		scheduler:          scheduler,
+		translationService: translationService,
+		fs:                 fs,
+		memoryPath:         filepath.Join(translationCacheDir, services.TranslationMemoryFile),
+	}
+}
+
+// newCreatePipeline creates the services of a run of cfg rendering slides, or every slide when empty
+func newCreatePipeline(
+	fs afero.Fs,
+	rootDir string,
+	cfg *config.Config,
+	opts createOptions,
+	slides []int,
+	progress interfaces.ProgressCallback,
+	logger interfaces.Logger,
+	shared *sharedServices,
+) (*createPipeline, error) {
+	scheduler := shared.scheduler
+
+	// The build manifest records every artifact of the run. A dry run records nothing.
+	manifestPath := filepath.Join(rootDir, cfg.Cache.Directory, services.ManifestFileName)
+	var manifest *services.Manifest
+	if !opts.dryRun {
+		var err error
+		manifest, err = services.OpenManifest(fs, manifestPath, opts.resume)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open build manifest: %w", err)
+		}
+		if manifest.Resumed {
+			logger.Info("Resuming from build manifest", "path", manifestPath)
+		}
+	}
+
+	// Create services with dependency injection
+	textService := services.NewTextService(fs, logger)
+	translationService, err := configureTranslation(shared.translationService, cfg.Translation, shared.openai)
+	if err != nil {
+		return nil, err
+	}
+	glossary, err := services.LoadGlossary(fs, filepath.Join(rootDir, "data", services.GlossaryFile))
+	if err != nil {
+		return nil, err
+	}
+	if glossary != nil {
+		translationService = translationService.WithGlossary(glossary)
+	}
+	if !cfg.Translation.Memory.Disabled {
+		memory, err := shared.translationMemory()
+		if err != nil {
+			return nil, err
+		}
+		translationService = translationService.WithMemory(memory, cfg.Input.Lang, cfg.Translation.Memory.FuzzyThreshold)
+	}
+	if cfg.Translation.QA.Enabled {
+		qa, err := translationQA(cfg.Translation.QA)
+		if err != nil {
+			return nil, err
+		}
+		translationService = translationService.WithQA(qa, cfg.Input.Lang)
+	}
+	prompt, err := translationPrompt(cfg.Translation)
+	if err != nil {
+		return nil, err
+	}
+	translationService, err = translationService.WithPrompt(prompt, cfg.Input.Lang)
+	if err != nil {
+		return nil, err
+	}
 	
-	audioService := services.NewAudioService(fs, openaiAdapter, textService, logger)
+	audioService := services.NewAudioService(fs, shared.openai, textService, logger)
+	audioService.SetScheduler(scheduler)
+	audioService.SetManifest(manifest)
+	lexicon, err := services.LoadLexicon(fs, filepath.Join(rootDir, "data", services.LexiconFile))
+	if err != nil {
+		return nil, err
+	}
+	audioService.SetLexicon(lexicon)
 	videoService := services.NewVideoService(fs, logger)
+	videoService.SetScheduler(scheduler)
+	videoService.SetManifest(manifest)
 	
 	// Choose slide service based on source
 	var slideService interfaces.SlideLoader
@@ -157,14 +450,7 @@ func runCreate(inputLang, outputLangs, googleSlidesID, configFile string, noProg
 		slideService,
 		logger,
 	)
-
-	// Create video creator configuration with progress callback
-	var progressCallback interfaces.ProgressCallback
-	if progressAdapter != nil {
-		progressCallback = progressAdapter
-	} else {
-		progressCallback = &interfaces.NoOpProgressCallback{}
-	}
+	creator.SetManifest(manifest)
 
 	// Convert config transition to services transition
 	transition := services.TransitionConfig{
@@ -179,34 +465,43 @@ func runCreate(inputLang, outputLangs, googleSlidesID, configFile string, noProg
 	}
 
 	creatorCfg := services.VideoCreatorConfig{
-		RootDir:          rootDir,
-		InputLang:        cfg.Input.Lang,
-		OutputLangs:      cfg.Output.Languages,
-		GoogleSlidesID:   cfg.Input.PresentationID,
-		ProgressCallback: progressCallback,
-		Transition:       transition,
+		RootDir:             rootDir,
+		InputLang:           cfg.Input.Lang,
+		OutputLangs:         cfg.Output.Languages,
+		GoogleSlidesID:      cfg.Input.PresentationID,
+		ProgressCallback:    progress,
+		Transition:          transition,
+		KeepGoing:           opts.keepGoing,
+		Slides:              slides,
+		SilentSlideDuration: cfg.Slides.SilentDuration,
 	}
 
-	// Run video creation
-	ctx := context.Background()
-	if err := creator.Create(ctx, creatorCfg); err != nil {
-		if prog != nil {
-			prog.Send(ui.CompleteMsg{})
-			prog.Wait()
+	reportPath := opts.reportPath
+	if reportPath == "" {
+		reportPath = filepath.Join(rootDir, cfg.Output.Directory, "report.json")
+		if creatorCfg.IsPreview() {
+			reportPath = filepath.Join(rootDir, cfg.Output.Directory, "preview", "report.json")
 		}
-		return fmt.Errorf("video creation failed: %w", err)
 	}
 
-	// Complete progress
-	if prog != nil {
-		prog.Send(ui.CompleteMsg{})
-		prog.Wait()
-	}
+	return &createPipeline{
+		textService:        textService,
+		translationService: translationService,
+		audioService:       audioService,
+		videoService:       videoService,
+		creator:            creator,
+		config:             creatorCfg,
+		manifestPath:       manifestPath,
+		reportPath:         reportPath,
+	}, nil
+}
 
-	if noProgress {
-		fmt.Println("✓ All videos created successfully!")
+// saveReport writes the report of a run of the pipeline and returns where
+func (p *createPipeline) saveReport(fs afero.Fs, report *services.RunReport, logger interfaces.Logger) string {
+	if err := report.Save(fs, p.reportPath); err != nil {
+		logger.Warn("Failed to save run report", "path", p.reportPath, "error", err)
 	}
-	return nil
+	return p.reportPath
 }
 
 // parseLanguages parses comma-separated languages
@@ -239,3 +534,60 @@ func ensureInputLanguageFirst(languages []string, inputLang string) []string {
 	// Prepend input language
 	return append([]string{inputLang}, filtered...)
 }
+
+// selectLanguages returns the languages of selection, which must all be in languages
+func selectLanguages(languages []string, selection string) ([]string, error) {
+	var selected []string
+	for _, lang := range strings.Split(selection, ",") {
+		lang = strings.TrimSpace(lang)
+		if lang == "" || slices.Contains(selected, lang) {
+			continue
+		}
+		if !slices.Contains(languages, lang) {
+			return nil, fmt.Errorf("language %q is not an output language (%s)", lang, strings.Join(languages, ", "))
+		}
+		selected = append(selected, lang)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no language selected by --langs %q", selection)
+	}
+	return selected, nil
+}
+
+// parseSlideSelection parses 1-based slide numbers and ranges, such as "1,3,12-15",
+// into sorted, unique 0-based slide indices
+func parseSlideSelection(selection string) ([]int, error) {
+	seen := make(map[int]bool)
+	var indices []int
+	for _, part := range strings.Split(selection, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(strings.TrimSpace(first))
+		if err != nil || from < 1 {
+			return nil, fmt.Errorf("invalid slide %q in --slides %q: slides are numbered from 1", part, selection)
+		}
+		to := from
+		if isRange {
+			to, err = strconv.Atoi(strings.TrimSpace(last))
+			if err != nil || to < from {
+				return nil, fmt.Errorf("invalid slide range %q in --slides %q", part, selection)
+			}
+		}
+
+		for n := from; n <= to; n++ {
+			if !seen[n-1] {
+				seen[n-1] = true
+				indices = append(indices, n-1)
+			}
+		}
+	}
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("no slide selected by --slides %q", selection)
+	}
+	slices.Sort(indices)
+	return indices, nil
+}
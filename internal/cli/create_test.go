@@ -33,6 +33,34 @@ func TestNewCreateCommand(t *testing.T) {
 	noProgressFlag := cmd.Flags().Lookup("no-progress")
 	assert.NotNil(t, noProgressFlag)
 	assert.Equal(t, "false", noProgressFlag.DefValue)
+
+	jobsFlag := cmd.Flags().Lookup("jobs")
+	assert.NotNil(t, jobsFlag)
+	assert.Equal(t, "j", jobsFlag.Shorthand)
+	assert.Equal(t, "0", jobsFlag.DefValue)
+
+	apiConcurrencyFlag := cmd.Flags().Lookup("api-concurrency")
+	assert.NotNil(t, apiConcurrencyFlag)
+	assert.Equal(t, "0", apiConcurrencyFlag.DefValue)
+
+	resumeFlag := cmd.Flags().Lookup("resume")
+	assert.NotNil(t, resumeFlag)
+	assert.Equal(t, "false", resumeFlag.DefValue)
+
+	keepGoingFlag := cmd.Flags().Lookup("keep-going")
+	assert.NotNil(t, keepGoingFlag)
+	assert.Equal(t, "false", keepGoingFlag.DefValue)
+
+	reportFlag := cmd.Flags().Lookup("report")
+	assert.NotNil(t, reportFlag)
+	assert.Equal(t, "", reportFlag.DefValue)
+
+	dryRunFlag := cmd.Flags().Lookup("dry-run")
+	assert.NotNil(t, dryRunFlag)
+	assert.Equal(t, "false", dryRunFlag.DefValue)
+
+	assert.NotNil(t, cmd.Flags().Lookup("slides"))
+	assert.NotNil(t, cmd.Flags().Lookup("langs"))
 }
 
 func TestCreateCommand_Help(t *testing.T) {
@@ -56,6 +84,14 @@ func TestCreateCommand_FlagsParsing(t *testing.T) {
 		"--google-slides", "test-id-123",
 		"--config", "/path/to/config.yaml",
 		"--no-progress",
+		"--jobs", "3",
+		"--api-concurrency", "6",
+		"--resume",
+		"--keep-going",
+		"--report", "/tmp/report.json",
+		"--dry-run",
+		"--slides", "12-15",
+		"--langs", "fr",
 	})
 
 	assert.NoError(t, err)
@@ -74,6 +110,15 @@ func TestCreateCommand_FlagsParsing(t *testing.T) {
 
 	noProgressFlag := cmd.Flags().Lookup("no-progress")
 	assert.Equal(t, "true", noProgressFlag.Value.String())
+
+	assert.Equal(t, "3", cmd.Flags().Lookup("jobs").Value.String())
+	assert.Equal(t, "6", cmd.Flags().Lookup("api-concurrency").Value.String())
+	assert.Equal(t, "true", cmd.Flags().Lookup("resume").Value.String())
+	assert.Equal(t, "true", cmd.Flags().Lookup("keep-going").Value.String())
+	assert.Equal(t, "/tmp/report.json", cmd.Flags().Lookup("report").Value.String())
+	assert.Equal(t, "true", cmd.Flags().Lookup("dry-run").Value.String())
+	assert.Equal(t, "12-15", cmd.Flags().Lookup("slides").Value.String())
+	assert.Equal(t, "fr", cmd.Flags().Lookup("langs").Value.String())
 }
 
 func TestParseLanguages(t *testing.T) {
@@ -169,3 +214,46 @@ func TestEnsureInputLanguageFirst(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSlideSelection(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection string
+		expected  []int
+		wantErr   bool
+	}{
+		{name: "range", selection: "12-15", expected: []int{11, 12, 13, 14}},
+		{name: "single slide", selection: "3", expected: []int{2}},
+		{name: "list and ranges", selection: "5, 1-2,2", expected: []int{0, 1, 4}},
+		{name: "zero", selection: "0", wantErr: true},
+		{name: "reversed range", selection: "5-3", wantErr: true},
+		{name: "not a number", selection: "a-b", wantErr: true},
+		{name: "empty", selection: ",", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseSlideSelection(tt.selection)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestSelectLanguages(t *testing.T) {
+	languages := []string{"en", "fr", "de"}
+
+	selected, err := selectLanguages(languages, "fr, de,fr")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fr", "de"}, selected)
+
+	_, err = selectLanguages(languages, "es")
+	assert.ErrorContains(t, err, "not an output language")
+
+	_, err = selectLanguages(languages, " ")
+	assert.Error(t, err)
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"gocreator/internal/config"
+	"gocreator/internal/interfaces"
+	"gocreator/internal/services"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// NewL10nCommand creates the l10n command, exchanging the narration with reviewers as XLIFF
+func NewL10nCommand() *cobra.Command {
+	var opts createOptions
+
+	cmd := &cobra.Command{
+		Use:   "l10n",
+		Short: "Export the narration for review, or import reviewed translations, as XLIFF 2.0",
+		Long: `Export the narration of a local project as an XLIFF 2.0 file with one unit per slide: the narration, its current
+translation and the slide image as a note. Once reviewed in a translation tool, import the file back: the translation of
+every unit reviewed or final, or translated with an edited target, is approved and locked, and create never machine
+translates it again as long as the narration of the slide is unchanged.`,
+	}
+	cmd.PersistentFlags().StringVarP(&opts.configFile, "config", "c", "", "Config file path (default: looks for gocreator.yaml in current and parent directories)")
+
+	var lang, output string
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the narration and its translation to a language as XLIFF 2.0",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runL10n(opts, func(ctx context.Context, rootDir string, cfg *config.Config, localizer *services.Localizer) error {
+				path := output
+				if path == "" {
+					path = lang + ".xlf"
+				}
+				count, err := exportL10n(ctx, afero.NewOsFs(), localizer, rootDir, cfg.Input.Lang, lang, path)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("✓ Exported %d slides to %s\n", count, path)
+				return nil
+			})
+		},
+	}
+	exportCmd.Flags().StringVar(&lang, "lang", "", "Language of the translation to review")
+	exportCmd.Flags().StringVarP(&output, "output", "o", "", "Path of the XLIFF file (default: <lang>.xlf)")
+	_ = exportCmd.MarkFlagRequired("lang")
+
+	importCmd := &cobra.Command{
+		Use:   "import <file.xlf>...",
+		Short: "Lock the reviewed translations of XLIFF 2.0 files",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runL10n(opts, func(ctx context.Context, rootDir string, cfg *config.Config, localizer *services.Localizer) error {
+				for _, path := range args {
+					result, err := importL10n(ctx, afero.NewOsFs(), localizer, rootDir, cfg, path)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("✓ Locked %d reviewed translations to %s from %s\n", result.Locked, result.Lang, path)
+					if len(result.Stale) > 0 {
+						fmt.Printf("⚠ Skipped slides %v, their narration changed since the export\n", result.Stale)
+					}
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.AddCommand(exportCmd, importCmd)
+	return cmd
+}
+
+// runL10n calls fn with the localizer of the project in the working directory
+func runL10n(opts createOptions, fn func(ctx context.Context, rootDir string, cfg *config.Config, localizer *services.Localizer) error) error {
+	rootDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	fs := afero.NewOsFs()
+
+	cfg, _, err := loadCreateConfig(fs, rootDir, opts)
+	if err != nil {
+		return err
+	}
+	return fn(context.Background(), rootDir, cfg, newLocalizer(fs))
+}
+
+// newLocalizer creates the localizer of projects on fs
+func newLocalizer(fs afero.Fs) *services.Localizer {
+	logger := &interfaces.SlogLogger{Logger: slog.New(slog.NewTextHandler(os.Stderr, nil))}
+	return services.NewLocalizer(fs, services.NewTextService(fs, logger), services.NewSlideService(fs, logger))
+}
+
+// exportL10n writes the narration of the project in rootDir and its translation to lang to the XLIFF file at path
+func exportL10n(ctx context.Context, fs afero.Fs, localizer *services.Localizer, rootDir, sourceLang, lang, path string) (int, error) {
+	file, err := fs.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	count, err := localizer.Export(ctx, filepath.Join(rootDir, "data"), sourceLang, lang, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = fs.Remove(path)
+		return 0, fmt.Errorf("failed to export %s: %w", path, err)
+	}
+	return count, nil
+}
+
+// importL10n locks the reviewed translations of the XLIFF file at path in the project in rootDir
+func importL10n(ctx context.Context, fs afero.Fs, localizer *services.Localizer, rootDir string, cfg *config.Config, path string) (*services.ReviewImport, error) {
+	file, err := fs.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	result, err := localizer.Import(ctx, filepath.Join(rootDir, "data"), cfg.Input.Lang, cfg.Output.Languages, file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return result, nil
+}
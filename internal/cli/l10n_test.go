@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gocreator/internal/config"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportL10n(t *testing.T) {
+	ctx := context.Background()
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/p/data/texts.txt", []byte("Hello\n-\nBye"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/p/data/slides/1.png", []byte("png"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/p/data/slides/2.png", []byte("png"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/p/data/cache/fr/text/texts.txt", []byte("Salut\n-\nAu revoir"), 0644))
+	cfg := config.DefaultConfig()
+	cfg.Output.Languages = []string{"en", "fr"}
+	localizer := newLocalizer(fs)
+
+	count, err := exportL10n(ctx, fs, localizer, "/p", cfg.Input.Lang, "fr", "/p/fr.xlf")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	exported, err := afero.ReadFile(fs, "/p/fr.xlf")
+	require.NoError(t, err)
+	assert.Contains(t, string(exported), `<note category="slide">data/slides/1.png</note>`)
+
+	// The reviewer fixes the first slide
+	reviewed := strings.Replace(string(exported), "<target>Salut</target>", "<target>Bonjour</target>", 1)
+	reviewed = strings.Replace(reviewed, `state="translated"`, `state="final"`, 1)
+	require.NoError(t, afero.WriteFile(fs, "/p/fr.xlf", []byte(reviewed), 0644))
+	result, err := importL10n(ctx, fs, localizer, "/p", cfg, "/p/fr.xlf")
+	require.NoError(t, err)
+	assert.Equal(t, "fr", result.Lang)
+	assert.Equal(t, 1, result.Locked) // The second slide was left as exported
+
+	saved, err := afero.ReadFile(fs, "/p/data/cache/fr/text/texts.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour\n-\nAu revoir", string(saved))
+
+	_, err = importL10n(ctx, fs, localizer, "/p", cfg, "/p/missing.xlf")
+	assert.ErrorContains(t, err, "failed to open /p/missing.xlf")
+	_, err = exportL10n(ctx, fs, localizer, "/p", cfg.Input.Lang, "en", "/p/en.xlf")
+	assert.ErrorContains(t, err, "failed to export /p/en.xlf")
+	exists, err := afero.Exists(fs, "/p/en.xlf")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gocreator/internal/adapters"
+	"gocreator/internal/config"
+	"gocreator/internal/interfaces"
+	"gocreator/internal/services"
+)
+
+// translationProviders creates the translation providers the config can select, by name
+var translationProviders = map[string]func(cfg config.TranslationConfig, openaiClient interfaces.OpenAIClient) (interfaces.TranslationProvider, error){
+	services.ProviderOpenAI: func(_ config.TranslationConfig, openaiClient interfaces.OpenAIClient) (interfaces.TranslationProvider, error) {
+		return services.NewOpenAITranslator(openaiClient), nil
+	},
+	services.ProviderDeepL: func(cfg config.TranslationConfig, _ interfaces.OpenAIClient) (interfaces.TranslationProvider, error) {
+		apiKey, err := providerAPIKey(cfg.DeepL, "DEEPL_API_KEY", true)
+		if err != nil {
+			return nil, err
+		}
+		return adapters.NewDeepLAdapter(cfg.DeepL.URL, apiKey), nil
+	},
+	services.ProviderLibreTranslate: func(cfg config.TranslationConfig, _ interfaces.OpenAIClient) (interfaces.TranslationProvider, error) {
+		if cfg.LibreTranslate.URL == "" {
+			return nil, fmt.Errorf("translation.libretranslate.url is required")
+		}
+		apiKey, err := providerAPIKey(cfg.LibreTranslate, "LIBRETRANSLATE_API_KEY", false)
+		if err != nil {
+			return nil, err
+		}
+		return adapters.NewLibreTranslateAdapter(cfg.LibreTranslate.URL, apiKey), nil
+	},
+}
+
+// providerAPIKey reads the API key of a provider from the environment variable its config names,
+// defaultEnv by default
+func providerAPIKey(cfg config.TranslationProviderConfig, defaultEnv string, required bool) (string, error) {
+	env := cfg.APIKeyEnv
+	if env == "" {
+		env = defaultEnv
+	}
+	apiKey := os.Getenv(env)
+	if apiKey == "" && required {
+		return "", fmt.Errorf("%s is not set", env)
+	}
+	return apiKey, nil
+}
+
+// newTranslationProviders creates the translation providers cfg selects, nil when every
+// language is translated with OpenAI. Only the providers in use are created, so only their
+// settings and API keys are required.
+func newTranslationProviders(cfg config.TranslationConfig, openaiClient interfaces.OpenAIClient) (*services.TranslationProviders, error) {
+	fallback := cfg.Provider
+	if fallback == "" {
+		fallback = services.ProviderOpenAI
+	}
+	if fallback == services.ProviderOpenAI && len(cfg.Languages) == 0 {
+		return nil, nil
+	}
+
+	used := map[string]bool{fallback: true}
+	for _, name := range cfg.Languages {
+		used[name] = true
+	}
+	providers := make(map[string]interfaces.TranslationProvider, len(used))
+	for name := range used {
+		factory, ok := translationProviders[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown translation provider %q, expected one of %s", name, strings.Join(translationProviderNames(), ", "))
+		}
+		provider, err := factory(cfg, openaiClient)
+		if err != nil {
+			return nil, fmt.Errorf("translation provider %s: %w", name, err)
+		}
+		providers[name] = provider
+	}
+	return services.NewTranslationProviders(providers, fallback, cfg.Languages)
+}
+
+// Translation modes of the config
+const (
+	translationModeSlide = "slide"
+	translationModeDeck  = "deck"
+)
+
+// configureTranslation returns the translation service translating as cfg says, sharing the caches of service
+func configureTranslation(service *services.TranslationService, cfg config.TranslationConfig, openaiClient interfaces.OpenAIClient) (*services.TranslationService, error) {
+	providers, err := newTranslationProviders(cfg, openaiClient)
+	if err != nil {
+		return nil, err
+	}
+	if providers != nil {
+		service = service.WithProviders(providers)
+	}
+
+	switch cfg.Mode {
+	case "", translationModeSlide:
+	case translationModeDeck:
+		service = service.WithDeck(cfg.ChunkSize)
+	default:
+		return nil, fmt.Errorf("unknown translation mode %q, expected %s or %s", cfg.Mode, translationModeSlide, translationModeDeck)
+	}
+	return service, nil
+}
+
+// translationQA returns the quality checks of machine translations set by cfg
+func translationQA(cfg config.TranslationQAConfig) (services.TranslationQA, error) {
+	if cfg.MinLengthRatio > 0 && cfg.MaxLengthRatio > 0 && cfg.MinLengthRatio >= cfg.MaxLengthRatio {
+		return services.TranslationQA{}, fmt.Errorf("translation.qa.min_length_ratio %g must be below max_length_ratio %g", cfg.MinLengthRatio, cfg.MaxLengthRatio)
+	}
+	if cfg.BackTranslationThreshold > 1 {
+		return services.TranslationQA{}, fmt.Errorf("translation.qa.back_translation_threshold %g must be between 0 and 1", cfg.BackTranslationThreshold)
+	}
+	return services.TranslationQA{
+		Attempts:                 cfg.Attempts,
+		MinLengthRatio:           cfg.MinLengthRatio,
+		MaxLengthRatio:           cfg.MaxLengthRatio,
+		BackTranslation:          cfg.BackTranslation,
+		BackTranslationThreshold: cfg.BackTranslationThreshold,
+		Strict:                   cfg.Strict,
+	}, nil
+}
+
+// translationPrompt returns how cfg requests translations from OpenAI
+func translationPrompt(cfg config.TranslationConfig) (services.TranslationPrompt, error) {
+	if cfg.Temperature != nil && (*cfg.Temperature < 0 || *cfg.Temperature > 2) {
+		return services.TranslationPrompt{}, fmt.Errorf("translation.temperature %g must be between 0 and 2", *cfg.Temperature)
+	}
+	return services.TranslationPrompt{
+		Model:       cfg.Model,
+		Temperature: cfg.Temperature,
+		System:      cfg.SystemPrompt,
+		User:        cfg.UserPrompt,
+		Audience:    cfg.Audience,
+		Tone:        cfg.Tone,
+	}, nil
+}
+
+// translationProviderNames returns the names of the translation providers, sorted
+func translationProviderNames() []string {
+	names := make([]string, 0, len(translationProviders))
+	for name := range translationProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gocreator/internal/config"
+	"gocreator/internal/interfaces"
+	"gocreator/internal/mocks"
+	"gocreator/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTranslationProviders(t *testing.T) {
+	openaiClient := new(mocks.MockOpenAIClient)
+
+	t.Run("openai only", func(t *testing.T) {
+		providers, err := newTranslationProviders(config.TranslationConfig{}, openaiClient)
+		require.NoError(t, err)
+		assert.Nil(t, providers)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		_, err := newTranslationProviders(config.TranslationConfig{Languages: map[string]string{"ja": "google"}}, openaiClient)
+		assert.EqualError(t, err, `unknown translation provider "google", expected one of deepl, libretranslate, openai`)
+	})
+
+	t.Run("missing API key", func(t *testing.T) {
+		t.Setenv("DEEPL_API_KEY", "")
+		_, err := newTranslationProviders(config.TranslationConfig{Provider: "deepl"}, openaiClient)
+		assert.EqualError(t, err, "translation provider deepl: DEEPL_API_KEY is not set")
+	})
+
+	t.Run("missing LibreTranslate URL", func(t *testing.T) {
+		_, err := newTranslationProviders(config.TranslationConfig{Provider: "libretranslate"}, openaiClient)
+		assert.EqualError(t, err, "translation provider libretranslate: translation.libretranslate.url is required")
+	})
+
+	t.Run("routes each language to its provider", func(t *testing.T) {
+		deepl := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "DeepL-Auth-Key secret", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"translations":[{"text":"Hallo"}]}`))
+		}))
+		defer deepl.Close()
+		libre := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"translatedText":"Hola"}`))
+		}))
+		defer libre.Close()
+		t.Setenv("MY_DEEPL_KEY", "secret")
+
+		providers, err := newTranslationProviders(config.TranslationConfig{
+			Provider:       "libretranslate",
+			Languages:      map[string]string{"de": "deepl"},
+			DeepL:          config.TranslationProviderConfig{URL: deepl.URL, APIKeyEnv: "MY_DEEPL_KEY"},
+			LibreTranslate: config.TranslationProviderConfig{URL: libre.URL},
+		}, openaiClient)
+		require.NoError(t, err)
+
+		logger := &interfaces.SlogLogger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+		service := services.NewTranslationService(openaiClient, logger).WithProviders(providers)
+		translated, err := service.TranslateBatch(context.Background(), []string{"Hello"}, "de")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Hallo"}, translated)
+		translated, err = service.TranslateBatch(context.Background(), []string{"Hello"}, "es")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Hola"}, translated)
+	})
+}
+
+func TestConfigureTranslation(t *testing.T) {
+	openaiClient := new(mocks.MockOpenAIClient)
+	logger := &interfaces.SlogLogger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	base := services.NewTranslationService(openaiClient, logger)
+
+	service, err := configureTranslation(base, config.TranslationConfig{}, openaiClient)
+	require.NoError(t, err)
+	assert.Same(t, base, service)
+
+	service, err = configureTranslation(base, config.TranslationConfig{Mode: "deck", ChunkSize: 10}, openaiClient)
+	require.NoError(t, err)
+	assert.NotSame(t, base, service)
+
+	_, err = configureTranslation(base, config.TranslationConfig{Mode: "document"}, openaiClient)
+	assert.EqualError(t, err, `unknown translation mode "document", expected slide or deck`)
+}
+
+func TestTranslationQA(t *testing.T) {
+	qa, err := translationQA(config.TranslationQAConfig{Enabled: true, Attempts: 3, MaxLengthRatio: 1.5, Strict: true})
+	require.NoError(t, err)
+	assert.Equal(t, services.TranslationQA{Attempts: 3, MaxLengthRatio: 1.5, Strict: true}, qa)
+
+	_, err = translationQA(config.TranslationQAConfig{MinLengthRatio: 2, MaxLengthRatio: 1.5})
+	assert.EqualError(t, err, "translation.qa.min_length_ratio 2 must be below max_length_ratio 1.5")
+	_, err = translationQA(config.TranslationQAConfig{BackTranslationThreshold: 40})
+	assert.EqualError(t, err, "translation.qa.back_translation_threshold 40 must be between 0 and 1")
+}
+
+func TestTranslationPrompt(t *testing.T) {
+	temperature := 0.3
+	prompt, err := translationPrompt(config.TranslationConfig{Model: "gpt-4o", Temperature: &temperature, UserPrompt: "Translate {{.Text}}", Tone: "formal"})
+	require.NoError(t, err)
+	assert.Equal(t, services.TranslationPrompt{Model: "gpt-4o", Temperature: &temperature, User: "Translate {{.Text}}", Tone: "formal"}, prompt)
+
+	hot := 2.5
+	_, err = translationPrompt(config.TranslationConfig{Temperature: &hot})
+	assert.EqualError(t, err, "translation.temperature 2.5 must be between 0 and 2")
+}
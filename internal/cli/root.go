@@ -15,6 +15,11 @@ func NewRootCommand() *cobra.Command {
 	// Add subcommands
 	rootCmd.AddCommand(NewInitCommand())
 	rootCmd.AddCommand(NewCreateCommand())
+	rootCmd.AddCommand(NewWatchCommand())
+	rootCmd.AddCommand(NewBuildAllCommand())
+	rootCmd.AddCommand(NewValidateCommand())
+	rootCmd.AddCommand(NewTMCommand())
+	rootCmd.AddCommand(NewL10nCommand())
 
 	return rootCmd
 }
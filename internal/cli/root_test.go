@@ -19,7 +19,7 @@ func TestNewRootCommand(t *testing.T) {
 	assert.NotEmpty(t, commands)
 	
 	// Verify the command has the expected subcommands
-	var hasInit, hasCreate bool
+	var hasInit, hasCreate, hasWatch, hasBuildAll, hasValidate bool
 	for _, c := range commands {
 		if c.Use == "init" {
 			hasInit = true
@@ -27,10 +27,22 @@ func TestNewRootCommand(t *testing.T) {
 		if c.Use == "create" {
 			hasCreate = true
 		}
+		if c.Use == "build-all [workspace-file]" {
+			hasBuildAll = true
+		}
+		if c.Use == "watch" {
+			hasWatch = true
+		}
+		if c.Use == "validate" {
+			hasValidate = true
+		}
 	}
 	
 	assert.True(t, hasInit, "init command should be present")
 	assert.True(t, hasCreate, "create command should be present")
+	assert.True(t, hasWatch, "watch command should be present")
+	assert.True(t, hasBuildAll, "build-all command should be present")
+	assert.True(t, hasValidate, "validate command should be present")
 }
 
 func TestRootCommandHelp(t *testing.T) {
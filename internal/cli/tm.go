@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gocreator/internal/config"
+	"gocreator/internal/services"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// NewTMCommand creates the tm command, importing and exporting the translation memory
+func NewTMCommand() *cobra.Command {
+	var opts createOptions
+
+	cmd := &cobra.Command{
+		Use:   "tm",
+		Short: "Import or export the translation memory as TMX",
+		Long: `The translation memory keeps the translation of every sentence translated by create, in the translation cache.
+A text whose sentences all have a translation in it is never sent to the translation API, and translations of similar
+sentences are offered to the translator as references. Import the TMX files of a localization vendor to reuse their
+translations, or export the memory to hand it over.`,
+	}
+	cmd.PersistentFlags().StringVarP(&opts.configFile, "config", "c", "", "Config file path (default: looks for gocreator.yaml in current and parent directories)")
+
+	var lang string
+	exportCmd := &cobra.Command{
+		Use:   "export <file.tmx>",
+		Short: "Write the translation memory to a TMX file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTM(opts, func(fs afero.Fs, cfg *config.Config, memory *services.TranslationMemory) error {
+				count, err := exportTM(fs, memory, args[0], cfg.Input.Lang, lang)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("✓ Exported %d segments to %s\n", count, args[0])
+				return nil
+			})
+		},
+	}
+	exportCmd.Flags().StringVar(&lang, "lang", "", "Only export the translations to this language (default: every language)")
+
+	importCmd := &cobra.Command{
+		Use:   "import <file.tmx>...",
+		Short: "Add the translation units of TMX files to the translation memory",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTM(opts, func(fs afero.Fs, _ *config.Config, memory *services.TranslationMemory) error {
+				for _, path := range args {
+					count, err := importTM(fs, memory, path)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("✓ Imported %d segments from %s\n", count, path)
+				}
+				return memory.Save()
+			})
+		},
+	}
+
+	cmd.AddCommand(exportCmd, importCmd)
+	return cmd
+}
+
+// runTM calls fn with the translation memory of the project in the working directory
+func runTM(opts createOptions, fn func(fs afero.Fs, cfg *config.Config, memory *services.TranslationMemory) error) error {
+	rootDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	fs := afero.NewOsFs()
+
+	cfg, _, err := loadCreateConfig(fs, rootDir, opts)
+	if err != nil {
+		return err
+	}
+	memory, err := services.OpenTranslationMemory(fs, translationMemoryPath(rootDir, cfg))
+	if err != nil {
+		return err
+	}
+	return fn(fs, cfg, memory)
+}
+
+// translationMemoryPath returns the path of the translation memory of the project in rootDir
+func translationMemoryPath(rootDir string, cfg *config.Config) string {
+	return filepath.Join(rootDir, cfg.Cache.Directory, "translations", services.TranslationMemoryFile)
+}
+
+// importTM adds the translation units of the TMX file at path to memory
+func importTM(fs afero.Fs, memory *services.TranslationMemory, path string) (int, error) {
+	file, err := fs.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	count, err := memory.ImportTMX(file)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	return count, nil
+}
+
+// exportTM writes the segments of memory from sourceLang to targetLang, every language when empty,
+// to the TMX file at path
+func exportTM(fs afero.Fs, memory *services.TranslationMemory, path, sourceLang, targetLang string) (int, error) {
+	file, err := fs.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	count, err := memory.ExportTMX(file, sourceLang, targetLang)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return count, nil
+}
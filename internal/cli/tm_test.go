@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"testing"
+
+	"gocreator/internal/config"
+	"gocreator/internal/services"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const vendorTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<tmx version="1.4">
+  <header creationtool="Vendor" creationtoolversion="2" segtype="sentence" o-tmf="vendor" adminlang="en-US" srclang="en-US" datatype="plaintext"/>
+  <body>
+    <tu>
+      <tuv xml:lang="en-US"><seg>Welcome to the course.</seg></tuv>
+      <tuv xml:lang="fr-FR"><seg>Bienvenue dans le cours.</seg></tuv>
+      <tuv xml:lang="de-DE"><seg>Willkommen zum Kurs.</seg></tuv>
+    </tu>
+  </body>
+</tmx>
+`
+
+func TestImportExportTM(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/p/vendor.tmx", []byte(vendorTMX), 0644))
+	cfg := config.DefaultConfig()
+	path := translationMemoryPath("/p", cfg)
+
+	memory, err := services.OpenTranslationMemory(fs, path)
+	require.NoError(t, err)
+	count, err := importTM(fs, memory, "/p/vendor.tmx")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.NoError(t, memory.Save())
+
+	// The memory is kept in the translation cache
+	memory, err = services.OpenTranslationMemory(fs, path)
+	require.NoError(t, err)
+	translated, ok := memory.Exact("en", "fr", "Welcome to the course.", "")
+	assert.True(t, ok)
+	assert.Equal(t, "Bienvenue dans le cours.", translated)
+
+	count, err = exportTM(fs, memory, "/p/fr.tmx", "en", "fr")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	exported, err := afero.ReadFile(fs, "/p/fr.tmx")
+	require.NoError(t, err)
+	assert.Contains(t, string(exported), `srclang="en"`)
+	assert.Contains(t, string(exported), `<tuv xml:lang="fr-fr">`)
+	assert.NotContains(t, string(exported), "Willkommen")
+
+	_, err = importTM(fs, memory, "/p/missing.tmx")
+	assert.ErrorContains(t, err, "failed to open /p/missing.tmx")
+}
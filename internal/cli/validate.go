@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"gocreator/internal/interfaces"
+	"gocreator/internal/services"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// NewValidateCommand creates the validate command
+func NewValidateCommand() *cobra.Command {
+	var opts createOptions
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the slides and narration of a project",
+		Long: `Check the slides and narration of a local project without generating anything, the same checks create runs first.
+Reports slides without narration and narration without slides, empty narration, unreadable or empty media, slides whose aspect ratio differs from slide 1,
+files in data/slides that are skipped for their extension, and narration too long for one speech request, each at its file and line.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runValidate(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.configFile, "config", "c", "", "Config file path (default: looks for gocreator.yaml in current and parent directories)")
+
+	return cmd
+}
+
+func runValidate(opts createOptions) error {
+	rootDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	fs := afero.NewOsFs()
+
+	cfg, configPath, err := loadCreateConfig(fs, rootDir, opts)
+	if err != nil {
+		return err
+	}
+	printConfigSource(configPath)
+	if cfg.Input.Source == "google-slides" && cfg.Input.PresentationID != "" {
+		fmt.Println("ℹ Slides come from Google Slides, validating the copy saved by the last run")
+	}
+
+	logger := &interfaces.SlogLogger{Logger: slog.New(slog.NewTextHandler(os.Stderr, nil))}
+	diags, err := validateProject(context.Background(), fs, rootDir, logger, os.Stdout)
+	if err != nil {
+		return err
+	}
+	if len(diags) == 0 {
+		fmt.Println("✓ No problems found")
+	}
+	return validationError(diags)
+}
+
+// validateProject checks the local inputs of the project in rootDir and prints every diagnostic to w,
+// with paths relative to rootDir
+func validateProject(ctx context.Context, fs afero.Fs, rootDir string, logger interfaces.Logger, w io.Writer) (services.Diagnostics, error) {
+	diags, err := services.NewProjectValidator(fs, logger).Validate(ctx, filepath.Join(rootDir, "data"))
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	for _, diag := range diags {
+		if rel, err := filepath.Rel(rootDir, diag.Path); err == nil {
+			diag.Path = rel
+		}
+		_, _ = fmt.Fprintln(w, diag)
+	}
+	return diags, nil
+}
+
+// validationError returns the error of a project with error diagnostics, nil otherwise
+func validationError(diags services.Diagnostics) error {
+	if !diags.HasErrors() {
+		return nil
+	}
+	return fmt.Errorf("project is invalid: %d errors, %d warnings", diags.Count(services.SeverityError), diags.Count(services.SeverityWarning))
+}
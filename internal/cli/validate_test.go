@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"gocreator/internal/interfaces"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateProject(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/p/data/slides/1.png", nil, 0644))
+	require.NoError(t, afero.WriteFile(fs, "/p/data/texts.txt", []byte("One\n-\nTwo"), 0644))
+
+	var out bytes.Buffer
+	diags, err := validateProject(context.Background(), fs, "/p", &interfaces.SlogLogger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, "data/slides/1.png: error: file is empty\ndata/texts.txt:3: error: narration 2 has no slide, there are only 1 slides\n", out.String())
+
+	err = validationError(diags)
+	require.Error(t, err)
+	assert.Equal(t, "project is invalid: 2 errors, 0 warnings", err.Error())
+	assert.NoError(t, validationError(nil))
+}
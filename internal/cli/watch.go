@@ -0,0 +1,253 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"gocreator/internal/interfaces"
+	"gocreator/internal/services"
+	"gocreator/internal/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// watchOptions holds the flags of the watch command
+type watchOptions struct {
+	createOptions
+	debounce time.Duration
+	interval time.Duration
+}
+
+// NewWatchCommand creates the watch command
+func NewWatchCommand() *cobra.Command {
+	var opts watchOptions
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Re-create videos whenever slides, texts or config change",
+		Long: `Watch data/slides, data/texts.txt, data/script.yaml or data/script.md, data/lexicon.yaml, data/glossary.yaml, the config file,
+the human translations data/texts.<lang>.txt or data/script.<lang>.md and the translations locked by l10n import in data/cache/<lang>/text/reviewed.json,
+and re-run the create pipeline when they change.
+Only the slides whose narration or image changed are translated, synthesized and encoded again, everything else comes from cache.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatch(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.inputLang, "lang", "l", "", "Language of the text input (overrides config file)")
+	cmd.Flags().StringVarP(&opts.outputLangs, "langs-out", "o", "", "Comma-separated list of output languages (overrides config file)")
+	cmd.Flags().StringVar(&opts.langs, "langs", "", "Comma-separated subset of the output languages to render, e.g. fr")
+	cmd.Flags().StringVarP(&opts.configFile, "config", "c", "", "Config file path (default: looks for gocreator.yaml in current and parent directories)")
+	cmd.Flags().BoolVar(&opts.noProgress, "no-progress", false, "Disable progress UI")
+	cmd.Flags().IntVarP(&opts.jobs, "jobs", "j", 0, "Maximum concurrent ffmpeg jobs (overrides config file, default: number of CPUs)")
+	cmd.Flags().IntVar(&opts.apiConcurrency, "api-concurrency", 0, "Maximum concurrent OpenAI API calls (overrides config file, default: 4)")
+	cmd.Flags().BoolVar(&opts.keepGoing, "keep-going", false, "Finish every language possible instead of stopping at the first failure")
+	cmd.Flags().StringVar(&opts.reportPath, "report", "", "Path of the JSON report of the last run (default: report.json in the output directory)")
+	cmd.Flags().DurationVar(&opts.debounce, "debounce", 500*time.Millisecond, "Wait this long after the last change before re-running")
+	cmd.Flags().DurationVar(&opts.interval, "interval", 250*time.Millisecond, "How often to check for changes")
+
+	return cmd
+}
+
+func runWatch(opts watchOptions) error {
+	if opts.debounce < 0 || opts.interval <= 0 {
+		return fmt.Errorf("--debounce must not be negative and --interval must be positive")
+	}
+
+	rootDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	fs := afero.NewOsFs()
+
+	// The first configuration decides what is watched
+	cfg, configPath, err := loadCreateConfig(fs, rootDir, opts.createOptions)
+	if err != nil {
+		return err
+	}
+	printConfigSource(configPath)
+	if cfg.Input.Source == "google-slides" {
+		return fmt.Errorf("watch only works with local slides: runs with Google Slides rewrite data/slides and data/texts.txt themselves")
+	}
+	if configPath == "" {
+		// Start a run as soon as a config file is created
+		configPath = filepath.Join(rootDir, "gocreator.yaml")
+	}
+	watched := watchedPaths(rootDir, configPath, cfg.Output.Languages)
+
+	slogger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	logger := &interfaces.SlogLogger{Logger: slogger}
+
+	// Ctrl-C and SIGTERM stop watching, canceling the current run
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The progress UI stays up across runs and is reset at the start of each
+	var prog *tea.Program
+	var progress interfaces.ProgressCallback = &interfaces.NoOpProgressCallback{}
+	uiDone := make(chan struct{})
+	if !opts.noProgress {
+		progressModel := ui.NewProgressModel()
+		prog = tea.NewProgram(progressModel, tea.WithContext(ctx))
+		progress = ui.NewProgressAdapter(prog)
+
+		go func() {
+			defer close(uiDone)
+			if _, err := prog.Run(); err != nil && ctx.Err() == nil {
+				logger.Error("Progress UI error", "error", err)
+			}
+			// The UI reads Ctrl-C and q as key presses, forward them
+			if progressModel.Interrupted() {
+				cancel()
+			}
+		}()
+	} else {
+		close(uiDone)
+	}
+
+	w := &watchSession{
+		fs:       fs,
+		rootDir:  rootDir,
+		opts:     opts.createOptions,
+		prog:     prog,
+		progress: progress,
+		logger:   logger,
+	}
+	w.runOnce(ctx, "Initial run")
+
+	watcher := services.NewFileWatcher(fs, watched, opts.interval, opts.debounce, logger)
+	err = watcher.Watch(ctx, func(changed []string) {
+		w.runOnce(ctx, "Changed: "+describeChanges(rootDir, changed))
+	})
+
+	if prog != nil {
+		prog.Send(ui.CompleteMsg{})
+		<-uiDone
+	}
+	if errors.Is(err, context.Canceled) {
+		fmt.Println("✓ Stopped watching")
+		return nil
+	}
+	return err
+}
+
+// watchSession runs the create pipeline each time watched files change
+type watchSession struct {
+	fs       afero.Fs
+	rootDir  string
+	opts     createOptions
+	prog     *tea.Program
+	progress interfaces.ProgressCallback
+	logger   interfaces.Logger
+	runs     int
+}
+
+// runOnce reloads the configuration and runs the pipeline, reporting the outcome.
+// A failed run is reported and the session keeps watching for a fix.
+func (w *watchSession) runOnce(ctx context.Context, reason string) {
+	w.runs++
+	w.send(ui.RunStartMsg{Run: w.runs, Reason: reason})
+	if w.prog == nil {
+		fmt.Printf("\n↻ Run #%d: %s\n", w.runs, reason)
+	}
+
+	cfg, _, err := loadCreateConfig(w.fs, w.rootDir, w.opts)
+	if err == nil && cfg.Input.Source == "google-slides" {
+		err = fmt.Errorf("watch only works with local slides")
+	}
+	if err != nil {
+		w.idle(fmt.Sprintf("✗ Invalid config, waiting for changes: %v", err))
+		return
+	}
+	shared := newSharedServices(w.fs, cfg.Concurrency, filepath.Join(w.rootDir, cfg.Cache.Directory, "translations"), w.logger)
+	p, err := newCreatePipeline(w.fs, w.rootDir, cfg, w.opts, nil, w.progress, w.logger, shared)
+	if err != nil {
+		w.idle(fmt.Sprintf("✗ Failed to start run, waiting for changes: %v", err))
+		return
+	}
+
+	report, err := p.creator.Run(ctx, p.config)
+	reportPath := p.saveReport(w.fs, report, w.logger)
+	if ctx.Err() != nil {
+		return
+	}
+
+	if w.prog == nil && len(report.Languages) > 0 {
+		if err := report.WriteSummary(os.Stdout); err != nil {
+			w.logger.Warn("Failed to print run summary", "error", err)
+		}
+	}
+	summary := fmt.Sprintf("in %.1fs, %d cache hits, %d API calls (report: %s)", report.Duration, report.CacheHits, report.APICalls, reportPath)
+	if err != nil {
+		w.idle(fmt.Sprintf("✗ Run #%d failed %s: %v. Waiting for changes...", w.runs, summary, err))
+		return
+	}
+	w.idle(fmt.Sprintf("✓ Run #%d succeeded %s. Waiting for changes...", w.runs, summary))
+}
+
+// idle shows message while waiting for the next change
+func (w *watchSession) idle(message string) {
+	if w.prog == nil {
+		fmt.Println(message)
+		return
+	}
+	w.send(ui.WatchStatusMsg{Message: message})
+}
+
+func (w *watchSession) send(msg tea.Msg) {
+	if w.prog != nil {
+		w.prog.Send(msg)
+	}
+}
+
+// watchedPaths returns the inputs of a run: the slides, the narration in any format, the lexicon, the glossary, the config file,
+// and the human and reviewed translations to langs
+func watchedPaths(rootDir, configPath string, langs []string) []string {
+	dataDir := filepath.Join(rootDir, "data")
+	paths := []string{
+		filepath.Join(dataDir, "slides"),
+		filepath.Join(dataDir, "texts.txt"),
+		filepath.Join(dataDir, services.ScriptFile),
+		filepath.Join(dataDir, services.MarkdownScriptFile),
+		filepath.Join(dataDir, services.LexiconFile),
+		filepath.Join(dataDir, services.GlossaryFile),
+		configPath,
+	}
+	for _, lang := range langs {
+		for _, name := range services.HumanTranslationFiles(lang) {
+			paths = append(paths, filepath.Join(dataDir, name))
+		}
+		paths = append(paths, filepath.Join(dataDir, "cache", lang, "text", services.ReviewedTranslationsFile))
+	}
+	return paths
+}
+
+// describeChanges lists the changed paths relative to rootDir, shortened past a few
+func describeChanges(rootDir string, changed []string) string {
+	const shown = 3
+
+	names := make([]string, 0, shown)
+	for i, path := range changed {
+		if i == shown {
+			names = append(names, fmt.Sprintf("and %d more", len(changed)-shown))
+			break
+		}
+		if rel, err := filepath.Rel(rootDir, path); err == nil {
+			path = rel
+		}
+		names = append(names, path)
+	}
+	return strings.Join(names, ", ")
+}
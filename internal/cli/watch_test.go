@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWatchCommand(t *testing.T) {
+	cmd := NewWatchCommand()
+
+	assert.Equal(t, "watch", cmd.Use)
+	assert.NotEmpty(t, cmd.Long)
+
+	debounceFlag := cmd.Flags().Lookup("debounce")
+	assert.NotNil(t, debounceFlag)
+	assert.Equal(t, "500ms", debounceFlag.DefValue)
+
+	assert.NotNil(t, cmd.Flags().Lookup("interval"))
+	assert.NotNil(t, cmd.Flags().Lookup("langs"))
+	assert.NotNil(t, cmd.Flags().Lookup("no-progress"))
+	assert.Nil(t, cmd.Flags().Lookup("slides"))
+}
+
+func TestWatchedPaths(t *testing.T) {
+	paths := watchedPaths("/project", "/project/gocreator.yaml", []string{"en", "fr"})
+	assert.Equal(t, []string{
+		filepath.Join("/project", "data", "slides"),
+		filepath.Join("/project", "data", "texts.txt"),
+		filepath.Join("/project", "data", "script.yaml"),
+		filepath.Join("/project", "data", "script.md"),
+		filepath.Join("/project", "data", "lexicon.yaml"),
+		filepath.Join("/project", "data", "glossary.yaml"),
+		"/project/gocreator.yaml",
+		filepath.Join("/project", "data", "texts.en.txt"),
+		filepath.Join("/project", "data", "script.en.md"),
+		filepath.Join("/project", "data", "cache", "en", "text", "reviewed.json"),
+		filepath.Join("/project", "data", "texts.fr.txt"),
+		filepath.Join("/project", "data", "script.fr.md"),
+		filepath.Join("/project", "data", "cache", "fr", "text", "reviewed.json"),
+	}, paths)
+}
+
+func TestDescribeChanges(t *testing.T) {
+	assert.Equal(t, "data/texts.txt", describeChanges("/project", []string{"/project/data/texts.txt"}))
+	assert.Equal(t, "a, b, c, and 2 more", describeChanges("/project", []string{
+		"/project/a", "/project/b", "/project/c", "/project/d", "/project/e",
+	}))
+}
@@ -11,11 +11,14 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	Input      InputConfig      `yaml:"input"`
-	Output     OutputConfig     `yaml:"output"`
-	Voice      VoiceConfig      `yaml:"voice,omitempty"`
-	Cache      CacheConfig      `yaml:"cache,omitempty"`
-	Transition TransitionConfig `yaml:"transition,omitempty"`
+	Input       InputConfig       `yaml:"input"`
+	Output      OutputConfig      `yaml:"output"`
+	Voice       VoiceConfig       `yaml:"voice,omitempty"`
+	Cache       CacheConfig       `yaml:"cache,omitempty"`
+	Transition  TransitionConfig  `yaml:"transition,omitempty"`
+	Concurrency ConcurrencyConfig `yaml:"concurrency,omitempty"`
+	Slides      SlidesConfig      `yaml:"slides,omitempty"`
+	Translation TranslationConfig `yaml:"translation,omitempty"`
 }
 
 // InputConfig represents input configuration
@@ -52,6 +55,58 @@ type TransitionConfig struct {
 	Duration float64 `yaml:"duration,omitempty"` // Duration in seconds
 }
 
+// SlidesConfig represents slide timing configuration
+type SlidesConfig struct {
+	SilentDuration float64 `yaml:"silent_duration,omitempty"` // Seconds a slide without narration is shown, 0 for the default (3)
+}
+
+// TranslationConfig selects the provider translating each language, and how
+type TranslationConfig struct {
+	Provider       string                    `yaml:"provider,omitempty"`      // openai (default), deepl, libretranslate
+	Languages      map[string]string         `yaml:"languages,omitempty"`     // Provider per output language, overriding provider
+	Mode           string                    `yaml:"mode,omitempty"`          // slide (default): one request per slide; deck: the slides of a language together, with OpenAI
+	ChunkSize      int                       `yaml:"chunk_size,omitempty"`    // Slides per request in deck mode, 0 for the default (40)
+	Model          string                    `yaml:"model,omitempty"`         // OpenAI chat model, "" for the default (gpt-4o-mini)
+	Temperature    *float64                  `yaml:"temperature,omitempty"`   // Sampling temperature from 0 to 2, unset for the default of the model
+	SystemPrompt   string                    `yaml:"system_prompt,omitempty"` // System prompt of OpenAI translations
+	UserPrompt     string                    `yaml:"user_prompt,omitempty"`   // Go template of the user prompt of OpenAI translations, "" for the built-in one
+	Audience       string                    `yaml:"audience,omitempty"`      // Who the narration is for, told to OpenAI
+	Tone           string                    `yaml:"tone,omitempty"`          // Tone of the narration, told to OpenAI
+	Memory         TranslationMemoryConfig   `yaml:"memory,omitempty"`
+	QA             TranslationQAConfig       `yaml:"qa,omitempty"`
+	DeepL          TranslationProviderConfig `yaml:"deepl,omitempty"`
+	LibreTranslate TranslationProviderConfig `yaml:"libretranslate,omitempty"`
+}
+
+// TranslationMemoryConfig tunes the translation memory, which keeps the translation of every sentence
+type TranslationMemoryConfig struct {
+	Disabled       bool    `yaml:"disabled,omitempty"`        // Neither reuse nor record translations of sentences
+	FuzzyThreshold float64 `yaml:"fuzzy_threshold,omitempty"` // Similarity from 0 to 1 of the sentences offered to the translator, 0 for the default (0.75)
+}
+
+// TranslationQAConfig enables the quality checks of machine translations, before any narration is synthesized
+type TranslationQAConfig struct {
+	Enabled                  bool    `yaml:"enabled,omitempty"`
+	Attempts                 int     `yaml:"attempts,omitempty"`                   // Times a translation failing a check is translated, 0 for the default (2)
+	MinLengthRatio           float64 `yaml:"min_length_ratio,omitempty"`           // Shortest translation relative to the source, 0 for the default (0.5)
+	MaxLengthRatio           float64 `yaml:"max_length_ratio,omitempty"`           // Longest translation relative to the source, 0 for the default (2)
+	BackTranslation          bool    `yaml:"back_translation,omitempty"`           // Translate every translation back and compare it to the source, one more request per slide
+	BackTranslationThreshold float64 `yaml:"back_translation_threshold,omitempty"` // Least similarity from 0 to 1 of the back translation, 0 for the default (0.4)
+	Strict                   bool    `yaml:"strict,omitempty"`                     // Fail the language when a translation still fails a check, instead of flagging it in the report
+}
+
+// TranslationProviderConfig locates the API of a translation provider
+type TranslationProviderConfig struct {
+	URL       string `yaml:"url,omitempty"`         // Base URL of the API, for a self-hosted or compatible server
+	APIKeyEnv string `yaml:"api_key_env,omitempty"` // Environment variable holding the API key
+}
+
+// ConcurrencyConfig limits how much work runs at once
+type ConcurrencyConfig struct {
+	Jobs int `yaml:"jobs,omitempty"` // Concurrent ffmpeg jobs, 0 for the number of CPUs
+	API  int `yaml:"api,omitempty"`  // Concurrent OpenAI API calls, 0 for the default (4)
+}
+
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
@@ -151,12 +206,15 @@ func FindConfigFile(fs afero.Fs) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return FindConfigFileFrom(fs, wd)
+}
 
+// FindConfigFileFrom searches for config file in dir and its parent directories
+func FindConfigFileFrom(fs afero.Fs, dir string) (string, error) {
 	// Check common config file names
 	configNames := []string{"gocreator.yaml", "gocreator.yml", ".gocreator.yaml", ".gocreator.yml"}
 
-	// Search current directory and parent directories
-	dir := wd
+	// Search the directory and parent directories
 	for {
 		for _, name := range configNames {
 			path := filepath.Join(dir, name)
@@ -79,6 +79,23 @@ output:
 				assert.Equal(t, []string{"en"}, cfg.Output.Languages)
 				// Defaults should be preserved
 				assert.Equal(t, "tts-1-hd", cfg.Voice.Model)
+				assert.Equal(t, 0, cfg.Concurrency.Jobs)
+			},
+		},
+		{
+			name: "concurrency limits",
+			yamlContent: `input:
+  lang: en
+output:
+  languages: [en]
+concurrency:
+  jobs: 2
+  api: 6
+`,
+			wantErr: false,
+			validate: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, 2, cfg.Concurrency.Jobs)
+				assert.Equal(t, 6, cfg.Concurrency.API)
 			},
 		},
 		{
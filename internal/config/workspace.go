@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/afero"
+)
+
+// DefaultWorkspaceFile is the workspace manifest build-all reads when none is given
+const DefaultWorkspaceFile = "gocreator-workspace.yaml"
+
+// Workspace lists the projects built together by build-all
+type Workspace struct {
+	// Cache is the translation cache shared by every project, relative to the workspace file
+	Cache       CacheConfig        `yaml:"cache,omitempty"`
+	Concurrency ConcurrencyConfig  `yaml:"concurrency,omitempty"`
+	Projects    []WorkspaceProject `yaml:"projects"`
+
+	dir string
+}
+
+// WorkspaceProject is one project of a workspace
+type WorkspaceProject struct {
+	Path      string         `yaml:"path"`                // Project directory, relative to the workspace file
+	Config    string         `yaml:"config,omitempty"`    // Config file, relative to the project. Default: found from the project directory
+	Overrides map[string]any `yaml:"overrides,omitempty"` // Config settings replacing those of the config file
+}
+
+// LoadWorkspace loads and validates a workspace manifest
+func LoadWorkspace(fs afero.Fs, path string) (*Workspace, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace file: %w", err)
+	}
+
+	ws := &Workspace{
+		Cache: CacheConfig{Enabled: true, Directory: "./.gocreator-cache"},
+	}
+	if err := yaml.Unmarshal(data, ws); err != nil {
+		return nil, fmt.Errorf("failed to parse workspace file: %w", err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve workspace file: %w", err)
+	}
+	ws.dir = filepath.Dir(absPath)
+
+	if len(ws.Projects) == 0 {
+		return nil, fmt.Errorf("workspace file %s lists no projects", path)
+	}
+	seen := make(map[string]bool)
+	for i, project := range ws.Projects {
+		if project.Path == "" {
+			return nil, fmt.Errorf("project %d of workspace file %s has no path", i+1, path)
+		}
+		dir := ws.ProjectDir(project)
+		if seen[dir] {
+			return nil, fmt.Errorf("project %s is listed twice in workspace file %s", project.Path, path)
+		}
+		seen[dir] = true
+	}
+	return ws, nil
+}
+
+// ProjectDir returns the absolute directory of project
+func (w *Workspace) ProjectDir(project WorkspaceProject) string {
+	if filepath.IsAbs(project.Path) {
+		return filepath.Clean(project.Path)
+	}
+	return filepath.Join(w.dir, project.Path)
+}
+
+// CacheDir returns the absolute directory of the shared translation cache
+func (w *Workspace) CacheDir() string {
+	if filepath.IsAbs(w.Cache.Directory) {
+		return w.Cache.Directory
+	}
+	return filepath.Join(w.dir, w.Cache.Directory)
+}
+
+// ProjectConfig loads the configuration of project and applies its overrides.
+// It also returns the path of the config file loaded, empty when the default configuration is used.
+func (w *Workspace) ProjectConfig(fs afero.Fs, project WorkspaceProject) (*Config, string, error) {
+	dir := w.ProjectDir(project)
+
+	configPath := ""
+	if project.Config != "" {
+		configPath = project.Config
+		if !filepath.IsAbs(configPath) {
+			configPath = filepath.Join(dir, configPath)
+		}
+	} else {
+		found, err := FindConfigFileFrom(fs, dir)
+		if err != nil {
+			return nil, "", fmt.Errorf("error searching for config file: %w", err)
+		}
+		configPath = found
+	}
+
+	cfg := DefaultConfig()
+	if configPath != "" {
+		loaded, err := LoadConfig(fs, configPath)
+		if err != nil {
+			return nil, "", err
+		}
+		cfg = loaded
+	}
+
+	if err := applyOverrides(cfg, project.Overrides); err != nil {
+		return nil, "", fmt.Errorf("invalid overrides of project %s: %w", project.Path, err)
+	}
+	return cfg, configPath, nil
+}
+
+// applyOverrides replaces the settings of cfg given in overrides, which mirror the config file.
+// Unknown settings are rejected so a typo can't be silently ignored.
+func applyOverrides(cfg *Config, overrides map[string]any) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+	data, err := yaml.Marshal(overrides)
+	if err != nil {
+		return err
+	}
+	return yaml.UnmarshalWithOptions(data, cfg, yaml.Strict())
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWorkspace(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "valid workspace",
+			content: `cache:
+  directory: ./shared-cache
+concurrency:
+  jobs: 2
+projects:
+  - path: course-a
+  - path: course-b
+    config: custom.yaml
+`,
+		},
+		{
+			name:    "no projects",
+			content: "projects: []\n",
+			wantErr: "lists no projects",
+		},
+		{
+			name:    "project without path",
+			content: "projects:\n  - config: custom.yaml\n",
+			wantErr: "has no path",
+		},
+		{
+			name:    "duplicate project",
+			content: "projects:\n  - path: course-a\n  - path: ./course-a\n",
+			wantErr: "listed twice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "/work/gocreator-workspace.yaml", []byte(tt.content), 0644))
+
+			ws, err := LoadWorkspace(fs, "/work/gocreator-workspace.yaml")
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, ws.Projects, 2)
+			assert.Equal(t, 2, ws.Concurrency.Jobs)
+			assert.Equal(t, filepath.Join("/work", "shared-cache"), ws.CacheDir())
+			assert.Equal(t, filepath.Join("/work", "course-a"), ws.ProjectDir(ws.Projects[0]))
+		})
+	}
+}
+
+func TestWorkspaceProjectConfig(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/work/gocreator-workspace.yaml", []byte(`projects:
+  - path: course-a
+    overrides:
+      output:
+        languages: [en, de]
+      voice:
+        voice: nova
+  - path: course-b
+    config: custom.yaml
+  - path: course-c
+  - path: course-d
+    overrides:
+      voice:
+        voise: nova
+`), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/work/course-a/gocreator.yaml", []byte(`input:
+  lang: en
+output:
+  languages: [en, fr]
+voice:
+  voice: alloy
+  speed: 1.2
+`), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/work/course-b/custom.yaml", []byte("input:\n  lang: fr\n"), 0644))
+	require.NoError(t, fs.MkdirAll("/work/course-c", 0755))
+
+	ws, err := LoadWorkspace(fs, "/work/gocreator-workspace.yaml")
+	require.NoError(t, err)
+
+	t.Run("overrides replace config file settings", func(t *testing.T) {
+		cfg, path, err := ws.ProjectConfig(fs, ws.Projects[0])
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("/work", "course-a", "gocreator.yaml"), path)
+		assert.Equal(t, []string{"en", "de"}, cfg.Output.Languages)
+		assert.Equal(t, "nova", cfg.Voice.Voice)
+		assert.Equal(t, 1.2, cfg.Voice.Speed, "settings not overridden are kept")
+	})
+
+	t.Run("explicit config file", func(t *testing.T) {
+		cfg, path, err := ws.ProjectConfig(fs, ws.Projects[1])
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("/work", "course-b", "custom.yaml"), path)
+		assert.Equal(t, "fr", cfg.Input.Lang)
+	})
+
+	t.Run("default config without a config file", func(t *testing.T) {
+		cfg, path, err := ws.ProjectConfig(fs, ws.Projects[2])
+		require.NoError(t, err)
+		assert.Empty(t, path)
+		assert.Equal(t, DefaultConfig().Voice, cfg.Voice)
+	})
+
+	t.Run("unknown override is rejected", func(t *testing.T) {
+		_, _, err := ws.ProjectConfig(fs, ws.Projects[3])
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid overrides of project course-d")
+	})
+}
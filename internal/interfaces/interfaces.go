@@ -5,6 +5,8 @@ import (
 	"io"
 	"log/slog"
 
+	"gocreator/internal/timeline"
+
 	"github.com/openai/openai-go/v3"
 	"github.com/spf13/afero"
 )
@@ -20,15 +22,48 @@ type Translator interface {
 	TranslateBatch(ctx context.Context, texts []string, targetLang string) ([]string, error)
 }
 
+// TranslationProvider translates one text with a translation backend, such as OpenAI or DeepL
+type TranslationProvider interface {
+	Translate(ctx context.Context, text, targetLang string) (string, error)
+}
+
+// DefaultChatModel is the model answering a chat completion whose options name none
+const DefaultChatModel = "gpt-4o-mini"
+
+// ChatOptions selects the model answering a chat completion, how it samples and the format of
+// its answer. Zero fields use the defaults of the chat client and a free text answer.
+type ChatOptions struct {
+	Model       string
+	Temperature *float64
+	Schema      *JSONSchema // JSON the answer must match, nil for free text
+}
+
 // AudioGenerator generates audio from text
 type AudioGenerator interface {
-	Generate(ctx context.Context, text, outputPath string) error
-	GenerateBatch(ctx context.Context, texts []string, outputDir string) ([]string, error)
+	// Generate generates audio from text spoken by voice, the zero Voice for the default one
+	Generate(ctx context.Context, text string, voice Voice, outputPath string) error
+	// GenerateBatch generates audio for multiple texts, text i spoken by voices[i], or every text
+	// by the default voice when voices is nil
+	GenerateBatch(ctx context.Context, texts []string, voices []Voice, outputDir string) ([]string, error)
 }
 
-// VideoGenerator generates videos from slides and audio
+// Voice selects how speech is synthesized. Zero fields use the defaults of the speech client.
+type Voice struct {
+	Name  string
+	Speed float64
+}
+
+// IsDefault reports whether the voice only uses the defaults of the speech client
+func (v Voice) IsDefault() bool {
+	return v.Name == "" && v.Speed == 0
+}
+
+// VideoGenerator renders a timeline of slides and audio to a video
 type VideoGenerator interface {
-	GenerateFromSlides(ctx context.Context, slides, audioPaths []string, outputPath string) error
+	GenerateFromTimeline(ctx context.Context, tl timeline.Timeline, outputPath string) error
+	// GeneratePreview renders a timeline of the slides at indices of the video at fullOutputPath,
+	// reusing its segment cache
+	GeneratePreview(ctx context.Context, tl timeline.Timeline, indices []int, fullOutputPath, outputPath string) error
 }
 
 // TextProcessor handles text loading and processing
@@ -63,8 +98,16 @@ type Logger interface {
 
 // OpenAIClient wraps OpenAI client operations
 type OpenAIClient interface {
-	ChatCompletion(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion) (string, error)
-	GenerateSpeech(ctx context.Context, text string) (io.ReadCloser, error)
+	// ChatCompletion sends a chat completion request with the model, sampling and answer format of options
+	ChatCompletion(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion, options ChatOptions) (string, error)
+	// GenerateSpeech generates speech from text spoken by voice, the zero Voice for the default one
+	GenerateSpeech(ctx context.Context, text string, voice Voice) (io.ReadCloser, error)
+}
+
+// JSONSchema constrains the answer of a chat completion to JSON matching Schema
+type JSONSchema struct {
+	Name   string
+	Schema map[string]any
 }
 
 // SlogLogger adapts slog.Logger to our Logger interface
@@ -4,6 +4,8 @@ import (
 	"context"
 	"io"
 
+	"gocreator/internal/interfaces"
+
 	"github.com/openai/openai-go/v3"
 	"github.com/stretchr/testify/mock"
 )
@@ -13,13 +15,13 @@ type MockOpenAIClient struct {
 	mock.Mock
 }
 
-func (m *MockOpenAIClient) ChatCompletion(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion) (string, error) {
-	args := m.Called(ctx, messages)
+func (m *MockOpenAIClient) ChatCompletion(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion, options interfaces.ChatOptions) (string, error) {
+	args := m.Called(ctx, messages, options)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockOpenAIClient) GenerateSpeech(ctx context.Context, text string) (io.ReadCloser, error) {
-	args := m.Called(ctx, text)
+func (m *MockOpenAIClient) GenerateSpeech(ctx context.Context, text string, voice interfaces.Voice) (io.ReadCloser, error) {
+	args := m.Called(ctx, text, voice)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -3,6 +3,9 @@ package mocks
 import (
 	"context"
 
+	"gocreator/internal/interfaces"
+	"gocreator/internal/timeline"
+
 	"github.com/stretchr/testify/mock"
 )
 
@@ -52,13 +55,13 @@ type MockAudioGenerator struct {
 	mock.Mock
 }
 
-func (m *MockAudioGenerator) Generate(ctx context.Context, text, outputPath string) error {
-	args := m.Called(ctx, text, outputPath)
+func (m *MockAudioGenerator) Generate(ctx context.Context, text string, voice interfaces.Voice, outputPath string) error {
+	args := m.Called(ctx, text, voice, outputPath)
 	return args.Error(0)
 }
 
-func (m *MockAudioGenerator) GenerateBatch(ctx context.Context, texts []string, outputDir string) ([]string, error) {
-	args := m.Called(ctx, texts, outputDir)
+func (m *MockAudioGenerator) GenerateBatch(ctx context.Context, texts []string, voices []interfaces.Voice, outputDir string) ([]string, error) {
+	args := m.Called(ctx, texts, voices, outputDir)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -70,8 +73,13 @@ type MockVideoGenerator struct {
 	mock.Mock
 }
 
-func (m *MockVideoGenerator) GenerateFromSlides(ctx context.Context, slides, audioPaths []string, outputPath string) error {
-	args := m.Called(ctx, slides, audioPaths, outputPath)
+func (m *MockVideoGenerator) GenerateFromTimeline(ctx context.Context, tl timeline.Timeline, outputPath string) error {
+	args := m.Called(ctx, tl, outputPath)
+	return args.Error(0)
+}
+
+func (m *MockVideoGenerator) GeneratePreview(ctx context.Context, tl timeline.Timeline, indices []int, fullOutputPath, outputPath string) error {
+	args := m.Called(ctx, tl, indices, fullOutputPath, outputPath)
 	return args.Error(0)
 }
 
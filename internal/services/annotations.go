@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Narration in texts.txt format may hold lines that are never spoken nor translated:
+//
+//	# a comment, for whoever edits the narration
+//	@voice: nova
+//	@duration: 4s
+//	\- a narrated line starting with a character above, escaped with a backslash
+var textMetadataLine = regexp.MustCompile(`^@([A-Za-z_][\w-]*):[ \t]*(.*?)[ \t]*$`)
+
+// textLineKind is what a line of narration in texts.txt format holds
+type textLineKind int
+
+const (
+	textLineNarration textLineKind = iota
+	textLineComment
+	textLineMetadata
+	textLineEscaped
+)
+
+// classifyTextLine returns what a line of narration holds
+func classifyTextLine(line string) textLineKind {
+	trimmed := strings.TrimLeft(line, " \t")
+	switch {
+	case strings.HasPrefix(trimmed, "#"):
+		return textLineComment
+	case textMetadataLine.MatchString(trimmed):
+		return textLineMetadata
+	case strings.HasPrefix(trimmed, `\`):
+		return textLineEscaped
+	}
+	return textLineNarration
+}
+
+// hasTextAnnotations reports whether text holds comments, metadata or escaped lines
+func hasTextAnnotations(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if classifyTextLine(line) != textLineNarration {
+			return true
+		}
+	}
+	return false
+}
+
+// textSpeech returns the narration of text without its comments and metadata, with escaped lines unescaped
+func textSpeech(text string) string {
+	if !hasTextAnnotations(text) {
+		return text
+	}
+	var spoken []string
+	for _, line := range strings.Split(text, "\n") {
+		switch classifyTextLine(line) {
+		case textLineComment, textLineMetadata:
+			continue
+		case textLineEscaped:
+			trimmed := strings.TrimLeft(line, " \t")
+			line = line[:len(line)-len(trimmed)] + trimmed[1:]
+		}
+		spoken = append(spoken, line)
+	}
+	return strings.TrimSpace(strings.Join(spoken, "\n"))
+}
+
+// escapeTextLines escapes the lines of narration that would otherwise read as a delimiter,
+// a comment, metadata or an escape
+func escapeTextLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "-" || classifyTextLine(line) != textLineNarration {
+			trimmed := strings.TrimLeft(line, " \t")
+			lines[i] = line[:len(line)-len(trimmed)] + `\` + trimmed
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// annotateTranslation returns the translation of source with the comments and metadata of source
+// above it, so the saved translation keeps them
+func annotateTranslation(source, translation string) string {
+	var lines []string
+	for _, line := range strings.Split(source, "\n") {
+		if kind := classifyTextLine(line); kind == textLineComment || kind == textLineMetadata {
+			lines = append(lines, line)
+		}
+	}
+	if translation != "" {
+		lines = append(lines, escapeTextLines(translation))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// textMetadataKeys are the metadata a block of texts.txt accepts, each a setting of ScriptSlide
+var textMetadataKeys = map[string]func(slide *ScriptSlide, value string) error{
+	"voice": func(slide *ScriptSlide, value string) error {
+		slide.Voice = value
+		return nil
+	},
+	"speed": func(slide *ScriptSlide, value string) (err error) {
+		slide.Speed, err = strconv.ParseFloat(value, 64)
+		return err
+	},
+	"duration":     secondsSetter(func(slide *ScriptSlide) *float64 { return &slide.Duration }),
+	"min_duration": secondsSetter(func(slide *ScriptSlide) *float64 { return &slide.MinDuration }),
+	"pause_before": secondsSetter(func(slide *ScriptSlide) *float64 { return &slide.PauseBefore }),
+	"pause_after":  secondsSetter(func(slide *ScriptSlide) *float64 { return &slide.PauseAfter }),
+	"transition": func(slide *ScriptSlide, value string) error {
+		// A type, optionally followed by a duration: "fade" or "fade 0.8s"
+		fields := strings.Fields(value)
+		if len(fields) == 0 || len(fields) > 2 {
+			return fmt.Errorf("expected a transition type and an optional duration")
+		}
+		slide.TransitionOut = &ScriptTransition{Type: fields[0]}
+		if len(fields) == 2 {
+			seconds, err := parseSeconds(fields[1])
+			if err != nil {
+				return err
+			}
+			slide.TransitionOut.Duration = seconds
+		}
+		return nil
+	},
+	"notes": func(slide *ScriptSlide, value string) error {
+		slide.Notes = value
+		return nil
+	},
+}
+
+// secondsSetter returns the setter of the duration field of a slide
+func secondsSetter(field func(slide *ScriptSlide) *float64) func(slide *ScriptSlide, value string) error {
+	return func(slide *ScriptSlide, value string) (err error) {
+		*field(slide), err = parseSeconds(value)
+		return err
+	}
+}
+
+// parseSeconds reads a duration such as 4s, 800ms or 1.5, in seconds
+func parseSeconds(value string) (float64, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return seconds, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, expected e.g. 4s, 800ms or 1.5", value)
+	}
+	return duration.Seconds(), nil
+}
+
+// textMetadataError is an invalid metadata line of a block, at its line within the block
+type textMetadataError struct {
+	Line int // 1-based
+	Err  error
+}
+
+func (e *textMetadataError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *textMetadataError) Unwrap() error {
+	return e.Err
+}
+
+// textSlide returns the slide settings of the metadata of a block of texts.txt, with the block as narration
+func textSlide(text string) (ScriptSlide, error) {
+	slide := ScriptSlide{Narration: text}
+	seen := make(map[string]bool)
+	for i, line := range strings.Split(text, "\n") {
+		if classifyTextLine(line) != textLineMetadata {
+			continue
+		}
+		match := textMetadataLine.FindStringSubmatch(strings.TrimLeft(line, " \t"))
+		key, value := strings.ToLower(match[1]), match[2]
+		set, ok := textMetadataKeys[key]
+		if !ok {
+			return ScriptSlide{}, &textMetadataError{Line: i + 1, Err: fmt.Errorf("unknown metadata @%s, expected one of %s", key, strings.Join(textMetadataNames(), ", "))}
+		}
+		if seen[key] {
+			return ScriptSlide{}, &textMetadataError{Line: i + 1, Err: fmt.Errorf("@%s is set twice", key)}
+		}
+		seen[key] = true
+		if err := set(&slide, value); err != nil {
+			return ScriptSlide{}, &textMetadataError{Line: i + 1, Err: fmt.Errorf("@%s: %w", key, err)}
+		}
+	}
+	return slide, nil
+}
+
+// textMetadataNames returns the metadata keys, sorted
+func textMetadataNames() []string {
+	names := make([]string, 0, len(textMetadataKeys))
+	for name := range textMetadataKeys {
+		names = append(names, "@"+name)
+	}
+	sort.Strings(names)
+	return names
+}
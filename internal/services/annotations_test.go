@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextSpeech(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{name: "plain narration", text: "Hello\nworld", expected: "Hello\nworld"},
+		{name: "comments and metadata", text: "# cut in v2?\n@voice: nova\nHello\n  # indented comment", expected: "Hello"},
+		{name: "escaped lines", text: `\# not a comment` + "\n" + `\@voice: spoken` + "\n" + `\\backslash`, expected: "# not a comment\n@voice: spoken\n\\backslash"},
+		{name: "only metadata", text: "@duration: 4s", expected: ""},
+		{name: "email is narration", text: "Write to me@example.com", expected: "Write to me@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, textSpeech(tt.text))
+		})
+	}
+}
+
+func TestAnnotateTranslation(t *testing.T) {
+	source := "# keep short\n@duration: 5\nHello"
+	annotated := annotateTranslation(source, "# Bonjour\n-\nsalut")
+	assert.Equal(t, "# keep short\n@duration: 5\n\\# Bonjour\n\\-\nsalut", annotated)
+	assert.Equal(t, "# Bonjour\n-\nsalut", textSpeech(annotated))
+
+	assert.Equal(t, "@duration: 4s", annotateTranslation("@duration: 4s", ""))
+}
+
+func TestTextSlide(t *testing.T) {
+	t.Run("every key", func(t *testing.T) {
+		text := "@voice: nova\n@Speed: 1.2\n@duration: 6s\n@pause_before: 500ms\n@pause_after: 1\n@transition: fade 0.8s\n@notes: check numbers\nHello"
+		slide, err := textSlide(text)
+		require.NoError(t, err)
+		assert.Equal(t, ScriptSlide{
+			Narration:     text,
+			Voice:         "nova",
+			Speed:         1.2,
+			Duration:      6,
+			PauseBefore:   0.5,
+			PauseAfter:    1,
+			TransitionOut: &ScriptTransition{Type: "fade", Duration: 0.8},
+			Notes:         "check numbers",
+		}, slide)
+	})
+
+	errorTests := []struct {
+		name string
+		text string
+		line int
+		msg  string
+	}{
+		{name: "unknown key", text: "Hello\n@volume: 3", line: 2, msg: "unknown metadata @volume, expected one of @duration, @min_duration, @notes, @pause_after, @pause_before, @speed, @transition, @voice"},
+		{name: "duplicate key", text: "@voice: nova\n@voice: echo", line: 2, msg: "@voice is set twice"},
+		{name: "invalid duration", text: "@duration: soon", line: 1, msg: `@duration: invalid duration "soon", expected e.g. 4s, 800ms or 1.5`},
+		{name: "invalid transition", text: "# note\n@transition:", line: 2, msg: "@transition: expected a transition type and an optional duration"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := textSlide(tt.text)
+			var metadataErr *textMetadataError
+			require.True(t, errors.As(err, &metadataErr))
+			assert.Equal(t, tt.line, metadataErr.Line)
+			assert.EqualError(t, metadataErr.Err, tt.msg)
+		})
+	}
+}
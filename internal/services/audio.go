@@ -1,12 +1,17 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"fmt"
 	"io"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"gocreator/internal/interfaces"
 
@@ -19,6 +24,9 @@ type AudioService struct {
 	client      interfaces.OpenAIClient
 	textService *TextService
 	logger      interfaces.Logger
+	scheduler   *Scheduler
+	manifest    *Manifest
+	lexicon     *Lexicon
 }
 
 // NewAudioService creates a new audio service
@@ -28,65 +36,117 @@ func NewAudioService(fs afero.Fs, client interfaces.OpenAIClient, textService *T
 		client:      client,
 		textService: textService,
 		logger:      logger,
+		scheduler:   NewScheduler(0, 0),
 	}
 }
 
-// Generate generates audio from text
-func (s *AudioService) Generate(ctx context.Context, text, outputPath string) error {
+// SetScheduler sets the scheduler bounding concurrent speech API calls
+func (s *AudioService) SetScheduler(scheduler *Scheduler) {
+	s.scheduler = scheduler
+}
+
+// SetManifest sets the manifest recording every audio file of the run
+func (s *AudioService) SetManifest(manifest *Manifest) {
+	s.manifest = manifest
+}
+
+// SetLexicon sets the pronunciations applied to narration before speech, in the language
+// carried by the context of each request
+func (s *AudioService) SetLexicon(lexicon *Lexicon) {
+	s.lexicon = lexicon
+}
+
+// inputsFingerprint identifies the pronunciations of lang in the lexicon, "" without any
+func (s *AudioService) inputsFingerprint(lang string) string {
+	if fingerprint := s.lexicon.fingerprint(lang); fingerprint != "" {
+		return "lexicon=" + fingerprint
+	}
+	return ""
+}
+
+// Generate generates audio from text spoken by voice, the zero Voice for the default one
+func (s *AudioService) Generate(ctx context.Context, text string, voice interfaces.Voice, outputPath string) error {
+	parts, hash := s.prepare(ctx, text, voice)
+
 	// Check cache
-	cached, err := s.checkCache(ctx, text, outputPath)
+	cached, err := s.checkCache(hash, outputPath)
 	if err != nil {
 		return fmt.Errorf("failed to check cache: %w", err)
 	}
 	if cached {
 		s.logger.Info("Using cached audio", "path", outputPath)
+		recordCacheHit(ctx)
 		return nil
 	}
 
-	// Generate audio
-	body, err := s.client.GenerateSpeech(ctx, text)
-	if err != nil {
-		return fmt.Errorf("failed to generate speech: %w", err)
+	// Generate audio, holding the API slot until the response is fully read.
+	// Narration with speech markup is spoken in parts, joined with the pauses between them.
+	if len(parts) == 1 && parts[0].isPlain() {
+		err = s.scheduler.API(ctx, func() error {
+			recordAPICall(ctx)
+			return s.synthesize(ctx, parts[0].Text, voice, outputPath)
+		})
+	} else {
+		err = s.render(ctx, parts, voice, outputPath)
 	}
-	defer func() { _ = body.Close() }()
-
-	// Ensure directory exists
-	dir := filepath.Dir(outputPath)
-	if err := s.fs.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
-	}
-
-	// Write to file
-	file, err := s.fs.Create(outputPath)
 	if err != nil {
-		return fmt.Errorf("failed to create audio file: %w", err)
-	}
-	defer func() { _ = file.Close() }()
-
-	if _, err := io.Copy(file, body); err != nil {
-		return fmt.Errorf("failed to write audio: %w", err)
+		return err
 	}
 
 	// Save hash for cache validation
-	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(text)))
 	hashPath := outputPath + ".hash"
-	if err := afero.WriteFile(s.fs, hashPath, []byte(hash), 0644); err != nil {
+	if err := writeFileAtomic(s.fs, hashPath, []byte(hash), 0644); err != nil {
 		return fmt.Errorf("failed to write hash file: %w", err)
 	}
 
 	return nil
 }
 
-// GenerateBatch generates audio for multiple texts in parallel
-func (s *AudioService) GenerateBatch(ctx context.Context, texts []string, outputDir string) ([]string, error) {
+// prepare splits text into the parts spoken on their own, pronounced with the lexicon of the
+// language of ctx, and returns the cache key of their audio spoken by voice
+func (s *AudioService) prepare(ctx context.Context, text string, voice interfaces.Voice) ([]speechPart, string) {
+	parts := parseSpeechMarkup(text)
+	var used []LexiconEntry
+	for i := range parts {
+		if parts[i].Pause > 0 {
+			continue
+		}
+		var partUsed []LexiconEntry
+		parts[i].Text, partUsed = s.lexicon.Pronounce(languageOf(ctx), parts[i].Text)
+		used = append(used, partUsed...)
+	}
+	return parts, speechHash(text, voice, lexiconFingerprint(used))
+}
+
+// speechHash is the cache key of the audio of text spoken by voice with the lexicon entries of
+// fingerprint. The default voice and an unused lexicon are left out so audio cached before
+// either existed stays valid.
+func speechHash(text string, voice interfaces.Voice, lexicon string) string {
+	if !voice.IsDefault() {
+		text = fmt.Sprintf("%s|voice=%s|speed=%.2f", text, voice.Name, voice.Speed)
+	}
+	if lexicon != "" {
+		text = fmt.Sprintf("%s|lexicon=%s", text, lexicon)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(text)))
+}
+
+// GenerateBatch generates audio for multiple texts in parallel, text i spoken by voices[i].
+// A nil voices speaks every text with the default voice. Silent texts get no audio and an empty path.
+func (s *AudioService) GenerateBatch(ctx context.Context, texts []string, voices []interfaces.Voice, outputDir string) ([]string, error) {
+	if voices != nil && len(voices) != len(texts) {
+		return nil, fmt.Errorf("texts and voices count mismatch: %d vs %d", len(texts), len(voices))
+	}
 	if err := s.fs.MkdirAll(outputDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Compute hashes and load cached hashes
+	// Compute hashes and load cached hashes, silent slides have neither
 	hashes := make([]string, len(texts))
 	for i, text := range texts {
-		hashes[i] = s.textService.Hash(text)
+		if !isSilent(text) {
+			_, hashes[i] = s.prepare(ctx, text, voiceAt(voices, i))
+		}
 	}
 
 	hashFile := filepath.Join(outputDir, "hashes")
@@ -95,36 +155,40 @@ func (s *AudioService) GenerateBatch(ctx context.Context, texts []string, output
 		return nil, fmt.Errorf("failed to load cached hashes: %w", err)
 	}
 
-	// Save current hashes
-	if err := s.textService.SaveHashes(ctx, hashFile, hashes); err != nil {
-		return nil, fmt.Errorf("failed to save hashes: %w", err)
-	}
-
 	// Generate audio files
 	audioPaths := make([]string, len(texts))
 	errors := make([]error, len(texts))
 	var wg sync.WaitGroup
 
 	for i, text := range texts {
+		if isSilent(text) {
+			continue // No audio: the slide plays silence
+		}
 		wg.Add(1)
 		go func(idx int, txt, hash string) {
 			defer wg.Done()
+			ctx := withSlideIndex(ctx, idx)
+			start := time.Now()
 
-			audioPath := filepath.Join(outputDir, fmt.Sprintf("%d.mp3", idx))
+			audioPath := batchAudioPath(outputDir, idx)
 			audioPaths[idx] = audioPath
 
 			// Check if cached
-			if idx < len(cachedHashes) && cachedHashes[idx] == hash {
-				exists, err := afero.Exists(s.fs, audioPath)
-				if err == nil && exists {
-					return
-				}
+			if s.inBatchCache(cachedHashes, idx, hash, audioPath) {
+				recordCacheHit(ctx)
+				recordSlideStep(ctx, start, nil)
+				recordArtifact(s.logger, s.manifest, Artifact{Kind: ArtifactAudio, InputsHash: hash, Output: audioPath, Status: ArtifactDone})
+				return
 			}
 
 			// Generate new audio
-			if err := s.Generate(ctx, txt, audioPath); err != nil {
+			err := s.Generate(ctx, txt, voiceAt(voices, idx), audioPath)
+			recordSlideStep(ctx, start, err)
+			if err != nil {
 				errors[idx] = err
 			}
+			status, message := artifactStatus(err)
+			recordArtifact(s.logger, s.manifest, Artifact{Kind: ArtifactAudio, InputsHash: hash, Output: audioPath, Status: status, Error: message})
 		}(i, text, hashes[i])
 	}
 
@@ -137,10 +201,165 @@ func (s *AudioService) GenerateBatch(ctx context.Context, texts []string, output
 		}
 	}
 
+	// Save current hashes only once every file matches them
+	if err := s.textService.SaveHashes(ctx, hashFile, hashes); err != nil {
+		return nil, fmt.Errorf("failed to save hashes: %w", err)
+	}
+
 	return audioPaths, nil
 }
 
-func (s *AudioService) checkCache(ctx context.Context, text, outputPath string) (bool, error) {
+// CachedBatch reports, for each text, whether GenerateBatch would reuse the audio in outputDir
+// rather than call the speech API. Nothing is generated or written.
+func (s *AudioService) CachedBatch(ctx context.Context, texts []string, voices []interfaces.Voice, outputDir string) ([]bool, error) {
+	cachedHashes, err := s.textService.LoadHashes(ctx, filepath.Join(outputDir, "hashes"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cached hashes: %w", err)
+	}
+
+	cached := make([]bool, len(texts))
+	for i, text := range texts {
+		if isSilent(text) {
+			cached[i] = true // Nothing to generate
+			continue
+		}
+		audioPath := batchAudioPath(outputDir, i)
+		_, hash := s.prepare(ctx, text, voiceAt(voices, i))
+		if s.inBatchCache(cachedHashes, i, hash, audioPath) {
+			cached[i] = true
+			continue
+		}
+		cached[i], err = s.checkCache(hash, audioPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check cache: %w", err)
+		}
+	}
+	return cached, nil
+}
+
+// inBatchCache reports whether the batch hashes file records the audio at idx as matching hash
+func (s *AudioService) inBatchCache(cachedHashes []string, idx int, hash, audioPath string) bool {
+	if idx >= len(cachedHashes) || cachedHashes[idx] != hash {
+		return false
+	}
+	exists, err := afero.Exists(s.fs, audioPath)
+	return err == nil && exists
+}
+
+// isSilent reports whether narration has nothing to speak, for a slide without audio
+func isSilent(text string) bool {
+	return strings.TrimSpace(text) == ""
+}
+
+// voiceAt returns the voice of text idx of a batch, the default voice when voices is nil
+func voiceAt(voices []interfaces.Voice, idx int) interfaces.Voice {
+	if voices == nil {
+		return interfaces.Voice{}
+	}
+	return voices[idx]
+}
+
+// batchAudioPath returns the path of the audio of text idx of a batch
+func batchAudioPath(outputDir string, idx int) string {
+	return filepath.Join(outputDir, fmt.Sprintf("%d.mp3", idx))
+}
+
+// synthesize calls the speech API and writes the audio to outputPath
+func (s *AudioService) synthesize(ctx context.Context, text string, voice interfaces.Voice, outputPath string) error {
+	body, err := s.client.GenerateSpeech(ctx, text, voice)
+	if err != nil {
+		return fmt.Errorf("failed to generate speech: %w", err)
+	}
+	defer func() { _ = body.Close() }()
+
+	// Ensure directory exists
+	dir := filepath.Dir(outputPath)
+	if err := s.fs.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Write to a temporary file so an interrupted download never looks like cached audio
+	file, err := afero.TempFile(s.fs, dir, filepath.Base(outputPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create audio file: %w", err)
+	}
+	tmpPath := file.Name()
+
+	_, err = io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = s.fs.Remove(tmpPath)
+		return fmt.Errorf("failed to write audio: %w", err)
+	}
+
+	// Drop the old hash before replacing the audio it describes
+	if err := s.fs.Remove(outputPath + ".hash"); err != nil && !os.IsNotExist(err) {
+		_ = s.fs.Remove(tmpPath)
+		return fmt.Errorf("failed to remove stale hash: %w", err)
+	}
+	return publishFile(s.fs, tmpPath, outputPath)
+}
+
+// render speaks every part of a narration with markup on its own, then joins them with the
+// generated silence of its pauses into outputPath
+func (s *AudioService) render(ctx context.Context, parts []speechPart, voice interfaces.Voice, outputPath string) error {
+	if len(parts) == 0 {
+		return fmt.Errorf("narration has nothing to speak")
+	}
+
+	partPaths := make([]string, len(parts))
+	defer func() {
+		for _, path := range partPaths {
+			if path != "" {
+				_ = s.fs.Remove(path)
+			}
+		}
+	}()
+
+	base := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
+	for i, part := range parts {
+		if part.Pause > 0 {
+			continue
+		}
+		partPaths[i] = fmt.Sprintf("%s.part%d.mp3", base, i)
+		err := s.scheduler.API(ctx, func() error {
+			recordAPICall(ctx)
+			return s.synthesize(ctx, part.Text, part.voice(voice), partPaths[i])
+		})
+		if err != nil {
+			return fmt.Errorf("failed to generate part %d: %w", i+1, err)
+		}
+	}
+
+	// Join into a temporary file so a failed run never looks like cached audio
+	tmpPath := base + ".render.mp3"
+	defer func() { _ = s.fs.Remove(tmpPath) }()
+	err := s.scheduler.Media(ctx, func() error {
+		cmd := exec.CommandContext(ctx, "ffmpeg", speechConcatArgs(parts, partPaths, tmpPath)...)
+		s.logger.Debug("Joining speech parts", "command", cmd.String())
+
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("ffmpeg speech concat error: %w, stderr: %s", subprocessError(ctx, err), stderr.String())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Drop the old hash before replacing the audio it describes
+	if err := s.fs.Remove(outputPath + ".hash"); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale hash: %w", err)
+	}
+	return publishFile(s.fs, tmpPath, outputPath)
+}
+
+// checkCache reports whether the audio at outputPath was generated with the cache key hash
+func (s *AudioService) checkCache(hash, outputPath string) (bool, error) {
 	exists, err := afero.Exists(s.fs, outputPath)
 	if err != nil {
 		return false, err
@@ -165,7 +384,5 @@ func (s *AudioService) checkCache(ctx context.Context, text, outputPath string)
 		return false, err
 	}
 
-	// Compute current hash
-	currentHash := fmt.Sprintf("%x", sha256.Sum256([]byte(text)))
-	return string(data) == currentHash, nil
+	return string(data) == hash, nil
 }
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"gocreator/internal/interfaces"
 	"gocreator/internal/mocks"
 
 	"github.com/spf13/afero"
@@ -38,13 +39,13 @@ func BenchmarkAudioService_Generate_NoCache(b *testing.B) {
 	ctx := context.Background()
 
 	// Mock API response
-	mockClient.On("GenerateSpeech", mock.Anything, text).
+	mockClient.On("GenerateSpeech", mock.Anything, text, interfaces.Voice{}).
 		Return(newBenchmarkReadCloser("audio data"), nil)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		outputPath := "/output/audio_" + strconv.Itoa(i) + ".mp3"
-		_ = service.Generate(ctx, text, outputPath)
+		_ = service.Generate(ctx, text, interfaces.Voice{}, outputPath)
 	}
 }
 
@@ -61,13 +62,13 @@ func BenchmarkAudioService_Generate_WithCache(b *testing.B) {
 	ctx := context.Background()
 
 	// Generate once to populate cache
-	mockClient.On("GenerateSpeech", mock.Anything, text).
+	mockClient.On("GenerateSpeech", mock.Anything, text, interfaces.Voice{}).
 		Return(newBenchmarkReadCloser("audio data"), nil).Once()
-	_ = service.Generate(ctx, text, outputPath)
+	_ = service.Generate(ctx, text, interfaces.Voice{}, outputPath)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_ = service.Generate(ctx, text, outputPath)
+		_ = service.Generate(ctx, text, interfaces.Voice{}, outputPath)
 	}
 }
 
@@ -90,14 +91,14 @@ func BenchmarkAudioService_GenerateBatch_NoCache(b *testing.B) {
 
 	// Mock API responses for all texts
 	for _, text := range texts {
-		mockClient.On("GenerateSpeech", mock.Anything, text).
+		mockClient.On("GenerateSpeech", mock.Anything, text, interfaces.Voice{}).
 			Return(newBenchmarkReadCloser("audio data"), nil)
 	}
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		outputDir := "/output/batch_" + strconv.Itoa(i)
-		_, _ = service.GenerateBatch(ctx, texts, outputDir)
+		_, _ = service.GenerateBatch(ctx, texts, nil, outputDir)
 	}
 }
 
@@ -121,15 +122,15 @@ func BenchmarkAudioService_GenerateBatch_WithCache(b *testing.B) {
 
 	// Mock API responses for initial generation
 	for _, text := range texts {
-		mockClient.On("GenerateSpeech", mock.Anything, text).
+		mockClient.On("GenerateSpeech", mock.Anything, text, interfaces.Voice{}).
 			Return(newBenchmarkReadCloser("audio data"), nil).Once()
 	}
 
 	// Generate once to populate cache
-	_, _ = service.GenerateBatch(ctx, texts, outputDir)
+	_, _ = service.GenerateBatch(ctx, texts, nil, outputDir)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = service.GenerateBatch(ctx, texts, outputDir)
+		_, _ = service.GenerateBatch(ctx, texts, nil, outputDir)
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"gocreator/internal/interfaces"
 	"gocreator/internal/mocks"
 
 	"github.com/spf13/afero"
@@ -65,15 +66,15 @@ func TestAudioService_Generate(t *testing.T) {
 
 			// Setup mock expectations
 			if tt.mockError == nil {
-				mockClient.On("GenerateSpeech", mock.Anything, tt.text).
+				mockClient.On("GenerateSpeech", mock.Anything, tt.text, interfaces.Voice{}).
 					Return(newMockReadCloser(tt.mockData), tt.mockError)
 			} else {
-				mockClient.On("GenerateSpeech", mock.Anything, tt.text).
+				mockClient.On("GenerateSpeech", mock.Anything, tt.text, interfaces.Voice{}).
 					Return(nil, tt.mockError)
 			}
 
 			ctx := context.Background()
-			err := service.Generate(ctx, tt.text, tt.outputPath)
+			err := service.Generate(ctx, tt.text, interfaces.Voice{}, tt.outputPath)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -104,21 +105,86 @@ func TestAudioService_Generate_WithCache(t *testing.T) {
 	outputPath := "/output/audio.mp3"
 
 	// First generation - should call API
-	mockClient.On("GenerateSpeech", mock.Anything, text).
+	mockClient.On("GenerateSpeech", mock.Anything, text, interfaces.Voice{}).
 		Return(newMockReadCloser("audio data"), nil).Once()
 
 	ctx := context.Background()
-	err := service.Generate(ctx, text, outputPath)
+	err := service.Generate(ctx, text, interfaces.Voice{}, outputPath)
 	require.NoError(t, err)
 
 	// Second generation with same text - should use cache, not call API again
 	// We don't set up another mock expectation, so if it calls the API, the test will fail
-	err = service.Generate(ctx, text, outputPath)
+	err = service.Generate(ctx, text, interfaces.Voice{}, outputPath)
 	assert.NoError(t, err)
 
 	mockClient.AssertExpectations(t)
 }
 
+func TestAudioService_Generate_Voice(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockClient := new(mocks.MockOpenAIClient)
+	logger := &mockLogger{}
+	service := NewAudioService(fs, mockClient, NewTextService(fs, logger), logger)
+	ctx := context.Background()
+
+	text := "Hello world"
+	nova := interfaces.Voice{Name: "nova", Speed: 1.2}
+	mockClient.On("GenerateSpeech", mock.Anything, text, interfaces.Voice{}).
+		Return(newMockReadCloser("default audio"), nil).Once()
+	mockClient.On("GenerateSpeech", mock.Anything, text, nova).
+		Return(newMockReadCloser("nova audio"), nil).Once()
+
+	// The default voice keeps the cache key of the text alone
+	require.NoError(t, service.Generate(ctx, text, interfaces.Voice{}, "/output/audio.mp3"))
+	hash, err := afero.ReadFile(fs, "/output/audio.mp3.hash")
+	require.NoError(t, err)
+	assert.Equal(t, service.textService.Hash(text), string(hash))
+
+	// Another voice regenerates the audio once, then comes from cache
+	require.NoError(t, service.Generate(ctx, text, nova, "/output/audio.mp3"))
+	require.NoError(t, service.Generate(ctx, text, nova, "/output/audio.mp3"))
+	data, err := afero.ReadFile(fs, "/output/audio.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, "nova audio", string(data))
+
+	cached, err := service.CachedBatch(ctx, []string{text}, []interfaces.Voice{nova}, "/output")
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false}, cached, "batch audio lives at its index, not at audio.mp3")
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestAudioService_GenerateBatch_Voices(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockClient := new(mocks.MockOpenAIClient)
+	logger := &mockLogger{}
+	service := NewAudioService(fs, mockClient, NewTextService(fs, logger), logger)
+	ctx := context.Background()
+
+	texts := []string{"Welcome", "Goodbye"}
+	voices := []interfaces.Voice{{}, {Name: "shimmer"}}
+	mockClient.On("GenerateSpeech", mock.Anything, "Welcome", interfaces.Voice{}).
+		Return(newMockReadCloser("welcome"), nil).Once()
+	mockClient.On("GenerateSpeech", mock.Anything, "Goodbye", voices[1]).
+		Return(newMockReadCloser("goodbye"), nil).Once()
+
+	paths, err := service.GenerateBatch(ctx, texts, voices, "/audio")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/audio/0.mp3", "/audio/1.mp3"}, paths)
+
+	cached, err := service.CachedBatch(ctx, texts, voices, "/audio")
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true}, cached)
+	cached, err = service.CachedBatch(ctx, texts, nil, "/audio")
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, cached, "changing the voice invalidates the audio")
+
+	_, err = service.GenerateBatch(ctx, texts, voices[:1], "/audio")
+	assert.Error(t, err)
+
+	mockClient.AssertExpectations(t)
+}
+
 func TestAudioService_GenerateBatch(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -170,12 +236,12 @@ func TestAudioService_GenerateBatch(t *testing.T) {
 
 			// Setup mock expectations for each text
 			for i, text := range tt.texts {
-				mockClient.On("GenerateSpeech", mock.Anything, text).
+				mockClient.On("GenerateSpeech", mock.Anything, text, interfaces.Voice{}).
 					Return(newMockReadCloser(tt.mockData[i]), nil)
 			}
 
 			ctx := context.Background()
-			paths, err := service.GenerateBatch(ctx, tt.texts, tt.outputDir)
+			paths, err := service.GenerateBatch(ctx, tt.texts, nil, tt.outputDir)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -198,6 +264,31 @@ func TestAudioService_GenerateBatch(t *testing.T) {
 	}
 }
 
+func TestAudioService_GenerateBatch_SilentSlides(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockClient := new(mocks.MockOpenAIClient)
+	logger := &mockLogger{}
+	service := NewAudioService(fs, mockClient, NewTextService(fs, logger), logger)
+	ctx := context.Background()
+
+	texts := []string{"Hello", "  ", "World"}
+	mockClient.On("GenerateSpeech", mock.Anything, "Hello", interfaces.Voice{}).Return(newMockReadCloser("hello"), nil).Once()
+	mockClient.On("GenerateSpeech", mock.Anything, "World", interfaces.Voice{}).Return(newMockReadCloser("world"), nil).Once()
+
+	paths, err := service.GenerateBatch(ctx, texts, nil, "/audio")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/audio/0.mp3", "", "/audio/2.mp3"}, paths, "silent slides have no audio")
+
+	cached, err := service.CachedBatch(ctx, texts, nil, "/audio")
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true, true}, cached)
+
+	// The hashes of the slides after a silent one still match
+	_, err = service.GenerateBatch(ctx, texts, nil, "/audio")
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
 func TestAudioService_GenerateBatch_WithCache(t *testing.T) {
 	fs := afero.NewMemMapFs()
 	mockClient := new(mocks.MockOpenAIClient)
@@ -209,21 +300,87 @@ func TestAudioService_GenerateBatch_WithCache(t *testing.T) {
 	outputDir := "/output"
 
 	// First batch generation
-	mockClient.On("GenerateSpeech", mock.Anything, "Hello").
+	mockClient.On("GenerateSpeech", mock.Anything, "Hello", interfaces.Voice{}).
 		Return(newMockReadCloser("audio1"), nil).Once()
-	mockClient.On("GenerateSpeech", mock.Anything, "World").
+	mockClient.On("GenerateSpeech", mock.Anything, "World", interfaces.Voice{}).
 		Return(newMockReadCloser("audio2"), nil).Once()
 
 	ctx := context.Background()
-	paths1, err := service.GenerateBatch(ctx, texts, outputDir)
+	paths1, err := service.GenerateBatch(ctx, texts, nil, outputDir)
 	require.NoError(t, err)
 	assert.Len(t, paths1, 2)
 
 	// Second batch generation with same texts - should use cache
-	paths2, err := service.GenerateBatch(ctx, texts, outputDir)
+	paths2, err := service.GenerateBatch(ctx, texts, nil, outputDir)
 	require.NoError(t, err)
 	assert.Equal(t, paths1, paths2)
 
 	// Verify API was only called once per text
 	mockClient.AssertExpectations(t)
 }
+
+func TestAudioService_GenerateBatch_FailureLeavesNoStaleCache(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockClient := new(mocks.MockOpenAIClient)
+	logger := &mockLogger{}
+	textService := NewTextService(fs, logger)
+	service := NewAudioService(fs, mockClient, textService, logger)
+
+	outputDir := "/output"
+	ctx := context.Background()
+
+	// First run: the old text is generated and cached
+	mockClient.On("GenerateSpeech", mock.Anything, "Old", interfaces.Voice{}).
+		Return(newMockReadCloser("old audio"), nil).Once()
+	_, err := service.GenerateBatch(ctx, []string{"Old"}, nil, outputDir)
+	require.NoError(t, err)
+
+	// Second run: the edited text fails to generate
+	mockClient.On("GenerateSpeech", mock.Anything, "New", interfaces.Voice{}).
+		Return(nil, errors.New("API error")).Once()
+	_, err = service.GenerateBatch(ctx, []string{"New"}, nil, outputDir)
+	require.Error(t, err)
+
+	// The old audio must not be recorded as matching the new text
+	hashes, err := textService.LoadHashes(ctx, "/output/hashes")
+	require.NoError(t, err)
+	assert.Equal(t, []string{textService.Hash("Old")}, hashes)
+
+	// No temporary files are left next to the audio
+	entries, err := afero.ReadDir(fs, outputDir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), ".tmp")
+	}
+
+	// Third run: the edited text is retried rather than served from cache
+	mockClient.On("GenerateSpeech", mock.Anything, "New", interfaces.Voice{}).
+		Return(newMockReadCloser("new audio"), nil).Once()
+	_, err = service.GenerateBatch(ctx, []string{"New"}, nil, outputDir)
+	require.NoError(t, err)
+
+	data, err := afero.ReadFile(fs, "/output/0.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, "new audio", string(data))
+	mockClient.AssertExpectations(t)
+}
+
+func TestAudioService_GenerateBatch_Canceled(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockClient := new(mocks.MockOpenAIClient)
+	logger := &mockLogger{}
+	service := NewAudioService(fs, mockClient, NewTextService(fs, logger), logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := service.GenerateBatch(ctx, []string{"Hello", "World"}, nil, "/output")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// Nothing was requested and nothing is left to be mistaken for cached audio
+	mockClient.AssertNotCalled(t, "GenerateSpeech", mock.Anything, mock.Anything, mock.Anything)
+	entries, err := afero.ReadDir(fs, "/output")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// BuildReport is the combined report of the projects built by build-all
+type BuildReport struct {
+	Status    RunStatus        `json:"status"`
+	StartedAt time.Time        `json:"started_at"`
+	Duration  float64          `json:"duration_seconds"`
+	CacheHits int              `json:"cache_hits"`
+	APICalls  int              `json:"api_calls"`
+	Error     string           `json:"error,omitempty"`
+	Projects  []*ProjectReport `json:"projects"`
+}
+
+// ProjectReport is the outcome of one project, with the report of its run if it started
+type ProjectReport struct {
+	Path   string     `json:"path"`
+	Status RunStatus  `json:"status"`
+	Error  string     `json:"error,omitempty"`
+	Run    *RunReport `json:"run,omitempty"`
+}
+
+// ProjectRunner runs the project at index and returns its report, nil if it failed to start
+type ProjectRunner func(ctx context.Context, index int) (*RunReport, error)
+
+// BuildProjects runs the projects at paths concurrently and combines their reports.
+// Unless keepGoing, the first failure cancels the other projects.
+func BuildProjects(ctx context.Context, paths []string, keepGoing bool, run ProjectRunner) (*BuildReport, error) {
+	report := &BuildReport{StartedAt: time.Now().UTC(), Projects: make([]*ProjectReport, len(paths))}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		report.Projects[i] = &ProjectReport{Path: path}
+
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			project := report.Projects[idx]
+
+			runReport, err := run(ctx, idx)
+			project.Run = runReport
+			switch {
+			case err == nil:
+				project.Status = StatusSucceeded
+			case errors.Is(err, context.Canceled):
+				project.Status = StatusCanceled
+				project.Error = err.Error()
+			default:
+				project.Status = StatusFailed
+				project.Error = err.Error()
+			}
+
+			if err != nil {
+				errs[idx] = fmt.Errorf("project %s: %w", project.Path, err)
+				if !keepGoing {
+					cancel()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	err := projectsError(errs, keepGoing)
+	report.finish(err)
+	return report, err
+}
+
+// projectsError combines the errors of the projects of a build, as languagesError does for languages
+func projectsError(errs []error, keepGoing bool) error {
+	if !keepGoing {
+		return languagesError(errs, false)
+	}
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d projects failed: %w", len(failed), len(errs), errors.Join(failed...))
+}
+
+// finish computes the totals and status of the build
+func (r *BuildReport) finish(err error) {
+	r.Duration = time.Since(r.StartedAt).Seconds()
+	r.Status = StatusSucceeded
+	r.CacheHits, r.APICalls = 0, 0
+	for _, project := range r.Projects {
+		if project.Run != nil {
+			r.CacheHits += project.Run.CacheHits
+			r.APICalls += project.Run.APICalls
+		}
+		if project.Status != StatusSucceeded {
+			r.Status = StatusFailed
+		}
+	}
+	if err != nil {
+		r.Status = StatusFailed
+		r.Error = err.Error()
+	}
+}
+
+// Save writes the report as JSON to path
+func (r *BuildReport) Save(fs afero.Fs, path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode build report: %w", err)
+	}
+	if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	if err := writeFileAtomic(fs, path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write build report: %w", err)
+	}
+	return nil
+}
+
+// WriteSummary writes a human-readable table of the projects and languages of the build
+func (r *BuildReport) WriteSummary(w io.Writer) error {
+	var table strings.Builder
+	table.WriteString("PROJECT\tLANGUAGE\tSTATUS\tDURATION\tCACHE HITS\tAPI CALLS\tERROR\n")
+	for _, project := range r.Projects {
+		if project.Run == nil || len(project.Run.Languages) == 0 {
+			fmt.Fprintf(&table, "%s\t-\t%s\t-\t-\t-\t%s\n", project.Path, project.Status, project.Error)
+			continue
+		}
+		for _, lang := range project.Run.Languages {
+			fmt.Fprintf(&table, "%s\t%s\t%s\t%.1fs\t%d\t%d\t%s\n", project.Path, lang.Lang, lang.Status, lang.Duration, lang.CacheHits, lang.APICalls, lang.Error)
+		}
+	}
+	fmt.Fprintf(&table, "total\t\t%s\t%.1fs\t%d\t%d\t%s\n", r.Status, r.Duration, r.CacheHits, r.APICalls, r.Error)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := tw.Write([]byte(table.String())); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
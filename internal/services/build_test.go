@@ -0,0 +1,99 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildProjects(t *testing.T) {
+	t.Run("all projects succeed", func(t *testing.T) {
+		paths := []string{"course-a", "course-b"}
+		report, err := BuildProjects(context.Background(), paths, false, func(ctx context.Context, i int) (*RunReport, error) {
+			return &RunReport{
+				Status:    StatusSucceeded,
+				CacheHits: i + 1,
+				APICalls:  2,
+				Languages: []*LanguageReport{{Lang: "en", Status: StatusSucceeded}},
+			}, nil
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, StatusSucceeded, report.Status)
+		assert.Equal(t, 3, report.CacheHits)
+		assert.Equal(t, 4, report.APICalls)
+		require.Len(t, report.Projects, 2)
+		assert.Equal(t, "course-b", report.Projects[1].Path)
+		assert.Equal(t, StatusSucceeded, report.Projects[1].Status)
+	})
+
+	t.Run("a failure cancels the other projects", func(t *testing.T) {
+		paths := []string{"course-a", "course-b"}
+		report, err := BuildProjects(context.Background(), paths, false, func(ctx context.Context, i int) (*RunReport, error) {
+			if i == 0 {
+				return nil, errors.New("no slides")
+			}
+			<-ctx.Done()
+			return &RunReport{Status: StatusCanceled}, ctx.Err()
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "project course-a: no slides")
+
+		assert.Equal(t, StatusFailed, report.Status)
+		assert.Equal(t, StatusFailed, report.Projects[0].Status)
+		assert.Nil(t, report.Projects[0].Run)
+		assert.Equal(t, StatusCanceled, report.Projects[1].Status)
+	})
+
+	t.Run("keep going finishes every project", func(t *testing.T) {
+		paths := []string{"course-a", "course-b", "course-c"}
+		report, err := BuildProjects(context.Background(), paths, true, func(ctx context.Context, i int) (*RunReport, error) {
+			if i == 1 {
+				return &RunReport{Status: StatusFailed}, errors.New("tts failed")
+			}
+			return &RunReport{Status: StatusSucceeded}, ctx.Err()
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "1 of 3 projects failed")
+
+		assert.Equal(t, StatusSucceeded, report.Projects[0].Status)
+		assert.Equal(t, StatusFailed, report.Projects[1].Status)
+		assert.Equal(t, StatusSucceeded, report.Projects[2].Status)
+	})
+}
+
+func TestBuildReport_SaveAndSummary(t *testing.T) {
+	report, err := BuildProjects(context.Background(), []string{"course-a", "course-b"}, true, func(ctx context.Context, i int) (*RunReport, error) {
+		if i == 1 {
+			return nil, errors.New("invalid config")
+		}
+		return &RunReport{Languages: []*LanguageReport{
+			{Lang: "en", Status: StatusSucceeded, CacheHits: 3},
+			{Lang: "fr", Status: StatusSucceeded, APICalls: 4},
+		}}, nil
+	})
+	require.Error(t, err)
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, report.Save(fs, "/work/build-report.json"))
+	data, err := afero.ReadFile(fs, "/work/build-report.json")
+	require.NoError(t, err)
+	var saved BuildReport
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.Len(t, saved.Projects, 2)
+	assert.Equal(t, "invalid config", saved.Projects[1].Error)
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteSummary(&out))
+	summary := out.String()
+	assert.Contains(t, summary, "PROJECT")
+	assert.Contains(t, summary, "course-a  fr")
+	assert.Contains(t, summary, "course-b  -")
+	assert.Contains(t, summary, "invalid config")
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"gocreator/internal/interfaces"
 	"gocreator/internal/mocks"
 
 	"github.com/spf13/afero"
@@ -59,9 +60,9 @@ func TestTranslationCacheHits(t *testing.T) {
 		
 		mockText.On("Save", mock.Anything, "/test/data/cache/es/text/texts.txt", translatedTexts).
 			Return(nil).Once()
-		mockAudio.On("GenerateBatch", mock.Anything, translatedTexts, "/test/data/cache/es/audio").
+		mockAudio.On("GenerateBatch", mock.Anything, translatedTexts, []interfaces.Voice(nil), "/test/data/cache/es/audio").
 			Return(audioPaths, nil).Once()
-		mockVideo.On("GenerateFromSlides", mock.Anything, slides, audioPaths, "/test/data/out/output-es.mp4").
+		mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, audioPaths), "/test/data/out/output-es.mp4").
 			Return(nil).Once()
 
 		// Create service
@@ -113,9 +114,9 @@ func TestTranslationCacheHits(t *testing.T) {
 		mockText.On("Load", mock.Anything, "/test/data/cache/es/text/texts.txt").
 			Return(cachedTexts, nil).Once()
 		
-		mockAudio.On("GenerateBatch", mock.Anything, cachedTexts, "/test/data/cache/es/audio").
+		mockAudio.On("GenerateBatch", mock.Anything, cachedTexts, []interfaces.Voice(nil), "/test/data/cache/es/audio").
 			Return(audioPaths, nil).Once()
-		mockVideo.On("GenerateFromSlides", mock.Anything, slides, audioPaths, "/test/data/out/output-es.mp4").
+		mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, audioPaths), "/test/data/out/output-es.mp4").
 			Return(nil).Once()
 
 		// Create service
@@ -166,10 +167,10 @@ func TestTranslationCacheHits(t *testing.T) {
 		// Spanish: Load from cache (cache hit)
 		mockText.On("Load", mock.Anything, "/test/data/cache/es/text/texts.txt").
 			Return(cachedSpanishTexts, nil).Once()
-		mockAudio.On("GenerateBatch", mock.Anything, cachedSpanishTexts, "/test/data/cache/es/audio").
+		mockAudio.On("GenerateBatch", mock.Anything, cachedSpanishTexts, []interfaces.Voice(nil), "/test/data/cache/es/audio").
 			Return([]string{"/test/data/cache/es/audio/0.mp3", "/test/data/cache/es/audio/1.mp3"}, nil).Once()
-		mockVideo.On("GenerateFromSlides", mock.Anything, slides, 
-			[]string{"/test/data/cache/es/audio/0.mp3", "/test/data/cache/es/audio/1.mp3"}, 
+		mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, 
+			[]string{"/test/data/cache/es/audio/0.mp3", "/test/data/cache/es/audio/1.mp3"}), 
 			"/test/data/out/output-es.mp4").
 			Return(nil).Once()
 
@@ -178,10 +179,10 @@ func TestTranslationCacheHits(t *testing.T) {
 			Return(frenchTexts, nil).Once()
 		mockText.On("Save", mock.Anything, "/test/data/cache/fr/text/texts.txt", frenchTexts).
 			Return(nil).Once()
-		mockAudio.On("GenerateBatch", mock.Anything, frenchTexts, "/test/data/cache/fr/audio").
+		mockAudio.On("GenerateBatch", mock.Anything, frenchTexts, []interfaces.Voice(nil), "/test/data/cache/fr/audio").
 			Return([]string{"/test/data/cache/fr/audio/0.mp3", "/test/data/cache/fr/audio/1.mp3"}, nil).Once()
-		mockVideo.On("GenerateFromSlides", mock.Anything, slides, 
-			[]string{"/test/data/cache/fr/audio/0.mp3", "/test/data/cache/fr/audio/1.mp3"}, 
+		mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, 
+			[]string{"/test/data/cache/fr/audio/0.mp3", "/test/data/cache/fr/audio/1.mp3"}), 
 			"/test/data/out/output-fr.mp4").
 			Return(nil).Once()
 
@@ -217,13 +218,13 @@ func TestAudioGenerationCacheHits(t *testing.T) {
 		outputDir := "/output"
 
 		// First generation - API should be called for each text
-		mockClient.On("GenerateSpeech", mock.Anything, "Hello").
+		mockClient.On("GenerateSpeech", mock.Anything, "Hello", interfaces.Voice{}).
 			Return(newCacheTestReadCloser("audio1"), nil).Once()
-		mockClient.On("GenerateSpeech", mock.Anything, "World").
+		mockClient.On("GenerateSpeech", mock.Anything, "World", interfaces.Voice{}).
 			Return(newCacheTestReadCloser("audio2"), nil).Once()
 
 		ctx := context.Background()
-		paths, err := service.GenerateBatch(ctx, texts, outputDir)
+		paths, err := service.GenerateBatch(ctx, texts, nil, outputDir)
 
 		assert.NoError(t, err)
 		assert.Len(t, paths, 2)
@@ -243,17 +244,17 @@ func TestAudioGenerationCacheHits(t *testing.T) {
 		outputDir := "/output"
 
 		// First generation - API should be called
-		mockClient.On("GenerateSpeech", mock.Anything, "Hello").
+		mockClient.On("GenerateSpeech", mock.Anything, "Hello", interfaces.Voice{}).
 			Return(newCacheTestReadCloser("audio1"), nil).Once()
-		mockClient.On("GenerateSpeech", mock.Anything, "World").
+		mockClient.On("GenerateSpeech", mock.Anything, "World", interfaces.Voice{}).
 			Return(newCacheTestReadCloser("audio2"), nil).Once()
 
 		ctx := context.Background()
-		paths1, err := service.GenerateBatch(ctx, texts, outputDir)
+		paths1, err := service.GenerateBatch(ctx, texts, nil, outputDir)
 		require.NoError(t, err)
 
 		// Second generation with same texts - should use cache, API not called
-		paths2, err := service.GenerateBatch(ctx, texts, outputDir)
+		paths2, err := service.GenerateBatch(ctx, texts, nil, outputDir)
 		assert.NoError(t, err)
 		assert.Equal(t, paths1, paths2)
 
@@ -274,21 +275,21 @@ func TestAudioGenerationCacheHits(t *testing.T) {
 		outputDir := "/output"
 
 		// First generation - API called for both texts
-		mockClient.On("GenerateSpeech", mock.Anything, "Hello").
+		mockClient.On("GenerateSpeech", mock.Anything, "Hello", interfaces.Voice{}).
 			Return(newCacheTestReadCloser("audio1"), nil).Once()
-		mockClient.On("GenerateSpeech", mock.Anything, "World").
+		mockClient.On("GenerateSpeech", mock.Anything, "World", interfaces.Voice{}).
 			Return(newCacheTestReadCloser("audio2"), nil).Once()
 
 		ctx := context.Background()
-		_, err := service.GenerateBatch(ctx, initialTexts, outputDir)
+		_, err := service.GenerateBatch(ctx, initialTexts, nil, outputDir)
 		require.NoError(t, err)
 
 		// Second generation with one changed text
 		// "Hello" should use cache, "Universe" should call API
-		mockClient.On("GenerateSpeech", mock.Anything, "Universe").
+		mockClient.On("GenerateSpeech", mock.Anything, "Universe", interfaces.Voice{}).
 			Return(newCacheTestReadCloser("audio3"), nil).Once()
 
-		paths, err := service.GenerateBatch(ctx, modifiedTexts, outputDir)
+		paths, err := service.GenerateBatch(ctx, modifiedTexts, nil, outputDir)
 		assert.NoError(t, err)
 		assert.Len(t, paths, 2)
 
@@ -308,15 +309,15 @@ func TestAudioGenerationCacheHits(t *testing.T) {
 		outputPath := "/output/audio.mp3"
 
 		// First generation - should call API
-		mockClient.On("GenerateSpeech", mock.Anything, text).
+		mockClient.On("GenerateSpeech", mock.Anything, text, interfaces.Voice{}).
 			Return(newCacheTestReadCloser("audio data"), nil).Once()
 
 		ctx := context.Background()
-		err := service.Generate(ctx, text, outputPath)
+		err := service.Generate(ctx, text, interfaces.Voice{}, outputPath)
 		require.NoError(t, err)
 
 		// Second generation with same text - should use cache, not call API again
-		err = service.Generate(ctx, text, outputPath)
+		err = service.Generate(ctx, text, interfaces.Voice{}, outputPath)
 		assert.NoError(t, err)
 
 		// Verify API was called exactly once
@@ -331,7 +332,7 @@ func TestFFmpegVideoCacheHits(t *testing.T) {
 	t.Run("video segments are generated on first run", func(t *testing.T) {
 		// Note: This test documents that VideoService generates new segments each time
 		// The cache behavior for video segments relies on the filesystem persisting
-		// the .temp directory between runs
+		// the per-output .temp/<output> directory between runs
 		fs := afero.NewMemMapFs()
 		logger := &mockLogger{}
 		service := NewVideoService(fs, logger)
@@ -342,7 +343,7 @@ func TestFFmpegVideoCacheHits(t *testing.T) {
 		
 		// The video service creates temp directory for segments
 		// This is where ffmpeg output caching happens via filesystem
-		tempDir := "/test/data/out/.temp"
+		tempDir := "/test/data/out/.temp/output-en"
 		err := fs.MkdirAll(tempDir, 0755)
 		assert.NoError(t, err)
 
@@ -357,14 +358,14 @@ func TestFFmpegVideoCacheHits(t *testing.T) {
 		fs := afero.NewMemMapFs()
 		
 		// Simulate the structure created during video generation
-		tempDir := "/test/data/out/.temp"
+		tempDir := "/test/data/out/.temp/output-en"
 		require.NoError(t, fs.MkdirAll(tempDir, 0755))
 		
 		// Simulate creation of video segments
 		segmentPaths := []string{
-			"/test/data/out/.temp/video_0.mp4",
-			"/test/data/out/.temp/video_1.mp4",
-			"/test/data/out/.temp/video_2.mp4",
+			"/test/data/out/.temp/output-en/video_0.mp4",
+			"/test/data/out/.temp/output-en/video_1.mp4",
+			"/test/data/out/.temp/output-en/video_2.mp4",
 		}
 		
 		for _, path := range segmentPaths {
@@ -414,9 +415,9 @@ func TestIntegratedCacheHitCount(t *testing.T) {
 			Return(translatedTexts, nil).Once()
 		mockText.On("Save", mock.Anything, "/test/data/cache/es/text/texts.txt", translatedTexts).
 			Return(nil).Once()
-		mockAudio.On("GenerateBatch", mock.Anything, translatedTexts, "/test/data/cache/es/audio").
+		mockAudio.On("GenerateBatch", mock.Anything, translatedTexts, []interfaces.Voice(nil), "/test/data/cache/es/audio").
 			Return(audioPaths, nil).Once()
-		mockVideo.On("GenerateFromSlides", mock.Anything, slides, audioPaths, "/test/data/out/output-es.mp4").
+		mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, audioPaths), "/test/data/out/output-es.mp4").
 			Return(nil).Once()
 
 		creator := NewVideoCreator(fs, mockText, mockTranslation, mockAudio, mockVideo, mockSlide, logger)
@@ -433,7 +434,7 @@ func TestIntegratedCacheHitCount(t *testing.T) {
 		// Verify cache misses (all API calls made)
 		mockTranslation.AssertNumberOfCalls(t, "TranslateBatch", 1) // Translation API called
 		mockAudio.AssertNumberOfCalls(t, "GenerateBatch", 1)       // Audio API called
-		mockVideo.AssertNumberOfCalls(t, "GenerateFromSlides", 1)  // Video generation called
+		mockVideo.AssertNumberOfCalls(t, "GenerateFromTimeline", 1)  // Video generation called
 		
 		mockText.AssertExpectations(t)
 		mockTranslation.AssertExpectations(t)
@@ -470,10 +471,10 @@ func TestIntegratedCacheHitCount(t *testing.T) {
 			Return(slides, nil).Once()
 		mockText.On("Load", mock.Anything, "/test/data/cache/es/text/texts.txt").
 			Return(cachedTexts, nil).Once()
-		mockAudio.On("GenerateBatch", mock.Anything, cachedTexts, "/test/data/cache/es/audio").
+		mockAudio.On("GenerateBatch", mock.Anything, cachedTexts, []interfaces.Voice(nil), "/test/data/cache/es/audio").
 			Return([]string{"/audio0.mp3", "/audio1.mp3"}, nil).Once()
-		mockVideo.On("GenerateFromSlides", mock.Anything, slides, 
-			[]string{"/audio0.mp3", "/audio1.mp3"}, "/test/data/out/output-es.mp4").
+		mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, 
+			[]string{"/audio0.mp3", "/audio1.mp3"}), "/test/data/out/output-es.mp4").
 			Return(nil).Once()
 
 		creator := NewVideoCreator(fs, mockText, mockTranslation, mockAudio, mockVideo, mockSlide, logger)
@@ -491,6 +492,6 @@ func TestIntegratedCacheHitCount(t *testing.T) {
 		mockTranslation.AssertNumberOfCalls(t, "TranslateBatch", 0) // Translation cache hit
 		// Note: Audio and video would still be called but with cached data
 		mockAudio.AssertNumberOfCalls(t, "GenerateBatch", 1)
-		mockVideo.AssertNumberOfCalls(t, "GenerateFromSlides", 1)
+		mockVideo.AssertNumberOfCalls(t, "GenerateFromTimeline", 1)
 	})
 }
@@ -2,23 +2,50 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"gocreator/internal/interfaces"
+	"gocreator/internal/timeline"
 
 	"github.com/spf13/afero"
 )
 
 // VideoCreatorConfig holds configuration for video creation
 type VideoCreatorConfig struct {
-	RootDir          string
-	InputLang        string
-	OutputLangs      []string
-	GoogleSlidesID   string // Google Slides presentation ID (found in the URL). When empty, uses local slides; when provided, fetches from Google Slides API
-	ProgressCallback interfaces.ProgressCallback
-	Transition       TransitionConfig // Transition configuration for slide transitions
+	RootDir             string
+	InputLang           string
+	OutputLangs         []string
+	GoogleSlidesID      string // Google Slides presentation ID (found in the URL). When empty, uses local slides; when provided, fetches from Google Slides API
+	ProgressCallback    interfaces.ProgressCallback
+	Transition          TransitionConfig // Transition configuration for slide transitions
+	KeepGoing           bool             // Finish every language it can instead of stopping at the first failure
+	Slides              []int            // Indices of the slides of a preview. When empty, the full videos are rendered
+	SilentSlideDuration float64          // Seconds a slide without narration is shown, 0 for DefaultSilentSlideDuration
+}
+
+// DefaultSilentSlideDuration is how long a slide without narration is shown, in seconds,
+// unless its script gives it a duration
+const DefaultSilentSlideDuration = 3.0
+
+// silentSlideDuration returns how long a slide without narration is shown, in seconds
+func (cfg VideoCreatorConfig) silentSlideDuration() float64 {
+	if cfg.SilentSlideDuration > 0 {
+		return cfg.SilentSlideDuration
+	}
+	return DefaultSilentSlideDuration
+}
+
+// IsPreview reports whether only the selected slides are rendered, to preview outputs
+func (cfg VideoCreatorConfig) IsPreview() bool {
+	return len(cfg.Slides) > 0
 }
 
 // VideoCreator orchestrates the video creation process
@@ -30,6 +57,7 @@ type VideoCreator struct {
 	videoService       interfaces.VideoGenerator
 	slideService       interfaces.SlideLoader
 	logger             interfaces.Logger
+	manifest           *Manifest
 }
 
 // NewVideoCreator creates a new video creator
@@ -53,8 +81,32 @@ func NewVideoCreator(
 	}
 }
 
+// SetManifest sets the manifest recording the translations and videos of the run.
+// Languages it records as done from the same inputs are skipped, which resumes a failed run.
+func (vc *VideoCreator) SetManifest(manifest *Manifest) {
+	vc.manifest = manifest
+}
+
 // Create creates videos for all specified languages
 func (vc *VideoCreator) Create(ctx context.Context, cfg VideoCreatorConfig) error {
+	_, err := vc.Run(ctx, cfg)
+	return err
+}
+
+// Run creates videos for all specified languages and reports the outcome of every language and slide.
+// The report is returned even when the run fails.
+func (vc *VideoCreator) Run(ctx context.Context, cfg VideoCreatorConfig) (*RunReport, error) {
+	report := &RunReport{StartedAt: time.Now().UTC()}
+	if vc.manifest != nil {
+		report.RunID = vc.manifest.RunID
+	}
+
+	err := vc.run(ctx, cfg, report)
+	report.finish(err)
+	return report, err
+}
+
+func (vc *VideoCreator) run(ctx context.Context, cfg VideoCreatorConfig, report *RunReport) error {
 	dataDir := filepath.Join(cfg.RootDir, "data")
 
 	// Use no-op callback if none provided
@@ -63,22 +115,11 @@ func (vc *VideoCreator) Create(ctx context.Context, cfg VideoCreatorConfig) erro
 		progress = &interfaces.NoOpProgressCallback{}
 	}
 
-	// Configure video service with transitions if available
-	if videoService, ok := vc.videoService.(*VideoService); ok {
-		if err := cfg.Transition.Validate(); err == nil && cfg.Transition.IsEnabled() {
-			videoService.SetTransition(cfg.Transition)
-			vc.logger.Info("Transitions enabled", "type", cfg.Transition.Type, "duration", cfg.Transition.Duration)
-		} else {
-			if err != nil {
-				vc.logger.Warn("Transitions not enabled due to validation failure", "type", cfg.Transition.Type, "duration", cfg.Transition.Duration, "error", err)
-			} else if !cfg.Transition.IsEnabled() {
-				vc.logger.Debug("Transitions are disabled", "type", cfg.Transition.Type)
-			}
-		}
-	}
+	cfg.Transition = effectiveTransition(vc.logger, cfg.Transition)
 
 	var inputTexts []string
 	var slides []string
+	var script *Script
 	var err error
 
 	// Loading stage
@@ -106,28 +147,15 @@ func (vc *VideoCreator) Create(ctx context.Context, cfg VideoCreatorConfig) erro
 		}
 	} else {
 		progress.OnStageProgress("Loading", 20, "Loading local files")
-		
-		// Load input texts from file
-		textsPath := filepath.Join(dataDir, "texts.txt")
-		inputTexts, err = vc.textService.Load(ctx, textsPath)
-		if err != nil {
-			progress.OnStageComplete("Loading", false, fmt.Sprintf("Failed: %v", err))
-			return fmt.Errorf("failed to load input texts: %w", err)
-		}
-
-		vc.logger.Info("Loaded texts", "count", len(inputTexts))
 
-		progress.OnStageProgress("Loading", 60, "Loading slides")
-		
-		// Load slides from directory
-		slidesDir := filepath.Join(dataDir, "slides")
-		slides, err = vc.slideService.LoadSlides(ctx, slidesDir)
+		// Load the narration and slides, from the script when the project has one
+		inputTexts, slides, script, err = loadLocalInputs(ctx, vc.fs, vc.textService, vc.slideService, dataDir)
 		if err != nil {
 			progress.OnStageComplete("Loading", false, fmt.Sprintf("Failed: %v", err))
-			return fmt.Errorf("failed to load slides: %w", err)
+			return err
 		}
 
-		vc.logger.Info("Loaded slides", "count", len(slides))
+		vc.logger.Info("Loaded slides", "count", len(slides), "texts", len(inputTexts), "script", script != nil)
 	}
 
 	if len(slides) != len(inputTexts) {
@@ -135,32 +163,111 @@ func (vc *VideoCreator) Create(ctx context.Context, cfg VideoCreatorConfig) erro
 		return fmt.Errorf("slide and text count mismatch: %d slides, %d texts", len(slides), len(inputTexts))
 	}
 
+	if err := checkSlideSelection(cfg.Slides, len(slides)); err != nil {
+		progress.OnStageComplete("Loading", false, "Invalid slide selection")
+		return err
+	}
+
 	progress.OnStageComplete("Loading", true, fmt.Sprintf("Loaded %d slides", len(slides)))
 
+	// Fingerprint the sources so the manifest can tell whether a language is up to date
+	var sourcesHash string
+	if vc.manifest != nil {
+		sourcesHash, err = vc.hashSources(inputTexts, slides, script)
+		if err != nil {
+			return fmt.Errorf("failed to hash sources: %w", err)
+		}
+	}
+
+	// Unless keeping going, the first failure cancels the other languages
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// Process each language in parallel
 	var wg sync.WaitGroup
-	errors := make([]error, len(cfg.OutputLangs))
+	errs := make([]error, len(cfg.OutputLangs))
+	report.Languages = make([]*LanguageReport, len(cfg.OutputLangs))
 	
 	for i, lang := range cfg.OutputLangs {
+		outputPath := languageOutputPath(dataDir, lang)
+		if cfg.IsPreview() {
+			outputPath = languagePreviewPath(dataDir, lang)
+		}
+		report.Languages[i] = newLanguageReport(lang, outputPath, len(slides))
+
 		wg.Add(1)
 		go func(idx int, l string) {
 			defer wg.Done()
-			if err := vc.processLanguage(ctx, cfg, l, inputTexts, slides, dataDir, progress); err != nil {
-				errors[idx] = fmt.Errorf("failed to process language %s: %w", l, err)
+			langReport := report.Languages[idx]
+
+			// A preview leaves the full video and its manifest entry alone
+			if cfg.IsPreview() {
+				langReport.selectSlides(cfg.Slides)
+				start := time.Now()
+				err := vc.previewLanguage(withLanguageReport(ctx, langReport), cfg, l, inputTexts, slides, script, dataDir, outputPath, progress)
+				langReport.finish(time.Since(start), err)
+				if err != nil {
+					errs[idx] = fmt.Errorf("failed to preview language %s: %w", l, err)
+					if !cfg.KeepGoing {
+						cancel()
+					}
+				}
+				return
+			}
+
+			inputsHash := languageInputsHash(sourcesHash, cfg, l, humanTranslationHash(vc.fs, dataDir, l, script)+reviewedTranslationsHash(vc.fs, dataDir, l), vc.settingsHash(cfg, l))
+			if vc.isResumable(outputPath, inputsHash) {
+				vc.logger.Info("Skipping language completed by a previous run", "lang", l, "path", outputPath)
+				for _, stage := range []string{"Translation", "Audio Generation", "Video Assembly"} {
+					progress.OnItemStart(stage, l)
+					progress.OnItemComplete(stage, l, true, "Resumed from manifest")
+				}
+				langReport.skip()
+				return
+			}
+
+			start := time.Now()
+			err := vc.processLanguage(withLanguageReport(ctx, langReport), cfg, l, inputTexts, slides, script, dataDir, progress)
+			langReport.finish(time.Since(start), err)
+			status, message := artifactStatus(err)
+			recordArtifact(vc.logger, vc.manifest, Artifact{Kind: ArtifactVideo, Lang: l, InputsHash: inputsHash, Output: outputPath, Status: status, Error: message})
+			if err != nil {
+				errs[idx] = fmt.Errorf("failed to process language %s: %w", l, err)
+				if !cfg.KeepGoing {
+					cancel()
+				}
 			}
 		}(i, lang)
 	}
 	
 	wg.Wait()
-	
-	// Check for any errors
-	for _, err := range errors {
+
+	return languagesError(errs, cfg.KeepGoing)
+}
+
+// languagesError combines the errors of the languages of a run.
+// When keeping going, every failure is reported; otherwise the first failure,
+// ignoring the cancellations it caused in other languages.
+func languagesError(errs []error, keepGoing bool) error {
+	var failed []error
+	for _, err := range errs {
 		if err != nil {
-			return err
+			failed = append(failed, err)
 		}
 	}
+	if len(failed) == 0 {
+		return nil
+	}
 
-	return nil
+	if keepGoing {
+		return fmt.Errorf("%d of %d languages failed: %w", len(failed), len(errs), errors.Join(failed...))
+	}
+	for _, err := range failed {
+		if !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+	return failed[0]
 }
 
 func (vc *VideoCreator) processLanguage(
@@ -169,15 +276,15 @@ func (vc *VideoCreator) processLanguage(
 	lang string,
 	inputTexts []string,
 	slides []string,
+	script *Script,
 	dataDir string,
 	progress interfaces.ProgressCallback,
 ) error {
 	logger := vc.logger.With("lang", lang)
 	logger.Info("Processing language")
+	ctx = withLanguage(ctx, lang)
 
-	cacheDir := filepath.Join(dataDir, "cache", lang)
-	textDir := filepath.Join(cacheDir, "text")
-	audioDir := filepath.Join(cacheDir, "audio")
+	audioDir := languageAudioDir(dataDir, lang)
 
 	var texts []string
 	var err error
@@ -190,39 +297,12 @@ func (vc *VideoCreator) processLanguage(
 		texts = inputTexts
 		progress.OnItemComplete("Translation", lang, true, "Using original text")
 	} else {
-		textsPath := filepath.Join(textDir, "texts.txt")
-
-		// Check if translation exists
-		exists, err := afero.Exists(vc.fs, textsPath)
+		var textsPath string
+		texts, textsPath, err = vc.translateLanguage(ctx, lang, inputTexts, script, dataDir, logger, progress)
+		status, message := artifactStatus(err)
+		recordArtifact(vc.logger, vc.manifest, Artifact{Kind: ArtifactTranslation, Lang: lang, InputsHash: hashTexts(lang, inputTexts), Output: textsPath, Status: status, Error: message})
 		if err != nil {
-			progress.OnItemComplete("Translation", lang, false, fmt.Sprintf("Error: %v", err))
-			return fmt.Errorf("failed to check translation cache: %w", err)
-		}
-
-		if exists {
-			logger.Info("Loading cached translation")
-			progress.OnItemProgress("Translation", lang, 50, "Loading from cache")
-			texts, err = vc.textService.Load(ctx, textsPath)
-			if err != nil {
-				progress.OnItemComplete("Translation", lang, false, fmt.Sprintf("Error: %v", err))
-				return fmt.Errorf("failed to load cached translation: %w", err)
-			}
-			progress.OnItemComplete("Translation", lang, true, "Loaded from cache")
-		} else {
-			logger.Info("Translating texts")
-			progress.OnItemProgress("Translation", lang, 30, "Translating...")
-			texts, err = vc.translationService.TranslateBatch(ctx, inputTexts, lang)
-			if err != nil {
-				progress.OnItemComplete("Translation", lang, false, fmt.Sprintf("Error: %v", err))
-				return fmt.Errorf("translation failed: %w", err)
-			}
-
-			// Save translated texts
-			if err := vc.textService.Save(ctx, textsPath, texts); err != nil {
-				progress.OnItemComplete("Translation", lang, false, fmt.Sprintf("Error: %v", err))
-				return fmt.Errorf("failed to save translation: %w", err)
-			}
-			progress.OnItemComplete("Translation", lang, true, fmt.Sprintf("Translated %d texts", len(texts)))
+			return err
 		}
 	}
 
@@ -231,7 +311,7 @@ func (vc *VideoCreator) processLanguage(
 	logger.Info("Generating audio")
 	progress.OnItemProgress("Audio Generation", lang, 20, "Generating speech...")
 	
-	audioPaths, err := vc.audioService.GenerateBatch(ctx, texts, audioDir)
+	audioPaths, err := vc.generateAudio(ctx, texts, script, audioDir)
 	if err != nil {
 		progress.OnItemComplete("Audio Generation", lang, false, fmt.Sprintf("Error: %v", err))
 		return fmt.Errorf("audio generation failed: %w", err)
@@ -243,10 +323,15 @@ func (vc *VideoCreator) processLanguage(
 	logger.Info("Generating video")
 	progress.OnItemProgress("Video Assembly", lang, 30, "Assembling video...")
 	
-	outputDir := filepath.Join(dataDir, "out")
-	outputPath := filepath.Join(outputDir, fmt.Sprintf("output-%s.mp4", lang))
+	outputPath := languageOutputPath(dataDir, lang)
 
-	if err := vc.videoService.GenerateFromSlides(ctx, slides, audioPaths, outputPath); err != nil {
+	tl, err := buildTimeline(slides, texts, audioPaths, cfg, script)
+	if err != nil {
+		progress.OnItemComplete("Video Assembly", lang, false, fmt.Sprintf("Error: %v", err))
+		return fmt.Errorf("failed to build timeline: %w", err)
+	}
+
+	if err := vc.videoService.GenerateFromTimeline(ctx, tl, outputPath); err != nil {
 		progress.OnItemComplete("Video Assembly", lang, false, fmt.Sprintf("Error: %v", err))
 		return fmt.Errorf("video generation failed: %w", err)
 	}
@@ -255,3 +340,515 @@ func (vc *VideoCreator) processLanguage(
 	progress.OnItemComplete("Video Assembly", lang, true, "Video complete")
 	return nil
 }
+
+// checkSlideSelection checks the slides selected for a preview are unique slides of a deck of count slides
+func checkSlideSelection(selection []int, count int) error {
+	selected := make(map[int]bool, len(selection))
+	for _, idx := range selection {
+		if idx < 0 || idx >= count || selected[idx] {
+			return fmt.Errorf("invalid slide %d selected for preview: slides must be unique and between 1 and %d", idx+1, count)
+		}
+		selected[idx] = true
+	}
+	return nil
+}
+
+// previewLanguage renders the slides selected by cfg in lang to outputPath.
+// It shares the translation, audio and segment caches of the full video, but never writes
+// the saved translation of the language nor its video.
+func (vc *VideoCreator) previewLanguage(
+	ctx context.Context,
+	cfg VideoCreatorConfig,
+	lang string,
+	inputTexts []string,
+	slides []string,
+	script *Script,
+	dataDir string,
+	outputPath string,
+	progress interfaces.ProgressCallback,
+) error {
+	logger := vc.logger.With("lang", lang)
+	logger.Info("Previewing language", "slides", len(cfg.Slides))
+	ctx = withLanguage(ctx, lang)
+
+	// Translation stage
+	progress.OnItemStart("Translation", lang)
+	texts, err := vc.previewTexts(ctx, cfg, lang, inputTexts, script, dataDir)
+	if err != nil {
+		progress.OnItemComplete("Translation", lang, false, fmt.Sprintf("Error: %v", err))
+		return err
+	}
+	progress.OnItemComplete("Translation", lang, true, fmt.Sprintf("Translated %d texts", len(cfg.Slides)))
+
+	// Audio generation stage, in the audio cache of the full video
+	progress.OnItemStart("Audio Generation", lang)
+	audioDir := languageAudioDir(dataDir, lang)
+	audioPaths := make([]string, len(cfg.Slides))
+	err = forSelectedSlides(ctx, cfg.Slides, func(ctx context.Context, pos, idx int) error {
+		speech := script.speech(texts[idx])
+		if isSilent(speech) {
+			return nil // No audio: the slide plays silence
+		}
+		audioPaths[pos] = batchAudioPath(audioDir, idx)
+		if err := vc.audioService.Generate(ctx, speech, script.voice(idx), audioPaths[pos]); err != nil {
+			return fmt.Errorf("failed to generate audio %d: %w", idx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		progress.OnItemComplete("Audio Generation", lang, false, fmt.Sprintf("Error: %v", err))
+		return fmt.Errorf("audio generation failed: %w", err)
+	}
+	progress.OnItemComplete("Audio Generation", lang, true, fmt.Sprintf("Generated %d audio files", len(audioPaths)))
+
+	// Video assembly stage
+	progress.OnItemStart("Video Assembly", lang)
+	selected := make([]string, len(cfg.Slides))
+	selectedTexts := make([]string, len(cfg.Slides))
+	for pos, idx := range cfg.Slides {
+		selected[pos] = slides[idx]
+		selectedTexts[pos] = texts[idx]
+	}
+	tl, err := buildTimeline(selected, selectedTexts, audioPaths, cfg, script.selected(cfg.Slides))
+	if err != nil {
+		progress.OnItemComplete("Video Assembly", lang, false, fmt.Sprintf("Error: %v", err))
+		return fmt.Errorf("failed to build timeline: %w", err)
+	}
+	if err := vc.videoService.GeneratePreview(ctx, tl, cfg.Slides, languageOutputPath(dataDir, lang), outputPath); err != nil {
+		progress.OnItemComplete("Video Assembly", lang, false, fmt.Sprintf("Error: %v", err))
+		return fmt.Errorf("video generation failed: %w", err)
+	}
+
+	logger.Info("Preview created successfully", "path", outputPath)
+	progress.OnItemComplete("Video Assembly", lang, true, "Preview complete")
+	return nil
+}
+
+// previewTexts returns the texts of every slide in lang, where only the selected slides are
+// guaranteed to be filled in. The selected slides are taken from the human and reviewed translations,
+// if any, then from the saved translation when it is up to date for them, and the others are translated
+// one by one, so a partial translation is never saved.
+func (vc *VideoCreator) previewTexts(ctx context.Context, cfg VideoCreatorConfig, lang string, inputTexts []string, script *Script, dataDir string) ([]string, error) {
+	if lang == cfg.InputLang {
+		return inputTexts, nil
+	}
+
+	human, err := loadHumanTranslation(vc.fs, dataDir, lang, len(inputTexts), script)
+	if err != nil {
+		return nil, err
+	}
+	reviewed, err := loadReviewedTranslations(vc.fs, dataDir, lang)
+	if err != nil {
+		return nil, err
+	}
+	keyer, _ := vc.translationService.(translationKeyer)
+	var saved *savedTranslation
+	if human == nil {
+		saved, err = loadSavedTranslation(ctx, vc.fs, vc.textService, languageTextsPath(dataDir, lang, script), len(inputTexts), keyer != nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The selected slides are translated together, the others are left empty, which TranslateBatch skips
+	ctx = withTranslatorNotes(ctx, script.notes())
+	texts := make([]string, len(inputTexts))
+	translatable := make([]string, len(inputTexts))
+	machine := make([]bool, len(inputTexts))
+	pending := false
+	for _, idx := range cfg.Slides {
+		if text, who, ok := approvedText(human, reviewed, idx, inputTexts[idx], script); ok {
+			texts[idx] = text
+			recordTranslationSource(ctx, idx, who)
+			continue
+		}
+		source := script.translatable(inputTexts[idx])
+		var key string
+		if keyer != nil && !isSilent(source) {
+			key = keyer.getCacheKey(withSlideIndex(ctx, idx), source, lang)
+		}
+		if saved.reusable(idx, key) {
+			texts[idx] = script.translated(inputTexts[idx], script.translatable(saved.texts[idx]))
+			if !isSilent(source) {
+				recordTranslationSource(ctx, idx, TranslationMachine)
+			}
+			continue
+		}
+		translatable[idx] = source
+		machine[idx] = true
+		pending = true
+	}
+	if !pending && saved != nil {
+		return texts, nil
+	}
+	translated, err := vc.translationService.TranslateBatch(ctx, translatable, lang)
+	if err != nil {
+		return nil, fmt.Errorf("translation failed: %w", err)
+	}
+	for idx := range texts {
+		if !machine[idx] {
+			continue
+		}
+		texts[idx] = script.translated(inputTexts[idx], translated[idx])
+		if !isSilent(translatable[idx]) {
+			recordTranslationSource(ctx, idx, TranslationMachine)
+		}
+	}
+	return texts, nil
+}
+
+// generateAudio generates the narration of every slide, with the voices of the script if it sets any
+func (vc *VideoCreator) generateAudio(ctx context.Context, texts []string, script *Script, audioDir string) ([]string, error) {
+	return vc.audioService.GenerateBatch(ctx, script.speechTexts(texts), script.voices(), audioDir)
+}
+
+// forSelectedSlides calls fn concurrently for the slide at every position of indices,
+// counting its work for that slide, and returns the first error
+func forSelectedSlides(ctx context.Context, indices []int, fn func(ctx context.Context, pos, idx int) error) error {
+	errs := make([]error, len(indices))
+	var wg sync.WaitGroup
+	for pos, idx := range indices {
+		wg.Add(1)
+		go func(pos, idx int) {
+			defer wg.Done()
+			ctx := withSlideIndex(ctx, idx)
+			start := time.Now()
+			errs[pos] = fn(ctx, pos, idx)
+			recordSlideStep(ctx, start, errs[pos])
+		}(pos, idx)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// translateLanguage translates the input texts to lang and returns the translation with the path it is
+// kept at. The translation supplied by a translator, if any, is used for every slide it translates, then
+// the translations approved in review, and the others are machine translated. Otherwise the machine
+// translation saved by a previous run is reused for every slide it is still up to date for.
+func (vc *VideoCreator) translateLanguage(
+	ctx context.Context,
+	lang string,
+	inputTexts []string,
+	script *Script,
+	dataDir string,
+	logger interfaces.Logger,
+	progress interfaces.ProgressCallback,
+) ([]string, string, error) {
+	textsPath := languageTextsPath(dataDir, lang, script)
+	human, err := loadHumanTranslation(vc.fs, dataDir, lang, len(inputTexts), script)
+	if err != nil {
+		progress.OnItemComplete("Translation", lang, false, fmt.Sprintf("Error: %v", err))
+		return nil, textsPath, err
+	}
+	reviewed, err := loadReviewedTranslations(vc.fs, dataDir, lang)
+	if err != nil {
+		progress.OnItemComplete("Translation", lang, false, fmt.Sprintf("Error: %v", err))
+		return nil, textsPath, err
+	}
+
+	keyer, _ := vc.translationService.(translationKeyer)
+	var saved *savedTranslation
+	if human == nil {
+		saved, err = loadSavedTranslation(ctx, vc.fs, vc.textService, textsPath, len(inputTexts), keyer != nil)
+		if err != nil {
+			progress.OnItemComplete("Translation", lang, false, fmt.Sprintf("Error: %v", err))
+			return nil, textsPath, err
+		}
+	} else {
+		logger.Info("Using human translation", "path", human.Path)
+		textsPath = human.Path
+	}
+
+	// Slides translated by the translator, approved in review or up to date in the saved translation
+	// are left empty, which TranslateBatch skips
+	ctx = withTranslatorNotes(ctx, script.notes())
+	translatable := make([]string, len(inputTexts))
+	batch := make([]string, len(inputTexts))
+	keys := make([]string, len(inputTexts))
+	reused := make([]bool, len(inputTexts))
+	pending := 0
+	for i, text := range inputTexts {
+		if _, _, ok := approvedText(human, reviewed, i, text, script); ok {
+			continue
+		}
+		translatable[i] = script.translatable(text)
+		if keyer != nil && !isSilent(translatable[i]) {
+			keys[i] = keyer.getCacheKey(withSlideIndex(ctx, i), translatable[i], lang)
+		}
+		if saved.reusable(i, keys[i]) {
+			reused[i] = true
+			continue
+		}
+		batch[i] = translatable[i]
+		if !isSilent(batch[i]) {
+			pending++
+		}
+	}
+
+	texts := make([]string, len(inputTexts))
+	if pending > 0 || saved == nil {
+		logger.Info("Translating texts", "count", pending)
+		progress.OnItemProgress("Translation", lang, 30, "Translating...")
+		texts, err = vc.translationService.TranslateBatch(ctx, batch, lang)
+		if err != nil {
+			progress.OnItemComplete("Translation", lang, false, fmt.Sprintf("Error: %v", err))
+			return nil, textsPath, fmt.Errorf("translation failed: %w", err)
+		}
+	} else {
+		logger.Info("Loading cached translation")
+		progress.OnItemProgress("Translation", lang, 50, "Loading from cache")
+	}
+	humanCount := 0
+	for i := range texts {
+		if text, who, ok := approvedText(human, reviewed, i, inputTexts[i], script); ok {
+			texts[i] = text
+			humanCount++
+			recordTranslationSource(ctx, i, who)
+			continue
+		}
+		if reused[i] {
+			texts[i] = script.translated(inputTexts[i], script.translatable(saved.texts[i]))
+		} else {
+			// Only a translation the translator cached, so passing its checks, is reused by the next run
+			if keys[i] != "" {
+				if cached, ok := keyer.Cached(withSlideIndex(ctx, i), translatable[i], lang); !ok || cached != texts[i] {
+					keys[i] = ""
+				}
+			}
+			texts[i] = script.translated(inputTexts[i], texts[i])
+		}
+		if !isSilent(translatable[i]) {
+			recordTranslationSource(ctx, i, TranslationMachine)
+		}
+	}
+
+	if human != nil {
+		// The translator's file stays the translation of record, the machine translated
+		// slides are in the translation cache
+		progress.OnItemComplete("Translation", lang, true, fmt.Sprintf("%d texts translated by a human, %d by machine", humanCount, len(texts)-humanCount))
+		return texts, textsPath, nil
+	}
+	if pending == 0 && saved != nil {
+		progress.OnItemComplete("Translation", lang, true, "Loaded from cache")
+		return texts, textsPath, nil
+	}
+
+	// Save translated texts, with the key of the translation of each slide when the translator has them
+	if err := vc.textService.Save(ctx, textsPath, texts); err != nil {
+		progress.OnItemComplete("Translation", lang, false, fmt.Sprintf("Error: %v", err))
+		return nil, textsPath, fmt.Errorf("failed to save translation: %w", err)
+	}
+	if keyer != nil {
+		if err := saveTranslationKeys(vc.fs, textsPath, keys); err != nil {
+			progress.OnItemComplete("Translation", lang, false, fmt.Sprintf("Error: %v", err))
+			return nil, textsPath, err
+		}
+	}
+	progress.OnItemComplete("Translation", lang, true, fmt.Sprintf("Translated %d texts", pending))
+	return texts, textsPath, nil
+}
+
+// isResumable reports whether the manifest records the video at outputPath as
+// built from inputsHash and the video is still there
+func (vc *VideoCreator) isResumable(outputPath, inputsHash string) bool {
+	if !vc.manifest.IsDone(outputPath, inputsHash) {
+		return false
+	}
+	exists, err := afero.Exists(vc.fs, outputPath)
+	return err == nil && exists
+}
+
+// hashSources fingerprints the input texts, the settings of the script, if any, and the content of every slide
+func (vc *VideoCreator) hashSources(inputTexts, slides []string, script *Script) (string, error) {
+	hasher := sha256.New()
+	hasher.Write([]byte(hashTexts("", inputTexts)))
+	hasher.Write([]byte(script.hash()))
+	for _, slide := range slides {
+		data, err := afero.ReadFile(vc.fs, slide)
+		if err != nil {
+			return "", fmt.Errorf("failed to read slide %s: %w", slide, err)
+		}
+		hasher.Write(data)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// hashTexts fingerprints texts, together with the language they are translated to
+func hashTexts(lang string, texts []string) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(lang))
+	for _, text := range texts {
+		sum := sha256.Sum256([]byte(text))
+		hasher.Write(sum[:])
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// languageInputsHash fingerprints everything the video of lang is built from,
+// including the hash of its human and reviewed translations, "" when there are none,
+// and the settings of the services building it, "" when they are the defaults
+func languageInputsHash(sourcesHash string, cfg VideoCreatorConfig, lang, humanHash, settingsHash string) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(fmt.Sprintf("%s|%s|%s|%s:%.2f", sourcesHash, cfg.InputLang, lang, cfg.Transition.Type, cfg.Transition.Duration)))
+	if cfg.SilentSlideDuration > 0 {
+		hasher.Write([]byte(fmt.Sprintf("|silent=%.2f", cfg.SilentSlideDuration)))
+	}
+	if humanHash != "" && lang != cfg.InputLang {
+		hasher.Write([]byte("|human=" + humanHash))
+	}
+	if settingsHash != "" {
+		hasher.Write([]byte("|settings=" + settingsHash))
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// inputsFingerprinter is implemented by the services whose settings change the video of a
+// language beyond its sources, such as the pronunciations of a lexicon or the translation provider
+type inputsFingerprinter interface {
+	inputsFingerprint(lang string) string
+}
+
+// settingsHash fingerprints the settings of the services building the video of lang, "" when
+// they are the defaults. The translation settings only count for a translated language.
+func (vc *VideoCreator) settingsHash(cfg VideoCreatorConfig, lang string) string {
+	services := []any{vc.audioService}
+	if lang != cfg.InputLang {
+		services = append(services, vc.translationService)
+	}
+	var fingerprints []string
+	for _, service := range services {
+		if fingerprinter, ok := service.(inputsFingerprinter); ok {
+			if fingerprint := fingerprinter.inputsFingerprint(lang); fingerprint != "" {
+				fingerprints = append(fingerprints, fingerprint)
+			}
+		}
+	}
+	return strings.Join(fingerprints, "|")
+}
+
+// languageTextsPath returns where the translation to lang is saved, in the format of script
+func languageTextsPath(dataDir, lang string, script *Script) string {
+	return filepath.Join(dataDir, "cache", lang, "text", script.textsFile())
+}
+
+// translationKeysPath returns where the key of the translation of each slide saved at textsPath is kept
+func translationKeysPath(textsPath string) string {
+	return filepath.Join(filepath.Dir(textsPath), "hashes")
+}
+
+// translationKeyer is implemented by the translators caching their translations by key. The machine
+// translation saved for a language is then reused slide by slide, for the slides whose key is unchanged;
+// with other translators it is reused whole.
+type translationKeyer interface {
+	getCacheKey(ctx context.Context, text, targetLang string) string
+	Cached(ctx context.Context, text, targetLang string) (string, bool)
+}
+
+// savedTranslation is the machine translation of a language saved by a previous run
+type savedTranslation struct {
+	texts []string
+	keys  []string // Key of the translation of each slide, "" when not to be reused; nil to reuse every slide
+}
+
+// loadSavedTranslation loads the translation saved at textsPath for count slides, nil when there is
+// none or its slides no longer line up, with the keys of its slides when keyed
+func loadSavedTranslation(ctx context.Context, fs afero.Fs, textService interfaces.TextProcessor, textsPath string, count int, keyed bool) (*savedTranslation, error) {
+	exists, err := afero.Exists(fs, textsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check translation cache: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	texts, err := textService.Load(ctx, textsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cached translation: %w", err)
+	}
+	if len(texts) != count {
+		return nil, nil
+	}
+	saved := &savedTranslation{texts: texts}
+	if keyed {
+		saved.keys = make([]string, count) // Without keys, no slide is known to be up to date
+		data, err := afero.ReadFile(fs, translationKeysPath(textsPath))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to load translation keys: %w", err)
+		}
+		if keys := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); err == nil && len(keys) == count {
+			saved.keys = keys
+		}
+	}
+	return saved, nil
+}
+
+// saveTranslationKeys saves the key of the translation of each slide saved at textsPath, one per line
+func saveTranslationKeys(fs afero.Fs, textsPath string, keys []string) error {
+	if err := writeFileAtomic(fs, translationKeysPath(textsPath), []byte(strings.Join(keys, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to save translation keys: %w", err)
+	}
+	return nil
+}
+
+// reusable reports whether the saved translation of slide i is up to date with key, the key of its translation now
+func (t *savedTranslation) reusable(i int, key string) bool {
+	return t != nil && (t.keys == nil || (key != "" && t.keys[i] == key))
+}
+
+// languageAudioDir returns where the narration of lang is saved
+func languageAudioDir(dataDir, lang string) string {
+	return filepath.Join(dataDir, "cache", lang, "audio")
+}
+
+// languagePreviewPath returns where the preview of selected slides of lang is written
+func languagePreviewPath(dataDir, lang string) string {
+	return filepath.Join(dataDir, "out", "preview", fmt.Sprintf("preview-%s.mp4", lang))
+}
+
+// languageOutputPath returns where the video of lang is written
+func languageOutputPath(dataDir, lang string) string {
+	return filepath.Join(dataDir, "out", fmt.Sprintf("output-%s.mp4", lang))
+}
+
+// effectiveTransition returns the transition applied to every timeline, dropping it if invalid
+func effectiveTransition(logger interfaces.Logger, transition TransitionConfig) TransitionConfig {
+	err := transition.Validate()
+	if err == nil && transition.IsEnabled() {
+		logger.Info("Transitions enabled", "type", transition.Type, "duration", transition.Duration)
+		return transition
+	}
+
+	if err != nil {
+		logger.Warn("Transitions not enabled due to validation failure", "type", transition.Type, "duration", transition.Duration, "error", err)
+	} else {
+		logger.Debug("Transitions are disabled", "type", transition.Type)
+	}
+	return TransitionConfig{Type: TransitionNone}
+}
+
+// buildTimeline builds the timeline of one language from its slides and narration,
+// with the per-slide settings and chapter titles of script, if any.
+// Slides without audio are shown for the silent slide duration of cfg unless script times them.
+func buildTimeline(slides, texts, audioPaths []string, cfg VideoCreatorConfig, script *Script) (timeline.Timeline, error) {
+	tl, err := timeline.FromSlides(slides, audioPaths)
+	if err != nil {
+		return timeline.Timeline{}, err
+	}
+	cfg.Transition.ApplyTo(&tl)
+	script.applyTo(&tl)
+	for i, title := range script.titles(texts) {
+		tl.Segments[i].SetTitle(title)
+	}
+	for i := range tl.Segments {
+		seg := &tl.Segments[i]
+		if len(seg.Audio) == 0 && seg.Duration == 0 && seg.MinDuration == 0 {
+			seg.MinDuration = cfg.silentSlideDuration()
+		}
+	}
+	return tl, nil
+}
@@ -3,10 +3,15 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
+	"gocreator/internal/interfaces"
 	"gocreator/internal/mocks"
+	"gocreator/internal/timeline"
 
+	"github.com/openai/openai-go/v3"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -38,9 +43,9 @@ func TestVideoCreator_Create(t *testing.T) {
 			Return(inputTexts, nil)
 		mockSlide.On("LoadSlides", mock.Anything, "/test/data/slides").
 			Return(slides, nil)
-		mockAudio.On("GenerateBatch", mock.Anything, inputTexts, "/test/data/cache/en/audio").
+		mockAudio.On("GenerateBatch", mock.Anything, inputTexts, []interfaces.Voice(nil), "/test/data/cache/en/audio").
 			Return(audioPaths, nil)
-		mockVideo.On("GenerateFromSlides", mock.Anything, slides, audioPaths, "/test/data/out/output-en.mp4").
+		mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, audioPaths), "/test/data/out/output-en.mp4").
 			Return(nil)
 
 		// Create service
@@ -91,9 +96,9 @@ func TestVideoCreator_Create(t *testing.T) {
 			Return(translatedTexts, nil)
 		mockText.On("Save", mock.Anything, "/test/data/cache/es/text/texts.txt", translatedTexts).
 			Return(nil)
-		mockAudio.On("GenerateBatch", mock.Anything, translatedTexts, "/test/data/cache/es/audio").
+		mockAudio.On("GenerateBatch", mock.Anything, translatedTexts, []interfaces.Voice(nil), "/test/data/cache/es/audio").
 			Return(audioPaths, nil)
-		mockVideo.On("GenerateFromSlides", mock.Anything, slides, audioPaths, "/test/data/out/output-es.mp4").
+		mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, audioPaths), "/test/data/out/output-es.mp4").
 			Return(nil)
 
 		// Create service
@@ -146,9 +151,9 @@ func TestVideoCreator_Create(t *testing.T) {
 		// Translation should load from cache, not translate
 		mockText.On("Load", mock.Anything, "/test/data/cache/fr/text/texts.txt").
 			Return(cachedTexts, nil)
-		mockAudio.On("GenerateBatch", mock.Anything, cachedTexts, "/test/data/cache/fr/audio").
+		mockAudio.On("GenerateBatch", mock.Anything, cachedTexts, []interfaces.Voice(nil), "/test/data/cache/fr/audio").
 			Return(audioPaths, nil)
-		mockVideo.On("GenerateFromSlides", mock.Anything, slides, audioPaths, "/test/data/out/output-fr.mp4").
+		mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, audioPaths), "/test/data/out/output-fr.mp4").
 			Return(nil)
 
 		// Create service
@@ -276,9 +281,9 @@ func TestVideoCreator_Create_WithGoogleSlides(t *testing.T) {
 			Return(slides, notes, nil)
 		mockText.On("Save", mock.Anything, "/test/data/texts.txt", notes).
 			Return(nil)
-		mockAudio.On("GenerateBatch", mock.Anything, notes, "/test/data/cache/en/audio").
+		mockAudio.On("GenerateBatch", mock.Anything, notes, []interfaces.Voice(nil), "/test/data/cache/en/audio").
 			Return(audioPaths, nil)
-		mockVideo.On("GenerateFromSlides", mock.Anything, slides, audioPaths, "/test/data/out/output-en.mp4").
+		mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, audioPaths), "/test/data/out/output-en.mp4").
 			Return(nil)
 
 		// Create service
@@ -333,3 +338,507 @@ func TestVideoCreator_Create_WithGoogleSlides(t *testing.T) {
 		mockSlide.AssertExpectations(t)
 	})
 }
+
+func TestVideoCreator_Create_WithTransition(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockText := new(mocks.MockTextProcessor)
+	mockTranslation := new(mocks.MockTranslator)
+	mockAudio := new(mocks.MockAudioGenerator)
+	mockVideo := new(mocks.MockVideoGenerator)
+	mockSlide := new(mocks.MockSlideLoader)
+	logger := &mockLogger{}
+
+	inputTexts := []string{"Text 1", "Text 2"}
+	slides := []string{"/test/data/slides/1.png", "/test/data/slides/2.png"}
+	audioPaths := []string{"/test/data/cache/en/audio/0.mp3", "/test/data/cache/en/audio/1.mp3"}
+
+	// The transition is carried by the timeline, out of every slide but the last
+	expected := slideTimeline(slides, audioPaths)
+	expected.Segments[0].TransitionOut = &timeline.Transition{Type: "fade", Duration: 0.5}
+
+	mockText.On("Load", mock.Anything, "/test/data/texts.txt").
+		Return(inputTexts, nil)
+	mockSlide.On("LoadSlides", mock.Anything, "/test/data/slides").
+		Return(slides, nil)
+	mockAudio.On("GenerateBatch", mock.Anything, inputTexts, []interfaces.Voice(nil), "/test/data/cache/en/audio").
+		Return(audioPaths, nil)
+	mockVideo.On("GenerateFromTimeline", mock.Anything, expected, "/test/data/out/output-en.mp4").
+		Return(nil)
+
+	creator := NewVideoCreator(fs, mockText, mockTranslation, mockAudio, mockVideo, mockSlide, logger)
+
+	cfg := VideoCreatorConfig{
+		RootDir:     "/test",
+		InputLang:   "en",
+		OutputLangs: []string{"en"},
+		Transition:  TransitionConfig{Type: TransitionFade, Duration: 0.5},
+	}
+	err := creator.Create(context.Background(), cfg)
+
+	assert.NoError(t, err)
+	mockVideo.AssertExpectations(t)
+}
+
+// slideTimeline is the timeline the creator builds for slides without transitions
+func slideTimeline(slides, audioPaths []string) timeline.Timeline {
+	tl, _ := timeline.FromSlides(slides, audioPaths)
+	return tl
+}
+
+func TestVideoCreator_Create_Resume(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	logger := &mockLogger{}
+	textService := NewTextService(fs, logger)
+	mockTranslation := new(mocks.MockTranslator)
+	mockAudio := new(mocks.MockAudioGenerator)
+	mockVideo := new(mocks.MockVideoGenerator)
+	mockSlide := new(mocks.MockSlideLoader)
+
+	inputTexts := []string{"Hello"}
+	slides := []string{"/test/data/slides/1.png"}
+	require.NoError(t, textService.Save(context.Background(), "/test/data/texts.txt", inputTexts))
+	require.NoError(t, afero.WriteFile(fs, slides[0], []byte("slide"), 0644))
+
+	enAudio := []string{"/test/data/cache/en/audio/0.mp3"}
+	frAudio := []string{"/test/data/cache/fr/audio/0.mp3"}
+	manifestPath := "/test/data/cache/" + ManifestFileName
+
+	mockSlide.On("LoadSlides", mock.Anything, "/test/data/slides").Return(slides, nil)
+	mockTranslation.On("TranslateBatch", mock.Anything, inputTexts, "fr").Return([]string{"Bonjour"}, nil).Once()
+	mockAudio.On("GenerateBatch", mock.Anything, inputTexts, []interfaces.Voice(nil), "/test/data/cache/en/audio").Return(enAudio, nil).Once()
+	mockAudio.On("GenerateBatch", mock.Anything, []string{"Bonjour"}, []interfaces.Voice(nil), "/test/data/cache/fr/audio").Return(frAudio, nil).Twice()
+	mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, enAudio), "/test/data/out/output-en.mp4").
+		Run(func(args mock.Arguments) {
+			require.NoError(t, afero.WriteFile(fs, "/test/data/out/output-en.mp4", []byte("video"), 0644))
+		}).
+		Return(nil).Once()
+	mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, frAudio), "/test/data/out/output-fr.mp4").
+		Return(errors.New("ffmpeg crashed")).Once()
+
+	cfg := VideoCreatorConfig{
+		RootDir:     "/test",
+		InputLang:   "en",
+		OutputLangs: []string{"en", "fr"},
+	}
+
+	// First run: French fails
+	manifest, err := OpenManifest(fs, manifestPath, false)
+	require.NoError(t, err)
+	creator := NewVideoCreator(fs, textService, mockTranslation, mockAudio, mockVideo, mockSlide, logger)
+	creator.SetManifest(manifest)
+	err = creator.Create(context.Background(), cfg)
+	require.Error(t, err)
+
+	saved, err := LoadManifest(fs, manifestPath)
+	require.NoError(t, err)
+	en, ok := saved.Lookup("/test/data/out/output-en.mp4")
+	require.True(t, ok)
+	assert.Equal(t, ArtifactDone, en.Status)
+	fr, ok := saved.Lookup("/test/data/out/output-fr.mp4")
+	require.True(t, ok)
+	assert.Equal(t, ArtifactFailed, fr.Status)
+	assert.Contains(t, fr.Error, "ffmpeg crashed")
+	translation, ok := saved.Lookup("/test/data/cache/fr/text/texts.txt")
+	require.True(t, ok)
+	assert.Equal(t, ArtifactTranslation, translation.Kind)
+	assert.Equal(t, ArtifactDone, translation.Status)
+
+	// Second run resumes: English is skipped, French is retried
+	mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, frAudio), "/test/data/out/output-fr.mp4").
+		Return(nil).Once()
+	manifest, err = OpenManifest(fs, manifestPath, true)
+	require.NoError(t, err)
+	assert.True(t, manifest.Resumed)
+	creator.SetManifest(manifest)
+	require.NoError(t, creator.Create(context.Background(), cfg))
+
+	mockAudio.AssertExpectations(t)
+	mockVideo.AssertExpectations(t)
+	mockTranslation.AssertExpectations(t)
+
+	// Changing the sources invalidates the finished language
+	require.NoError(t, afero.WriteFile(fs, slides[0], []byte("edited slide"), 0644))
+	assert.False(t, creator.isResumable("/test/data/out/output-en.mp4",
+		languageInputsHash(mustHashSources(t, creator, inputTexts, slides), cfg, "en", "", "")))
+}
+
+func TestVideoCreator_SettingsHash(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	logger := &mockLogger{}
+	audioService := NewAudioService(fs, new(mocks.MockOpenAIClient), NewTextService(fs, logger), logger)
+	creator := NewVideoCreator(fs, NewTextService(fs, logger), new(mocks.MockTranslator), audioService, new(mocks.MockVideoGenerator), new(mocks.MockSlideLoader), logger)
+	cfg := VideoCreatorConfig{InputLang: "en", OutputLangs: []string{"en", "fr"}}
+	assert.Empty(t, creator.settingsHash(cfg, "fr"))
+
+	// The pronunciations of a language are part of the inputs of its video only
+	audioService.SetLexicon(loadTestLexicon(t))
+	fr := creator.settingsHash(cfg, "fr")
+	assert.NotEmpty(t, fr)
+	assert.Empty(t, creator.settingsHash(cfg, "de"))
+	assert.NotEqual(t, languageInputsHash("sources", cfg, "fr", "", ""), languageInputsHash("sources", cfg, "fr", "", fr))
+
+	// So are the settings of its translations
+	translationService := NewTranslationService(new(mocks.MockOpenAIClient), logger)
+	creator = NewVideoCreator(fs, NewTextService(fs, logger), translationService, audioService, new(mocks.MockVideoGenerator), new(mocks.MockSlideLoader), logger)
+	assert.Equal(t, fr, creator.settingsHash(cfg, "fr"))
+	providers, err := NewTranslationProviders(map[string]interfaces.TranslationProvider{
+		ProviderOpenAI: NewOpenAITranslator(new(mocks.MockOpenAIClient)),
+		ProviderDeepL:  &fakeTranslationProvider{name: "deepl"},
+	}, ProviderOpenAI, map[string]string{"de": ProviderDeepL, "en": ProviderDeepL})
+	require.NoError(t, err)
+	creator.translationService = translationService.WithProviders(providers)
+	assert.Equal(t, fr, creator.settingsHash(cfg, "fr"))
+	assert.Equal(t, "provider=deepl", creator.settingsHash(cfg, "de"))
+	assert.NotContains(t, creator.settingsHash(cfg, "en"), "provider") // The narration is not translated
+	creator.translationService = translationService.WithDeck(0)
+	assert.Equal(t, fr+"|deck=40", creator.settingsHash(cfg, "fr"))
+	creator.translationService = translationService.WithGlossary(loadTestGlossary(t))
+	assert.Contains(t, creator.settingsHash(cfg, "fr"), "glossary=GoCreator=;kubectl apply=;slide deck=présentation")
+	assert.Contains(t, creator.settingsHash(cfg, "de"), "glossary=GoCreator=;kubectl apply=")
+	creator.translationService = translationService.WithQA(TranslationQA{BackTranslation: true}, "en")
+	assert.Equal(t, fr+"|qa=2|0.5|2|back=0.4", creator.settingsHash(cfg, "fr"))
+	withPrompt, err := translationService.WithPrompt(TranslationPrompt{Tone: "friendly"}, "en")
+	require.NoError(t, err)
+	creator.translationService = withPrompt
+	friendly := creator.settingsHash(cfg, "fr")
+	assert.Contains(t, friendly, "|prompt=")
+	withPrompt, err = translationService.WithPrompt(TranslationPrompt{Tone: "formal"}, "en")
+	require.NoError(t, err)
+	creator.translationService = withPrompt
+	assert.NotEqual(t, friendly, creator.settingsHash(cfg, "fr"))
+}
+
+func mustHashSources(t *testing.T, vc *VideoCreator, inputTexts, slides []string) string {
+	t.Helper()
+	hash, err := vc.hashSources(inputTexts, slides, nil)
+	require.NoError(t, err)
+	return hash
+}
+
+func TestVideoCreator_Run_KeepGoing(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockText := new(mocks.MockTextProcessor)
+	mockTranslation := new(mocks.MockTranslator)
+	mockAudio := new(mocks.MockAudioGenerator)
+	mockVideo := new(mocks.MockVideoGenerator)
+	mockSlide := new(mocks.MockSlideLoader)
+	logger := &mockLogger{}
+
+	inputTexts := []string{"Hello"}
+	slides := []string{"/test/data/slides/1.png"}
+	enAudio := []string{"/test/data/cache/en/audio/0.mp3"}
+
+	mockText.On("Load", mock.Anything, "/test/data/texts.txt").Return(inputTexts, nil)
+	mockSlide.On("LoadSlides", mock.Anything, "/test/data/slides").Return(slides, nil)
+	mockTranslation.On("TranslateBatch", mock.Anything, inputTexts, "fr").Return(nil, errors.New("quota exceeded"))
+	mockAudio.On("GenerateBatch", mock.Anything, inputTexts, []interfaces.Voice(nil), "/test/data/cache/en/audio").Return(enAudio, nil)
+	mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, enAudio), "/test/data/out/output-en.mp4").Return(nil)
+
+	creator := NewVideoCreator(fs, mockText, mockTranslation, mockAudio, mockVideo, mockSlide, logger)
+	report, err := creator.Run(context.Background(), VideoCreatorConfig{
+		RootDir:     "/test",
+		InputLang:   "en",
+		OutputLangs: []string{"en", "fr"},
+		KeepGoing:   true,
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 2 languages failed")
+	assert.Contains(t, err.Error(), "quota exceeded")
+
+	require.Len(t, report.Languages, 2)
+	assert.Equal(t, StatusSucceeded, report.Languages[0].Status)
+	assert.Equal(t, "/test/data/out/output-en.mp4", report.Languages[0].Output)
+	assert.Equal(t, StatusFailed, report.Languages[1].Status)
+	assert.Contains(t, report.Languages[1].Error, "quota exceeded")
+	assert.Equal(t, StatusFailed, report.Status)
+	mockVideo.AssertExpectations(t)
+}
+
+func TestVideoCreator_Run_Preview(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockText := new(mocks.MockTextProcessor)
+	mockTranslation := new(mocks.MockTranslator)
+	mockAudio := new(mocks.MockAudioGenerator)
+	mockVideo := new(mocks.MockVideoGenerator)
+	mockSlide := new(mocks.MockSlideLoader)
+	logger := &mockLogger{}
+
+	inputTexts := []string{"One", "Two", "Three"}
+	slides := []string{"/test/data/slides/1.png", "/test/data/slides/2.png", "/test/data/slides/3.png"}
+	previewAudio := []string{"/test/data/cache/fr/audio/1.mp3", "/test/data/cache/fr/audio/2.mp3"}
+
+	mockText.On("Load", mock.Anything, "/test/data/texts.txt").Return(inputTexts, nil)
+	mockSlide.On("LoadSlides", mock.Anything, "/test/data/slides").Return(slides, nil)
+	// Only the selected slides are translated and synthesized, in the caches of the full video
+	mockTranslation.On("TranslateBatch", mock.Anything, []string{"", "Two", "Three"}, "fr").Return([]string{"", "Deux", "Trois"}, nil)
+	mockAudio.On("Generate", mock.Anything, "Deux", interfaces.Voice{}, previewAudio[0]).Return(nil)
+	mockAudio.On("Generate", mock.Anything, "Trois", interfaces.Voice{}, previewAudio[1]).Return(nil)
+	mockVideo.On("GeneratePreview", mock.Anything, slideTimeline(slides[1:], previewAudio), []int{1, 2},
+		"/test/data/out/output-fr.mp4", "/test/data/out/preview/preview-fr.mp4").Return(nil)
+
+	creator := NewVideoCreator(fs, mockText, mockTranslation, mockAudio, mockVideo, mockSlide, logger)
+	report, err := creator.Run(context.Background(), VideoCreatorConfig{
+		RootDir:     "/test",
+		InputLang:   "en",
+		OutputLangs: []string{"fr"},
+		Slides:      []int{1, 2},
+	})
+	require.NoError(t, err)
+
+	// A partial translation is never saved
+	mockText.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+	mockTranslation.AssertExpectations(t)
+	mockAudio.AssertExpectations(t)
+	mockVideo.AssertExpectations(t)
+
+	require.Len(t, report.Languages, 1)
+	lang := report.Languages[0]
+	assert.Equal(t, "/test/data/out/preview/preview-fr.mp4", lang.Output)
+	assert.Equal(t, StatusSucceeded, lang.Status)
+	assert.Equal(t, StatusSkipped, lang.Slides[0].Status)
+	assert.Equal(t, StatusSucceeded, lang.Slides[1].Status)
+	assert.Equal(t, StatusSucceeded, lang.Slides[2].Status)
+}
+
+func TestVideoCreator_Run_HumanTranslation(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockText := new(mocks.MockTextProcessor)
+	mockTranslation := new(mocks.MockTranslator)
+	mockAudio := new(mocks.MockAudioGenerator)
+	mockVideo := new(mocks.MockVideoGenerator)
+	mockSlide := new(mocks.MockSlideLoader)
+	logger := &mockLogger{}
+
+	inputTexts := []string{"One", "Two", "Three"}
+	slides := []string{"/test/data/slides/1.png", "/test/data/slides/2.png", "/test/data/slides/3.png"}
+	frAudio := []string{"/test/data/cache/fr/audio/0.mp3", "/test/data/cache/fr/audio/1.mp3", "/test/data/cache/fr/audio/2.mp3"}
+	require.NoError(t, afero.WriteFile(fs, "/test/data/texts.fr.txt", []byte("Un\n-\n-\n# relu\nTrois"), 0644))
+	// A stale machine translation is ignored
+	require.NoError(t, afero.WriteFile(fs, "/test/data/cache/fr/text/texts.txt", []byte("Uno\n-\nDos\n-\nTres"), 0644))
+
+	mockText.On("Load", mock.Anything, "/test/data/texts.txt").Return(inputTexts, nil)
+	mockSlide.On("LoadSlides", mock.Anything, "/test/data/slides").Return(slides, nil)
+	// Only the slide the translator left empty is machine translated
+	mockTranslation.On("TranslateBatch", mock.Anything, []string{"", "Two", ""}, "fr").Return([]string{"", "Deux", ""}, nil)
+	mockAudio.On("GenerateBatch", mock.Anything, []string{"Un", "Deux", "Trois"}, []interfaces.Voice(nil), "/test/data/cache/fr/audio").Return(frAudio, nil)
+	mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, frAudio), "/test/data/out/output-fr.mp4").Return(nil)
+
+	creator := NewVideoCreator(fs, mockText, mockTranslation, mockAudio, mockVideo, mockSlide, logger)
+	report, err := creator.Run(context.Background(), VideoCreatorConfig{
+		RootDir:     "/test",
+		InputLang:   "en",
+		OutputLangs: []string{"fr"},
+	})
+	require.NoError(t, err)
+
+	// The translator's file stays the translation of record
+	mockText.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+	mockAudio.AssertExpectations(t)
+	mockVideo.AssertExpectations(t)
+
+	slideReports := report.Languages[0].Slides
+	assert.Equal(t, TranslationHuman, slideReports[0].Translation)
+	assert.Equal(t, TranslationMachine, slideReports[1].Translation)
+	assert.Equal(t, TranslationHuman, slideReports[2].Translation)
+}
+
+func TestVideoCreator_Run_ReviewedTranslation(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockText := new(mocks.MockTextProcessor)
+	mockTranslation := new(mocks.MockTranslator)
+	mockAudio := new(mocks.MockAudioGenerator)
+	mockVideo := new(mocks.MockVideoGenerator)
+	mockSlide := new(mocks.MockSlideLoader)
+	logger := &mockLogger{}
+
+	inputTexts := []string{"One", "Two", "Three"}
+	slides := []string{"/test/data/slides/1.png", "/test/data/slides/2.png", "/test/data/slides/3.png"}
+	frAudio := []string{"/test/data/cache/fr/audio/0.mp3", "/test/data/cache/fr/audio/1.mp3", "/test/data/cache/fr/audio/2.mp3"}
+	// The reviewed translation of a slide that moved from slide 3 still applies
+	require.NoError(t, afero.WriteFile(fs, "/test/data/cache/fr/text/reviewed.json",
+		[]byte(`{"version":1,"translations":[{"slide":3,"source":"Two","target":"Deux, relu"}]}`), 0644))
+
+	mockText.On("Load", mock.Anything, "/test/data/texts.txt").Return(inputTexts, nil)
+	mockSlide.On("LoadSlides", mock.Anything, "/test/data/slides").Return(slides, nil)
+	// The reviewed slide is never machine translated
+	mockTranslation.On("TranslateBatch", mock.Anything, []string{"One", "", "Three"}, "fr").Return([]string{"Un", "", "Trois"}, nil)
+	mockText.On("Save", mock.Anything, "/test/data/cache/fr/text/texts.txt", []string{"Un", "Deux, relu", "Trois"}).Return(nil)
+	mockAudio.On("GenerateBatch", mock.Anything, []string{"Un", "Deux, relu", "Trois"}, []interfaces.Voice(nil), "/test/data/cache/fr/audio").Return(frAudio, nil)
+	mockVideo.On("GenerateFromTimeline", mock.Anything, slideTimeline(slides, frAudio), "/test/data/out/output-fr.mp4").Return(nil)
+
+	creator := NewVideoCreator(fs, mockText, mockTranslation, mockAudio, mockVideo, mockSlide, logger)
+	report, err := creator.Run(context.Background(), VideoCreatorConfig{
+		RootDir:     "/test",
+		InputLang:   "en",
+		OutputLangs: []string{"fr"},
+	})
+	require.NoError(t, err)
+	mockTranslation.AssertExpectations(t)
+	mockText.AssertExpectations(t)
+
+	slideReports := report.Languages[0].Slides
+	assert.Equal(t, TranslationMachine, slideReports[0].Translation)
+	assert.Equal(t, TranslationReviewed, slideReports[1].Translation)
+	assert.Equal(t, TranslationMachine, slideReports[2].Translation)
+}
+
+// translatedRun creates the French video of inputTexts with translator and returns the narration
+// its audio is generated from
+func translatedRun(t *testing.T, fs afero.Fs, translator interfaces.Translator, inputTexts []string) []string {
+	t.Helper()
+	logger := &mockLogger{}
+	textService := NewTextService(fs, logger)
+	require.NoError(t, textService.Save(context.Background(), "/test/data/texts.txt", inputTexts))
+	slides := make([]string, len(inputTexts))
+	audio := make([]string, len(inputTexts))
+	for i := range inputTexts {
+		slides[i] = fmt.Sprintf("/test/data/slides/%d.png", i+1)
+		audio[i] = fmt.Sprintf("/test/data/cache/fr/audio/%d.mp3", i)
+	}
+	mockSlide := new(mocks.MockSlideLoader)
+	mockAudio := new(mocks.MockAudioGenerator)
+	mockVideo := new(mocks.MockVideoGenerator)
+	mockSlide.On("LoadSlides", mock.Anything, "/test/data/slides").Return(slides, nil)
+	var narrated []string
+	mockAudio.On("GenerateBatch", mock.Anything, mock.Anything, []interfaces.Voice(nil), "/test/data/cache/fr/audio").
+		Run(func(args mock.Arguments) { narrated = args.Get(1).([]string) }).
+		Return(audio, nil)
+	mockVideo.On("GenerateFromTimeline", mock.Anything, mock.Anything, "/test/data/out/output-fr.mp4").Return(nil)
+
+	creator := NewVideoCreator(fs, textService, translator, mockAudio, mockVideo, mockSlide, logger)
+	require.NoError(t, creator.Create(context.Background(), VideoCreatorConfig{RootDir: "/test", InputLang: "en", OutputLangs: []string{"fr"}}))
+	return narrated
+}
+
+// requestAbout matches the chat completion requests translating text
+func requestAbout(text string) any {
+	return mock.MatchedBy(func(messages []openai.ChatCompletionMessageParamUnion) bool {
+		return strings.Contains(userPrompt(messages), text)
+	})
+}
+
+func TestVideoCreator_Run_RetranslatesFlaggedSlides(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockClient := new(mocks.MockOpenAIClient)
+	translator := NewTranslationService(mockClient, &mockLogger{}).WithQA(TranslationQA{}, "en")
+	inputTexts := []string{"Visit https://example.com to learn more about it.", "Thanks for watching this video until the end."}
+
+	// The translation of the first slide loses the URL at every attempt, so it is flagged
+	mockClient.On("ChatCompletion", mock.Anything, requestAbout(inputTexts[0]), interfaces.ChatOptions{}).
+		Return("Visitez notre site pour en savoir plus à ce sujet.", nil).Times(2)
+	mockClient.On("ChatCompletion", mock.Anything, requestAbout(inputTexts[1]), interfaces.ChatOptions{}).
+		Return("Merci d'avoir regardé cette vidéo jusqu'au bout.", nil).Once()
+	assert.Equal(t, []string{"Visitez notre site pour en savoir plus à ce sujet.", "Merci d'avoir regardé cette vidéo jusqu'au bout."},
+		translatedRun(t, fs, translator, inputTexts))
+	mockClient.AssertExpectations(t)
+
+	// The next run translates the flagged slide again, and only that one
+	mockClient.On("ChatCompletion", mock.Anything, requestAbout(inputTexts[0]), interfaces.ChatOptions{}).
+		Return("Visitez https://example.com pour en savoir plus à ce sujet.", nil).Once()
+	assert.Equal(t, []string{"Visitez https://example.com pour en savoir plus à ce sujet.", "Merci d'avoir regardé cette vidéo jusqu'au bout."},
+		translatedRun(t, fs, translator, inputTexts))
+	mockClient.AssertExpectations(t)
+
+	// Once every slide passes, the saved translation is reused whole
+	assert.Equal(t, []string{"Visitez https://example.com pour en savoir plus à ce sujet.", "Merci d'avoir regardé cette vidéo jusqu'au bout."},
+		translatedRun(t, fs, translator, inputTexts))
+	mockClient.AssertExpectations(t)
+}
+
+// plannedTranslations returns the number of translation requests the dry run of the French video
+// of the project at /test plans with translator
+func plannedTranslations(t *testing.T, fs afero.Fs, translator *TranslationService) int {
+	t.Helper()
+	logger := &mockLogger{}
+	textService := NewTextService(fs, logger)
+	planner := NewPlanner(fs, textService, NewSlideService(fs, logger), translator,
+		NewAudioService(fs, new(mocks.MockOpenAIClient), textService, logger), NewVideoService(fs, logger), logger)
+	plan, err := planner.Plan(context.Background(), VideoCreatorConfig{RootDir: "/test", InputLang: "en", OutputLangs: []string{"fr"}})
+	require.NoError(t, err)
+	return plan.Languages[0].TranslationRequests
+}
+
+func TestVideoCreator_Run_PromptChange(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockClient := new(mocks.MockOpenAIClient)
+	base := NewTranslationService(mockClient, &mockLogger{})
+	inputTexts := []string{"Hello", "Goodbye"}
+	writeTestPNG(t, fs, "/test/data/slides/1.png", 640, 480)
+	writeTestPNG(t, fs, "/test/data/slides/2.png", 640, 480)
+
+	mockClient.On("ChatCompletion", mock.Anything, requestAbout("'Hello'"), interfaces.ChatOptions{}).Return("Bonjour", nil).Once()
+	mockClient.On("ChatCompletion", mock.Anything, requestAbout("'Goodbye'"), interfaces.ChatOptions{}).Return("Au revoir", nil).Once()
+	assert.Equal(t, []string{"Bonjour", "Au revoir"}, translatedRun(t, fs, base, inputTexts))
+	assert.Equal(t, 0, plannedTranslations(t, fs, base))
+
+	// Another model and system prompt translate every slide again, as the dry run tells
+	formal, err := base.WithPrompt(TranslationPrompt{Model: "gpt-4o", System: "Use the formal register."}, "en")
+	require.NoError(t, err)
+	assert.Equal(t, 2, plannedTranslations(t, fs, formal))
+	mockClient.On("ChatCompletion", mock.Anything, requestAbout("'Hello'"), interfaces.ChatOptions{Model: "gpt-4o"}).Return("Bonjour à vous", nil).Once()
+	mockClient.On("ChatCompletion", mock.Anything, requestAbout("'Goodbye'"), interfaces.ChatOptions{Model: "gpt-4o"}).Return("Au revoir à vous", nil).Once()
+	assert.Equal(t, []string{"Bonjour à vous", "Au revoir à vous"}, translatedRun(t, fs, formal, inputTexts))
+	assert.Equal(t, 0, plannedTranslations(t, fs, formal))
+	mockClient.AssertExpectations(t)
+}
+
+func TestVideoCreator_Run_GlossaryEdit(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockClient := new(mocks.MockOpenAIClient)
+	base := NewTranslationService(mockClient, &mockLogger{})
+	withGlossary := func(translation string) *TranslationService {
+		t.Helper()
+		require.NoError(t, afero.WriteFile(fs, "/test/data/glossary.yaml", []byte("translate:\n  fr:\n    slide deck: "+translation+"\n"), 0644))
+		glossary, err := LoadGlossary(fs, "/test/data/glossary.yaml")
+		require.NoError(t, err)
+		return base.WithGlossary(glossary)
+	}
+	inputTexts := []string{"Open the slide deck now.", "Goodbye"}
+
+	mockClient.On("ChatCompletion", mock.Anything, requestAbout("'Open the slide deck now.'"), interfaces.ChatOptions{}).
+		Return("Ouvrez la présentation maintenant.", nil).Once()
+	mockClient.On("ChatCompletion", mock.Anything, requestAbout("'Goodbye'"), interfaces.ChatOptions{}).Return("Au revoir", nil).Once()
+	assert.Equal(t, []string{"Ouvrez la présentation maintenant.", "Au revoir"}, translatedRun(t, fs, withGlossary("présentation"), inputTexts))
+
+	// Editing a term translates again the slides using it, and only those
+	mockClient.On("ChatCompletion", mock.Anything, requestAbout("'Open the slide deck now.'"), interfaces.ChatOptions{}).
+		Return("Ouvrez le diaporama maintenant.", nil).Once()
+	assert.Equal(t, []string{"Ouvrez le diaporama maintenant.", "Au revoir"}, translatedRun(t, fs, withGlossary("diaporama"), inputTexts))
+	mockClient.AssertExpectations(t)
+}
+
+func TestVideoCreator_Run_PreviewOutOfRange(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockText := new(mocks.MockTextProcessor)
+	mockSlide := new(mocks.MockSlideLoader)
+	mockVideo := new(mocks.MockVideoGenerator)
+
+	mockText.On("Load", mock.Anything, "/test/data/texts.txt").Return([]string{"One"}, nil)
+	mockSlide.On("LoadSlides", mock.Anything, "/test/data/slides").Return([]string{"/test/data/slides/1.png"}, nil)
+
+	creator := NewVideoCreator(fs, mockText, new(mocks.MockTranslator), new(mocks.MockAudioGenerator), mockVideo, mockSlide, &mockLogger{})
+	_, err := creator.Run(context.Background(), VideoCreatorConfig{
+		RootDir:     "/test",
+		InputLang:   "en",
+		OutputLangs: []string{"en"},
+		Slides:      []int{3},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid slide 4")
+	mockVideo.AssertNotCalled(t, "GeneratePreview", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLanguagesError(t *testing.T) {
+	first := errors.New("failed to process language fr: boom")
+	canceled := fmt.Errorf("failed to process language de: %w", context.Canceled)
+
+	assert.NoError(t, languagesError([]error{nil, nil}, false))
+
+	// Without keep-going, the cancellations caused by the first failure are not reported
+	assert.Equal(t, first, languagesError([]error{nil, canceled, first}, false))
+
+	err := languagesError([]error{nil, canceled, first}, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 of 3 languages failed")
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gocreator/internal/interfaces"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// DefaultDeckChunkSize is the number of slides translated in one request in deck mode
+const DefaultDeckChunkSize = 40
+
+// errInvalidDeckResponse is the error of a deck translation whose answer doesn't match its request
+var errInvalidDeckResponse = errors.New("invalid deck translation")
+
+// deckSlide is the narration of a slide, by index, in a deck translation request or its answer,
+// with the notes for translators of the slide in a request
+type deckSlide struct {
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+	Notes string `json:"notes,omitempty"`
+}
+
+// deckRequest is the content of a deck translation request
+type deckRequest struct {
+	TargetLanguage string        `json:"target_language"`
+	Audience       string        `json:"audience,omitempty"`
+	Tone           string        `json:"tone,omitempty"`
+	Glossary       *deckGlossary `json:"glossary,omitempty"`
+	Memory         []deckMemory  `json:"translation_memory,omitempty"`
+	Slides         []deckSlide   `json:"slides"`
+}
+
+// deckGlossary is the glossary terms of the slides of a deck translation request
+type deckGlossary struct {
+	Keep      []string          `json:"keep,omitempty"`
+	Translate map[string]string `json:"translate,omitempty"`
+}
+
+// deckResponse is the answer to a deck translation request
+type deckResponse struct {
+	Translations []deckSlide `json:"translations"`
+}
+
+// deckMemory is a match of the translation memory for a sentence of the slides of a deck translation request
+type deckMemory struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// deckSchema constrains the answer to a deck translation request
+var deckSchema = interfaces.JSONSchema{
+	Name: "deck_translation",
+	Schema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"translations": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"index": map[string]any{"type": "integer"},
+						"text":  map[string]any{"type": "string"},
+					},
+					"required":             []string{"index", "text"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []string{"translations"},
+		"additionalProperties": false,
+	},
+}
+
+// deckSystemPrompt instructs the model how to translate a deck
+const deckSystemPrompt = "You translate the narration of a slide deck. The user sends JSON with the target language " +
+	"and the slides to translate, in order, each with its index. Translate the narration of every slide to the " +
+	"target language, keeping terminology and tone consistent across the slides. Answer with one translation per " +
+	"slide, in the order of the slides and with the index of its slide. Keep every placeholder such as " +
+	placeholderOpen + "1" + placeholderClose + " exactly as it is, where it belongs in the translation. When there " +
+	"is a glossary, never translate or transliterate its keep terms, and translate each of its translate terms as given. " +
+	"When there is a translation memory, its approved translations of the sentences of the slides, or of similar ones, " +
+	"are to be reused where they apply. When there is an audience or a tone, translate for that audience, in that tone. " +
+	"The notes of a slide are context for its translation, never to be translated or included in it."
+
+// newDeckRequest returns the request translating chunk to targetLang, with the glossary terms of the
+// source texts of its slides, in sources by slide index, and their notes for translators in ctx
+func (s *TranslationService) newDeckRequest(ctx context.Context, chunk []deckSlide, sources []string, targetLang string) deckRequest {
+	request := deckRequest{TargetLanguage: targetLang, Slides: make([]deckSlide, len(chunk))}
+	if s.prompt != nil {
+		request.Audience, request.Tone = s.prompt.Audience, s.prompt.Tone
+	}
+	glossary := &deckGlossary{}
+	for i, slide := range chunk {
+		slide.Notes = translatorNotes(ctx, slide.Index)
+		request.Slides[i] = slide
+		for _, match := range s.memoryMatches(sources[slide.Index], targetLang) {
+			if memory := (deckMemory{Source: match.Source, Target: match.Target}); !slices.Contains(request.Memory, memory) {
+				request.Memory = append(request.Memory, memory)
+			}
+		}
+		for _, term := range s.glossary.Terms(sources[slide.Index], targetLang) {
+			if term.Keep() {
+				if !slices.Contains(glossary.Keep, term.Source) {
+					glossary.Keep = append(glossary.Keep, term.Source)
+				}
+				continue
+			}
+			if glossary.Translate == nil {
+				glossary.Translate = make(map[string]string)
+			}
+			glossary.Translate[term.Source] = term.Target
+		}
+	}
+	if len(glossary.Keep) > 0 || len(glossary.Translate) > 0 {
+		request.Glossary = glossary
+	}
+	return request
+}
+
+// deckMessages returns the messages of a deck translation request, with the configured system
+// prompt, if any, after the one explaining the request
+func deckMessages(req deckRequest, system string) ([]openai.ChatCompletionMessageParamUnion, error) {
+	request, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	messages := []openai.ChatCompletionMessageParamUnion{openai.SystemMessage(deckSystemPrompt)}
+	if system != "" {
+		messages = append(messages, openai.SystemMessage(system))
+	}
+	return append(messages, openai.UserMessage(string(request))), nil
+}
+
+// parseDeckResponse decodes the answer to the request translating slides, checking it has
+// one non-empty translation per slide, in the order of the slides
+func parseDeckResponse(answer string, slides []deckSlide) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(answer)))
+	decoder.DisallowUnknownFields()
+	var resp deckResponse
+	if err := decoder.Decode(&resp); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidDeckResponse, err)
+	}
+	if len(resp.Translations) != len(slides) {
+		return nil, fmt.Errorf("%w: expected %d translations, got %d", errInvalidDeckResponse, len(slides), len(resp.Translations))
+	}
+
+	texts := make([]string, len(slides))
+	for i, translation := range resp.Translations {
+		if translation.Index != slides[i].Index {
+			return nil, fmt.Errorf("%w: translation %d is of slide %d, expected slide %d", errInvalidDeckResponse, i, translation.Index, slides[i].Index)
+		}
+		if isSilent(translation.Text) {
+			return nil, fmt.Errorf("%w: translation of slide %d is empty", errInvalidDeckResponse, translation.Index)
+		}
+		texts[i] = translation.Text
+	}
+	return texts, nil
+}
+
+// deckChunks splits slides into chunks of at most size slides
+func deckChunks(slides []deckSlide, size int) [][]deckSlide {
+	var chunks [][]deckSlide
+	for len(slides) > size {
+		chunks = append(chunks, slides[:size])
+		slides = slides[size:]
+	}
+	if len(slides) > 0 {
+		chunks = append(chunks, slides)
+	}
+	return chunks
+}
+
+// translateDeck translates the texts missing from the cache to targetLang in deck mode: together, in
+// requests of at most deckChunkSize slides. A chunk whose answer doesn't match its request is translated
+// slide by slide instead.
+func (s *TranslationService) translateDeck(ctx context.Context, texts []string, targetLang string) ([]string, error) {
+	results := make([]string, len(texts))
+	var pending []deckSlide
+	directives := make(map[int][]string)
+	for i, text := range texts {
+		if isSilent(text) {
+			continue
+		}
+		cacheKey := s.getCacheKey(withSlideIndex(ctx, i), text, targetLang)
+		if cached, ok := s.lookup(withSlideIndex(ctx, i), cacheKey); ok {
+			results[i] = cached
+			continue
+		}
+		if translated, ok := s.fromMemory(withSlideIndex(ctx, i), text, targetLang); ok {
+			recordCacheHit(withSlideIndex(ctx, i))
+			s.setInMemoryCache(cacheKey, translated)
+			s.setInDiskCache(cacheKey, translated)
+			results[i] = translated
+			continue
+		}
+		// Speech markup is kept out of the translation as placeholders
+		protected, slideDirectives := protectMarkup(text)
+		pending = append(pending, deckSlide{Index: i, Text: protected})
+		directives[i] = slideDirectives
+	}
+
+	chunks := deckChunks(pending, s.deckChunkSize)
+	errs := make([]error, len(chunks))
+	var wg sync.WaitGroup
+	for c, chunk := range chunks {
+		wg.Add(1)
+		go func(c int, chunk []deckSlide) {
+			defer wg.Done()
+			start := time.Now()
+			translated, err := s.translateChunk(ctx, chunk, texts, directives, targetLang)
+			if errors.Is(err, errInvalidDeckResponse) {
+				s.logger.Warn("Deck translation rejected, translating its slides one by one",
+					"lang", targetLang, "from", chunk[0].Index, "to", chunk[len(chunk)-1].Index, "error", err)
+				translated, err = s.translateSlides(ctx, chunk, texts, targetLang)
+			}
+			for i, slide := range chunk {
+				recordSlideStep(withSlideIndex(ctx, slide.Index), start, err)
+				if err == nil {
+					results[slide.Index] = translated[i]
+				}
+			}
+			errs[c] = err
+		}(c, chunk)
+	}
+	wg.Wait()
+
+	for c, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("translation failed for texts %d to %d: %w", chunks[c][0].Index, chunks[c][len(chunks[c])-1].Index, err)
+		}
+	}
+	return results, nil
+}
+
+// translateChunk translates the slides of a chunk, whose source texts are in texts by slide index,
+// in one request, and caches their translations once the answer is checked against the request.
+// A slide whose translation breaks the glossary, or fails the quality checks when they allow
+// another attempt, is translated on its own instead.
+func (s *TranslationService) translateChunk(ctx context.Context, chunk []deckSlide, texts []string, directives map[int][]string, targetLang string) ([]string, error) {
+	messages, err := deckMessages(s.newDeckRequest(ctx, chunk, texts, targetLang), s.prompt.system())
+	if err != nil {
+		return nil, err
+	}
+
+	var answer string
+	options := s.prompt.options()
+	options.Schema = &deckSchema
+	err = s.scheduler.API(ctx, func() error {
+		var err error
+		recordAPICall(ctx)
+		answer, err = s.client.ChatCompletion(ctx, messages, options)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("deck translation failed: %w", err)
+	}
+	translated, err := parseDeckResponse(answer, chunk)
+	if err != nil {
+		return nil, err
+	}
+	for i, slide := range chunk {
+		translated[i], err = restoreMarkup(translated[i], directives[slide.Index])
+		if err != nil {
+			return nil, fmt.Errorf("%w: slide %d lost speech markup: %v", errInvalidDeckResponse, slide.Index, err)
+		}
+	}
+
+	// Only a translation checked as a whole is cached
+	for i, slide := range chunk {
+		source := texts[slide.Index]
+		problems := glossaryViolations(translated[i], s.glossary.Terms(source, targetLang))
+		issues := s.qaIssues(withSlideIndex(ctx, slide.Index), source, translated[i], targetLang)
+		if len(issues) > 0 && s.qaAttempts() > 1 {
+			problems = append(problems, issues...)
+		}
+		if len(problems) > 0 {
+			s.logger.Warn("Deck translation fails its checks, translating the text on its own",
+				"lang", targetLang, "text", slide.Index, "problems", strings.Join(problems, "; "))
+			translated[i], err = s.Translate(withSlideIndex(ctx, slide.Index), source, targetLang)
+			if err != nil {
+				return nil, fmt.Errorf("text %d: %w", slide.Index, err)
+			}
+			continue
+		}
+		if len(issues) > 0 {
+			continue // Flagged once the batch is translated, and translated again by the next run
+		}
+		cacheKey := s.getCacheKey(withSlideIndex(ctx, slide.Index), source, targetLang)
+		s.setInMemoryCache(cacheKey, translated[i])
+		s.setInDiskCache(cacheKey, translated[i])
+		s.remember(withSlideIndex(ctx, slide.Index), source, translated[i], targetLang)
+	}
+	return translated, nil
+}
+
+// translateSlides translates the slides of a chunk one by one, from their source texts in texts
+func (s *TranslationService) translateSlides(ctx context.Context, chunk []deckSlide, texts []string, targetLang string) ([]string, error) {
+	translated := make([]string, len(chunk))
+	for i, slide := range chunk {
+		var err error
+		translated[i], err = s.Translate(withSlideIndex(ctx, slide.Index), texts[slide.Index], targetLang)
+		if err != nil {
+			return nil, fmt.Errorf("text %d: %w", slide.Index, err)
+		}
+	}
+	return translated, nil
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"gocreator/internal/interfaces"
+	"gocreator/internal/mocks"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// deckRequestWith matches the messages of a deck translation request of the slides at indices
+func deckRequestWith(indices ...int) interface{} {
+	return mock.MatchedBy(func(messages []openai.ChatCompletionMessageParamUnion) bool {
+		if len(messages) != 2 || messages[1].OfUser == nil {
+			return false
+		}
+		var req deckRequest
+		if err := json.Unmarshal([]byte(messages[1].OfUser.Content.OfString.Value), &req); err != nil || len(req.Slides) != len(indices) {
+			return false
+		}
+		for i, slide := range req.Slides {
+			if slide.Index != indices[i] {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestTranslationService_TranslateBatchDeck(t *testing.T) {
+	ctx := context.Background()
+	fs := afero.NewMemMapFs()
+	logger := &mockLogger{}
+	mockClient := new(mocks.MockOpenAIClient)
+	service := NewTranslationServiceWithCache(mockClient, logger, fs, "/cache").WithDeck(2)
+
+	// Cached in deck mode: the key of slide mode is not reused
+	mockClient.On("ChatCompletion", mock.Anything, deckRequestWith(0), interfaces.ChatOptions{Schema: &deckSchema}).
+		Return(`{"translations":[{"index":0,"text":"Bonjour"}]}`, nil).Once()
+	_, err := service.TranslateBatch(ctx, []string{"Hello"}, "fr")
+	require.NoError(t, err)
+	assert.NotEqual(t, NewTranslationService(mockClient, logger).getCacheKey(context.Background(), "Hello", "fr"), service.getCacheKey(context.Background(), "Hello", "fr"))
+
+	mockClient.On("ChatCompletion", mock.Anything, deckRequestWith(2, 3), interfaces.ChatOptions{Schema: &deckSchema}).
+		Return(`{"translations":[{"index":2,"text":"Il a dit « oui »"},{"index":3,"text":"Attendez ⟦1⟧ ici"}]}`, nil).Once()
+	mockClient.On("ChatCompletion", mock.Anything, deckRequestWith(4), interfaces.ChatOptions{Schema: &deckSchema}).
+		Return(`{"translations":[{"index":4,"text":"Fin"}]}`, nil).Once()
+
+	translated, err := service.TranslateBatch(ctx, []string{"Hello", "", `He said "yes"`, "Wait [pause 1s] here", "The end"}, "fr")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bonjour", "", "Il a dit « oui »", "Attendez [pause 1s] ici", "Fin"}, translated)
+	mockClient.AssertExpectations(t)
+
+	cached, ok := service.Cached(context.Background(), "Wait [pause 1s] here", "fr")
+	assert.True(t, ok)
+	assert.Equal(t, "Attendez [pause 1s] ici", cached)
+}
+
+func TestTranslationService_TranslateBatchDeckInvalidAnswer(t *testing.T) {
+	ctx := context.Background()
+	fs := afero.NewMemMapFs()
+	logger := &mockLogger{}
+	mockClient := new(mocks.MockOpenAIClient)
+	service := NewTranslationServiceWithCache(mockClient, logger, fs, "/cache").WithDeck(0)
+
+	// The answer is out of order: nothing of it is cached, and the slides are translated one by one
+	mockClient.On("ChatCompletion", mock.Anything, deckRequestWith(0, 1), interfaces.ChatOptions{Schema: &deckSchema}).
+		Return(`{"translations":[{"index":1,"text":"Monde"},{"index":0,"text":"Bonjour"}]}`, nil).Once()
+	mockClient.On("ChatCompletion", mock.Anything, mock.Anything, interfaces.ChatOptions{}).Return("Bonjour", nil).Once()
+	mockClient.On("ChatCompletion", mock.Anything, mock.Anything, interfaces.ChatOptions{}).Return("Monde", nil).Once()
+
+	translated, err := service.TranslateBatch(ctx, []string{"Hello", "World"}, "fr")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Bonjour", "Monde"}, translated)
+	mockClient.AssertExpectations(t)
+}
+
+func TestTranslationService_TranslateBatchDeckOtherProvider(t *testing.T) {
+	mockClient := new(mocks.MockOpenAIClient)
+	providers, err := NewTranslationProviders(map[string]interfaces.TranslationProvider{
+		ProviderDeepL: &fakeTranslationProvider{name: "deepl"},
+	}, ProviderDeepL, nil)
+	require.NoError(t, err)
+	service := NewTranslationService(mockClient, &mockLogger{}).WithProviders(providers).WithDeck(0)
+
+	// Deck mode only applies to OpenAI, other providers translate each slide
+	translated, err := service.TranslateBatch(context.Background(), []string{"Hello", "World"}, "de")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deepl:Hello", "deepl:World"}, translated)
+}
+
+func TestParseDeckResponse(t *testing.T) {
+	slides := []deckSlide{{Index: 1, Text: "Hello"}, {Index: 4, Text: "World"}}
+
+	tests := []struct {
+		name    string
+		answer  string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			answer: `{"translations":[{"index":1,"text":"Bonjour"},{"index":4,"text":"Monde"}]}`,
+			want:   []string{"Bonjour", "Monde"},
+		},
+		{
+			name:    "not JSON",
+			answer:  `Here is the translation: Bonjour, Monde`,
+			wantErr: "invalid deck translation: invalid character 'H' looking for beginning of value",
+		},
+		{
+			name:    "unknown field",
+			answer:  `{"translations":[],"note":"done"}`,
+			wantErr: `invalid deck translation: json: unknown field "note"`,
+		},
+		{
+			name:    "missing translation",
+			answer:  `{"translations":[{"index":1,"text":"Bonjour"}]}`,
+			wantErr: "invalid deck translation: expected 2 translations, got 1",
+		},
+		{
+			name:    "out of order",
+			answer:  `{"translations":[{"index":4,"text":"Monde"},{"index":1,"text":"Bonjour"}]}`,
+			wantErr: "invalid deck translation: translation 0 is of slide 4, expected slide 1",
+		},
+		{
+			name:    "empty translation",
+			answer:  `{"translations":[{"index":1,"text":"Bonjour"},{"index":4,"text":" "}]}`,
+			wantErr: "invalid deck translation: translation of slide 4 is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDeckResponse(tt.answer, slides)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDeckChunks(t *testing.T) {
+	slides := []deckSlide{{Index: 0}, {Index: 1}, {Index: 2}, {Index: 3}, {Index: 4}}
+	assert.Equal(t, [][]deckSlide{slides[:2], slides[2:4], slides[4:]}, deckChunks(slides, 2))
+	assert.Equal(t, [][]deckSlide{slides}, deckChunks(slides, 5))
+	assert.Empty(t, deckChunks(nil, 2))
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/afero"
+)
+
+// GlossaryFile is the name of the translation glossary of a project, in its data directory
+const GlossaryFile = "glossary.yaml"
+
+// Glossary is the terms translations must respect: terms never translated, and terms
+// translated as given in each language
+type Glossary struct {
+	keep      []string
+	translate map[string][]GlossaryTerm
+}
+
+// GlossaryTerm is a term of the glossary and its translation, "" for a term never translated
+type GlossaryTerm struct {
+	Source string
+	Target string
+}
+
+// Keep reports whether the term is never translated
+func (t GlossaryTerm) Keep() bool {
+	return t.Target == ""
+}
+
+// LoadGlossary loads the glossary at path, listing the terms kept as is and the
+// translation of terms in each language:
+//
+//	keep:
+//	  - GoCreator
+//	  - kubectl apply
+//	translate:
+//	  fr:
+//	    slide deck: présentation
+//
+// A missing file is an empty glossary, returned as nil.
+func LoadGlossary(fs afero.Fs, path string) (*Glossary, error) {
+	data, err := afero.ReadFile(fs, path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read glossary: %w", err)
+	}
+
+	var file struct {
+		Keep      []string                     `yaml:"keep"`
+		Translate map[string]map[string]string `yaml:"translate"`
+	}
+	if err := yaml.UnmarshalWithOptions(data, &file, yaml.Strict()); err != nil {
+		return nil, fmt.Errorf("failed to parse glossary %s: %w", path, err)
+	}
+
+	glossary := &Glossary{translate: make(map[string][]GlossaryTerm, len(file.Translate))}
+	for _, term := range file.Keep {
+		if strings.TrimSpace(term) == "" {
+			return nil, fmt.Errorf("invalid glossary %s: keep: empty term", path)
+		}
+		glossary.keep = append(glossary.keep, term)
+	}
+	for lang, terms := range file.Translate {
+		for source, target := range terms {
+			if strings.TrimSpace(source) == "" || strings.TrimSpace(target) == "" {
+				return nil, fmt.Errorf("invalid glossary %s: translate: %s: term %q needs a translation", path, lang, source)
+			}
+			glossary.translate[lang] = append(glossary.translate[lang], GlossaryTerm{Source: source, Target: target})
+		}
+		sort.Slice(glossary.translate[lang], func(i, j int) bool {
+			return glossary.translate[lang][i].Source < glossary.translate[lang][j].Source
+		})
+	}
+	return glossary, nil
+}
+
+// Terms returns the terms of the glossary in text, for a translation to lang: the terms kept as is,
+// matched as whole words with their case, then the terms translated, matched as whole words ignoring case
+func (g *Glossary) Terms(text, lang string) []GlossaryTerm {
+	if g == nil {
+		return nil
+	}
+	var terms []GlossaryTerm
+	for _, term := range g.keep {
+		if containsTerm(text, term, false) {
+			terms = append(terms, GlossaryTerm{Source: term})
+		}
+	}
+	for _, term := range g.translate[lang] {
+		if containsTerm(text, term.Source, true) {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// fingerprint identifies the terms of the glossary for a translation to lang, "" when none
+func (g *Glossary) fingerprint(lang string) string {
+	if g == nil {
+		return ""
+	}
+	terms := make([]GlossaryTerm, 0, len(g.keep)+len(g.translate[lang]))
+	for _, term := range g.keep {
+		terms = append(terms, GlossaryTerm{Source: term})
+	}
+	return glossaryFingerprint(append(terms, g.translate[lang]...))
+}
+
+// containsTerm reports whether text contains term as a whole word, ignoring case when foldCase is set
+func containsTerm(text, term string, foldCase bool) bool {
+	if foldCase {
+		text, term = strings.ToLower(text), strings.ToLower(term)
+	}
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], term)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(term)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// glossaryViolations returns how a translation breaks the glossary terms of its source: a kept
+// term it lacks or changed, or a term it doesn't translate as given
+func glossaryViolations(translated string, terms []GlossaryTerm) []string {
+	var violations []string
+	for _, term := range terms {
+		if term.Keep() {
+			if !containsTerm(translated, term.Source, false) {
+				violations = append(violations, fmt.Sprintf("%q is missing or changed", term.Source))
+			}
+		} else if !containsTerm(translated, term.Target, true) {
+			violations = append(violations, fmt.Sprintf("%q is not translated as %q", term.Source, term.Target))
+		}
+	}
+	return violations
+}
+
+// glossaryFingerprint identifies the glossary terms of a text, "" when none
+func glossaryFingerprint(terms []GlossaryTerm) string {
+	if len(terms) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(terms))
+	for i, term := range terms {
+		pairs[i] = term.Source + "=" + term.Target
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
+
+// glossaryInstructions returns the instructions of a prompt asking to respect terms, "" when none
+func glossaryInstructions(terms []GlossaryTerm) string {
+	var keep, translate []string
+	for _, term := range terms {
+		if term.Keep() {
+			keep = append(keep, fmt.Sprintf("%q", term.Source))
+		} else {
+			translate = append(translate, fmt.Sprintf("%q as %q", term.Source, term.Target))
+		}
+	}
+	var instructions string
+	if len(keep) > 0 {
+		instructions += fmt.Sprintf(" Never translate or transliterate %s: keep them exactly as written.", strings.Join(keep, ", "))
+	}
+	if len(translate) > 0 {
+		instructions += fmt.Sprintf(" Translate %s.", strings.Join(translate, ", "))
+	}
+	return instructions
+}
+
+// protectTerms replaces the terms kept as is in text with numbered placeholders, numbered after
+// the placeholders of directives, for providers that follow no instructions. It returns the
+// directives with the kept terms appended, restored by restoreMarkup.
+func protectTerms(text string, terms []GlossaryTerm, directives []string) (string, []string) {
+	for _, term := range terms {
+		if !term.Keep() {
+			continue
+		}
+		var out strings.Builder
+		for offset := 0; ; {
+			i := strings.Index(text[offset:], term.Source)
+			if i < 0 {
+				out.WriteString(text[offset:])
+				break
+			}
+			start, end := offset+i, offset+i+len(term.Source)
+			before, _ := utf8.DecodeLastRuneInString(text[:start])
+			after, _ := utf8.DecodeRuneInString(text[end:])
+			out.WriteString(text[offset:start])
+			if isWordRune(before) || isWordRune(after) {
+				out.WriteString(term.Source) // Part of a longer word
+			} else {
+				directives = append(directives, term.Source)
+				fmt.Fprintf(&out, "%s%d%s", placeholderOpen, len(directives), placeholderClose)
+			}
+			offset = end
+		}
+		text = out.String()
+	}
+	return text, directives
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gocreator/internal/interfaces"
+	"gocreator/internal/mocks"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testGlossary = `
+keep:
+  - GoCreator
+  - kubectl apply
+translate:
+  fr:
+    slide deck: présentation
+`
+
+func loadTestGlossary(t *testing.T) *Glossary {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/data/glossary.yaml", []byte(testGlossary), 0644))
+	glossary, err := LoadGlossary(fs, "/data/glossary.yaml")
+	require.NoError(t, err)
+	return glossary
+}
+
+func TestLoadGlossary(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	glossary, err := LoadGlossary(fs, "/data/glossary.yaml")
+	require.NoError(t, err)
+	assert.Nil(t, glossary)
+	assert.Nil(t, glossary.Terms("GoCreator", "fr"))
+
+	require.NoError(t, afero.WriteFile(fs, "/data/glossary.yaml", []byte("keep: [GoCreator]\nunknown: true\n"), 0644))
+	_, err = LoadGlossary(fs, "/data/glossary.yaml")
+	assert.ErrorContains(t, err, "failed to parse glossary /data/glossary.yaml")
+
+	require.NoError(t, afero.WriteFile(fs, "/data/glossary.yaml", []byte("translate:\n  fr:\n    slide deck: ''\n"), 0644))
+	_, err = LoadGlossary(fs, "/data/glossary.yaml")
+	assert.EqualError(t, err, `invalid glossary /data/glossary.yaml: translate: fr: term "slide deck" needs a translation`)
+}
+
+func TestGlossary_Terms(t *testing.T) {
+	glossary := loadTestGlossary(t)
+
+	assert.Equal(t, []GlossaryTerm{{Source: "GoCreator"}, {Source: "slide deck", Target: "présentation"}},
+		glossary.Terms("GoCreator turns a Slide Deck into videos.", "fr"))
+	// Kept terms match with their case, and only whole words
+	assert.Empty(t, glossary.Terms("gocreator and GoCreators", "fr"))
+	assert.Equal(t, []GlossaryTerm{{Source: "kubectl apply"}}, glossary.Terms("Run kubectl apply, then the slide deck.", "de"))
+}
+
+func TestGlossaryViolations(t *testing.T) {
+	terms := []GlossaryTerm{{Source: "GoCreator"}, {Source: "slide deck", Target: "présentation"}}
+
+	assert.Empty(t, glossaryViolations("GoCreator transforme une Présentation en vidéos.", terms))
+	assert.Equal(t, []string{`"GoCreator" is missing or changed`, `"slide deck" is not translated as "présentation"`},
+		glossaryViolations("Go Créateur transforme un diaporama en vidéos.", terms))
+}
+
+func TestProtectTerms(t *testing.T) {
+	protected, directives := protectMarkup("GoCreator [pause 1s] runs GoCreators and GoCreator.")
+	protected, directives = protectTerms(protected, []GlossaryTerm{{Source: "GoCreator"}, {Source: "slide deck", Target: "présentation"}}, directives)
+	assert.Equal(t, "⟦2⟧ ⟦1⟧ runs GoCreators and ⟦3⟧.", protected)
+
+	restored, err := restoreMarkup("⟦2⟧ ⟦1⟧ lance GoCreators et ⟦3⟧.", directives)
+	require.NoError(t, err)
+	assert.Equal(t, "GoCreator [pause 1s] lance GoCreators et GoCreator.", restored)
+}
+
+// userPrompt returns the content of the single user message of a chat completion request
+func userPrompt(messages []openai.ChatCompletionMessageParamUnion) string {
+	return messages[len(messages)-1].OfUser.Content.OfString.Value
+}
+
+func TestTranslationService_TranslateGlossary(t *testing.T) {
+	ctx := context.Background()
+	glossary := loadTestGlossary(t)
+	mockClient := new(mocks.MockOpenAIClient)
+	base := NewTranslationService(mockClient, &mockLogger{})
+	service := base.WithGlossary(glossary)
+
+	// The terms of a text are part of its key, a text without any keeps its key
+	assert.NotEqual(t, base.getCacheKey(context.Background(), "Open GoCreator", "fr"), service.getCacheKey(context.Background(), "Open GoCreator", "fr"))
+	assert.Equal(t, base.getCacheKey(context.Background(), "Hello", "fr"), service.getCacheKey(context.Background(), "Hello", "fr"))
+
+	// The prompt carries the terms, and a translation breaking them is retried
+	prompted := mock.MatchedBy(func(messages []openai.ChatCompletionMessageParamUnion) bool {
+		prompt := userPrompt(messages)
+		return strings.Contains(prompt, `Never translate or transliterate "GoCreator"`) &&
+			strings.Contains(prompt, `Translate "slide deck" as "présentation"`)
+	})
+	mockClient.On("ChatCompletion", mock.Anything, prompted, interfaces.ChatOptions{}).Return("Ouvrez Go Créateur et son diaporama", nil).Once()
+	mockClient.On("ChatCompletion", mock.Anything, prompted, interfaces.ChatOptions{}).Return("Ouvrez GoCreator et sa présentation", nil).Once()
+
+	translated, err := service.Translate(ctx, "Open GoCreator and its slide deck", "fr")
+	require.NoError(t, err)
+	assert.Equal(t, "Ouvrez GoCreator et sa présentation", translated)
+	mockClient.AssertExpectations(t)
+}
+
+func TestTranslationService_TranslateGlossaryOtherProvider(t *testing.T) {
+	glossary := loadTestGlossary(t)
+	providers, err := NewTranslationProviders(map[string]interfaces.TranslationProvider{
+		ProviderDeepL: &fakeTranslationProvider{name: "deepl"},
+	}, ProviderDeepL, nil)
+	require.NoError(t, err)
+	service := NewTranslationService(new(mocks.MockOpenAIClient), &mockLogger{}).WithProviders(providers).WithGlossary(glossary)
+
+	// Kept terms reach the provider as placeholders, and are restored
+	translated, err := service.Translate(context.Background(), "Open GoCreator", "de")
+	require.NoError(t, err)
+	assert.Equal(t, "deepl:Open GoCreator", translated)
+}
+
+func TestTranslationService_TranslateBatchDeckGlossary(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mocks.MockOpenAIClient)
+	service := NewTranslationService(mockClient, &mockLogger{}).WithGlossary(loadTestGlossary(t)).WithDeck(0)
+
+	withGlossary := mock.MatchedBy(func(messages []openai.ChatCompletionMessageParamUnion) bool {
+		return strings.Contains(userPrompt(messages), `"glossary":{"keep":["GoCreator"],"translate":{"slide deck":"présentation"}}`)
+	})
+	mockClient.On("ChatCompletion", mock.Anything, withGlossary, interfaces.ChatOptions{Schema: &deckSchema}).
+		Return(`{"translations":[{"index":0,"text":"Bonjour"},{"index":1,"text":"Ouvrez Go Créateur et sa présentation"}]}`, nil).Once()
+	// The slide breaking the glossary is translated on its own
+	mockClient.On("ChatCompletion", mock.Anything, mock.Anything, interfaces.ChatOptions{}).Return("Ouvrez GoCreator et sa présentation", nil).Once()
+
+	translated, err := service.TranslateBatch(ctx, []string{"Hello", "Open GoCreator and its slide deck"}, "fr")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bonjour", "Ouvrez GoCreator et sa présentation"}, translated)
+	mockClient.AssertExpectations(t)
+}
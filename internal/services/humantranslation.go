@@ -0,0 +1,103 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// TranslationSource is who translated the narration of a slide
+type TranslationSource string
+
+const (
+	TranslationHuman   TranslationSource = "human"
+	TranslationMachine TranslationSource = "machine"
+)
+
+// humanTranslation is the translation of a language supplied by a translator, in data/texts.<lang>.txt,
+// or data/script.<lang>.md for projects narrated in data/script.md. It is authoritative: only the
+// slides whose block is left empty, or missing at the end, are machine translated.
+type humanTranslation struct {
+	Path  string
+	texts []string // Narration of each slide, without comments and metadata; "" where left empty
+}
+
+// HumanTranslationFiles returns the names, in the data directory, of the files a translator may
+// supply the translation to lang in
+func HumanTranslationFiles(lang string) []string {
+	return []string{humanTranslationFile(lang, nil), humanTranslationFile(lang, &Script{markdown: true})}
+}
+
+// humanTranslationFile returns the name of the translator's translation to lang, in the format of script
+func humanTranslationFile(lang string, script *Script) string {
+	name := script.textsFile()
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + lang + ext
+}
+
+// loadHumanTranslation loads the translator's translation to lang of slideCount slides, nil when there is none
+func loadHumanTranslation(fs afero.Fs, dataDir, lang string, slideCount int, script *Script) (*humanTranslation, error) {
+	path := filepath.Join(dataDir, humanTranslationFile(lang, script))
+	data, err := afero.ReadFile(fs, path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read human translation: %w", err)
+	}
+
+	// Empty blocks are kept, unlike when loading narration, so every block stays on its slide
+	var blocks []textBlock
+	if script != nil && script.markdown {
+		blocks = parseMarkdownBlocks(string(data))
+	} else if blocks, err = parseTextBlocks(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to read human translation: %w", err)
+	}
+	if len(blocks) > slideCount {
+		return nil, fmt.Errorf("human translation %s has %d blocks for %d slides", path, len(blocks), slideCount)
+	}
+
+	human := &humanTranslation{Path: path, texts: make([]string, slideCount)}
+	for i, block := range blocks {
+		text := block.Text
+		if script == nil || !script.markdown {
+			text = textSpeech(text)
+		}
+		if !isSilent(script.speech(text)) {
+			human.texts[i] = text
+		}
+	}
+	return human, nil
+}
+
+// text returns the narration of slide idx as the translator wrote it, in the format of script,
+// and whether the translator translated it
+func (h *humanTranslation) text(idx int, source string, script *Script) (string, bool) {
+	if h == nil || h.texts[idx] == "" {
+		return "", false
+	}
+	return script.translated(source, h.texts[idx]), true
+}
+
+// recordTranslationSource marks who translated slide idx in the report of ctx
+func recordTranslationSource(ctx context.Context, idx int, source TranslationSource) {
+	recordInReport(withSlideIndex(ctx, idx), func(c *SlideReport) { c.Translation = source })
+}
+
+// humanTranslationHash fingerprints the content of the translator's translation to lang, "" when there is none
+func humanTranslationHash(fs afero.Fs, dataDir, lang string, script *Script) string {
+	data, err := afero.ReadFile(fs, filepath.Join(dataDir, humanTranslationFile(lang, script)))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadHumanTranslation(t *testing.T) {
+	t.Run("no translation", func(t *testing.T) {
+		human, err := loadHumanTranslation(afero.NewMemMapFs(), "/p/data", "fr", 2, nil)
+		require.NoError(t, err)
+		assert.Nil(t, human)
+		_, ok := human.text(0, "One", nil)
+		assert.False(t, ok)
+	})
+
+	t.Run("empty and missing blocks are machine translated", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/p/data/texts.fr.txt", []byte("Un\n-\n-\n# relu par Anne\nTrois\n-\n# à faire\n"), 0644))
+
+		human, err := loadHumanTranslation(fs, "/p/data", "fr", 5, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "/p/data/texts.fr.txt", human.Path)
+		assert.Equal(t, []string{"Un", "", "Trois", "", ""}, human.texts)
+
+		text, ok := human.text(2, "Three", nil)
+		assert.True(t, ok)
+		assert.Equal(t, "Trois", text)
+		_, ok = human.text(3, "Four", nil)
+		assert.False(t, ok)
+	})
+
+	t.Run("keeps the metadata of the source", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/p/data/texts.fr.txt", []byte("@voice: echo\nUn"), 0644))
+		script := &Script{Slides: []ScriptSlide{{Narration: "@voice: nova\nOne", Voice: "nova"}}}
+
+		human, err := loadHumanTranslation(fs, "/p/data", "fr", 1, script)
+		require.NoError(t, err)
+		text, ok := human.text(0, "@voice: nova\nOne", script)
+		assert.True(t, ok)
+		assert.Equal(t, "@voice: nova\nUn", text)
+	})
+
+	t.Run("markdown script", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/p/data/script.fr.md", []byte("## Introduction\n\n**Bonjour**\n\n## Suite\n"), 0644))
+		script := &Script{markdown: true}
+
+		human, err := loadHumanTranslation(fs, "/p/data", "fr", 2, script)
+		require.NoError(t, err)
+		assert.Equal(t, "/p/data/script.fr.md", human.Path)
+		assert.Equal(t, []string{"## Introduction\n\n**Bonjour**", ""}, human.texts)
+	})
+
+	t.Run("more blocks than slides", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/p/data/texts.fr.txt", []byte("Un\n-\nDeux"), 0644))
+
+		_, err := loadHumanTranslation(fs, "/p/data", "fr", 1, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "human translation /p/data/texts.fr.txt has 2 blocks for 1 slides")
+	})
+}
+
+func TestHumanTranslationFiles(t *testing.T) {
+	assert.Equal(t, []string{"texts.pt-BR.txt", "script.pt-BR.md"}, HumanTranslationFiles("pt-BR"))
+}
@@ -0,0 +1,372 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gocreator/internal/interfaces"
+
+	"github.com/spf13/afero"
+)
+
+// ReviewedTranslationsFile is the name, in the translation cache of a language, of the translations
+// approved in review and imported from XLIFF
+const ReviewedTranslationsFile = "reviewed.json"
+
+// reviewedTranslationsVersion is the version of the format of the reviewed translations file
+const reviewedTranslationsVersion = 1
+
+// TranslationReviewed marks a slide whose translation was approved in review
+const TranslationReviewed TranslationSource = "reviewed"
+
+// ReviewedTranslation is the approved translation of the narration of a slide. It is locked: it is
+// used whenever the slide's narration is still Source, and never machine translated again.
+type ReviewedTranslation struct {
+	Slide   int       `json:"slide"` // 1-based, the slide the narration was on when imported
+	Source  string    `json:"source"`
+	Target  string    `json:"target"`
+	Updated time.Time `json:"updated"`
+}
+
+// reviewedTranslations are the translations of a language approved in review, by source narration,
+// so they follow a slide that moves
+type reviewedTranslations struct {
+	path     string
+	bySource map[string]ReviewedTranslation
+}
+
+type reviewedTranslationsFile struct {
+	Version      int                   `json:"version"`
+	Translations []ReviewedTranslation `json:"translations"`
+}
+
+// reviewedTranslationsPath returns where the reviewed translations to lang are kept
+func reviewedTranslationsPath(dataDir, lang string) string {
+	return filepath.Join(dataDir, "cache", lang, "text", ReviewedTranslationsFile)
+}
+
+// loadReviewedTranslations loads the reviewed translations to lang, empty when there are none
+func loadReviewedTranslations(fs afero.Fs, dataDir, lang string) (*reviewedTranslations, error) {
+	reviewed := &reviewedTranslations{path: reviewedTranslationsPath(dataDir, lang), bySource: make(map[string]ReviewedTranslation)}
+	data, err := afero.ReadFile(fs, reviewed.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return reviewed, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reviewed translations: %w", err)
+	}
+
+	var file reviewedTranslationsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse reviewed translations %s: %w", reviewed.path, err)
+	}
+	if file.Version != reviewedTranslationsVersion {
+		return nil, fmt.Errorf("reviewed translations %s have version %d, expected %d", reviewed.path, file.Version, reviewedTranslationsVersion)
+	}
+	for _, translation := range file.Translations {
+		reviewed.bySource[translation.Source] = translation
+	}
+	return reviewed, nil
+}
+
+// text returns the reviewed translation of the narration source, in the format of script,
+// and whether it was reviewed
+func (r *reviewedTranslations) text(source string, script *Script) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	translation, ok := r.bySource[strings.TrimSpace(script.translatable(source))]
+	if !ok {
+		return "", false
+	}
+	return script.translated(source, translation.Target), true
+}
+
+// add locks the translation
+func (r *reviewedTranslations) add(translation ReviewedTranslation) {
+	r.bySource[translation.Source] = translation
+}
+
+// save writes the reviewed translations, in slide order
+func (r *reviewedTranslations) save(fs afero.Fs) error {
+	file := reviewedTranslationsFile{Version: reviewedTranslationsVersion, Translations: make([]ReviewedTranslation, 0, len(r.bySource))}
+	for _, translation := range r.bySource {
+		file.Translations = append(file.Translations, translation)
+	}
+	sort.Slice(file.Translations, func(i, j int) bool {
+		a, b := file.Translations[i], file.Translations[j]
+		if a.Slide != b.Slide {
+			return a.Slide < b.Slide
+		}
+		return a.Source < b.Source
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode reviewed translations: %w", err)
+	}
+	if err := fs.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := writeFileAtomic(fs, r.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write reviewed translations: %w", err)
+	}
+	return nil
+}
+
+// reviewedTranslationsHash fingerprints the reviewed translations to lang, "" when there are none
+func reviewedTranslationsHash(fs afero.Fs, dataDir, lang string) string {
+	data, err := afero.ReadFile(fs, reviewedTranslationsPath(dataDir, lang))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// approvedText returns the narration of slide idx as translated by a person, from the translator's
+// file first, then from the reviewed translations, and who translated it
+func approvedText(human *humanTranslation, reviewed *reviewedTranslations, idx int, source string, script *Script) (string, TranslationSource, bool) {
+	if text, ok := human.text(idx, source, script); ok {
+		return text, TranslationHuman, true
+	}
+	if text, ok := reviewed.text(source, script); ok {
+		return text, TranslationReviewed, true
+	}
+	return "", "", false
+}
+
+// Localizer exchanges the narration of a local project with reviewers as XLIFF 2.0 files
+type Localizer struct {
+	fs           afero.Fs
+	textService  interfaces.TextProcessor
+	slideService interfaces.SlideLoader
+}
+
+// NewLocalizer creates a new localizer
+func NewLocalizer(fs afero.Fs, textService interfaces.TextProcessor, slideService interfaces.SlideLoader) *Localizer {
+	return &Localizer{
+		fs:           fs,
+		textService:  textService,
+		slideService: slideService,
+	}
+}
+
+// ReviewImport is the outcome of importing a reviewed XLIFF file
+type ReviewImport struct {
+	Lang   string
+	Locked int   // Translations approved and locked
+	Stale  []int // 1-based slides whose narration changed since the export, left out
+}
+
+// xliffUnitID returns the id of the unit of slide idx
+func xliffUnitID(idx int) string {
+	return "slide-" + strconv.Itoa(idx+1)
+}
+
+// Export writes the narration of the project in dataDir, in sourceLang, as an XLIFF 2.0 file to
+// translate to lang, with a unit per narrated slide. Each unit holds the current translation of the
+// slide, if any, in the final state when a person translated it, and the path of the slide image
+// as a note. It returns the number of units written.
+func (l *Localizer) Export(ctx context.Context, dataDir, sourceLang, lang string, w io.Writer) (int, error) {
+	if normalizeLang(lang) == normalizeLang(sourceLang) {
+		return 0, fmt.Errorf("%s is the language of the narration, there is no translation to review", lang)
+	}
+	inputTexts, slides, script, err := loadLocalInputs(ctx, l.fs, l.textService, l.slideService, dataDir)
+	if err != nil {
+		return 0, err
+	}
+	current, err := l.currentTranslation(ctx, dataDir, lang, inputTexts, script)
+	if err != nil {
+		return 0, err
+	}
+
+	projectDir := filepath.Dir(dataDir)
+	narration := xliffFile{ID: "narration"}
+	notes := script.notes()
+	for i, source := range inputTexts {
+		text := strings.TrimSpace(script.translatable(source))
+		if isSilent(text) {
+			continue // Nothing to translate
+		}
+		target, state := current[i].text, xliffStateTranslated
+		switch {
+		case target == "":
+			state = xliffStateInitial
+		case current[i].source != TranslationMachine:
+			state = xliffStateFinal
+		}
+		segment, err := newXLIFFSegment(text, target, state)
+		if err != nil {
+			return 0, fmt.Errorf("slide %d: %w", i+1, err)
+		}
+		unit := xliffUnit{ID: xliffUnitID(i), Parts: []xliffPart{segment}}
+		if i < len(slides) {
+			slide := slides[i]
+			if rel, err := filepath.Rel(projectDir, slide); err == nil {
+				slide = rel
+			}
+			unit.Name = filepath.Base(slides[i])
+			unit.Notes = []xliffNote{{Category: "slide", Text: filepath.ToSlash(slide)}}
+		}
+		if i < len(notes) && notes[i] != "" {
+			unit.Notes = append(unit.Notes, xliffNote{Category: "translator", Text: notes[i]})
+		}
+		narration.Units = append(narration.Units, unit)
+	}
+
+	doc := &xliffDocument{SrcLang: sourceLang, TrgLang: lang, Files: []xliffFile{narration}}
+	if err := writeXLIFF(w, doc); err != nil {
+		return 0, err
+	}
+	return len(narration.Units), nil
+}
+
+// currentText is the current translation of a slide and who translated it
+type currentText struct {
+	text   string
+	source TranslationSource
+}
+
+// currentTranslation returns the current translation to lang of every slide, without its comments
+// and metadata: the translation by a person, then the saved machine translation
+func (l *Localizer) currentTranslation(ctx context.Context, dataDir, lang string, inputTexts []string, script *Script) ([]currentText, error) {
+	human, err := loadHumanTranslation(l.fs, dataDir, lang, len(inputTexts), script)
+	if err != nil {
+		return nil, err
+	}
+	reviewed, err := loadReviewedTranslations(l.fs, dataDir, lang)
+	if err != nil {
+		return nil, err
+	}
+	var saved []string
+	textsPath := languageTextsPath(dataDir, lang, script)
+	if exists, err := afero.Exists(l.fs, textsPath); err != nil {
+		return nil, fmt.Errorf("failed to check translation cache: %w", err)
+	} else if exists {
+		if saved, err = l.textService.Load(ctx, textsPath); err != nil {
+			return nil, fmt.Errorf("failed to load cached translation: %w", err)
+		}
+		if len(saved) != len(inputTexts) {
+			saved = nil // Out of date, its slides no longer line up
+		}
+	}
+
+	current := make([]currentText, len(inputTexts))
+	for i, source := range inputTexts {
+		if text, who, ok := approvedText(human, reviewed, i, source, script); ok {
+			current[i] = currentText{text: text, source: who}
+		} else if saved != nil {
+			current[i] = currentText{text: saved[i], source: TranslationMachine}
+		}
+		current[i].text = strings.TrimSpace(script.translatable(current[i].text))
+	}
+	return current, nil
+}
+
+// Import reads a reviewed XLIFF 2.0 file exported from the project in dataDir and locks the
+// translation of every unit reviewed or final, or translated with a target other than the current
+// translation of its slide, as long as the slide's narration is still the unit's source. The reviewed translations replace those of the saved
+// translation of the language, and are never machine translated again. languages are the languages
+// the project is translated to, one of which must be the target language of the file or the
+// language of its regional variant.
+func (l *Localizer) Import(ctx context.Context, dataDir, sourceLang string, languages []string, r io.Reader) (*ReviewImport, error) {
+	doc, err := readXLIFF(r)
+	if err != nil {
+		return nil, err
+	}
+	if !langMatches(doc.SrcLang, sourceLang) {
+		return nil, fmt.Errorf("source language is %q, expected %q", doc.SrcLang, sourceLang)
+	}
+	// The file of a regional variant, such as fr-FR, is for the language itself unless the project has the variant
+	result := &ReviewImport{}
+	for _, lang := range languages {
+		if normalizeLang(lang) == normalizeLang(doc.TrgLang) || (result.Lang == "" && langMatches(doc.TrgLang, lang)) {
+			result.Lang = lang
+		}
+	}
+	if result.Lang == "" {
+		return nil, fmt.Errorf("target language %q is not one of the output languages %v", doc.TrgLang, languages)
+	}
+
+	inputTexts, _, script, err := loadLocalInputs(ctx, l.fs, l.textService, l.slideService, dataDir)
+	if err != nil {
+		return nil, err
+	}
+	reviewed, err := loadReviewedTranslations(l.fs, dataDir, result.Lang)
+	if err != nil {
+		return nil, err
+	}
+	// A unit left as exported, in the translated state, was not reviewed
+	exported, err := l.currentTranslation(ctx, dataDir, result.Lang, inputTexts, script)
+	if err != nil {
+		return nil, err
+	}
+
+	approved := make(map[int]string)
+	for _, file := range doc.Files {
+		for _, unit := range file.Units {
+			number, err := strconv.Atoi(strings.TrimPrefix(unit.ID, "slide-"))
+			if !strings.HasPrefix(unit.ID, "slide-") || err != nil || number < 1 || number > len(inputTexts) {
+				return nil, fmt.Errorf("unit %q is not a slide of the project, which has %d slides", unit.ID, len(inputTexts))
+			}
+			source, target, state, err := unit.texts()
+			if err != nil {
+				return nil, err
+			}
+			target = strings.TrimSpace(target)
+			if target == "" || state == xliffStateInitial || (state == xliffStateTranslated && target == exported[number-1].text) {
+				continue // Not reviewed
+			}
+			current := strings.TrimSpace(script.translatable(inputTexts[number-1]))
+			if strings.TrimSpace(source) != current {
+				result.Stale = append(result.Stale, number)
+				continue
+			}
+			reviewed.add(ReviewedTranslation{Slide: number, Source: current, Target: target, Updated: time.Now().UTC()})
+			approved[number-1] = target
+		}
+	}
+	if len(approved) == 0 {
+		return result, nil
+	}
+	if err := reviewed.save(l.fs); err != nil {
+		return nil, err
+	}
+	result.Locked = len(approved)
+
+	// The saved translation is updated in place; without one, the next run translates the other slides
+	textsPath := languageTextsPath(dataDir, result.Lang, script)
+	exists, err := afero.Exists(l.fs, textsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check translation cache: %w", err)
+	}
+	if !exists {
+		return result, nil
+	}
+	saved, err := l.textService.Load(ctx, textsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cached translation: %w", err)
+	}
+	if len(saved) != len(inputTexts) {
+		return result, nil
+	}
+	for idx, target := range approved {
+		saved[idx] = script.translated(inputTexts[idx], target)
+	}
+	if err := l.textService.Save(ctx, textsPath, saved); err != nil {
+		return nil, fmt.Errorf("failed to save translation: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newL10nProject writes a local project of three slides, the second silent, with a saved French translation
+func newL10nProject(t *testing.T) (afero.Fs, *Localizer) {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/p/data/texts.txt", []byte("# intro\nHello & welcome.\n-\n# title slide\n-\nBye <now>."), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/p/data/cache/fr/text/texts.txt", []byte("# intro\nBonjour et bienvenue.\n-\n# title slide\n-\nAu revoir."), 0644))
+	for _, name := range []string{"1.png", "2.png", "3.png"} {
+		require.NoError(t, afero.WriteFile(fs, "/p/data/slides/"+name, []byte("png"), 0644))
+	}
+	logger := &mockLogger{}
+	return fs, NewLocalizer(fs, NewTextService(fs, logger), NewSlideService(fs, logger))
+}
+
+func TestLocalizer_Export(t *testing.T) {
+	fs, localizer := newL10nProject(t)
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	count, err := localizer.Export(ctx, "/p/data", "en", "fr", &out)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en" trgLang="fr">
+  <file id="narration">
+    <unit id="slide-1" name="1.png">
+      <notes>
+        <note category="slide">data/slides/1.png</note>
+      </notes>
+      <segment state="translated">
+        <source>Hello &amp; welcome.</source>
+        <target>Bonjour et bienvenue.</target>
+      </segment>
+    </unit>
+    <unit id="slide-3" name="3.png">
+      <notes>
+        <note category="slide">data/slides/3.png</note>
+      </notes>
+      <segment state="translated">
+        <source>Bye &lt;now&gt;.</source>
+        <target>Au revoir.</target>
+      </segment>
+    </unit>
+  </file>
+</xliff>
+`, out.String())
+
+	// Without a saved translation, the units are left to translate
+	out.Reset()
+	require.NoError(t, fs.Remove("/p/data/cache/fr/text/texts.txt"))
+	_, err = localizer.Export(ctx, "/p/data", "en", "de", &out)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `<segment state="initial">`)
+	assert.NotContains(t, out.String(), "<target>")
+
+	// The notes for translators of a slide are exported with it
+	out.Reset()
+	require.NoError(t, afero.WriteFile(fs, "/p/data/texts.txt", []byte("@notes: a greeting, keep it informal\nHello & welcome.\n-\n-\nBye <now>."), 0644))
+	_, err = localizer.Export(ctx, "/p/data", "en", "de", &out)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `<note category="slide">data/slides/1.png</note>
+        <note category="translator">a greeting, keep it informal</note>
+      </notes>`)
+	assert.Equal(t, 1, strings.Count(out.String(), `category="translator"`))
+
+	_, err = localizer.Export(ctx, "/p/data", "en", "en", &out)
+	assert.EqualError(t, err, "en is the language of the narration, there is no translation to review")
+}
+
+func TestLocalizer_Import(t *testing.T) {
+	fs, localizer := newL10nProject(t)
+	ctx := context.Background()
+
+	reviewed := `<?xml version="1.0" encoding="UTF-8"?>
+<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en-US" trgLang="fr-FR">
+  <file id="narration">
+    <unit id="slide-1">
+      <segment state="reviewed">
+        <source>Hello &amp; welcome.</source>
+        <target>Bonjour, <pc id="1">bienvenue</pc>&#160;!</target>
+      </segment>
+    </unit>
+    <unit id="slide-3">
+      <segment state="initial">
+        <source>Bye &lt;now&gt;.</source>
+        <target>Au revoir.</target>
+      </segment>
+    </unit>
+  </file>
+</xliff>`
+	result, err := localizer.Import(ctx, "/p/data", "en", []string{"de", "fr"}, strings.NewReader(reviewed))
+	require.NoError(t, err)
+	assert.Equal(t, &ReviewImport{Lang: "fr", Locked: 1}, result)
+
+	// The saved translation is updated, keeping the comments of the narration
+	saved, err := afero.ReadFile(fs, "/p/data/cache/fr/text/texts.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "# intro\nBonjour, bienvenue\u00a0!\n-\n# title slide\n-\nAu revoir.", string(saved))
+	locked, err := loadReviewedTranslations(fs, "/p/data", "fr")
+	require.NoError(t, err)
+	text, ok := locked.text("# intro\nHello & welcome.", &Script{})
+	assert.True(t, ok)
+	assert.Equal(t, "# intro\nBonjour, bienvenue\u00a0!", text)
+
+	// A translated unit is only locked when its translation was edited
+	translated := strings.Replace(reviewed, `<segment state="initial">`, `<segment state="translated">`, 1)
+	result, err = localizer.Import(ctx, "/p/data", "en", []string{"fr"}, strings.NewReader(translated))
+	require.NoError(t, err)
+	assert.Equal(t, &ReviewImport{Lang: "fr", Locked: 1}, result)
+	locked, err = loadReviewedTranslations(fs, "/p/data", "fr")
+	require.NoError(t, err)
+	_, ok = locked.text("Bye <now>.", &Script{})
+	assert.False(t, ok)
+	result, err = localizer.Import(ctx, "/p/data", "en", []string{"fr"}, strings.NewReader(strings.Replace(translated, "Au revoir.", "À bientôt.", 1)))
+	require.NoError(t, err)
+	assert.Equal(t, &ReviewImport{Lang: "fr", Locked: 2}, result)
+
+	// Reviewed translations are exported as final
+	var out bytes.Buffer
+	_, err = localizer.Export(ctx, "/p/data", "en", "fr", &out)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `<segment state="final">`)
+
+	// A slide whose narration changed since the export is left out
+	require.NoError(t, afero.WriteFile(fs, "/p/data/texts.txt", []byte("Hello, welcome.\n-\n# title slide\n-\nBye <now>."), 0644))
+	result, err = localizer.Import(ctx, "/p/data", "en", []string{"fr"}, strings.NewReader(reviewed))
+	require.NoError(t, err)
+	assert.Equal(t, &ReviewImport{Lang: "fr", Stale: []int{1}}, result)
+
+	_, err = localizer.Import(ctx, "/p/data", "en", []string{"de"}, strings.NewReader(reviewed))
+	assert.EqualError(t, err, `target language "fr-FR" is not one of the output languages [de]`)
+	_, err = localizer.Import(ctx, "/p/data", "en-GB", []string{"fr"}, strings.NewReader(reviewed))
+	assert.EqualError(t, err, `source language is "en-US", expected "en-GB"`)
+	_, err = localizer.Import(ctx, "/p/data", "en", []string{"fr"}, strings.NewReader(strings.Replace(reviewed, "slide-3", "slide-4", 1)))
+	assert.EqualError(t, err, `unit "slide-4" is not a slide of the project, which has 3 slides`)
+	_, err = localizer.Import(ctx, "/p/data", "en", []string{"fr"}, strings.NewReader(`<xliff xmlns="urn:oasis:names:tc:xliff:document:1.2" version="1.2"/>`))
+	assert.ErrorContains(t, err, "failed to parse XLIFF 2.0")
+}
+
+func TestXLIFFUnit_Texts(t *testing.T) {
+	doc, err := readXLIFF(strings.NewReader(`<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en"><file id="f"><unit id="u">
+<segment state="translated"><source>One.</source><target>Un.</target></segment>
+<ignorable><source> </source></ignorable>
+<segment state="initial"><source>Two<cp hex="0009"/>.</source><target>Deux<cp hex="0009"/>.</target></segment>
+</unit></file></xliff>`))
+	require.NoError(t, err)
+	source, target, state, err := doc.Files[0].Units[0].texts()
+	require.NoError(t, err)
+	assert.Equal(t, "One. Two\t.", source)
+	assert.Equal(t, "Un. Deux\t.", target)
+	assert.Equal(t, xliffStateInitial, state)
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/afero"
+)
+
+// LexiconFile is the name of the pronunciation lexicon of a project, in its data directory
+const LexiconFile = "lexicon.yaml"
+
+// Lexicon is how terms are pronounced in each language, applied to narration before speech
+type Lexicon struct {
+	languages map[string]*languageLexicon
+}
+
+// languageLexicon holds the terms of one language, matched by a single pattern whose
+// first group is the term, between the non-word characters, if any, around it
+type languageLexicon struct {
+	entries []LexiconEntry
+	pattern *regexp.Regexp
+}
+
+// LexiconEntry is the pronunciation of one term
+type LexiconEntry struct {
+	Term string
+	Say  string // Spoken form sent to the speech API
+	IPA  string // Phonetic transcription, for reference: the speech API has no phoneme input
+}
+
+// UnmarshalYAML reads an entry either as its spoken form or as a mapping with say and ipa
+func (e *LexiconEntry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&e.Say); err == nil {
+		return nil
+	}
+	var entry struct {
+		Say string `yaml:"say"`
+		IPA string `yaml:"ipa"`
+	}
+	if err := unmarshal(&entry); err != nil {
+		return err
+	}
+	e.Say, e.IPA = entry.Say, entry.IPA
+	return nil
+}
+
+// LoadLexicon loads the lexicon at path, mapping each language to its terms:
+//
+//	fr:
+//	  gocreator: go créateur
+//	  Kubernetes: {say: kou-ber-nè-tisse, ipa: kubɛʁnɛtis}
+//
+// A missing file is an empty lexicon, returned as nil.
+func LoadLexicon(fs afero.Fs, path string) (*Lexicon, error) {
+	data, err := afero.ReadFile(fs, path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lexicon: %w", err)
+	}
+
+	var languages map[string]map[string]LexiconEntry
+	if err := yaml.UnmarshalWithOptions(data, &languages, yaml.Strict()); err != nil {
+		return nil, fmt.Errorf("failed to parse lexicon %s: %w", path, err)
+	}
+
+	lexicon := &Lexicon{languages: make(map[string]*languageLexicon, len(languages))}
+	for lang, terms := range languages {
+		entries := make([]LexiconEntry, 0, len(terms))
+		for term, entry := range terms {
+			if strings.TrimSpace(term) == "" || strings.TrimSpace(entry.Say) == "" {
+				return nil, fmt.Errorf("invalid lexicon %s: %s: term %q needs a spoken form", path, lang, term)
+			}
+			entry.Term = term
+			entries = append(entries, entry)
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		// Longer terms first, so "Kubernetes operator" wins over "Kubernetes", which is still
+		// tried when the longer term is part of a longer word, the boundaries being in the pattern
+		sort.Slice(entries, func(i, j int) bool {
+			if len(entries[i].Term) != len(entries[j].Term) {
+				return len(entries[i].Term) > len(entries[j].Term)
+			}
+			return entries[i].Term < entries[j].Term
+		})
+		alternatives := make([]string, len(entries))
+		for i, entry := range entries {
+			alternatives[i] = regexp.QuoteMeta(entry.Term)
+		}
+		lexicon.languages[lang] = &languageLexicon{
+			entries: entries,
+			pattern: regexp.MustCompile(`(?i)(?:^|[^\pL\pN_])(` + strings.Join(alternatives, "|") + `)(?:[^\pL\pN_]|$)`),
+		}
+	}
+	return lexicon, nil
+}
+
+// Pronounce replaces every whole-word occurrence of a term of lang in text, ignoring case,
+// with its spoken form. It returns the entries used, in lexicon order.
+func (l *Lexicon) Pronounce(lang, text string) (string, []LexiconEntry) {
+	if l == nil || l.languages[lang] == nil {
+		return text, nil
+	}
+	terms := l.languages[lang]
+
+	usedTerms := make(map[int]bool)
+	var out strings.Builder
+	end := 0
+	// Matching resumes right after each term, so the character ending it can start the next one
+	for pos := 0; pos < len(text); {
+		m := terms.pattern.FindStringSubmatchIndex(text[pos:])
+		if m == nil {
+			break
+		}
+		start, stop := pos+m[2], pos+m[3]
+		if before, _ := utf8.DecodeLastRuneInString(text[:start]); start == pos && isWordRune(before) {
+			_, size := utf8.DecodeRuneInString(text[pos:])
+			pos += size // Matched the start of text[pos:], in the middle of a word
+			continue
+		}
+		idx := terms.lookup(text[start:stop])
+		usedTerms[idx] = true
+		out.WriteString(text[end:start])
+		out.WriteString(terms.entries[idx].Say)
+		end, pos = stop, stop
+	}
+	if len(usedTerms) == 0 {
+		return text, nil
+	}
+	out.WriteString(text[end:])
+
+	used := make([]LexiconEntry, 0, len(usedTerms))
+	for i, entry := range terms.entries {
+		if usedTerms[i] {
+			used = append(used, entry)
+		}
+	}
+	return out.String(), used
+}
+
+// fingerprint identifies the pronunciations of lang, "" when it has none
+func (l *Lexicon) fingerprint(lang string) string {
+	if l == nil || l.languages[lang] == nil {
+		return ""
+	}
+	return lexiconFingerprint(l.languages[lang].entries)
+}
+
+// lookup returns the index of the entry of a matched term
+func (l *languageLexicon) lookup(match string) int {
+	for i, entry := range l.entries {
+		if strings.EqualFold(entry.Term, match) {
+			return i
+		}
+	}
+	return -1 // Unreachable: every match is one of the terms
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// lexiconFingerprint identifies the pronunciations used for a narration, "" when none
+func lexiconFingerprint(used []LexiconEntry) string {
+	if len(used) == 0 {
+		return ""
+	}
+	seen := make(map[string]bool, len(used))
+	var pairs []string
+	for _, entry := range used {
+		pair := entry.Term + "=" + entry.Say
+		if !seen[pair] {
+			seen[pair] = true
+			pairs = append(pairs, pair)
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
+
+type languageKey struct{}
+
+// withLanguage returns a context whose work is for the output language lang
+func withLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageKey{}, lang)
+}
+
+// languageOf returns the output language of the work of ctx, "" when unknown
+func languageOf(ctx context.Context) string {
+	lang, _ := ctx.Value(languageKey{}).(string)
+	return lang
+}
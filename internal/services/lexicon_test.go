@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"gocreator/internal/interfaces"
+	"gocreator/internal/mocks"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testLexicon = `
+en:
+  gocreator: go creator
+fr:
+  gocreator: go créateur
+  Kubernetes:
+    say: kou-ber-nè-tisse
+    ipa: kubɛʁnɛtis
+  Kubernetes operator: opérateur kou-ber-nè-tisse
+`
+
+func loadTestLexicon(t *testing.T) *Lexicon {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/data/lexicon.yaml", []byte(testLexicon), 0644))
+	lexicon, err := LoadLexicon(fs, "/data/lexicon.yaml")
+	require.NoError(t, err)
+	return lexicon
+}
+
+func TestLoadLexicon(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	lexicon, err := LoadLexicon(fs, "/data/lexicon.yaml")
+	require.NoError(t, err)
+	assert.Nil(t, lexicon, "no file, no lexicon")
+
+	require.NoError(t, afero.WriteFile(fs, "/data/lexicon.yaml", []byte("fr:\n  gocreator: {ipa: ɡo}\n"), 0644))
+	_, err = LoadLexicon(fs, "/data/lexicon.yaml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `term "gocreator" needs a spoken form`)
+
+	require.NoError(t, afero.WriteFile(fs, "/data/lexicon.yaml", []byte("fr:\n  gocreator: {say: go, stress: 1}\n"), 0644))
+	_, err = LoadLexicon(fs, "/data/lexicon.yaml")
+	require.Error(t, err, "unknown settings are rejected")
+
+	lexicon = loadTestLexicon(t)
+	_, used := lexicon.Pronounce("fr", "Kubernetes")
+	require.Len(t, used, 1)
+	assert.Equal(t, "kubɛʁnɛtis", used[0].IPA)
+}
+
+func TestLexicon_Pronounce(t *testing.T) {
+	lexicon := loadTestLexicon(t)
+
+	tests := []struct {
+		name     string
+		lang     string
+		text     string
+		expected string
+		used     []string
+	}{
+		{"whole words, any case", "fr", "GoCreator et gocreator.", "go créateur et go créateur.", []string{"gocreator"}},
+		{"longest term first", "fr", "Un Kubernetes operator sur Kubernetes", "Un opérateur kou-ber-nè-tisse sur kou-ber-nè-tisse", []string{"Kubernetes operator", "Kubernetes"}},
+		{"not inside longer words", "fr", "gocreators", "gocreators", nil},
+		{"shorter term when the longer is inside a word", "fr", "Kubernetes operators run", "kou-ber-nè-tisse operators run", []string{"Kubernetes"}},
+		{"adjacent terms", "fr", "gocreator gocreator,Kubernetes", "go créateur go créateur,kou-ber-nè-tisse", []string{"Kubernetes", "gocreator"}},
+		{"per language", "en", "Kubernetes and gocreator", "Kubernetes and go creator", []string{"gocreator"}},
+		{"language without lexicon", "de", "gocreator", "gocreator", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spoken, used := lexicon.Pronounce(tt.lang, tt.text)
+			assert.Equal(t, tt.expected, spoken)
+			var terms []string
+			for _, entry := range used {
+				terms = append(terms, entry.Term)
+			}
+			assert.Equal(t, tt.used, terms)
+		})
+	}
+
+	var none *Lexicon
+	spoken, used := none.Pronounce("fr", "gocreator")
+	assert.Equal(t, "gocreator", spoken)
+	assert.Nil(t, used)
+}
+
+func TestAudioService_Generate_WithLexicon(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockClient := new(mocks.MockOpenAIClient)
+	logger := &mockLogger{}
+	service := NewAudioService(fs, mockClient, NewTextService(fs, logger), logger)
+	service.SetLexicon(loadTestLexicon(t))
+	ctx := withLanguage(context.Background(), "fr")
+
+	mockClient.On("GenerateSpeech", mock.Anything, "Bienvenue dans go créateur", interfaces.Voice{}).Return(newMockReadCloser("fr audio"), nil).Once()
+	mockClient.On("GenerateSpeech", mock.Anything, "Sans terme", interfaces.Voice{}).Return(newMockReadCloser("plain audio"), nil).Once()
+
+	paths, err := service.GenerateBatch(ctx, []string{"Bienvenue dans gocreator", "Sans terme"}, nil, "/audio")
+	require.NoError(t, err)
+	require.Len(t, paths, 2)
+
+	// Slides using a pronunciation are keyed by it, the others keep the key of their text
+	hash, err := afero.ReadFile(fs, "/audio/0.mp3.hash")
+	require.NoError(t, err)
+	assert.Equal(t, speechHash("Bienvenue dans gocreator", interfaces.Voice{}, "gocreator=go créateur"), string(hash))
+	hash, err = afero.ReadFile(fs, "/audio/1.mp3.hash")
+	require.NoError(t, err)
+	assert.Equal(t, service.textService.Hash("Sans terme"), string(hash))
+
+	// Changing a pronunciation only regenerates the slides using it
+	require.NoError(t, afero.WriteFile(fs, "/data/lexicon.yaml", []byte("fr:\n  gocreator: gauche créateur\n"), 0644))
+	changed, err := LoadLexicon(fs, "/data/lexicon.yaml")
+	require.NoError(t, err)
+	service.SetLexicon(changed)
+	cached, err := service.CachedBatch(ctx, []string{"Bienvenue dans gocreator", "Sans terme"}, nil, "/audio")
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true}, cached)
+
+	mockClient.AssertExpectations(t)
+}
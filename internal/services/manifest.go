@@ -0,0 +1,215 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gocreator/internal/interfaces"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	// ManifestFileName is the name of the build manifest inside the cache directory
+	ManifestFileName = "manifest.json"
+
+	// manifestVersion is bumped whenever the manifest format changes incompatibly
+	manifestVersion = 1
+)
+
+// ArtifactKind identifies what produced an artifact
+type ArtifactKind string
+
+const (
+	ArtifactTranslation ArtifactKind = "translation"
+	ArtifactAudio       ArtifactKind = "audio"
+	ArtifactSegment     ArtifactKind = "segment"
+	ArtifactVideo       ArtifactKind = "video"
+)
+
+// ArtifactStatus is the state of an artifact after the last attempt to build it
+type ArtifactStatus string
+
+const (
+	ArtifactDone   ArtifactStatus = "done"
+	ArtifactFailed ArtifactStatus = "failed"
+)
+
+// Artifact is one file produced by a run
+type Artifact struct {
+	Kind       ArtifactKind   `json:"kind"`
+	Lang       string         `json:"lang,omitempty"`
+	InputsHash string         `json:"inputs_hash"`
+	Output     string         `json:"output"`
+	Status     ArtifactStatus `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// Manifest records every artifact of a run, keyed by output path.
+// It is written to disk after every change so it survives a crash,
+// and it is plain JSON so other tools can read it.
+type Manifest struct {
+	mu   sync.Mutex
+	fs   afero.Fs
+	path string
+
+	Version   int                  `json:"version"`
+	RunID     string               `json:"run_id"`
+	StartedAt time.Time            `json:"started_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+	Resumed   bool                 `json:"resumed,omitempty"`
+	Artifacts map[string]*Artifact `json:"artifacts"`
+}
+
+// NewManifest creates an empty manifest that will be saved to path
+func NewManifest(fs afero.Fs, path string) *Manifest {
+	now := time.Now().UTC()
+	return &Manifest{
+		fs:        fs,
+		path:      path,
+		Version:   manifestVersion,
+		RunID:     newRunID(),
+		StartedAt: now,
+		UpdatedAt: now,
+		Artifacts: make(map[string]*Artifact),
+	}
+}
+
+// LoadManifest reads the manifest saved at path
+func LoadManifest(fs afero.Fs, path string) (*Manifest, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d in %s", m.Version, path)
+	}
+	if m.Artifacts == nil {
+		m.Artifacts = make(map[string]*Artifact)
+	}
+	m.fs = fs
+	m.path = path
+	return m, nil
+}
+
+// OpenManifest starts the manifest of a new run. When resume is set, the
+// artifacts recorded by the previous run at path are kept; a missing manifest
+// simply starts a fresh one.
+func OpenManifest(fs afero.Fs, path string, resume bool) (*Manifest, error) {
+	if !resume {
+		return NewManifest(fs, path), nil
+	}
+
+	exists, err := afero.Exists(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check manifest: %w", err)
+	}
+	if !exists {
+		return NewManifest(fs, path), nil
+	}
+
+	previous, err := LoadManifest(fs, path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewManifest(fs, path)
+	m.Resumed = true
+	m.Artifacts = previous.Artifacts
+	return m, nil
+}
+
+// Path returns where the manifest is saved
+func (m *Manifest) Path() string {
+	return m.path
+}
+
+// Record stores the outcome of an artifact and saves the manifest.
+// It is a no-op on a nil manifest, so services can record unconditionally.
+func (m *Manifest) Record(a Artifact) error {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a.UpdatedAt = time.Now().UTC()
+	m.Artifacts[a.Output] = &a
+	m.UpdatedAt = a.UpdatedAt
+	return m.save()
+}
+
+// IsDone reports whether the artifact at output was built from inputsHash by a previous attempt
+func (m *Manifest) IsDone(output, inputsHash string) bool {
+	if m == nil {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.Artifacts[output]
+	return ok && a.Status == ArtifactDone && a.InputsHash == inputsHash
+}
+
+// Lookup returns the artifact recorded for output
+func (m *Manifest) Lookup(output string) (Artifact, bool) {
+	if m == nil {
+		return Artifact{}, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.Artifacts[output]
+	if !ok {
+		return Artifact{}, false
+	}
+	return *a, true
+}
+
+// Save writes the manifest to disk
+func (m *Manifest) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.save()
+}
+
+func (m *Manifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := m.fs.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("failed to create manifest directory: %w", err)
+	}
+	if err := writeFileAtomic(m.fs, m.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// recordArtifact records a to m, logging instead of failing when the manifest can't be saved
+func recordArtifact(logger interfaces.Logger, m *Manifest, a Artifact) {
+	if err := m.Record(a); err != nil {
+		logger.Warn("Failed to update manifest", "output", a.Output, "error", err)
+	}
+}
+
+// artifactStatus maps the error of a build step to the status recorded for its artifact
+func artifactStatus(err error) (ArtifactStatus, string) {
+	if err != nil {
+		return ArtifactFailed, err.Error()
+	}
+	return ArtifactDone, ""
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest_RecordAndLoad(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := "/cache/" + ManifestFileName
+
+	m := NewManifest(fs, path)
+	require.NoError(t, m.Record(Artifact{Kind: ArtifactAudio, InputsHash: "abc", Output: "/cache/en/audio/0.mp3", Status: ArtifactDone}))
+	status, message := artifactStatus(errors.New("boom"))
+	require.NoError(t, m.Record(Artifact{Kind: ArtifactVideo, Lang: "fr", InputsHash: "def", Output: "/out/output-fr.mp4", Status: status, Error: message}))
+
+	assert.True(t, m.IsDone("/cache/en/audio/0.mp3", "abc"))
+	assert.False(t, m.IsDone("/cache/en/audio/0.mp3", "changed"))
+	assert.False(t, m.IsDone("/out/output-fr.mp4", "def"), "failed artifacts are not done")
+	assert.False(t, m.IsDone("/missing", "abc"))
+
+	// Every record is saved, as plain JSON other tools can read
+	data, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.EqualValues(t, 1, raw["version"])
+	artifacts := raw["artifacts"].(map[string]any)
+	fr := artifacts["/out/output-fr.mp4"].(map[string]any)
+	assert.Equal(t, "failed", fr["status"])
+	assert.Equal(t, "boom", fr["error"])
+
+	loaded, err := LoadManifest(fs, path)
+	require.NoError(t, err)
+	assert.Equal(t, m.RunID, loaded.RunID)
+	a, ok := loaded.Lookup("/cache/en/audio/0.mp3")
+	require.True(t, ok)
+	assert.Equal(t, ArtifactAudio, a.Kind)
+}
+
+func TestOpenManifest(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := "/cache/" + ManifestFileName
+
+	previous := NewManifest(fs, path)
+	require.NoError(t, previous.Record(Artifact{Kind: ArtifactVideo, InputsHash: "abc", Output: "/out/output-en.mp4", Status: ArtifactDone}))
+
+	t.Run("resume keeps previous artifacts", func(t *testing.T) {
+		m, err := OpenManifest(fs, path, true)
+		require.NoError(t, err)
+		assert.True(t, m.Resumed)
+		assert.NotEqual(t, previous.RunID, m.RunID)
+		assert.True(t, m.IsDone("/out/output-en.mp4", "abc"))
+	})
+
+	t.Run("fresh run starts empty", func(t *testing.T) {
+		m, err := OpenManifest(fs, path, false)
+		require.NoError(t, err)
+		assert.False(t, m.Resumed)
+		assert.False(t, m.IsDone("/out/output-en.mp4", "abc"))
+	})
+
+	t.Run("resume without manifest starts empty", func(t *testing.T) {
+		m, err := OpenManifest(fs, "/other/"+ManifestFileName, true)
+		require.NoError(t, err)
+		assert.False(t, m.Resumed)
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		require.NoError(t, afero.WriteFile(fs, "/bad/"+ManifestFileName, []byte(`{"version": 99}`), 0644))
+		_, err := OpenManifest(fs, "/bad/"+ManifestFileName, true)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported manifest version")
+	})
+}
+
+func TestManifest_Nil(t *testing.T) {
+	var m *Manifest
+	assert.NoError(t, m.Record(Artifact{Output: "/x"}))
+	assert.False(t, m.IsDone("/x", ""))
+	_, ok := m.Lookup("/x")
+	assert.False(t, ok)
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MarkdownScriptFile is the name of the Markdown narration, read instead of texts.txt when present
+const MarkdownScriptFile = "script.md"
+
+var (
+	// mdRule matches a horizontal rule, which ends a slide
+	mdRule = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	// mdSlideHeading matches a level 2 heading, which starts a slide and holds its title
+	mdSlideHeading = regexp.MustCompile(`^ {0,3}##[ \t]+(.*?)[ \t#]*$`)
+	// mdTitleHeading matches a level 1 heading, the title of the document
+	mdTitleHeading = regexp.MustCompile(`^ {0,3}#[ \t]+`)
+	mdFence        = regexp.MustCompile("^ {0,3}(```|~~~)")
+
+	// Inline formatting, replaced by the text it formats or its speech markup
+	mdImage      = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	mdLink       = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdStrong     = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	mdUnderscore = regexp.MustCompile(`(^|\W)_(\S(?:[^_]*?\S)?)_(\W|$)`)
+	mdStrike     = regexp.MustCompile(`~~(.+?)~~`)
+	mdCode       = regexp.MustCompile("`([^`]*)`")
+	mdHTML       = regexp.MustCompile(`<[^>]+>`)
+
+	// Line prefixes, removed
+	mdHeading    = regexp.MustCompile(`^ {0,3}#{1,6}[ \t]+`)
+	mdQuote      = regexp.MustCompile(`^ {0,3}>[ \t]?`)
+	mdListMarker = regexp.MustCompile(`^[ \t]*(?:[-*+]|\d+[.)])[ \t]+`)
+)
+
+// isMarkdown reports whether path is a Markdown narration file
+func isMarkdown(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".md" || ext == ".markdown"
+}
+
+// parseMarkdownSlides splits a Markdown narration into one block per slide. A "## " heading starts
+// a slide and stays at the top of its block, a horizontal rule ends one. A "# " document title
+// before the first slide is never narrated. Blocks keep their formatting, for transcripts.
+func parseMarkdownSlides(content string) []string {
+	blocks := parseMarkdownBlocks(content)
+	texts := make([]string, len(blocks))
+	for i, block := range blocks {
+		texts[i] = block.Text
+	}
+	return texts
+}
+
+// parseMarkdownBlocks splits a Markdown narration like parseMarkdownSlides, with the line each block starts on
+func parseMarkdownBlocks(content string) []textBlock {
+	blocks := make([]textBlock, 0)
+	var current []string
+	start := 0
+	flush := func() {
+		if block := strings.TrimSpace(strings.Join(current, "\n")); block != "" {
+			blocks = append(blocks, textBlock{Text: block, Line: start})
+		}
+		current = nil
+	}
+	add := func(line string, lineNo int) {
+		if strings.TrimSpace(strings.Join(current, "")) == "" {
+			start = lineNo // Blank lines before a block are trimmed
+		}
+		current = append(current, line)
+	}
+
+	inFence := false
+	for i, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if mdFence.MatchString(line) {
+			inFence = !inFence
+		}
+		switch {
+		case inFence:
+			add(line, i+1)
+		case mdSlideHeading.MatchString(line):
+			flush()
+			add(line, i+1)
+		case mdRule.MatchString(line):
+			flush()
+		case len(blocks) == 0 && strings.TrimSpace(strings.Join(current, "")) == "" && mdTitleHeading.MatchString(line):
+			// Document title
+		default:
+			add(line, i+1)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// formatMarkdownSlides joins slide blocks back into a Markdown narration that parses to the same blocks.
+// Blocks starting with a heading follow the previous one directly, the others after a horizontal rule.
+func formatMarkdownSlides(blocks []string) string {
+	var out strings.Builder
+	for i, block := range blocks {
+		if i > 0 {
+			if mdSlideHeading.MatchString(firstLine(block)) {
+				out.WriteString("\n\n")
+			} else {
+				out.WriteString("\n\n---\n\n")
+			}
+		}
+		out.WriteString(block)
+	}
+	if len(blocks) > 0 {
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+// markdownTitle returns the title of a slide block, the text of its "## " heading if it starts with one
+func markdownTitle(block string) string {
+	match := mdSlideHeading.FindStringSubmatch(firstLine(block))
+	if match == nil {
+		return ""
+	}
+	return displayText(markdownSpeech(match[1]))
+}
+
+// markdownSpeech returns the narration of a slide block without Markdown formatting, as spoken.
+// The slide heading is a title and is not narrated. Bold and italic become speech emphasis.
+func markdownSpeech(block string) string {
+	lines := strings.Split(block, "\n")
+	if mdSlideHeading.MatchString(lines[0]) {
+		lines = lines[1:]
+	}
+
+	var spoken []string
+	blank := false
+	for _, line := range lines {
+		if mdFence.MatchString(line) {
+			continue
+		}
+		line = mdHeading.ReplaceAllString(line, "")
+		line = mdQuote.ReplaceAllString(line, "")
+		line = mdListMarker.ReplaceAllString(line, "")
+		line = mdImage.ReplaceAllString(line, "")
+		line = mdLink.ReplaceAllString(line, "$1")
+		line = mdStrong.ReplaceAllString(line, "*$2*")
+		line = mdUnderscore.ReplaceAllString(line, "$1$2$3")
+		line = mdStrike.ReplaceAllString(line, "$1")
+		line = mdCode.ReplaceAllString(line, "$1")
+		line = mdHTML.ReplaceAllString(line, "")
+		line = strings.TrimSpace(line)
+
+		// Paragraphs stay apart, runs of blank lines collapse
+		if line == "" {
+			blank = len(spoken) > 0
+			continue
+		}
+		if blank {
+			spoken = append(spoken, "")
+			blank = false
+		}
+		spoken = append(spoken, line)
+	}
+	return strings.Join(spoken, "\n")
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return line
+}
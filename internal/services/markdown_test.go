@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMarkdownScript = `# Course title
+
+## Welcome
+
+Welcome to **GoCreator**, the _fastest_ way to narrate slides.
+
+## Getting [started](https://example.com)
+
+- Install it
+- Run ` + "`gocreator create`" + `
+
+---
+
+A slide without a heading.
+
+` + "```" + `
+## not a heading
+---
+` + "```" + `
+`
+
+func TestParseMarkdownSlides(t *testing.T) {
+	blocks := parseMarkdownSlides(testMarkdownScript)
+	require.Len(t, blocks, 3)
+	assert.Equal(t, "## Welcome\n\nWelcome to **GoCreator**, the _fastest_ way to narrate slides.", blocks[0])
+	assert.Equal(t, "## Getting [started](https://example.com)\n\n- Install it\n- Run `gocreator create`", blocks[1])
+	assert.Equal(t, "A slide without a heading.\n\n```\n## not a heading\n---\n```", blocks[2])
+
+	assert.Empty(t, parseMarkdownSlides(""))
+	assert.Equal(t, []string{"One", "Two"}, parseMarkdownSlides("One\n\n***\n\nTwo\n"), "any rule ends a slide")
+}
+
+func TestFormatMarkdownSlides_RoundTrip(t *testing.T) {
+	blocks := parseMarkdownSlides(testMarkdownScript)
+	assert.Equal(t, blocks, parseMarkdownSlides(formatMarkdownSlides(blocks)))
+
+	assert.Equal(t, "## One\nText\n\n---\n\nTwo\n\n## Three\n", formatMarkdownSlides([]string{"## One\nText", "Two", "## Three"}))
+}
+
+func TestMarkdownSpeech(t *testing.T) {
+	tests := []struct {
+		name     string
+		block    string
+		expected string
+	}{
+		{"heading is not narrated", "## Welcome\n\nHello", "Hello"},
+		{"emphasis is spoken", "A **bold**, *italic* and _underlined_ ~~word~~", "A *bold*, *italic* and underlined word"},
+		{"identifiers keep underscores", "Set max_retries to 3", "Set max_retries to 3"},
+		{"links and images", "See [the docs](https://example.com) ![diagram](d.png)", "See the docs"},
+		{"lists and quotes", "- one\n2. two\n> quoted", "one\ntwo\nquoted"},
+		{"code", "Run `make` then\n```\nmake test\n```", "Run make then\nmake test"},
+		{"paragraphs", "First\n\n\n\nSecond", "First\n\nSecond"},
+		{"html", "Line<br/>break", "Linebreak"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, markdownSpeech(tt.block))
+		})
+	}
+}
+
+func TestMarkdownTitle(t *testing.T) {
+	assert.Equal(t, "Getting started", markdownTitle("## Getting [started](https://example.com) ##\n\nText"))
+	assert.Equal(t, "Why SQL matters", markdownTitle("## Why SQL{say: \"sequel\"} **matters**"))
+	assert.Equal(t, "", markdownTitle("No heading\n## Later"))
+}
+
+func TestTextService_LoadAndSaveMarkdown(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	service := NewTextService(fs, &mockLogger{})
+	ctx := context.Background()
+
+	require.NoError(t, afero.WriteFile(fs, "/data/script.md", []byte(testMarkdownScript), 0644))
+	texts, err := service.Load(ctx, "/data/script.md")
+	require.NoError(t, err)
+	assert.Equal(t, parseMarkdownSlides(testMarkdownScript), texts)
+
+	// Saved translations can be edited as Markdown and load back unchanged
+	require.NoError(t, service.Save(ctx, "/data/cache/fr/text/script.md", texts))
+	saved, err := afero.ReadFile(fs, "/data/cache/fr/text/script.md")
+	require.NoError(t, err)
+	assert.Contains(t, string(saved), "## Welcome\n")
+	loaded, err := service.Load(ctx, "/data/cache/fr/text/script.md")
+	require.NoError(t, err)
+	assert.Equal(t, texts, loaded)
+}
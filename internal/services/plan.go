@@ -0,0 +1,561 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"gocreator/internal/interfaces"
+
+	"github.com/spf13/afero"
+)
+
+// TokenCount is a number of input and output tokens of chat completions
+type TokenCount struct {
+	Input  int
+	Output int
+}
+
+// chatPrices are the OpenAI list prices of chat models in USD per million input and output tokens.
+// They only feed the estimate printed by a dry run, which leaves out the cost of other models.
+var chatPrices = map[string]struct{ Input, Output float64 }{
+	"gpt-4o-mini":  {Input: 0.15, Output: 0.60},
+	"gpt-4o":       {Input: 2.50, Output: 10.00},
+	"gpt-4.1":      {Input: 2.00, Output: 8.00},
+	"gpt-4.1-mini": {Input: 0.40, Output: 1.60},
+	"gpt-4.1-nano": {Input: 0.10, Output: 0.40},
+}
+
+const (
+	// speechPricePerMillionChars is the OpenAI list price of tts-1-hd, the model of the speech adapter
+	speechPricePerMillionChars = 30.0
+
+	// charsPerToken approximates how many characters of text make one token
+	charsPerToken = 4
+)
+
+// PlanAction is what a run would do for one artifact
+type PlanAction string
+
+const (
+	PlanNone       PlanAction = "-"
+	PlanCached     PlanAction = "cached"
+	PlanRegenerate PlanAction = "regenerate"
+	// PlanHuman marks a slide whose translation is supplied by a translator
+	PlanHuman PlanAction = "human"
+	// PlanReviewed marks a slide whose translation was approved in review
+	PlanReviewed PlanAction = "reviewed"
+	// PlanUnknown marks an artifact whose cache can't be checked without running ffmpeg
+	PlanUnknown PlanAction = "unknown"
+)
+
+// Plan is what a run would regenerate, and what it would cost, without running it
+type Plan struct {
+	SlideCount int
+	Languages  []*LanguagePlan
+}
+
+// LanguagePlan is what a run would do for one language
+type LanguagePlan struct {
+	Lang       string
+	Output     string
+	Slides     []SlidePlan
+	FinalVideo PlanAction
+	Note       string
+
+	TranslationRequests int
+	InputTokens         int
+	OutputTokens        int
+	SpeechRequests      int
+	SpeechChars         int
+
+	// ModelTokens splits the tokens between the chat models answering the requests
+	ModelTokens map[string]TokenCount
+}
+
+// SlidePlan is what a run would do for one slide of a language
+type SlidePlan struct {
+	Index       int
+	Translation PlanAction
+	Audio       PlanAction
+	Segment     PlanAction
+}
+
+// UpToDate reports whether the slide needs no work
+func (p SlidePlan) UpToDate() bool {
+	for _, action := range []PlanAction{p.Translation, p.Audio, p.Segment} {
+		if action != PlanNone && action != PlanCached && action != PlanHuman && action != PlanReviewed {
+			return false
+		}
+	}
+	return true
+}
+
+// addTokens counts the tokens of requests answered by model, "" for the default one
+func (p *LanguagePlan) addTokens(model string, input, output int) {
+	if model == "" {
+		model = interfaces.DefaultChatModel
+	}
+	if p.ModelTokens == nil {
+		p.ModelTokens = make(map[string]TokenCount)
+	}
+	tokens := p.ModelTokens[model]
+	tokens.Input += input
+	tokens.Output += output
+	p.ModelTokens[model] = tokens
+	p.InputTokens += input
+	p.OutputTokens += output
+}
+
+// Cost returns the estimated API cost of the language in USD, leaving out the chat models without a
+// known price, and whether it is complete, with none left out
+func (p *LanguagePlan) Cost() (float64, bool) {
+	cost := float64(p.SpeechChars) * speechPricePerMillionChars / 1e6
+	complete := true
+	for model, tokens := range p.ModelTokens {
+		prices, ok := chatPrices[model]
+		if !ok {
+			complete = false
+			continue
+		}
+		cost += float64(tokens.Input)*prices.Input/1e6 + float64(tokens.Output)*prices.Output/1e6
+	}
+	return cost, complete
+}
+
+// Cost returns the estimated API cost of the run in USD, leaving out the chat models without a
+// known price, and whether it is complete, with none left out
+func (p *Plan) Cost() (float64, bool) {
+	var cost float64
+	complete := true
+	for _, lang := range p.Languages {
+		langCost, langComplete := lang.Cost()
+		cost += langCost
+		complete = complete && langComplete
+	}
+	return cost, complete
+}
+
+// unpricedModels returns the chat models of the plan without a known price, sorted
+func (p *Plan) unpricedModels() []string {
+	var models []string
+	for _, lang := range p.Languages {
+		for model := range lang.ModelTokens {
+			if _, ok := chatPrices[model]; !ok && !slices.Contains(models, model) {
+				models = append(models, model)
+			}
+		}
+	}
+	slices.Sort(models)
+	return models
+}
+
+// formatCost formats an estimated cost, marked partial when it is not complete
+func formatCost(cost float64, complete bool) string {
+	if !complete {
+		return fmt.Sprintf("~$%.4f (partial)", cost)
+	}
+	return fmt.Sprintf("~$%.4f", cost)
+}
+
+// Planner works out what VideoCreator would regenerate by checking the
+// same caches as the services, without calling any API or running ffmpeg
+type Planner struct {
+	fs                 afero.Fs
+	textService        interfaces.TextProcessor
+	slideService       interfaces.SlideLoader
+	translationService *TranslationService
+	audioService       *AudioService
+	videoService       *VideoService
+	logger             interfaces.Logger
+}
+
+// NewPlanner creates a planner checking the caches of the given services
+func NewPlanner(
+	fs afero.Fs,
+	textService interfaces.TextProcessor,
+	slideService interfaces.SlideLoader,
+	translationService *TranslationService,
+	audioService *AudioService,
+	videoService *VideoService,
+	logger interfaces.Logger,
+) *Planner {
+	return &Planner{
+		fs:                 fs,
+		textService:        textService,
+		slideService:       slideService,
+		translationService: translationService,
+		audioService:       audioService,
+		videoService:       videoService,
+		logger:             logger,
+	}
+}
+
+// Plan works out what a run with cfg would do, for the selected slides only when cfg is a preview.
+// Google Slides are not fetched: the slides and notes saved by the previous run are planned instead.
+func (p *Planner) Plan(ctx context.Context, cfg VideoCreatorConfig) (*Plan, error) {
+	dataDir := filepath.Join(cfg.RootDir, "data")
+	if cfg.GoogleSlidesID != "" {
+		p.logger.Info("Planning from the local copy of the Google Slides presentation")
+	}
+
+	inputTexts, slides, script, err := loadLocalInputs(ctx, p.fs, p.textService, p.slideService, dataDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(slides) != len(inputTexts) {
+		return nil, fmt.Errorf("slide and text count mismatch: %d slides, %d texts", len(slides), len(inputTexts))
+	}
+
+	if err := checkSlideSelection(cfg.Slides, len(slides)); err != nil {
+		return nil, err
+	}
+
+	cfg.Transition = effectiveTransition(p.logger, cfg.Transition)
+
+	plan := &Plan{SlideCount: len(slides)}
+	if cfg.IsPreview() {
+		plan.SlideCount = len(cfg.Slides)
+	}
+	for _, lang := range cfg.OutputLangs {
+		langPlan, err := p.planLanguage(ctx, cfg, dataDir, lang, inputTexts, slides, script)
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan language %s: %w", lang, err)
+		}
+		plan.Languages = append(plan.Languages, langPlan)
+	}
+	for _, model := range plan.unpricedModels() {
+		p.logger.Warn("No known price for the chat model, the cost is left out of the estimate", "model", model)
+	}
+	return plan, nil
+}
+
+func (p *Planner) planLanguage(
+	ctx context.Context,
+	cfg VideoCreatorConfig,
+	dataDir string,
+	lang string,
+	inputTexts []string,
+	slides []string,
+	script *Script,
+) (*LanguagePlan, error) {
+	ctx = withLanguage(ctx, lang)
+	plan := &LanguagePlan{
+		Lang:   lang,
+		Output: languageOutputPath(dataDir, lang),
+		Slides: make([]SlidePlan, len(slides)),
+	}
+	for i := range plan.Slides {
+		plan.Slides[i].Index = i
+	}
+
+	// A preview only renders the selected slides, in the order of the selection
+	indices := cfg.Slides
+	selected := make([]bool, len(slides))
+	if cfg.IsPreview() {
+		plan.Output = languagePreviewPath(dataDir, lang)
+		for _, idx := range indices {
+			selected[idx] = true
+		}
+	} else {
+		indices = make([]int, len(slides))
+		for i := range indices {
+			indices[i] = i
+			selected[i] = true
+		}
+	}
+
+	// Translation: the texts whose translation is not cached are unknown until translated
+	texts := make([]string, len(inputTexts))
+	known := make([]bool, len(inputTexts))
+	if err := p.planTranslation(ctx, cfg, dataDir, lang, inputTexts, script, selected, plan, texts, known); err != nil {
+		return nil, err
+	}
+
+	// Audio: unknown texts always need new audio
+	audioDir := languageAudioDir(dataDir, lang)
+	speech := script.speechTexts(texts)
+	cachedAudio, err := p.audioService.CachedBatch(ctx, speech, script.voices(), audioDir)
+	if err != nil {
+		return nil, err
+	}
+	audioPaths := make([]string, len(texts))
+	for i := range texts {
+		if !selected[i] {
+			continue
+		}
+		spoken := speech[i]
+		if !known[i] {
+			// Assume the translation is as long as the source
+			spoken = script.speech(inputTexts[i])
+		}
+		if isSilent(spoken) {
+			plan.Slides[i].Audio = PlanNone
+			continue
+		}
+		audioPaths[i] = batchAudioPath(audioDir, i)
+		if known[i] && cachedAudio[i] {
+			plan.Slides[i].Audio = PlanCached
+			continue
+		}
+		plan.Slides[i].Audio = PlanRegenerate
+		requests, chars := speechCost(spoken)
+		plan.SpeechRequests += requests
+		plan.SpeechChars += chars
+	}
+
+	// Video: a segment is reused only if its audio is and its cache matches
+	var cachedSegments []bool
+	var cachedFinal bool
+	if cfg.IsPreview() {
+		selectedSlides := make([]string, len(indices))
+		selectedTexts := make([]string, len(indices))
+		selectedAudio := make([]string, len(indices))
+		for pos, idx := range indices {
+			selectedSlides[pos], selectedTexts[pos], selectedAudio[pos] = slides[idx], texts[idx], audioPaths[idx]
+		}
+		tl, err := buildTimeline(selectedSlides, selectedTexts, selectedAudio, cfg, script.selected(indices))
+		if err != nil {
+			return nil, fmt.Errorf("failed to build timeline: %w", err)
+		}
+		cachedSegments, cachedFinal, err = p.videoService.CachedPreview(tl, indices, languageOutputPath(dataDir, lang), plan.Output)
+	} else {
+		tl, err := buildTimeline(slides, texts, audioPaths, cfg, script)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build timeline: %w", err)
+		}
+		cachedSegments, cachedFinal, err = p.videoService.CachedTimeline(tl, plan.Output)
+	}
+	if err != nil {
+		plan.Note = err.Error()
+		plan.FinalVideo = PlanUnknown
+		for _, idx := range indices {
+			plan.Slides[idx].Segment = PlanUnknown
+			if plan.Slides[idx].Audio == PlanRegenerate {
+				plan.Slides[idx].Segment = PlanRegenerate
+				plan.FinalVideo = PlanRegenerate
+			}
+		}
+		plan.Slides = plannedSlides(plan.Slides, indices)
+		return plan, nil
+	}
+
+	plan.FinalVideo = PlanCached
+	for pos, idx := range indices {
+		if cachedSegments[pos] && plan.Slides[idx].Audio != PlanRegenerate {
+			plan.Slides[idx].Segment = PlanCached
+			continue
+		}
+		plan.Slides[idx].Segment = PlanRegenerate
+		plan.FinalVideo = PlanRegenerate
+	}
+	if !cachedFinal {
+		plan.FinalVideo = PlanRegenerate
+	}
+	plan.Slides = plannedSlides(plan.Slides, indices)
+	return plan, nil
+}
+
+// plannedSlides returns the plans of the slides at indices, the slides a run renders
+func plannedSlides(slides []SlidePlan, indices []int) []SlidePlan {
+	planned := make([]SlidePlan, len(indices))
+	for pos, idx := range indices {
+		planned[pos] = slides[idx]
+	}
+	return planned
+}
+
+// planTranslation fills in the translation of every slide, and the texts known without calling the API.
+// Only the selected slides are translated when missing from the caches.
+func (p *Planner) planTranslation(
+	ctx context.Context,
+	cfg VideoCreatorConfig,
+	dataDir string,
+	lang string,
+	inputTexts []string,
+	script *Script,
+	selected []bool,
+	plan *LanguagePlan,
+	texts []string,
+	known []bool,
+) error {
+	if lang == cfg.InputLang {
+		copy(texts, inputTexts)
+		for i := range known {
+			known[i] = true
+			plan.Slides[i].Translation = PlanNone
+		}
+		return nil
+	}
+
+	human, err := loadHumanTranslation(p.fs, dataDir, lang, len(inputTexts), script)
+	if err != nil {
+		return err
+	}
+	reviewed, err := loadReviewedTranslations(p.fs, dataDir, lang)
+	if err != nil {
+		return err
+	}
+
+	// Without a human translation, the saved translation of the language is reused for the slides it is up to date for
+	var saved *savedTranslation
+	if human == nil {
+		saved, err = loadSavedTranslation(ctx, p.fs, p.textService, languageTextsPath(dataDir, lang, script), len(inputTexts), true)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The other texts the translator left empty are looked up in the translation cache
+	ctx = withTranslatorNotes(ctx, script.notes())
+	var pending []deckSlide
+	sources := make([]string, len(inputTexts))
+	for i, source := range inputTexts {
+		if !selected[i] {
+			continue
+		}
+		if translated, who, ok := approvedText(human, reviewed, i, source, script); ok {
+			texts[i], known[i] = translated, true
+			plan.Slides[i].Translation = PlanHuman
+			if who == TranslationReviewed {
+				plan.Slides[i].Translation = PlanReviewed
+			}
+			continue
+		}
+		text := script.translatable(source)
+		if isSilent(text) {
+			texts[i], known[i] = script.translated(source, ""), true
+			plan.Slides[i].Translation = PlanNone
+			continue
+		}
+		if saved.reusable(i, p.translationService.getCacheKey(withSlideIndex(ctx, i), text, lang)) {
+			texts[i], known[i] = script.translated(source, script.translatable(saved.texts[i])), true
+			plan.Slides[i].Translation = PlanCached
+			continue
+		}
+		if translated, ok := p.translationService.Cached(withSlideIndex(ctx, i), text, lang); ok {
+			texts[i], known[i] = script.translated(source, translated), true
+			plan.Slides[i].Translation = PlanCached
+			continue
+		}
+		plan.Slides[i].Translation = PlanRegenerate
+		if err := p.planBackTranslation(plan, text, lang); err != nil {
+			return err
+		}
+		if p.translationService.deck(lang) {
+			pending = append(pending, deckSlide{Index: i, Text: text})
+			sources[i] = text
+			continue
+		}
+		plan.TranslationRequests++
+		if provider, _ := p.translationService.provider(lang); provider != ProviderOpenAI {
+			continue // Priced by the provider's own plan, not in the estimate
+		}
+		prompt, err := translationPrompt(text, lang, p.translationService.hints(withSlideIndex(ctx, i), text, lang))
+		if err != nil {
+			return err
+		}
+		plan.addTokens(p.translationService.prompt.options().Model,
+			estimateTokens(p.translationService.prompt.system())+estimateTokens(prompt), estimateTokens(text))
+	}
+
+	// In deck mode, the texts are translated together, a chunk per request
+	for _, chunk := range deckChunks(pending, p.translationService.deckChunkSize) {
+		messages, err := json.Marshal(p.translationService.newDeckRequest(ctx, chunk, sources, lang))
+		if err != nil {
+			return err
+		}
+		answer, err := json.Marshal(deckResponse{Translations: chunk})
+		if err != nil {
+			return err
+		}
+		plan.TranslationRequests++
+		plan.addTokens(p.translationService.prompt.options().Model,
+			estimateTokens(deckSystemPrompt)+estimateTokens(p.translationService.prompt.system())+estimateTokens(string(messages)),
+			estimateTokens(string(answer)))
+	}
+	return nil
+}
+
+// planBackTranslation counts the request translating the translation of text to lang back to the
+// source language, when the quality checks compare back translations. The translation is about as
+// long as text.
+func (p *Planner) planBackTranslation(plan *LanguagePlan, text, lang string) error {
+	if qa := p.translationService.qa; qa == nil || !qa.BackTranslation {
+		return nil
+	}
+	plan.TranslationRequests++
+	if provider, _ := p.translationService.provider(lang); provider != ProviderOpenAI {
+		return nil // Priced by the provider's own plan, not in the estimate
+	}
+	prompt, err := translationPrompt(text, p.translationService.sourceLang, translationHints{Slide: -1})
+	if err != nil {
+		return err
+	}
+	// Back translations use the built-in prompt, so the default model
+	plan.addTokens("", estimateTokens(prompt), estimateTokens(text))
+	return nil
+}
+
+// estimateTokens approximates the number of tokens of text
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// WriteSummary writes what the run would regenerate per language and slide, and its estimated cost
+func (p *Plan) WriteSummary(w io.Writer) error {
+	var out strings.Builder
+	var translationRequests, inputTokens, outputTokens, speechRequests, speechChars int
+
+	fmt.Fprintf(&out, "Dry run: %d slides, %d languages. Nothing was generated.\n", p.SlideCount, len(p.Languages))
+	for _, lang := range p.Languages {
+		fmt.Fprintf(&out, "\n%s -> %s\n", lang.Lang, lang.Output)
+
+		pending := 0
+		for _, slide := range lang.Slides {
+			if slide.UpToDate() {
+				continue
+			}
+			if pending == 0 {
+				out.WriteString("  SLIDE\tTRANSLATION\tAUDIO\tSEGMENT\n")
+			}
+			pending++
+			fmt.Fprintf(&out, "  %d\t%s\t%s\t%s\n", slide.Index+1, slide.Translation, slide.Audio, slide.Segment)
+		}
+		if pending == 0 && lang.FinalVideo == PlanCached {
+			out.WriteString("  up to date\n")
+			continue
+		}
+		fmt.Fprintf(&out, "  final video\t%s\n", lang.FinalVideo)
+		if lang.Note != "" {
+			fmt.Fprintf(&out, "  note: %s\n", lang.Note)
+		}
+		fmt.Fprintf(&out, "  API: %d translation requests, %d speech requests (%d characters), cost %s\n",
+			lang.TranslationRequests, lang.SpeechRequests, lang.SpeechChars, formatCost(lang.Cost()))
+
+		translationRequests += lang.TranslationRequests
+		inputTokens += lang.InputTokens
+		outputTokens += lang.OutputTokens
+		speechRequests += lang.SpeechRequests
+		speechChars += lang.SpeechChars
+	}
+
+	fmt.Fprintf(&out, "\nTranslation: %d requests, ~%d input tokens, ~%d output tokens\n", translationRequests, inputTokens, outputTokens)
+	fmt.Fprintf(&out, "Speech: %d requests, %d characters\n", speechRequests, speechChars)
+	fmt.Fprintf(&out, "Estimated cost: %s\n", formatCost(p.Cost()))
+	if models := p.unpricedModels(); len(models) > 0 {
+		fmt.Fprintf(&out, "Left out of the cost, without a known price: %s\n", strings.Join(models, ", "))
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := tw.Write([]byte(out.String())); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"path/filepath"
+	"testing"
+
+	"gocreator/internal/interfaces"
+	"gocreator/internal/mocks"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestPNG writes a blank PNG of the given size to path
+func writeTestPNG(t *testing.T, fs afero.Fs, path string, width, height int) {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	require.NoError(t, afero.WriteFile(fs, path, buf.Bytes(), 0644))
+}
+
+func TestPlanner_Plan(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	logger := &mockLogger{}
+	mockClient := new(mocks.MockOpenAIClient)
+	ctx := context.Background()
+
+	dataDir := "/project/data"
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "texts.txt"), []byte("Hello\n-\nWorld"), 0644))
+	writeTestPNG(t, fs, filepath.Join(dataDir, "slides", "1.png"), 641, 480)
+	writeTestPNG(t, fs, filepath.Join(dataDir, "slides", "2.png"), 641, 480)
+
+	textService := NewTextService(fs, logger)
+	translationService := NewTranslationServiceWithCache(mockClient, logger, fs, "/project/.cache/translations")
+	audioService := NewAudioService(fs, mockClient, textService, logger)
+	videoService := NewVideoService(fs, logger)
+
+	// The English audio of the first slide is already cached
+	mockClient.On("GenerateSpeech", mock.Anything, "Hello", interfaces.Voice{}).Return(newMockReadCloser("audio"), nil).Once()
+	err := audioService.Generate(ctx, "Hello", interfaces.Voice{}, batchAudioPath(languageAudioDir(dataDir, "en"), 0))
+	require.NoError(t, err)
+
+	planner := NewPlanner(fs, textService, NewSlideService(fs, logger), translationService, audioService, videoService, logger)
+	plan, err := planner.Plan(ctx, VideoCreatorConfig{
+		RootDir:     "/project",
+		InputLang:   "en",
+		OutputLangs: []string{"en", "fr"},
+	})
+	require.NoError(t, err)
+
+	// Planning calls no API
+	mockClient.AssertExpectations(t)
+
+	require.Len(t, plan.Languages, 2)
+	assert.Equal(t, 2, plan.SlideCount)
+
+	en := plan.Languages[0]
+	assert.Equal(t, languageOutputPath(dataDir, "en"), en.Output)
+	assert.Equal(t, PlanNone, en.Slides[0].Translation)
+	assert.Equal(t, PlanCached, en.Slides[0].Audio)
+	assert.Equal(t, PlanRegenerate, en.Slides[0].Segment)
+	assert.Equal(t, PlanRegenerate, en.Slides[1].Audio)
+	assert.Equal(t, PlanRegenerate, en.FinalVideo)
+	assert.Equal(t, 0, en.TranslationRequests)
+	assert.Equal(t, 1, en.SpeechRequests)
+	assert.Equal(t, len("World"), en.SpeechChars)
+
+	fr := plan.Languages[1]
+	assert.Equal(t, PlanRegenerate, fr.Slides[0].Translation)
+	assert.Equal(t, PlanRegenerate, fr.Slides[0].Audio)
+	assert.Equal(t, 2, fr.TranslationRequests)
+	assert.Equal(t, 2, fr.SpeechRequests)
+	assert.Equal(t, len("Hello")+len("World"), fr.SpeechChars)
+	assert.Positive(t, fr.InputTokens)
+	assert.Positive(t, fr.OutputTokens)
+
+	var out bytes.Buffer
+	require.NoError(t, plan.WriteSummary(&out))
+	assert.Contains(t, out.String(), "Translation: 2 requests")
+	assert.Contains(t, out.String(), "Speech: 3 requests, 15 characters")
+	assert.Contains(t, out.String(), "Estimated cost:")
+}
+
+func TestPlanner_Plan_CachedTranslation(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	logger := &mockLogger{}
+	mockClient := new(mocks.MockOpenAIClient)
+	ctx := context.Background()
+
+	dataDir := "/project/data"
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "texts.txt"), []byte("Hello"), 0644))
+	writeTestPNG(t, fs, filepath.Join(dataDir, "slides", "1.png"), 640, 480)
+
+	textService := NewTextService(fs, logger)
+	translationService := NewTranslationServiceWithCache(mockClient, logger, fs, "/project/.cache/translations")
+	translationService.setInMemoryCache(translationService.getCacheKey(context.Background(), "Hello", "fr"), "Bonjour")
+
+	planner := NewPlanner(fs, textService, NewSlideService(fs, logger), translationService,
+		NewAudioService(fs, mockClient, textService, logger), NewVideoService(fs, logger), logger)
+	plan, err := planner.Plan(ctx, VideoCreatorConfig{
+		RootDir:     "/project",
+		InputLang:   "en",
+		OutputLangs: []string{"fr"},
+	})
+	require.NoError(t, err)
+
+	fr := plan.Languages[0]
+	assert.Equal(t, PlanCached, fr.Slides[0].Translation)
+	assert.Equal(t, 0, fr.TranslationRequests)
+	// The speech estimate uses the cached translation
+	assert.Equal(t, len("Bonjour"), fr.SpeechChars)
+}
+
+func TestPlanner_Plan_Preview(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	logger := &mockLogger{}
+	mockClient := new(mocks.MockOpenAIClient)
+	ctx := context.Background()
+
+	dataDir := "/project/data"
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "texts.txt"), []byte("One\n-\nTwo\n-\nThree\n-\nFour"), 0644))
+	for _, name := range []string{"1.png", "2.png", "3.png", "4.png"} {
+		writeTestPNG(t, fs, filepath.Join(dataDir, "slides", name), 640, 480)
+	}
+
+	textService := NewTextService(fs, logger)
+	planner := NewPlanner(fs, textService, NewSlideService(fs, logger), NewTranslationService(mockClient, logger),
+		NewAudioService(fs, mockClient, textService, logger), NewVideoService(fs, logger), logger)
+	cfg := VideoCreatorConfig{
+		RootDir:     "/project",
+		InputLang:   "en",
+		OutputLangs: []string{"fr"},
+		Slides:      []int{1, 2},
+	}
+	plan, err := planner.Plan(ctx, cfg)
+	require.NoError(t, err)
+
+	// Only the selected slides are planned and priced, into the preview
+	assert.Equal(t, 2, plan.SlideCount)
+	fr := plan.Languages[0]
+	assert.Equal(t, languagePreviewPath(dataDir, "fr"), fr.Output)
+	require.Len(t, fr.Slides, 2)
+	assert.Equal(t, 1, fr.Slides[0].Index)
+	assert.Equal(t, 2, fr.Slides[1].Index)
+	assert.Equal(t, PlanRegenerate, fr.Slides[0].Segment)
+	assert.Equal(t, 2, fr.TranslationRequests)
+	assert.Equal(t, 2, fr.SpeechRequests)
+	assert.Equal(t, len("Two")+len("Three"), fr.SpeechChars)
+
+	cfg.Slides = []int{4}
+	_, err = planner.Plan(ctx, cfg)
+	assert.EqualError(t, err, "invalid slide 5 selected for preview: slides must be unique and between 1 and 4")
+}
+
+func TestPlanner_Plan_HumanTranslation(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	logger := &mockLogger{}
+	mockClient := new(mocks.MockOpenAIClient)
+	ctx := context.Background()
+
+	dataDir := "/project/data"
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "texts.txt"), []byte("Hello\n-\nWorld"), 0644))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(dataDir, "texts.fr.txt"), []byte("Salut\n-\n"), 0644))
+	writeTestPNG(t, fs, filepath.Join(dataDir, "slides", "1.png"), 640, 480)
+	writeTestPNG(t, fs, filepath.Join(dataDir, "slides", "2.png"), 640, 480)
+
+	textService := NewTextService(fs, logger)
+	planner := NewPlanner(fs, textService, NewSlideService(fs, logger), NewTranslationService(mockClient, logger),
+		NewAudioService(fs, mockClient, textService, logger), NewVideoService(fs, logger), logger)
+	plan, err := planner.Plan(ctx, VideoCreatorConfig{
+		RootDir:     "/project",
+		InputLang:   "en",
+		OutputLangs: []string{"fr"},
+	})
+	require.NoError(t, err)
+
+	fr := plan.Languages[0]
+	assert.Equal(t, PlanHuman, fr.Slides[0].Translation)
+	assert.Equal(t, PlanRegenerate, fr.Slides[1].Translation)
+	assert.Equal(t, 1, fr.TranslationRequests)
+	assert.Equal(t, len("Salut")+len("World"), fr.SpeechChars)
+}
+
+func TestPlanner_Plan_Deck(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	logger := &mockLogger{}
+	mockClient := new(mocks.MockOpenAIClient)
+
+	require.NoError(t, afero.WriteFile(fs, "/project/data/texts.txt", []byte("One\n-\nTwo\n-\nThree"), 0644))
+	for _, name := range []string{"1.png", "2.png", "3.png"} {
+		writeTestPNG(t, fs, filepath.Join("/project/data/slides", name), 640, 480)
+	}
+
+	textService := NewTextService(fs, logger)
+	translationService := NewTranslationService(mockClient, logger).WithDeck(2)
+	planner := NewPlanner(fs, textService, NewSlideService(fs, logger), translationService,
+		NewAudioService(fs, mockClient, textService, logger), NewVideoService(fs, logger), logger)
+	plan, err := planner.Plan(context.Background(), VideoCreatorConfig{RootDir: "/project", InputLang: "en", OutputLangs: []string{"fr"}})
+	require.NoError(t, err)
+
+	// Three slides in chunks of two
+	fr := plan.Languages[0]
+	assert.Equal(t, 2, fr.TranslationRequests)
+	assert.Greater(t, fr.InputTokens, estimateTokens(deckSystemPrompt)*2)
+	assert.Positive(t, fr.OutputTokens)
+}
+
+func TestPlanner_Plan_BackTranslation(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	logger := &mockLogger{}
+	mockClient := new(mocks.MockOpenAIClient)
+
+	require.NoError(t, afero.WriteFile(fs, "/project/data/texts.txt", []byte("One\n-\nTwo"), 0644))
+	for _, name := range []string{"1.png", "2.png"} {
+		writeTestPNG(t, fs, filepath.Join("/project/data/slides", name), 640, 480)
+	}
+
+	textService := NewTextService(fs, logger)
+	cfg := VideoCreatorConfig{RootDir: "/project", InputLang: "en", OutputLangs: []string{"fr"}}
+	plan := func(translationService *TranslationService) *LanguagePlan {
+		t.Helper()
+		planner := NewPlanner(fs, textService, NewSlideService(fs, logger), translationService,
+			NewAudioService(fs, mockClient, textService, logger), NewVideoService(fs, logger), logger)
+		plan, err := planner.Plan(context.Background(), cfg)
+		require.NoError(t, err)
+		return plan.Languages[0]
+	}
+	withoutBack := plan(NewTranslationService(mockClient, logger).WithQA(TranslationQA{}, "en"))
+	withBack := plan(NewTranslationService(mockClient, logger).WithQA(TranslationQA{BackTranslation: true}, "en"))
+
+	// Every translation is translated back
+	assert.Equal(t, 2, withoutBack.TranslationRequests)
+	assert.Equal(t, 4, withBack.TranslationRequests)
+	assert.Greater(t, withBack.InputTokens, withoutBack.InputTokens)
+	assert.Greater(t, withBack.OutputTokens, withoutBack.OutputTokens)
+}
+
+func TestPlanner_Plan_CountMismatch(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	logger := &mockLogger{}
+	mockClient := new(mocks.MockOpenAIClient)
+
+	require.NoError(t, afero.WriteFile(fs, "/project/data/texts.txt", []byte("Hello\n-\nWorld"), 0644))
+	writeTestPNG(t, fs, "/project/data/slides/1.png", 640, 480)
+
+	textService := NewTextService(fs, logger)
+	planner := NewPlanner(fs, textService, NewSlideService(fs, logger), NewTranslationService(mockClient, logger),
+		NewAudioService(fs, mockClient, textService, logger), NewVideoService(fs, logger), logger)
+	_, err := planner.Plan(context.Background(), VideoCreatorConfig{RootDir: "/project", InputLang: "en", OutputLangs: []string{"en"}})
+	assert.ErrorContains(t, err, "count mismatch")
+}
+
+func TestLanguagePlan_Cost(t *testing.T) {
+	plan := &LanguagePlan{SpeechChars: 1_000_000}
+	plan.addTokens("", 1_000_000, 1_000_000)
+	plan.addTokens("gpt-4o", 1_000_000, 0)
+	assert.Equal(t, 2_000_000, plan.InputTokens)
+	cost, ok := plan.Cost()
+	assert.True(t, ok)
+	assert.InDelta(t, 0.15+0.60+2.50+30.0, cost, 1e-9)
+
+	// A model without a known price is left out of a partial estimate
+	plan.addTokens("my-fine-tuned-model", 1000, 1000)
+	cost, ok = (&Plan{Languages: []*LanguagePlan{plan}}).Cost()
+	assert.False(t, ok)
+	assert.InDelta(t, 0.15+0.60+2.50+30.0, cost, 1e-9)
+	var out bytes.Buffer
+	require.NoError(t, (&Plan{Languages: []*LanguagePlan{plan}}).WriteSummary(&out))
+	assert.Contains(t, out.String(), "Estimated cost: ~$33.2500 (partial)")
+	assert.Contains(t, out.String(), "Left out of the cost, without a known price: my-fine-tuned-model")
+	assert.Equal(t, 2, estimateTokens("héllo!"))
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// RunStatus is the outcome of a run, a language or a slide
+type RunStatus string
+
+const (
+	StatusSucceeded RunStatus = "succeeded"
+	StatusFailed    RunStatus = "failed"
+	StatusCanceled  RunStatus = "canceled"
+	// StatusSkipped marks a language a resumed run found already complete
+	StatusSkipped RunStatus = "skipped"
+	// StatusIncomplete marks a slide that did not fail but whose language stopped before finishing it
+	StatusIncomplete RunStatus = "incomplete"
+)
+
+// RunReport is the machine-readable summary of a run
+type RunReport struct {
+	RunID     string            `json:"run_id,omitempty"`
+	Status    RunStatus         `json:"status"`
+	StartedAt time.Time         `json:"started_at"`
+	Duration  float64           `json:"duration_seconds"`
+	CacheHits int               `json:"cache_hits"`
+	APICalls  int               `json:"api_calls"`
+	Error     string            `json:"error,omitempty"`
+	Languages []*LanguageReport `json:"languages"`
+}
+
+// LanguageReport is the outcome of one language.
+// Its counters include the slide counters plus work shared by all slides, such as concatenation.
+type LanguageReport struct {
+	mu sync.Mutex
+
+	Lang      string        `json:"lang"`
+	Status    RunStatus     `json:"status"`
+	Output    string        `json:"output"`
+	Duration  float64       `json:"duration_seconds"`
+	CacheHits int           `json:"cache_hits"`
+	APICalls  int           `json:"api_calls"`
+	Error     string        `json:"error,omitempty"`
+	Slides    []SlideReport `json:"slides"`
+}
+
+// SlideReport is the outcome of one slide of a language.
+// Its duration is the time spent translating, synthesizing and rendering the slide.
+type SlideReport struct {
+	Index       int               `json:"index"`
+	Status      RunStatus         `json:"status"`
+	Duration    float64           `json:"duration_seconds"`
+	CacheHits   int               `json:"cache_hits"`
+	APICalls    int               `json:"api_calls"`
+	Translation TranslationSource `json:"translation,omitempty"` // Unset for the input language and silent slides
+	QA          []string          `json:"qa,omitempty"`          // Problems the translation quality checks found
+	Error       string            `json:"error,omitempty"`
+}
+
+// newLanguageReport creates the report of a language with slideCount slides
+func newLanguageReport(lang, output string, slideCount int) *LanguageReport {
+	slides := make([]SlideReport, slideCount)
+	for i := range slides {
+		slides[i].Index = i
+	}
+	return &LanguageReport{Lang: lang, Output: output, Slides: slides}
+}
+
+// finish sets the status of the language and its slides once processing ended with err
+func (r *LanguageReport) finish(duration time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Duration = duration.Seconds()
+	switch {
+	case err == nil:
+		r.Status = StatusSucceeded
+	case errors.Is(err, context.Canceled):
+		r.Status = StatusCanceled
+		r.Error = err.Error()
+	default:
+		r.Status = StatusFailed
+		r.Error = err.Error()
+	}
+
+	for i := range r.Slides {
+		slide := &r.Slides[i]
+		switch {
+		case slide.Status == StatusSkipped:
+			// Not selected for a preview
+		case slide.Error != "":
+			slide.Status = StatusFailed
+		case err == nil:
+			slide.Status = StatusSucceeded
+		default:
+			slide.Status = StatusIncomplete
+		}
+	}
+}
+
+// skip marks a language that needed no work
+func (r *LanguageReport) skip() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Status = StatusSkipped
+	for i := range r.Slides {
+		r.Slides[i].Status = StatusSkipped
+	}
+}
+
+// selectSlides marks every slide not in indices as skipped
+func (r *LanguageReport) selectSlides(indices []int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	selected := make(map[int]bool, len(indices))
+	for _, idx := range indices {
+		selected[idx] = true
+	}
+	for i := range r.Slides {
+		if !selected[i] {
+			r.Slides[i].Status = StatusSkipped
+		}
+	}
+}
+
+// record applies update to the language and, when slide is in range, to that slide
+func (r *LanguageReport) record(slide int, update func(counters *SlideReport)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The language counters are kept in a scratch slide report
+	totals := SlideReport{CacheHits: r.CacheHits, APICalls: r.APICalls}
+	update(&totals)
+	r.CacheHits, r.APICalls = totals.CacheHits, totals.APICalls
+
+	if slide >= 0 && slide < len(r.Slides) {
+		update(&r.Slides[slide])
+	}
+}
+
+// finish computes the totals and status of the run
+func (r *RunReport) finish(err error) {
+	r.Duration = time.Since(r.StartedAt).Seconds()
+	r.Status = StatusSucceeded
+	r.CacheHits, r.APICalls = 0, 0
+	for _, lang := range r.Languages {
+		if lang == nil {
+			continue
+		}
+		r.CacheHits += lang.CacheHits
+		r.APICalls += lang.APICalls
+		if lang.Status == StatusFailed || lang.Status == StatusCanceled {
+			r.Status = StatusFailed
+		}
+	}
+	if err != nil {
+		r.Status = StatusFailed
+		r.Error = err.Error()
+	}
+}
+
+// Save writes the report as JSON to path
+func (r *RunReport) Save(fs afero.Fs, path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run report: %w", err)
+	}
+	if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	if err := writeFileAtomic(fs, path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write run report: %w", err)
+	}
+	return nil
+}
+
+// WriteSummary writes a human-readable table of the languages of the run
+func (r *RunReport) WriteSummary(w io.Writer) error {
+	var table strings.Builder
+	table.WriteString("LANGUAGE\tSTATUS\tDURATION\tCACHE HITS\tAPI CALLS\tERROR\n")
+	for _, lang := range r.Languages {
+		fmt.Fprintf(&table, "%s\t%s\t%.1fs\t%d\t%d\t%s\n", lang.Lang, lang.Status, lang.Duration, lang.CacheHits, lang.APICalls, lang.Error)
+	}
+	fmt.Fprintf(&table, "total\t%s\t%.1fs\t%d\t%d\t%s\n", r.Status, r.Duration, r.CacheHits, r.APICalls, r.Error)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := tw.Write([]byte(table.String())); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+type languageReportKey struct{}
+
+type slideIndexKey struct{}
+
+// withLanguageReport returns a context whose work is counted in report
+func withLanguageReport(ctx context.Context, report *LanguageReport) context.Context {
+	return context.WithValue(ctx, languageReportKey{}, report)
+}
+
+// withSlideIndex returns a context whose work is counted for the slide at index
+func withSlideIndex(ctx context.Context, index int) context.Context {
+	return context.WithValue(ctx, slideIndexKey{}, index)
+}
+
+// slideIndex returns the index of the slide whose work ctx carries, -1 for none
+func slideIndex(ctx context.Context) int {
+	slide, ok := ctx.Value(slideIndexKey{}).(int)
+	if !ok {
+		return -1
+	}
+	return slide
+}
+
+// recordInReport applies update to the language report and slide carried by ctx, if any
+func recordInReport(ctx context.Context, update func(counters *SlideReport)) {
+	report, ok := ctx.Value(languageReportKey{}).(*LanguageReport)
+	if !ok {
+		return
+	}
+	report.record(slideIndex(ctx), update)
+}
+
+// recordCacheHit counts a cache hit for the work of ctx
+func recordCacheHit(ctx context.Context) {
+	recordInReport(ctx, func(c *SlideReport) { c.CacheHits++ })
+}
+
+// recordAPICall counts an API call for the work of ctx
+func recordAPICall(ctx context.Context) {
+	recordInReport(ctx, func(c *SlideReport) { c.APICalls++ })
+}
+
+// recordSlideStep adds the time since start to the slide of ctx and keeps the first error of the slide
+func recordSlideStep(ctx context.Context, start time.Time, err error) {
+	elapsed := time.Since(start).Seconds()
+	slide, ok := ctx.Value(slideIndexKey{}).(int)
+	if !ok {
+		return
+	}
+	report, ok := ctx.Value(languageReportKey{}).(*LanguageReport)
+	if !ok {
+		return
+	}
+
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	if slide < 0 || slide >= len(report.Slides) {
+		return
+	}
+	report.Slides[slide].Duration += elapsed
+	if err != nil && report.Slides[slide].Error == "" {
+		report.Slides[slide].Error = err.Error()
+	}
+}
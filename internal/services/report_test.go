@@ -0,0 +1,138 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gocreator/internal/interfaces"
+	"gocreator/internal/mocks"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageReport_Finish(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		r := newLanguageReport("en", "/out/output-en.mp4", 2)
+		r.finish(2*time.Second, nil)
+
+		assert.Equal(t, StatusSucceeded, r.Status)
+		assert.Equal(t, 2.0, r.Duration)
+		assert.Equal(t, StatusSucceeded, r.Slides[0].Status)
+		assert.Equal(t, 1, r.Slides[1].Index)
+	})
+
+	t.Run("failure marks the failing slide", func(t *testing.T) {
+		r := newLanguageReport("fr", "/out/output-fr.mp4", 2)
+		ctx := withSlideIndex(withLanguageReport(context.Background(), r), 1)
+		recordSlideStep(ctx, time.Now(), errors.New("tts failed"))
+		r.finish(time.Second, fmt.Errorf("audio generation failed: %w", errors.New("tts failed")))
+
+		assert.Equal(t, StatusFailed, r.Status)
+		assert.Contains(t, r.Error, "tts failed")
+		assert.Equal(t, StatusIncomplete, r.Slides[0].Status)
+		assert.Equal(t, StatusFailed, r.Slides[1].Status)
+		assert.Equal(t, "tts failed", r.Slides[1].Error)
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		r := newLanguageReport("de", "/out/output-de.mp4", 1)
+		r.finish(time.Second, fmt.Errorf("translation failed: %w", context.Canceled))
+		assert.Equal(t, StatusCanceled, r.Status)
+	})
+}
+
+func TestRecordInReport(t *testing.T) {
+	r := newLanguageReport("en", "/out/output-en.mp4", 2)
+	ctx := withLanguageReport(context.Background(), r)
+
+	recordAPICall(withSlideIndex(ctx, 0))
+	recordCacheHit(withSlideIndex(ctx, 1))
+	recordCacheHit(ctx) // shared work, such as the final video
+
+	assert.Equal(t, 1, r.APICalls)
+	assert.Equal(t, 2, r.CacheHits)
+	assert.Equal(t, 1, r.Slides[0].APICalls)
+	assert.Equal(t, 1, r.Slides[1].CacheHits)
+
+	// Work outside of a run is not counted anywhere
+	recordAPICall(context.Background())
+	assert.Equal(t, 1, r.APICalls)
+}
+
+func TestRunReport_SaveAndSummary(t *testing.T) {
+	en := newLanguageReport("en", "/out/output-en.mp4", 1)
+	en.CacheHits = 3
+	en.finish(time.Second, nil)
+	fr := newLanguageReport("fr", "/out/output-fr.mp4", 1)
+	fr.APICalls = 2
+	fr.finish(time.Second, errors.New("boom"))
+
+	report := &RunReport{RunID: "run", StartedAt: time.Now(), Languages: []*LanguageReport{en, fr}}
+	report.finish(nil)
+
+	assert.Equal(t, StatusFailed, report.Status)
+	assert.Equal(t, 3, report.CacheHits)
+	assert.Equal(t, 2, report.APICalls)
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, report.Save(fs, "/out/report.json"))
+	data, err := afero.ReadFile(fs, "/out/report.json")
+	require.NoError(t, err)
+
+	var parsed struct {
+		Status    string `json:"status"`
+		Languages []struct {
+			Lang   string `json:"lang"`
+			Status string `json:"status"`
+			Error  string `json:"error"`
+			Slides []struct {
+				Status string `json:"status"`
+			} `json:"slides"`
+		} `json:"languages"`
+	}
+	require.NoError(t, json.Unmarshal(data, &parsed))
+	assert.Equal(t, "failed", parsed.Status)
+	require.Len(t, parsed.Languages, 2)
+	assert.Equal(t, "succeeded", parsed.Languages[0].Status)
+	assert.Equal(t, "boom", parsed.Languages[1].Error)
+	assert.Equal(t, "incomplete", parsed.Languages[1].Slides[0].Status)
+
+	var summary bytes.Buffer
+	require.NoError(t, report.WriteSummary(&summary))
+	assert.Contains(t, summary.String(), "LANGUAGE")
+	assert.Regexp(t, `fr\s+failed\s+1.0s\s+0\s+2\s+boom`, summary.String())
+}
+
+func TestAudioService_GenerateBatch_Report(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockClient := new(mocks.MockOpenAIClient)
+	logger := &mockLogger{}
+	textService := NewTextService(fs, logger)
+	service := NewAudioService(fs, mockClient, textService, logger)
+
+	mockClient.On("GenerateSpeech", mock.Anything, "Hello", interfaces.Voice{}).Return(newMockReadCloser("audio1"), nil).Once()
+	mockClient.On("GenerateSpeech", mock.Anything, "World", interfaces.Voice{}).Return(newMockReadCloser("audio2"), nil).Once()
+	mockClient.On("GenerateSpeech", mock.Anything, "Again", interfaces.Voice{}).Return(newMockReadCloser("audio3"), nil).Once()
+
+	_, err := service.GenerateBatch(context.Background(), []string{"Hello", "World"}, nil, "/audio")
+	require.NoError(t, err)
+
+	// Second run: slide 0 is cached, slide 1 changed
+	r := newLanguageReport("en", "/out/output-en.mp4", 2)
+	_, err = service.GenerateBatch(withLanguageReport(context.Background(), r), []string{"Hello", "Again"}, nil, "/audio")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, r.Slides[0].CacheHits)
+	assert.Equal(t, 0, r.Slides[0].APICalls)
+	assert.Equal(t, 1, r.Slides[1].APICalls)
+	assert.Equal(t, 1, r.CacheHits)
+	assert.Equal(t, 1, r.APICalls)
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"runtime"
+)
+
+const (
+	// defaultAPIConcurrency is the number of concurrent API calls when none is configured
+	defaultAPIConcurrency = 4
+)
+
+// Scheduler bounds how much work runs at once across all languages and slides.
+// CPU-bound media jobs (ffmpeg) and network-bound API calls have separate limits.
+// A single scheduler is meant to be shared by every service of a run.
+type Scheduler struct {
+	media chan struct{}
+	api   chan struct{}
+}
+
+// NewScheduler creates a scheduler running at most jobs media jobs and
+// apiConcurrency API calls at once. Values <= 0 select the defaults:
+// the number of CPUs for media jobs and 4 for API calls.
+func NewScheduler(jobs, apiConcurrency int) *Scheduler {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	if apiConcurrency <= 0 {
+		apiConcurrency = defaultAPIConcurrency
+	}
+	return &Scheduler{
+		media: make(chan struct{}, jobs),
+		api:   make(chan struct{}, apiConcurrency),
+	}
+}
+
+// Jobs returns the maximum number of concurrent media jobs
+func (s *Scheduler) Jobs() int {
+	return cap(s.media)
+}
+
+// APIConcurrency returns the maximum number of concurrent API calls
+func (s *Scheduler) APIConcurrency() int {
+	return cap(s.api)
+}
+
+// Media runs fn once a media job slot is free
+func (s *Scheduler) Media(ctx context.Context, fn func() error) error {
+	return runWithSlot(ctx, s.media, fn)
+}
+
+// API runs fn once an API call slot is free
+func (s *Scheduler) API(ctx context.Context, fn func() error) error {
+	return runWithSlot(ctx, s.api, fn)
+}
+
+// runWithSlot acquires a slot of sem for the duration of fn, giving up if ctx is done first
+func runWithSlot(ctx context.Context, sem chan struct{}, fn func() error) error {
+	// select picks at random when both are ready, so never start work for a canceled run
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-sem }()
+
+	return fn()
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewScheduler(t *testing.T) {
+	t.Run("explicit limits", func(t *testing.T) {
+		scheduler := NewScheduler(2, 3)
+		assert.Equal(t, 2, scheduler.Jobs())
+		assert.Equal(t, 3, scheduler.APIConcurrency())
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		scheduler := NewScheduler(0, -1)
+		assert.Equal(t, runtime.NumCPU(), scheduler.Jobs())
+		assert.Equal(t, defaultAPIConcurrency, scheduler.APIConcurrency())
+	})
+}
+
+func TestScheduler_BoundsConcurrency(t *testing.T) {
+	scheduler := NewScheduler(2, 1)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		run   func(fn func() error) error
+		limit int
+	}{
+		{name: "media", run: func(fn func() error) error { return scheduler.Media(ctx, fn) }, limit: 2},
+		{name: "api", run: func(fn func() error) error { return scheduler.API(ctx, fn) }, limit: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, peak int32
+			var wg sync.WaitGroup
+
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					err := tt.run(func() error {
+						n := atomic.AddInt32(&running, 1)
+						for {
+							p := atomic.LoadInt32(&peak)
+							if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+								break
+							}
+						}
+						time.Sleep(5 * time.Millisecond)
+						atomic.AddInt32(&running, -1)
+						return nil
+					})
+					assert.NoError(t, err)
+				}()
+			}
+
+			wg.Wait()
+			assert.LessOrEqual(t, int(peak), tt.limit)
+		})
+	}
+}
+
+func TestScheduler_ContextCancelled(t *testing.T) {
+	scheduler := NewScheduler(1, 1)
+
+	// Hold the only media slot
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = scheduler.Media(context.Background(), func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	err := scheduler.Media(ctx, func() error {
+		called = true
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.False(t, called)
+}
+
+func TestScheduler_ContextCancelledWithFreeSlot(t *testing.T) {
+	scheduler := NewScheduler(1, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A free slot must not win over the cancellation
+	for i := 0; i < 100; i++ {
+		err := scheduler.API(ctx, func() error {
+			t.Fatal("work started for a canceled run")
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	}
+}
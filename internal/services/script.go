@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gocreator/internal/interfaces"
+	"gocreator/internal/timeline"
+
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/afero"
+)
+
+// ScriptFile is the name of the structured script, read instead of texts.txt when present
+const ScriptFile = "script.yaml"
+
+// Script is the narration of a presentation with per-slide settings, loaded from data/script.yaml,
+// from data/script.md without settings, or from the metadata of data/texts.txt.
+// Narration outside Markdown follows the texts.txt format, see textSpeech.
+type Script struct {
+	Slides []ScriptSlide `yaml:"slides"`
+
+	markdown bool // Narration is Markdown, stripped before speech
+}
+
+// ScriptSlide is one slide of a script. Settings left empty use the project defaults.
+type ScriptSlide struct {
+	Narration     string            `yaml:"narration"`                // Empty for a silent slide
+	Slide         string            `yaml:"slide,omitempty"`          // Slide file, relative to data/slides. Default: the slide at the same position
+	Voice         string            `yaml:"voice,omitempty"`          // alloy, echo, fable, onyx, nova, shimmer
+	Speed         float64           `yaml:"speed,omitempty"`          // 0.25 to 4.0
+	PauseBefore   float64           `yaml:"pause_before,omitempty"`   // Silence before the narration, in seconds
+	PauseAfter    float64           `yaml:"pause_after,omitempty"`    // Silence after the narration, in seconds
+	MinDuration   float64           `yaml:"min_duration,omitempty"`   // Shortest time the slide is shown, in seconds
+	Duration      float64           `yaml:"duration,omitempty"`       // Exact time the slide is shown, in seconds, cutting longer narration
+	TransitionOut *ScriptTransition `yaml:"transition_out,omitempty"` // Transition into the next slide, replacing the project transition
+	Notes         string            `yaml:"notes,omitempty"`          // Context for translators, never narrated
+}
+
+// ScriptTransition is the transition out of one slide of a script
+type ScriptTransition struct {
+	Type     string  `yaml:"type"`               // none, fade, wipeleft, wiperight, etc.
+	Duration float64 `yaml:"duration,omitempty"` // Duration in seconds, default 0.5
+}
+
+// LoadScript loads and validates a script. Unknown settings are rejected so a typo can't be silently ignored.
+func LoadScript(fs afero.Fs, path string) (*Script, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+
+	var script Script
+	if err := yaml.UnmarshalWithOptions(data, &script, yaml.Strict()); err != nil {
+		return nil, fmt.Errorf("failed to parse script %s: %w", path, err)
+	}
+	if err := script.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid script %s: %w", path, err)
+	}
+	return &script, nil
+}
+
+// Validate checks the settings of every slide
+func (s *Script) Validate() error {
+	if len(s.Slides) == 0 {
+		return fmt.Errorf("script has no slides")
+	}
+	for i, slide := range s.Slides {
+		if err := slide.Validate(); err != nil {
+			return fmt.Errorf("slide %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the settings of the slide
+func (s ScriptSlide) Validate() error {
+	if s.Speed != 0 && (s.Speed < 0.25 || s.Speed > 4.0) {
+		return fmt.Errorf("speed must be between 0.25 and 4.0, got %g", s.Speed)
+	}
+	if s.PauseBefore < 0 || s.PauseAfter < 0 {
+		return fmt.Errorf("pauses must be non-negative, got %g before and %g after", s.PauseBefore, s.PauseAfter)
+	}
+	if s.MinDuration < 0 {
+		return fmt.Errorf("minimum duration must be non-negative, got %g", s.MinDuration)
+	}
+	if s.Duration < 0 {
+		return fmt.Errorf("duration must be non-negative, got %g", s.Duration)
+	}
+	if s.Duration > 0 && s.MinDuration > 0 {
+		return fmt.Errorf("duration and min_duration are exclusive")
+	}
+	if s.TransitionOut != nil {
+		if err := s.TransitionOut.config().Validate(); err != nil {
+			return fmt.Errorf("transition_out: %w", err)
+		}
+	}
+	return nil
+}
+
+// config returns the transition, with the default duration when none is given
+func (t *ScriptTransition) config() TransitionConfig {
+	transition := TransitionConfig{Type: TransitionType(t.Type), Duration: t.Duration}
+	if transition.Type != TransitionNone && transition.Duration == 0 {
+		transition.Duration = DefaultTransitionConfig().Duration
+	}
+	return transition
+}
+
+// Texts returns the narration of every slide
+func (s *Script) Texts() []string {
+	texts := make([]string, len(s.Slides))
+	for i, slide := range s.Slides {
+		texts[i] = slide.Narration
+	}
+	return texts
+}
+
+// selected returns the script of the slides at indices, nil for a nil script
+func (s *Script) selected(indices []int) *Script {
+	if s == nil {
+		return nil
+	}
+	subset := &Script{Slides: make([]ScriptSlide, len(indices)), markdown: s.markdown}
+	for pos, idx := range indices {
+		subset.Slides[pos] = s.Slides[idx]
+	}
+	return subset
+}
+
+// textsFile returns the name translations of the script are saved under, in its own format
+func (s *Script) textsFile() string {
+	if s != nil && s.markdown {
+		return MarkdownScriptFile
+	}
+	return "texts.txt"
+}
+
+// speech returns the narration of text as spoken, without its Markdown formatting,
+// or its comments and metadata in texts.txt format
+func (s *Script) speech(text string) string {
+	switch {
+	case s == nil:
+		return text
+	case s.markdown:
+		return markdownSpeech(text)
+	}
+	return textSpeech(text)
+}
+
+// speechTexts returns the narration of every text as spoken
+func (s *Script) speechTexts(texts []string) []string {
+	if s == nil {
+		return texts
+	}
+	spoken := make([]string, len(texts))
+	for i, text := range texts {
+		spoken[i] = s.speech(text)
+	}
+	return spoken
+}
+
+// translatable returns the part of text sent for translation: Markdown is translated as a whole,
+// without the comments and metadata of the texts.txt format
+func (s *Script) translatable(text string) string {
+	if s == nil || s.markdown {
+		return text
+	}
+	return textSpeech(text)
+}
+
+// translated returns the text of a slide in a language from the translation of translatable(source),
+// keeping the comments and metadata of source
+func (s *Script) translated(source, translation string) string {
+	if s == nil || s.markdown {
+		return translation
+	}
+	return annotateTranslation(source, translation)
+}
+
+// titles returns the chapter title of every text, nil when the script has none
+func (s *Script) titles(texts []string) []string {
+	if s == nil || !s.markdown {
+		return nil
+	}
+	titles := make([]string, len(texts))
+	for i, text := range texts {
+		titles[i] = markdownTitle(text)
+	}
+	return titles
+}
+
+// notes returns the notes for translators of every slide, nil when the script has none
+func (s *Script) notes() []string {
+	if s == nil {
+		return nil
+	}
+	var notes []string
+	for i, slide := range s.Slides {
+		if slide.Notes == "" {
+			continue
+		}
+		if notes == nil {
+			notes = make([]string, len(s.Slides))
+		}
+		notes[i] = slide.Notes
+	}
+	return notes
+}
+
+// voices returns the voice of every slide, or nil when every slide uses the default voice
+func (s *Script) voices() []interfaces.Voice {
+	if s == nil {
+		return nil
+	}
+	voices := make([]interfaces.Voice, len(s.Slides))
+	custom := false
+	for i, slide := range s.Slides {
+		voices[i] = interfaces.Voice{Name: slide.Voice, Speed: slide.Speed}
+		custom = custom || !voices[i].IsDefault()
+	}
+	if !custom {
+		return nil
+	}
+	return voices
+}
+
+// voice returns the voice of slide idx, the default voice for a nil script
+func (s *Script) voice(idx int) interfaces.Voice {
+	if s == nil {
+		return interfaces.Voice{}
+	}
+	return interfaces.Voice{Name: s.Slides[idx].Voice, Speed: s.Slides[idx].Speed}
+}
+
+// applyTo sets the pauses, durations and transitions of the script on the segments of tl.
+// It must be applied after the project transition, which the slides of the script override.
+func (s *Script) applyTo(tl *timeline.Timeline) {
+	if s == nil {
+		return
+	}
+	for i := range tl.Segments {
+		slide := s.Slides[i]
+		seg := &tl.Segments[i]
+		if slide.PauseBefore > 0 && len(seg.Audio) > 0 {
+			seg.Audio[0].Offset = slide.PauseBefore
+		}
+		seg.PadEnd = slide.PauseAfter
+		seg.MinDuration = slide.MinDuration
+		seg.Duration = slide.Duration
+
+		if slide.TransitionOut == nil || i == len(tl.Segments)-1 {
+			continue
+		}
+		transition := slide.TransitionOut.config()
+		if transition.IsEnabled() {
+			seg.TransitionOut = &timeline.Transition{Type: string(transition.Type), Duration: transition.Duration}
+		} else {
+			seg.TransitionOut = nil
+		}
+	}
+}
+
+// hash fingerprints every setting of the script, "" for a nil script
+func (s *Script) hash() string {
+	if s == nil {
+		return ""
+	}
+	data, _ := json.Marshal(s.Slides) // Plain values always encode
+	if s.markdown {
+		data = append(data, "|markdown"...)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadMarkdownScript loads the Markdown narration at path, one text per slide
+func loadMarkdownScript(ctx context.Context, textService interfaces.TextProcessor, path string) ([]string, *Script, error) {
+	texts, err := textService.Load(ctx, path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load input texts: %w", err)
+	}
+	if len(texts) == 0 {
+		return nil, nil, fmt.Errorf("invalid script %s: script has no slides", path)
+	}
+
+	// A slide with a heading and no narration is silent
+	script := &Script{Slides: make([]ScriptSlide, len(texts)), markdown: true}
+	for i, text := range texts {
+		script.Slides[i].Narration = text
+	}
+	return texts, script, nil
+}
+
+// loadTextScript loads the narration of texts.txt at path, one text per slide. Narration with
+// comments, metadata or escaped lines gets a script holding the settings of its metadata,
+// plain narration a nil script.
+func loadTextScript(ctx context.Context, textService interfaces.TextProcessor, path string) ([]string, *Script, error) {
+	texts, err := textService.Load(ctx, path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load input texts: %w", err)
+	}
+
+	annotated := false
+	for _, text := range texts {
+		annotated = annotated || hasTextAnnotations(text)
+	}
+	if !annotated {
+		return texts, nil, nil
+	}
+
+	script := &Script{Slides: make([]ScriptSlide, len(texts))}
+	for i, text := range texts {
+		script.Slides[i], err = textSlide(text)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid texts %s: slide %d: %w", path, i+1, err)
+		}
+	}
+	if err := script.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid texts %s: %w", path, err)
+	}
+	return texts, script, nil
+}
+
+// loadLocalInputs loads the narration and slides of a local project: from data/script.yaml when
+// present, with its per-slide settings, then from data/script.md, and from data/texts.txt
+// otherwise, with the settings of its metadata, see loadTextScript
+func loadLocalInputs(ctx context.Context, fs afero.Fs, textService interfaces.TextProcessor, slideService interfaces.SlideLoader, dataDir string) ([]string, []string, *Script, error) {
+	slidesDir := filepath.Join(dataDir, "slides")
+	scriptPath := filepath.Join(dataDir, ScriptFile)
+	hasScript, err := afero.Exists(fs, scriptPath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to check script: %w", err)
+	}
+
+	markdownPath := filepath.Join(dataDir, MarkdownScriptFile)
+	hasMarkdown, err := afero.Exists(fs, markdownPath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to check script: %w", err)
+	}
+
+	if !hasScript && hasMarkdown {
+		texts, script, err := loadMarkdownScript(ctx, textService, markdownPath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		slides, err := slideService.LoadSlides(ctx, slidesDir)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to load slides: %w", err)
+		}
+		return texts, slides, script, nil
+	}
+
+	if !hasScript {
+		texts, script, err := loadTextScript(ctx, textService, filepath.Join(dataDir, "texts.txt"))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		slides, err := slideService.LoadSlides(ctx, slidesDir)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to load slides: %w", err)
+		}
+		return texts, slides, script, nil
+	}
+
+	script, err := LoadScript(fs, scriptPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	dirSlides, err := slideService.LoadSlides(ctx, slidesDir)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load slides: %w", err)
+	}
+
+	// Slides without a file take the slide at their position in data/slides
+	slides := make([]string, len(script.Slides))
+	for i, slide := range script.Slides {
+		if slide.Slide == "" {
+			if i >= len(dirSlides) {
+				return nil, nil, nil, fmt.Errorf("script slide %d names no slide file and %s has only %d slides", i+1, slidesDir, len(dirSlides))
+			}
+			slides[i] = dirSlides[i]
+			continue
+		}
+		path := filepath.Join(slidesDir, slide.Slide)
+		if _, err := fs.Stat(path); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, nil, nil, fmt.Errorf("script slide %d: slide file %s not found", i+1, path)
+			}
+			return nil, nil, nil, fmt.Errorf("script slide %d: %w", i+1, err)
+		}
+		slides[i] = path
+	}
+	return script.Texts(), slides, script, nil
+}
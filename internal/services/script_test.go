@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"gocreator/internal/interfaces"
+	"gocreator/internal/mocks"
+	"gocreator/internal/timeline"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testScript = `slides:
+  - narration: Welcome to the course.
+    slide: title.png
+    pause_after: 1.5
+    min_duration: 4
+    transition_out:
+      type: fade
+  - narration: |
+      Let's look at the architecture.
+    voice: nova
+    speed: 1.1
+    pause_before: 0.5
+    transition_out:
+      type: none
+    notes: Architecture is the product name, keep it in English.
+  - narration: Thanks for watching.
+`
+
+func TestLoadScript(t *testing.T) {
+	t.Run("valid script", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/data/script.yaml", []byte(testScript), 0644))
+
+		script, err := LoadScript(fs, "/data/script.yaml")
+		require.NoError(t, err)
+		require.Len(t, script.Slides, 3)
+		assert.Equal(t, []string{"Welcome to the course.", "Let's look at the architecture.\n", "Thanks for watching."}, script.Texts())
+		assert.Equal(t, "title.png", script.Slides[0].Slide)
+		assert.Equal(t, 4.0, script.Slides[0].MinDuration)
+		assert.Equal(t, "nova", script.Slides[1].Voice)
+		assert.Contains(t, script.Slides[1].Notes, "product name")
+	})
+
+	t.Run("silent slide", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/data/script.yaml", []byte("slides:\n  - narration: Hi\n  - slide: 2.png\n    duration: 5\n"), 0644))
+
+		script, err := LoadScript(fs, "/data/script.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Hi", ""}, script.Texts())
+		assert.Equal(t, 5.0, script.Slides[1].Duration)
+	})
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "no slides", content: "slides: []\n", wantErr: "no slides"},
+		{name: "unknown setting", content: "slides:\n  - narration: Hi\n    pause: 1\n", wantErr: "failed to parse script"},
+		{name: "negative duration", content: "slides:\n  - narration: Hi\n  - slide: 2.png\n    duration: -1\n", wantErr: "slide 2: duration must be non-negative"},
+		{name: "duration and minimum duration", content: "slides:\n  - narration: Hi\n    duration: 3\n    min_duration: 4\n", wantErr: "duration and min_duration are exclusive"},
+		{name: "speed out of range", content: "slides:\n  - narration: Hi\n    speed: 5\n", wantErr: "speed must be between"},
+		{name: "negative pause", content: "slides:\n  - narration: Hi\n    pause_after: -1\n", wantErr: "pauses must be non-negative"},
+		{name: "unknown transition", content: "slides:\n  - narration: Hi\n    transition_out:\n      type: spin\n", wantErr: "invalid transition type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "/data/script.yaml", []byte(tt.content), 0644))
+
+			_, err := LoadScript(fs, "/data/script.yaml")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestScript_ApplyTo(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/data/script.yaml", []byte(testScript), 0644))
+	script, err := LoadScript(fs, "/data/script.yaml")
+	require.NoError(t, err)
+
+	slides := []string{"/s/1.png", "/s/2.png", "/s/3.png"}
+	audio := []string{"/a/0.mp3", "/a/1.mp3", "/a/2.mp3"}
+	tl, err := buildTimeline(slides, script.Texts(), audio, VideoCreatorConfig{Transition: TransitionConfig{Type: TransitionWipeleft, Duration: 1}}, script)
+	require.NoError(t, err)
+
+	first := tl.Segments[0]
+	assert.Equal(t, 1.5, first.PadEnd)
+	assert.Equal(t, 4.0, first.MinDuration)
+	assert.Equal(t, &timeline.Transition{Type: "fade", Duration: 0.5}, first.TransitionOut, "the script replaces the project transition")
+
+	second := tl.Segments[1]
+	assert.Equal(t, 0.5, second.Audio[0].Offset)
+	assert.Nil(t, second.TransitionOut, "a transition of none is a hard cut")
+
+	assert.True(t, tl.Segments[2].IsSimple())
+
+	assert.Equal(t, []interfaces.Voice{{}, {Name: "nova", Speed: 1.1}, {}}, script.voices())
+	assert.Nil(t, script.selected([]int{0, 2}).voices(), "slides with the default voice need no voices")
+	assert.Nil(t, (*Script)(nil).voices())
+}
+
+func TestBuildTimeline_SilentSlides(t *testing.T) {
+	script := &Script{Slides: []ScriptSlide{
+		{Narration: "Hello", Duration: 2},
+		{PauseBefore: 1},
+		{MinDuration: 6},
+	}}
+	slides := []string{"/s/1.png", "/s/2.png", "/s/3.png"}
+	audio := []string{"/a/0.mp3", "", ""}
+
+	tl, err := buildTimeline(slides, script.Texts(), audio, VideoCreatorConfig{}, script)
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, tl.Segments[0].Duration, "a fixed duration applies to narrated slides")
+	assert.Empty(t, tl.Segments[1].Audio)
+	assert.Equal(t, DefaultSilentSlideDuration, tl.Segments[1].MinDuration, "silent slides are shown for the default duration")
+	assert.Equal(t, 6.0, tl.Segments[2].MinDuration, "the script times silent slides")
+	require.NoError(t, tl.Validate())
+
+	tl, err = buildTimeline(slides, script.Texts(), audio, VideoCreatorConfig{SilentSlideDuration: 1.5}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, tl.Segments[2].MinDuration)
+}
+
+func TestLoadLocalInputs(t *testing.T) {
+	ctx := context.Background()
+	logger := &mockLogger{}
+
+	t.Run("texts.txt without a script", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/p/data/texts.txt", []byte("One\n-\nTwo"), 0644))
+		mockSlide := new(mocks.MockSlideLoader)
+		mockSlide.On("LoadSlides", mock.Anything, "/p/data/slides").Return([]string{"/p/data/slides/1.png", "/p/data/slides/2.png"}, nil)
+
+		texts, slides, script, err := loadLocalInputs(ctx, fs, NewTextService(fs, logger), mockSlide, "/p/data")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"One", "Two"}, texts)
+		assert.Len(t, slides, 2)
+		assert.Nil(t, script)
+	})
+
+	t.Run("texts.txt with comments and metadata", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/p/data/texts.txt", []byte("# intro\n@voice: nova\nOne\n-\n@duration: 4s\n-\n\\# Two"), 0644))
+		mockSlide := new(mocks.MockSlideLoader)
+		mockSlide.On("LoadSlides", mock.Anything, "/p/data/slides").
+			Return([]string{"/p/data/slides/1.png", "/p/data/slides/2.png", "/p/data/slides/3.png"}, nil)
+
+		texts, _, script, err := loadLocalInputs(ctx, fs, NewTextService(fs, logger), mockSlide, "/p/data")
+		require.NoError(t, err)
+		require.NotNil(t, script)
+		assert.Equal(t, []string{"One", "", "# Two"}, script.speechTexts(texts))
+		assert.Equal(t, "nova", script.Slides[0].Voice)
+		assert.Equal(t, 4.0, script.Slides[1].Duration)
+		assert.Equal(t, "/p/data/cache/fr/text/texts.txt", languageTextsPath("/p/data", "fr", script))
+		assert.Equal(t, "# intro\n@voice: nova\nUn", script.translated(texts[0], "Un"))
+	})
+
+	t.Run("texts.txt with invalid metadata", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/p/data/texts.txt", []byte("One\n-\nTwo\n@speed: fast"), 0644))
+		mockSlide := new(mocks.MockSlideLoader)
+
+		_, _, _, err := loadLocalInputs(ctx, fs, NewTextService(fs, logger), mockSlide, "/p/data")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid texts /p/data/texts.txt: slide 2: line 2: @speed:")
+	})
+
+	t.Run("script naming some slides", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/p/data/script.yaml", []byte(testScript), 0644))
+		require.NoError(t, afero.WriteFile(fs, "/p/data/slides/title.png", []byte("png"), 0644))
+		mockSlide := new(mocks.MockSlideLoader)
+		mockSlide.On("LoadSlides", mock.Anything, "/p/data/slides").
+			Return([]string{"/p/data/slides/1.png", "/p/data/slides/2.png", "/p/data/slides/3.png"}, nil)
+
+		texts, slides, script, err := loadLocalInputs(ctx, fs, NewTextService(fs, logger), mockSlide, "/p/data")
+		require.NoError(t, err)
+		assert.Equal(t, script.Texts(), texts)
+		assert.Equal(t, []string{"/p/data/slides/title.png", "/p/data/slides/2.png", "/p/data/slides/3.png"}, slides)
+	})
+
+	t.Run("markdown script", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/p/data/script.md", []byte("## Intro\n\n**Hello**\n\n## Next\n\nBye"), 0644))
+		require.NoError(t, afero.WriteFile(fs, "/p/data/texts.txt", []byte("Ignored"), 0644))
+		mockSlide := new(mocks.MockSlideLoader)
+		mockSlide.On("LoadSlides", mock.Anything, "/p/data/slides").Return([]string{"/p/data/slides/1.png", "/p/data/slides/2.png"}, nil)
+
+		texts, slides, script, err := loadLocalInputs(ctx, fs, NewTextService(fs, logger), mockSlide, "/p/data")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"## Intro\n\n**Hello**", "## Next\n\nBye"}, texts)
+		assert.Len(t, slides, 2)
+		assert.Equal(t, []string{"*Hello*", "Bye"}, script.speechTexts(texts))
+		assert.Equal(t, []string{"Intro", "Next"}, script.titles(texts))
+		assert.Equal(t, "/p/data/cache/fr/text/script.md", languageTextsPath("/p/data", "fr", script))
+		assert.Nil(t, script.voices())
+	})
+
+	t.Run("markdown slide without narration is silent", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/p/data/script.md", []byte("## Intro\n\nHello\n\n## Empty\n"), 0644))
+		mockSlide := new(mocks.MockSlideLoader)
+		mockSlide.On("LoadSlides", mock.Anything, "/p/data/slides").Return([]string{"/p/data/slides/1.png", "/p/data/slides/2.png"}, nil)
+
+		texts, _, script, err := loadLocalInputs(ctx, fs, NewTextService(fs, logger), mockSlide, "/p/data")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Hello", ""}, script.speechTexts(texts))
+		assert.Equal(t, []string{"Intro", "Empty"}, script.titles(texts))
+	})
+
+	t.Run("missing slide file", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/p/data/script.yaml", []byte(testScript), 0644))
+		mockSlide := new(mocks.MockSlideLoader)
+		mockSlide.On("LoadSlides", mock.Anything, "/p/data/slides").Return([]string{}, nil)
+
+		_, _, _, err := loadLocalInputs(ctx, fs, NewTextService(fs, logger), mockSlide, "/p/data")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "script slide 1: slide file /p/data/slides/title.png not found")
+	})
+}
+
+func TestVideoCreator_Create_WithScript(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	logger := &mockLogger{}
+	mockText := new(mocks.MockTextProcessor)
+	mockTranslation := new(mocks.MockTranslator)
+	mockAudio := new(mocks.MockAudioGenerator)
+	mockVideo := new(mocks.MockVideoGenerator)
+	mockSlide := new(mocks.MockSlideLoader)
+
+	require.NoError(t, afero.WriteFile(fs, "/test/data/script.yaml", []byte(testScript), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/test/data/slides/title.png", []byte("png"), 0644))
+	dirSlides := []string{"/test/data/slides/1.png", "/test/data/slides/2.png", "/test/data/slides/3.png"}
+	slides := []string{"/test/data/slides/title.png", dirSlides[1], dirSlides[2]}
+	audioPaths := []string{"/test/data/cache/en/audio/0.mp3", "/test/data/cache/en/audio/1.mp3", "/test/data/cache/en/audio/2.mp3"}
+
+	script, err := LoadScript(fs, "/test/data/script.yaml")
+	require.NoError(t, err)
+	expected, err := buildTimeline(slides, script.Texts(), audioPaths, VideoCreatorConfig{Transition: TransitionConfig{Type: TransitionNone}}, script)
+	require.NoError(t, err)
+
+	mockSlide.On("LoadSlides", mock.Anything, "/test/data/slides").Return(dirSlides, nil)
+	mockAudio.On("GenerateBatch", mock.Anything, script.Texts(), script.voices(), "/test/data/cache/en/audio").
+		Return(audioPaths, nil)
+	mockVideo.On("GenerateFromTimeline", mock.Anything, expected, "/test/data/out/output-en.mp4").Return(nil)
+
+	creator := NewVideoCreator(fs, mockText, mockTranslation, mockAudio, mockVideo, mockSlide, logger)
+	err = creator.Create(context.Background(), VideoCreatorConfig{
+		RootDir:     "/test",
+		InputLang:   "en",
+		OutputLangs: []string{"en"},
+	})
+	require.NoError(t, err)
+
+	// The script replaces texts.txt
+	mockText.AssertNotCalled(t, "Load", mock.Anything, mock.Anything)
+	mockAudio.AssertExpectations(t)
+	mockVideo.AssertExpectations(t)
+}
@@ -11,6 +11,23 @@ import (
 	"github.com/spf13/afero"
 )
 
+// slideExtensions are the extensions of the files LoadSlides reads as slides, others are skipped
+var slideExtensions = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".mp4":  true, // Support video files
+	".mov":  true,
+	".avi":  true,
+	".mkv":  true,
+	".webm": true,
+}
+
+// isSlideFile reports whether the file name has the extension of a slide
+func isSlideFile(name string) bool {
+	return slideExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
 // SlideService handles slide loading
 type SlideService struct {
 	fs     afero.Fs
@@ -44,23 +61,11 @@ func (s *SlideService) LoadSlides(ctx context.Context, dir string) ([]string, er
 	}
 
 	var slides []string
-	validExts := map[string]bool{
-		".png":  true,
-		".jpg":  true,
-		".jpeg": true,
-		".mp4":  true, // Support video files
-		".mov":  true,
-		".avi":  true,
-		".mkv":  true,
-		".webm": true,
-	}
-
 	for _, file := range files {
 		if file.IsDir() {
 			continue
 		}
-		ext := strings.ToLower(filepath.Ext(file.Name()))
-		if validExts[ext] {
+		if isSlideFile(file.Name()) {
 			slides = append(slides, filepath.Join(dir, file.Name()))
 		}
 	}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gocreator/internal/interfaces"
+)
+
+const (
+	// slowRate is the speed of [slow]…[/slow] passages, relative to the voice
+	slowRate = 0.75
+	// emphasisRate is the speed of *emphasized* words, spoken as their own phrase
+	emphasisRate = 0.9
+)
+
+// speechMarkup matches the inline directives of narration:
+// [pause 800ms], [slow], [/slow], word{say: "spoken form"} and *emphasis*
+var speechMarkup = regexp.MustCompile(`\[pause\s+(\d+(?:\.\d+)?)(ms|s)\]|\[(/?)slow\]|([^\s{}]*)\{say:\s*"([^"]*)"\}|\*([^*\s](?:[^*]*[^*\s])?)\*`)
+
+// Submatch indices of speechMarkup
+const (
+	markupPauseValue = 1
+	markupPauseUnit  = 2
+	markupSlowClose  = 3
+	markupSayWord    = 4
+	markupSaySpoken  = 5
+	markupEmphasis   = 6
+)
+
+// speechPart is one piece of narration rendered on its own: spoken text or a pause
+type speechPart struct {
+	Text  string  // Spoken text, empty for a pause
+	Pause float64 // Silence, in seconds
+	Rate  float64 // Speed relative to the voice, 1 for normal speech
+}
+
+// isPlain reports whether the part is spoken as is, without markup
+func (p speechPart) isPlain() bool {
+	return p.Pause == 0 && p.Rate == 1
+}
+
+// voice returns the voice speaking the part
+func (p speechPart) voice(voice interfaces.Voice) interfaces.Voice {
+	if p.Rate == 1 {
+		return voice
+	}
+	speed := voice.Speed
+	if speed == 0 {
+		speed = 1
+	}
+	speed = min(max(speed*p.Rate, 0.25), 4.0)
+	return interfaces.Voice{Name: voice.Name, Speed: speed}
+}
+
+// markupSpan returns the text of submatch group of match m in text, "" when the group did not match
+func markupSpan(text string, m []int, group int) (string, bool) {
+	if m[2*group] < 0 {
+		return "", false
+	}
+	return text[m[2*group]:m[2*group+1]], true
+}
+
+// parseSpeechMarkup splits narration into the parts rendered by separate speech calls and silences.
+// Narration without markup is a single plain part holding the text unchanged.
+func parseSpeechMarkup(text string) []speechPart {
+	matches := speechMarkup.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return []speechPart{{Text: text, Rate: 1}}
+	}
+
+	var parts []speechPart
+	slow := false
+	rate := func() float64 {
+		if slow {
+			return slowRate
+		}
+		return 1
+	}
+	speak := func(spoken string, rate float64) {
+		if last := len(parts) - 1; last >= 0 && parts[last].Pause == 0 && parts[last].Rate == rate {
+			parts[last].Text += spoken
+			return
+		}
+		parts = append(parts, speechPart{Text: spoken, Rate: rate})
+	}
+
+	end := 0
+	for _, m := range matches {
+		speak(text[end:m[0]], rate())
+		end = m[1]
+
+		if value, ok := markupSpan(text, m, markupPauseValue); ok {
+			seconds, _ := strconv.ParseFloat(value, 64) // Matched digits always parse
+			if unit, _ := markupSpan(text, m, markupPauseUnit); unit == "ms" {
+				seconds /= 1000
+			}
+			parts = append(parts, speechPart{Pause: seconds})
+			continue
+		}
+		if closing, ok := markupSpan(text, m, markupSlowClose); ok {
+			slow = closing == ""
+			continue
+		}
+		if spoken, ok := markupSpan(text, m, markupSaySpoken); ok {
+			speak(spoken, rate())
+			continue
+		}
+		emphasized, _ := markupSpan(text, m, markupEmphasis)
+		speak(emphasized, min(rate(), emphasisRate))
+	}
+	speak(text[end:], rate())
+
+	// Whitespace around directives is not spoken
+	spoken := parts[:0]
+	for _, part := range parts {
+		part.Text = strings.TrimSpace(part.Text)
+		if part.Text != "" || part.Pause > 0 {
+			spoken = append(spoken, part)
+		}
+	}
+	return spoken
+}
+
+// speechCost returns the number of speech requests and characters spoken for narration
+func speechCost(text string) (int, int) {
+	requests, chars := 0, 0
+	for _, part := range parseSpeechMarkup(text) {
+		if part.Pause == 0 {
+			requests++
+			chars += utf8.RuneCountInString(part.Text)
+		}
+	}
+	return requests, chars
+}
+
+// displayText returns narration as shown in subtitles, transcripts and chapter titles,
+// without its speech directives
+func displayText(text string) string {
+	if !speechMarkup.MatchString(text) {
+		return text
+	}
+	display := speechMarkup.ReplaceAllStringFunc(text, func(directive string) string {
+		m := speechMarkup.FindStringSubmatchIndex(directive)
+		if word, ok := markupSpan(directive, m, markupSayWord); ok {
+			return word
+		}
+		if emphasized, ok := markupSpan(directive, m, markupEmphasis); ok {
+			return emphasized
+		}
+		return ""
+	})
+
+	// Drop the spaces left around removed directives
+	lines := strings.Split(display, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Placeholders standing for directives during translation, numbered from 1
+const (
+	placeholderOpen  = "⟦"
+	placeholderClose = "⟧"
+)
+
+// protectMarkup replaces the directives of text with numbered placeholders a translation keeps as is.
+// It returns the directive of every placeholder, none for text without markup.
+func protectMarkup(text string) (string, []string) {
+	var directives []string
+	placeholder := func(directive string) string {
+		directives = append(directives, directive)
+		return fmt.Sprintf("%s%d%s", placeholderOpen, len(directives), placeholderClose)
+	}
+
+	protected := speechMarkup.ReplaceAllStringFunc(text, func(directive string) string {
+		m := speechMarkup.FindStringSubmatchIndex(directive)
+		// The words around directives are translated: the emphasized words and the displayed word of a say
+		if emphasized, ok := markupSpan(directive, m, markupEmphasis); ok {
+			return placeholder("*") + emphasized + placeholder("*")
+		}
+		if word, ok := markupSpan(directive, m, markupSayWord); ok {
+			return word + placeholder(directive[len(word):])
+		}
+		return placeholder(directive)
+	})
+	return protected, directives
+}
+
+// restoreMarkup puts the directives back in place of the placeholders of a translation.
+// Every placeholder must appear exactly once, or the translation lost part of the markup.
+func restoreMarkup(translated string, directives []string) (string, error) {
+	for i, directive := range directives {
+		placeholder := fmt.Sprintf("%s%d%s", placeholderOpen, i+1, placeholderClose)
+		if count := strings.Count(translated, placeholder); count != 1 {
+			return "", fmt.Errorf("translation has %d copies of placeholder %s for %q, expected 1", count, placeholder, directive)
+		}
+		translated = strings.Replace(translated, placeholder, directive, 1)
+	}
+	return translated, nil
+}
+
+// speechConcatArgs builds the ffmpeg arguments joining the parts of a narration into outputPath.
+// Spoken parts are read from partPaths, pauses are generated silence.
+func speechConcatArgs(parts []speechPart, partPaths []string, outputPath string) []string {
+	args := []string{"-y"}
+	for i, part := range parts {
+		if part.Pause > 0 {
+			args = append(args, "-f", "lavfi", "-t", fmt.Sprintf("%.3f", part.Pause), "-i", "anullsrc=r=24000:cl=mono")
+			continue
+		}
+		args = append(args, "-i", partPaths[i])
+	}
+
+	var filter strings.Builder
+	for i := range parts {
+		fmt.Fprintf(&filter, "[%d:a]", i)
+	}
+	fmt.Fprintf(&filter, "concat=n=%d:v=0:a=1[outa]", len(parts))
+
+	return append(args, "-filter_complex", filter.String(), "-map", "[outa]", "-c:a", "libmp3lame", outputPath)
+}
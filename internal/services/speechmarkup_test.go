@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"gocreator/internal/interfaces"
+	"gocreator/internal/mocks"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSpeechMarkup(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []speechPart
+	}{
+		{
+			name:     "plain text is unchanged",
+			text:     "  Hello, world ",
+			expected: []speechPart{{Text: "  Hello, world ", Rate: 1}},
+		},
+		{
+			name: "pauses",
+			text: "First. [pause 800ms] Second. [pause 1.5s]",
+			expected: []speechPart{
+				{Text: "First.", Rate: 1},
+				{Pause: 0.8},
+				{Text: "Second.", Rate: 1},
+				{Pause: 1.5},
+			},
+		},
+		{
+			name:     "spoken form replaces the word",
+			text:     "We use SQL{say: \"S-Q-L\"} daily",
+			expected: []speechPart{{Text: "We use S-Q-L daily", Rate: 1}},
+		},
+		{
+			name: "emphasis and slow passages",
+			text: "This is *really* important. [slow]Read it *twice*.[/slow] Done",
+			expected: []speechPart{
+				{Text: "This is", Rate: 1},
+				{Text: "really", Rate: emphasisRate},
+				{Text: "important.", Rate: 1},
+				{Text: "Read it twice.", Rate: slowRate},
+				{Text: "Done", Rate: 1},
+			},
+		},
+		{
+			name:     "arithmetic is not emphasis",
+			text:     "2 * 3 * 4",
+			expected: []speechPart{{Text: "2 * 3 * 4", Rate: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseSpeechMarkup(tt.text))
+		})
+	}
+}
+
+func TestSpeechPart_Voice(t *testing.T) {
+	assert.Equal(t, interfaces.Voice{}, speechPart{Rate: 1}.voice(interfaces.Voice{}))
+	assert.Equal(t, interfaces.Voice{Speed: 0.75}, speechPart{Rate: slowRate}.voice(interfaces.Voice{}))
+	assert.Equal(t, interfaces.Voice{Name: "nova", Speed: 0.25}, speechPart{Rate: slowRate}.voice(interfaces.Voice{Name: "nova", Speed: 0.3}))
+}
+
+func TestSpeechCost(t *testing.T) {
+	requests, chars := speechCost("Hello [pause 1s] *world*")
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 10, chars)
+}
+
+func TestDisplayText(t *testing.T) {
+	assert.Equal(t, "Plain  text", displayText("Plain  text"))
+	assert.Equal(t, "We use SQL, really.\nThen slowly.",
+		displayText("We use SQL{say: \"S-Q-L\"}, *really*. [pause 800ms]\n[slow]Then slowly.[/slow]"))
+}
+
+func TestProtectAndRestoreMarkup(t *testing.T) {
+	text := "Use SQL{say: \"S-Q-L\"} [pause 1s] *carefully*"
+	protected, directives := protectMarkup(text)
+	assert.Equal(t, "Use SQL⟦1⟧ ⟦2⟧ ⟦3⟧carefully⟦4⟧", protected)
+
+	restored, err := restoreMarkup("Utilisez SQL⟦1⟧ ⟦2⟧ ⟦3⟧prudemment⟦4⟧", directives)
+	require.NoError(t, err)
+	assert.Equal(t, "Utilisez SQL{say: \"S-Q-L\"} [pause 1s] *prudemment*", restored)
+
+	_, err = restoreMarkup("Utilisez SQL⟦1⟧ prudemment", directives)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "placeholder ⟦2⟧")
+
+	protected, directives = protectMarkup("No markup")
+	assert.Equal(t, "No markup", protected)
+	assert.Empty(t, directives)
+}
+
+func TestTranslationService_Translate_KeepsMarkup(t *testing.T) {
+	mockClient := new(mocks.MockOpenAIClient)
+	service := NewTranslationService(mockClient, &mockLogger{})
+
+	mockClient.On("ChatCompletion", mock.Anything, mock.Anything, interfaces.ChatOptions{}).Return("Bonjour ⟦1⟧ tout le monde", nil).Once()
+	translated, err := service.Translate(context.Background(), "Hello [pause 500ms] everyone", "fr")
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour [pause 500ms] tout le monde", translated)
+
+	mockClient.On("ChatCompletion", mock.Anything, mock.Anything, interfaces.ChatOptions{}).Return("Salut tout le monde", nil).Once()
+	_, err = service.Translate(context.Background(), "Hi [pause 500ms] everyone", "fr")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "lost speech markup")
+	_, cached := service.Cached(context.Background(), "Hi [pause 500ms] everyone", "fr")
+	assert.False(t, cached, "a translation without its markup is not cached")
+}
+
+func TestSpeechConcatArgs(t *testing.T) {
+	parts := []speechPart{{Text: "One", Rate: 1}, {Pause: 0.8}, {Text: "Two", Rate: slowRate}}
+	args := speechConcatArgs(parts, []string{"/a/0.part0.mp3", "", "/a/0.part2.mp3"}, "/a/0.render.mp3")
+	assert.Equal(t, []string{
+		"-y",
+		"-i", "/a/0.part0.mp3",
+		"-f", "lavfi", "-t", "0.800", "-i", "anullsrc=r=24000:cl=mono",
+		"-i", "/a/0.part2.mp3",
+		"-filter_complex", "[0:a][1:a][2:a]concat=n=3:v=0:a=1[outa]",
+		"-map", "[outa]", "-c:a", "libmp3lame", "/a/0.render.mp3",
+	}, args)
+}
+
+func TestAudioService_Generate_SpokenForm(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockClient := new(mocks.MockOpenAIClient)
+	logger := &mockLogger{}
+	service := NewAudioService(fs, mockClient, NewTextService(fs, logger), logger)
+
+	// A spoken form alone needs no pauses, so it is still a single speech request
+	mockClient.On("GenerateSpeech", mock.Anything, "Learn S-Q-L", interfaces.Voice{}).Return(newMockReadCloser("audio"), nil)
+	require.NoError(t, service.Generate(context.Background(), "Learn SQL{say: \"S-Q-L\"}", interfaces.Voice{}, "/output/audio.mp3"))
+
+	data, err := afero.ReadFile(fs, "/output/audio.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, "audio", string(data))
+	mockClient.AssertExpectations(t)
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"path/filepath"
 	"strings"
 
@@ -28,45 +29,86 @@ func NewTextService(fs afero.Fs, logger interfaces.Logger) *TextService {
 	}
 }
 
-// Load loads texts from a file, splitting by "-" delimiter
+// Load loads texts from a file, splitting by "-" delimiter.
+// Markdown files (.md) are split into one text per slide instead, see parseMarkdownSlides.
 func (s *TextService) Load(ctx context.Context, path string) ([]string, error) {
+	if isMarkdown(path) {
+		data, err := afero.ReadFile(s.fs, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file: %w", err)
+		}
+		return parseMarkdownSlides(string(data)), nil
+	}
+
 	file, err := s.fs.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer func() { _ = file.Close() }()
 
+	blocks, err := parseTextBlocks(file)
+	if err != nil {
+		return nil, err
+	}
+
 	texts := make([]string, 0) // Initialize as empty slice to ensure []string{} not nil
+	for _, block := range blocks {
+		if !block.empty {
+			texts = append(texts, block.Text)
+		}
+	}
+	return texts, nil
+}
+
+// textBlock is the narration of one slide and the line of its file it starts on
+type textBlock struct {
+	Text  string
+	Line  int
+	empty bool // Nothing between two delimiters, skipped when loading
+}
+
+// parseTextBlocks splits a texts.txt narration on its "-" delimiter lines. Empty blocks are kept,
+// marked, at the line of the delimiter closing them.
+func parseTextBlocks(r io.Reader) ([]textBlock, error) {
+	var blocks []textBlock
 	var current strings.Builder
-	scanner := bufio.NewScanner(file)
+	lineNo, start := 0, 0
+	scanner := bufio.NewScanner(r)
 
 	for scanner.Scan() {
+		lineNo++
 		line := scanner.Text()
 		if line == "-" {
 			if current.Len() > 0 {
-				texts = append(texts, strings.TrimSpace(current.String()))
+				blocks = append(blocks, textBlock{Text: strings.TrimSpace(current.String()), Line: start})
 				current.Reset()
+			} else {
+				blocks = append(blocks, textBlock{Line: lineNo, empty: true})
 			}
 		} else {
 			if current.Len() > 0 {
 				current.WriteString("\n")
+			} else {
+				start = lineNo
 			}
 			current.WriteString(line)
 		}
 	}
 
 	if current.Len() > 0 {
-		texts = append(texts, strings.TrimSpace(current.String()))
+		blocks = append(blocks, textBlock{Text: strings.TrimSpace(current.String()), Line: start})
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("error reading file: %w", err)
 	}
 
-	return texts, nil
+	return blocks, nil
 }
 
-// Save saves texts to a file with "-" delimiter
+// Save saves texts to a file with "-" delimiter, in the format Load reads them back: narrated lines
+// that are a bare "-" are escaped, and an empty text is saved as a space to keep its place.
+// Markdown files (.md) are saved in the format Load reads them.
 func (s *TextService) Save(ctx context.Context, path string, texts []string) error {
 	// Ensure directory exists
 	dir := filepath.Dir(path)
@@ -74,21 +116,31 @@ func (s *TextService) Save(ctx context.Context, path string, texts []string) err
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	file, err := s.fs.Create(path)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+	if isMarkdown(path) {
+		if err := writeFileAtomic(s.fs, path, []byte(formatMarkdownSlides(texts)), 0644); err != nil {
+			return fmt.Errorf("failed to write text: %w", err)
+		}
+		return nil
 	}
-	defer func() { _ = file.Close() }()
 
+	var content strings.Builder
 	for i, text := range texts {
-		if _, err := file.WriteString(text); err != nil {
-			return fmt.Errorf("failed to write text: %w", err)
+		if text == "" {
+			text = " " // An empty block is skipped on load
 		}
-		if i < len(texts)-1 {
-			if _, err := file.WriteString("\n-\n"); err != nil {
-				return fmt.Errorf("failed to write delimiter: %w", err)
+		lines := strings.Split(text, "\n")
+		for j, line := range lines {
+			if line == "-" {
+				lines[j] = `\-`
 			}
 		}
+		content.WriteString(strings.Join(lines, "\n"))
+		if i < len(texts)-1 {
+			content.WriteString("\n-\n")
+		}
+	}
+	if err := writeFileAtomic(s.fs, path, []byte(content.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write text: %w", err)
 	}
 
 	return nil
@@ -107,16 +159,13 @@ func (s *TextService) SaveHashes(ctx context.Context, path string, hashes []stri
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	file, err := s.fs.Create(path)
-	if err != nil {
-		return fmt.Errorf("failed to create hash file: %w", err)
+	var content strings.Builder
+	for _, hash := range hashes {
+		content.WriteString(hash + "\n")
 	}
-	defer func() { _ = file.Close() }()
 
-	for _, hash := range hashes {
-		if _, err := file.WriteString(hash + "\n"); err != nil {
-			return fmt.Errorf("failed to write hash: %w", err)
-		}
+	if err := writeFileAtomic(s.fs, path, []byte(content.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write hash file: %w", err)
 	}
 
 	return nil
@@ -110,6 +110,11 @@ func TestTextService_Save(t *testing.T) {
 			texts:    []string{"Line 1\nLine 2", "Another text"},
 			expected: "Line 1\nLine 2\n-\nAnother text",
 		},
+		{
+			name:     "annotated and empty texts",
+			texts:    []string{"# note\n@voice: nova\nHi\n-\n\\# not a comment", "", "Bye"},
+			expected: "# note\n@voice: nova\nHi\n\\-\n\\# not a comment\n-\n \n-\nBye",
+		},
 	}
 
 	for _, tt := range tests {
@@ -133,6 +138,25 @@ func TestTextService_Save(t *testing.T) {
 	}
 }
 
+func TestTextService_Save_Replaces(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	service := NewTextService(fs, &mockLogger{})
+	ctx := context.Background()
+
+	require.NoError(t, service.Save(ctx, "/data/texts.txt", []string{"A longer first text", "Second"}))
+	require.NoError(t, service.Save(ctx, "/data/texts.txt", []string{"Short"}))
+
+	content, err := afero.ReadFile(fs, "/data/texts.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "Short", string(content))
+
+	// The texts are written aside and renamed, no temporary file is left
+	entries, err := afero.ReadDir(fs, "/data")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "texts.txt", entries[0].Name())
+}
+
 func TestTextService_Hash(t *testing.T) {
 	fs := afero.NewMemMapFs()
 	logger := &mockLogger{}
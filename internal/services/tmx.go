@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// tmxDocument is a TMX 1.4 file
+type tmxDocument struct {
+	XMLName xml.Name  `xml:"tmx"`
+	Version string    `xml:"version,attr"`
+	Header  tmxHeader `xml:"header"`
+	Body    struct {
+		Units []tmxUnit `xml:"tu"`
+	} `xml:"body"`
+}
+
+type tmxHeader struct {
+	CreationTool        string `xml:"creationtool,attr"`
+	CreationToolVersion string `xml:"creationtoolversion,attr"`
+	SegType             string `xml:"segtype,attr"`
+	OTmf                string `xml:"o-tmf,attr"`
+	AdminLang           string `xml:"adminlang,attr"`
+	SrcLang             string `xml:"srclang,attr"`
+	DataType            string `xml:"datatype,attr"`
+}
+
+type tmxUnit struct {
+	SrcLang  string       `xml:"srclang,attr,omitempty"`
+	Variants []tmxVariant `xml:"tuv"`
+}
+
+type tmxVariant struct {
+	Lang      string `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	LegacyLng string `xml:"lang,attr,omitempty"` // TMX 1.1 and 1.2
+	Seg       tmxSeg `xml:"seg"`
+}
+
+// tmxSeg is the text of a segment. Inline markup, such as the bpt and ept tags of formatting,
+// is dropped on import: only the text is kept.
+type tmxSeg struct {
+	Inner string `xml:",innerxml"`
+}
+
+// lang returns the language of the variant, in either attribute
+func (v tmxVariant) lang() string {
+	if v.Lang != "" {
+		return v.Lang
+	}
+	return v.LegacyLng
+}
+
+// text returns the text of the segment without its inline markup
+func (s tmxSeg) text() (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader("<seg>" + s.Inner + "</seg>"))
+	var text strings.Builder
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return text.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			// The content of inline tags is native code of the original format, not text
+			if depth == 1 {
+				text.Write(t)
+			}
+		}
+	}
+}
+
+// ImportTMX adds the translation units of a TMX file to the memory, from the source language of each
+// unit (the header's unless it sets one) to every other language of the unit. It returns the number
+// of segments added or updated.
+func (m *TranslationMemory) ImportTMX(r io.Reader) (int, error) {
+	var doc tmxDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return 0, fmt.Errorf("failed to parse TMX: %w", err)
+	}
+
+	count := 0
+	for i, unit := range doc.Body.Units {
+		srcLang := unit.SrcLang
+		if srcLang == "" {
+			srcLang = doc.Header.SrcLang
+		}
+		if srcLang == "" || srcLang == "*all*" {
+			return count, fmt.Errorf("translation unit %d: no source language", i+1)
+		}
+
+		var source string
+		found := false
+		for _, variant := range unit.Variants {
+			if normalizeLang(variant.lang()) == normalizeLang(srcLang) {
+				text, err := variant.Seg.text()
+				if err != nil {
+					return count, fmt.Errorf("translation unit %d: %w", i+1, err)
+				}
+				source, found = text, true
+				break
+			}
+		}
+		if !found {
+			continue // No variant in the source language to translate from
+		}
+		for _, variant := range unit.Variants {
+			if normalizeLang(variant.lang()) == normalizeLang(srcLang) {
+				continue
+			}
+			target, err := variant.Seg.text()
+			if err != nil {
+				return count, fmt.Errorf("translation unit %d: %w", i+1, err)
+			}
+			if strings.TrimSpace(target) == "" {
+				continue
+			}
+			m.Add(MemorySegment{SourceLang: srcLang, TargetLang: variant.lang(), Source: source, Target: target, Origin: MemoryOriginTMX})
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ExportTMX writes the segments from sourceLang to targetLang as a TMX 1.4 file, of every language
+// when empty. It returns the number of segments written.
+func (m *TranslationMemory) ExportTMX(w io.Writer, sourceLang, targetLang string) (int, error) {
+	doc := tmxDocument{
+		Version: "1.4",
+		Header: tmxHeader{
+			CreationTool:        "gocreator",
+			CreationToolVersion: "1",
+			SegType:             "sentence",
+			OTmf:                "gocreator",
+			AdminLang:           "en",
+			SrcLang:             sourceLang,
+			DataType:            "plaintext",
+		},
+	}
+	if sourceLang == "" {
+		doc.Header.SrcLang = "*all*"
+	}
+	segments := m.Segments(sourceLang, targetLang)
+	for _, segment := range segments {
+		source, target := tmxSeg{}, tmxSeg{}
+		if err := source.set(segment.Source); err != nil {
+			return 0, err
+		}
+		if err := target.set(segment.Target); err != nil {
+			return 0, err
+		}
+		unit := tmxUnit{Variants: []tmxVariant{
+			{Lang: segment.SourceLang, Seg: source},
+			{Lang: segment.TargetLang, Seg: target},
+		}}
+		if sourceLang == "" {
+			unit.SrcLang = segment.SourceLang
+		}
+		doc.Body.Units = append(doc.Body.Units, unit)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return 0, err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return 0, fmt.Errorf("failed to write TMX: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return len(segments), err
+}
+
+// set sets the segment to text, escaped
+func (s *tmxSeg) set(text string) error {
+	var escaped bytes.Buffer
+	if err := xml.EscapeText(&escaped, []byte(text)); err != nil {
+		return err
+	}
+	s.Inner = escaped.String()
+	return nil
+}
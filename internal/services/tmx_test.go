@@ -0,0 +1,71 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslationMemory_ImportTMX(t *testing.T) {
+	memory, err := OpenTranslationMemory(afero.NewMemMapFs(), "/memory.json")
+	require.NoError(t, err)
+
+	count, err := memory.ImportTMX(strings.NewReader(`<?xml version="1.0"?>
+<tmx version="1.4">
+  <header srclang="en" datatype="html"/>
+  <body>
+    <tu>
+      <tuv xml:lang="en"><seg>Click <bpt i="1">&lt;b&gt;</bpt>Save<ept i="1">&lt;/b&gt;</ept> &amp; quit.</seg></tuv>
+      <tuv xml:lang="fr"><seg>Cliquez sur <bpt i="1">&lt;b&gt;</bpt>Enregistrer<ept i="1">&lt;/b&gt;</ept> et quittez.</seg></tuv>
+    </tu>
+    <tu srclang="de">
+      <tuv lang="de"><seg>Hallo</seg></tuv>
+      <tuv lang="en"><seg>Hello</seg></tuv>
+      <tuv lang="fr"><seg></seg></tuv>
+    </tu>
+  </body>
+</tmx>`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Inline markup is dropped, the text is kept
+	translated, ok := memory.Exact("en", "fr", "Click Save & quit.", "")
+	assert.True(t, ok)
+	assert.Equal(t, "Cliquez sur Enregistrer et quittez.", translated)
+	translated, ok = memory.Exact("de", "en", "Hallo", "")
+	assert.True(t, ok)
+	assert.Equal(t, "Hello", translated)
+
+	_, err = memory.ImportTMX(strings.NewReader(`<tmx version="1.4"><header srclang="*all*"/><body><tu><tuv xml:lang="en"><seg>Hi</seg></tuv></tu></body></tmx>`))
+	assert.EqualError(t, err, "translation unit 1: no source language")
+	_, err = memory.ImportTMX(strings.NewReader(`<tmx>`))
+	assert.ErrorContains(t, err, "failed to parse TMX")
+}
+
+func TestTranslationMemory_ExportTMX(t *testing.T) {
+	memory, err := OpenTranslationMemory(afero.NewMemMapFs(), "/memory.json")
+	require.NoError(t, err)
+	memory.Add(MemorySegment{SourceLang: "en", TargetLang: "fr", Source: `Say "hi" <now>`, Target: `Dites « salut » <maintenant>`})
+	memory.Add(MemorySegment{SourceLang: "en", TargetLang: "de", Source: "Hello", Target: "Hallo"})
+
+	var out bytes.Buffer
+	count, err := memory.ExportTMX(&out, "en", "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Contains(t, out.String(), `<header creationtool="gocreator" creationtoolversion="1" segtype="sentence" o-tmf="gocreator" adminlang="en" srclang="en" datatype="plaintext"></header>`)
+	assert.Contains(t, out.String(), `<seg>Say &#34;hi&#34; &lt;now&gt;</seg>`)
+
+	// What is exported imports back
+	imported, err := OpenTranslationMemory(afero.NewMemMapFs(), "/memory.json")
+	require.NoError(t, err)
+	count, err = imported.ImportTMX(&out)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	translated, ok := imported.Exact("en", "fr", `Say "hi" <now>`, "")
+	assert.True(t, ok)
+	assert.Equal(t, `Dites « salut » <maintenant>`, translated)
+}
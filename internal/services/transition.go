@@ -1,6 +1,10 @@
 package services
 
-import "fmt"
+import (
+	"fmt"
+
+	"gocreator/internal/timeline"
+)
 
 // TransitionType defines the type of transition between slides
 type TransitionType string
@@ -123,3 +127,22 @@ func (tc TransitionConfig) GetFFmpegTransitionName() string {
 		return ""
 	}
 }
+
+// ApplyTo sets the transition between every pair of consecutive segments of a timeline.
+// It does nothing when transitions are disabled.
+func (tc TransitionConfig) ApplyTo(tl *timeline.Timeline) {
+	if !tc.IsEnabled() {
+		return
+	}
+	for i := 0; i < len(tl.Segments)-1; i++ {
+		tl.Segments[i].TransitionOut = &timeline.Transition{Type: string(tc.Type), Duration: tc.Duration}
+	}
+}
+
+// transitionFromTimeline converts a timeline transition, nil meaning a hard cut
+func transitionFromTimeline(t *timeline.Transition) TransitionConfig {
+	if t == nil {
+		return TransitionConfig{Type: TransitionNone}
+	}
+	return TransitionConfig{Type: TransitionType(t.Type), Duration: t.Duration}
+}
@@ -6,22 +6,35 @@ import (
 	"encoding/hex"
 	"fmt"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
+	"unicode"
 
 	"gocreator/internal/interfaces"
 
-	"github.com/openai/openai-go/v3"
 	"github.com/spf13/afero"
 )
 
-// TranslationService handles text translation with caching
+// TranslationService handles text translation with caching. It translates with OpenAI
+// unless providers route a language to another translation provider.
 type TranslationService struct {
-	client       interfaces.OpenAIClient
-	logger       interfaces.Logger
-	fs           afero.Fs
-	memoryCache  map[string]string
-	cacheMutex   sync.RWMutex
-	cacheDir     string
+	client        interfaces.OpenAIClient
+	logger        interfaces.Logger
+	fs            afero.Fs
+	memoryCache   map[string]string
+	cacheMutex    *sync.RWMutex
+	cacheDir      string
+	scheduler     *Scheduler
+	providers     *TranslationProviders
+	deckChunkSize int // Slides per request in deck mode, 0 to translate each slide on its own
+	glossary      *Glossary
+	memory        *TranslationMemory
+	sourceLang    string  // Language translated from, of the segments recorded in memory, of back translations and of prompts
+	fuzzy         float64 // Similarity of the fuzzy matches of memory offered to the translator
+	qa            *TranslationQA
+	prompt        *TranslationPrompt
 }
 
 // NewTranslationService creates a new translation service
@@ -30,6 +43,8 @@ func NewTranslationService(client interfaces.OpenAIClient, logger interfaces.Log
 		client:      client,
 		logger:      logger,
 		memoryCache: make(map[string]string),
+		cacheMutex:  &sync.RWMutex{},
+		scheduler:   NewScheduler(0, 0),
 	}
 }
 
@@ -40,13 +55,120 @@ func NewTranslationServiceWithCache(client interfaces.OpenAIClient, logger inter
 		logger:      logger,
 		fs:          fs,
 		memoryCache: make(map[string]string),
+		cacheMutex:  &sync.RWMutex{},
 		cacheDir:    cacheDir,
+		scheduler:   NewScheduler(0, 0),
 	}
 }
 
-// getCacheKey generates a cache key from text and target language
-func (s *TranslationService) getCacheKey(text, targetLang string) string {
+// SetScheduler sets the scheduler bounding concurrent API calls
+func (s *TranslationService) SetScheduler(scheduler *Scheduler) {
+	s.scheduler = scheduler
+}
+
+// WithProviders returns a translation service translating with providers, sharing the caches
+// and scheduler of s
+func (s *TranslationService) WithProviders(providers *TranslationProviders) *TranslationService {
+	routed := *s
+	routed.providers = providers
+	return &routed
+}
+
+// WithDeck returns a translation service translating the slides of a language translated with
+// OpenAI in deck mode, chunkSize slides per request (DefaultDeckChunkSize when 0), sharing the
+// caches and scheduler of s
+func (s *TranslationService) WithDeck(chunkSize int) *TranslationService {
+	if chunkSize <= 0 {
+		chunkSize = DefaultDeckChunkSize
+	}
+	deck := *s
+	deck.deckChunkSize = chunkSize
+	return &deck
+}
+
+// WithGlossary returns a translation service respecting the terms of glossary, sharing the caches
+// and scheduler of s
+func (s *TranslationService) WithGlossary(glossary *Glossary) *TranslationService {
+	withGlossary := *s
+	withGlossary.glossary = glossary
+	return &withGlossary
+}
+
+// WithMemory returns a translation service reusing and recording the translations of sentences from
+// sourceLang in memory, offering the segments at least fuzzyThreshold similar (DefaultFuzzyThreshold
+// when 0) to the translator, sharing the caches and scheduler of s
+func (s *TranslationService) WithMemory(memory *TranslationMemory, sourceLang string, fuzzyThreshold float64) *TranslationService {
+	if fuzzyThreshold <= 0 {
+		fuzzyThreshold = DefaultFuzzyThreshold
+	}
+	withMemory := *s
+	withMemory.memory = memory
+	withMemory.sourceLang = sourceLang
+	withMemory.fuzzy = fuzzyThreshold
+	return &withMemory
+}
+
+// deck reports whether the slides translated to lang are translated in deck mode
+func (s *TranslationService) deck(lang string) bool {
+	if s.deckChunkSize == 0 {
+		return false
+	}
+	name, _ := s.provider(lang)
+	return name == ProviderOpenAI
+}
+
+// provider returns the name and provider translating to lang
+func (s *TranslationService) provider(lang string) (string, interfaces.TranslationProvider) {
+	if s.providers == nil {
+		return ProviderOpenAI, NewOpenAITranslator(s.client)
+	}
+	return s.providers.For(lang)
+}
+
+// inputsFingerprint identifies the settings of the translations to lang, "" with the defaults
+func (s *TranslationService) inputsFingerprint(lang string) string {
+	var settings []string
+	if name, _ := s.provider(lang); name != ProviderOpenAI {
+		settings = append(settings, "provider="+name)
+	} else if prompt := s.prompt.fingerprint(); prompt != "" {
+		settings = append(settings, "prompt="+prompt)
+	}
+	if s.deck(lang) {
+		settings = append(settings, fmt.Sprintf("deck=%d", s.deckChunkSize))
+	}
+	if glossary := s.glossary.fingerprint(lang); glossary != "" {
+		settings = append(settings, "glossary="+glossary)
+	}
+	if qa := s.qa.fingerprint(); qa != "" {
+		settings = append(settings, "qa="+qa)
+	}
+	return strings.Join(settings, "|")
+}
+
+// getCacheKey generates a cache key from text, target language and the settings translating it for the slide of ctx
+func (s *TranslationService) getCacheKey(ctx context.Context, text, targetLang string) string {
 	data := fmt.Sprintf("%s|%s", text, targetLang)
+	if name, _ := s.provider(targetLang); name != ProviderOpenAI {
+		data += "|" + name
+	} else {
+		if s.deck(targetLang) {
+			data += "|deck"
+		}
+		slide := slideIndex(ctx)
+		notes := translatorNotes(ctx, slide)
+		if notes != "" {
+			data += "|notes=" + notes
+		}
+		if prompt := s.promptFingerprint(text, targetLang, slide, notes); prompt != "" {
+			data += "|prompt=" + prompt
+		}
+	}
+	if glossary := glossaryFingerprint(s.glossary.Terms(text, targetLang)); glossary != "" {
+		data += "|glossary=" + glossary
+	}
+	if qa := s.qa.fingerprint(); qa != "" {
+		data += "|qa=" + qa
+	}
 	hash := sha256.Sum256([]byte(data))
 	return hex.EncodeToString(hash[:])
 }
@@ -94,55 +216,303 @@ func (s *TranslationService) setInDiskCache(key, value string) {
 	}
 
 	cachePath := filepath.Join(s.cacheDir, key+".txt")
-	if err := afero.WriteFile(s.fs, cachePath, []byte(value), 0644); err != nil {
+	if err := writeFileAtomic(s.fs, cachePath, []byte(value), 0644); err != nil {
 		s.logger.Warn("Failed to write to disk cache", "error", err)
 	}
 }
 
-// Translate translates text to target language with caching
-func (s *TranslationService) Translate(ctx context.Context, text, targetLang string) (string, error) {
+// Cached returns the cached translation of text, of the slide of ctx if any, without calling the API
+func (s *TranslationService) Cached(ctx context.Context, text, targetLang string) (string, bool) {
+	cacheKey := s.getCacheKey(ctx, text, targetLang)
+	if cached, ok := s.getFromMemoryCache(cacheKey); ok {
+		return cached, true
+	}
+	if s.fs == nil || s.cacheDir == "" {
+		return "", false
+	}
+	data, err := afero.ReadFile(s.fs, filepath.Join(s.cacheDir, cacheKey+".txt"))
+	if err != nil {
+		return s.fromMemory(ctx, text, targetLang)
+	}
+	return string(data), true
+}
+
+// lookup returns the cached translation with cacheKey, counting the cache hit for the work of ctx
+func (s *TranslationService) lookup(ctx context.Context, cacheKey string) (string, bool) {
 	// Check memory cache first
-	cacheKey := s.getCacheKey(text, targetLang)
 	if cached, ok := s.getFromMemoryCache(cacheKey); ok {
 		s.logger.Info("Translation cache hit (memory)", "key", cacheKey)
-		return cached, nil
+		recordCacheHit(ctx)
+		return cached, true
 	}
 
 	// Check disk cache
 	if cached, ok := s.getFromDiskCache(cacheKey); ok {
 		// Store in memory for faster future access
 		s.setInMemoryCache(cacheKey, cached)
+		recordCacheHit(ctx)
+		return cached, true
+	}
+	return "", false
+}
+
+// Translate translates text to target language with caching
+func (s *TranslationService) Translate(ctx context.Context, text, targetLang string) (string, error) {
+	cacheKey := s.getCacheKey(ctx, text, targetLang)
+	if cached, ok := s.lookup(ctx, cacheKey); ok {
 		return cached, nil
 	}
+	if translated, ok := s.fromMemory(ctx, text, targetLang); ok {
+		s.logger.Info("Translation memory hit", "lang", targetLang)
+		recordCacheHit(ctx)
+		s.setInMemoryCache(cacheKey, translated)
+		s.setInDiskCache(cacheKey, translated)
+		return translated, nil
+	}
 
-	// No cache, call API
-	messages := []openai.ChatCompletionMessageParamUnion{
-		openai.UserMessage(fmt.Sprintf("Translate '%s' to %s and don't return anything else than the translation.", text, targetLang)),
+	// No cache, call API. Speech markup is kept out of the translation as placeholders, and so
+	// are the terms of the glossary kept as is, for providers that follow no instructions.
+	protected, directives := protectMarkup(text)
+	hints := s.hints(ctx, text, targetLang)
+	name, provider := s.provider(targetLang)
+	instructed, ok := provider.(hintedProvider)
+	if !ok {
+		protected, directives = protectTerms(protected, hints.Terms, directives)
 	}
 
-	translated, err := s.client.ChatCompletion(ctx, messages)
-	if err != nil {
-		return "", fmt.Errorf("translation failed: %w", err)
+	// A translation breaking the glossary is retried once by a provider following its instructions,
+	// and one failing the quality checks as many times as they allow, told what to avoid
+	var translated string
+	var issues []string
+	for attempt := 1; ; attempt++ {
+		err := s.scheduler.API(ctx, func() error {
+			var err error
+			recordAPICall(ctx)
+			if instructed != nil {
+				translated, err = instructed.translateWithHints(ctx, protected, targetLang, hints)
+			} else {
+				translated, err = provider.Translate(ctx, protected, targetLang)
+			}
+			return err
+		})
+		if err != nil {
+			return "", fmt.Errorf("translation with %s failed: %w", name, err)
+		}
+		translated, err = restoreMarkup(translated, directives)
+		if err != nil {
+			return "", fmt.Errorf("translation lost speech markup: %w", err)
+		}
+
+		violations := glossaryViolations(translated, hints.Terms)
+		issues = s.qaIssues(ctx, text, translated, targetLang)
+		retryGlossary := len(violations) > 0 && instructed != nil && attempt < glossaryAttempts
+		retryQA := len(issues) > 0 && attempt < s.qaAttempts()
+		if !retryGlossary && !retryQA {
+			if len(violations) > 0 {
+				s.logger.Warn("Translation breaks the glossary", "lang", targetLang, "text", text, "problems", strings.Join(violations, "; "))
+			}
+			break // Quality problems left are flagged once the batch is translated
+		}
+		hints.Problems = append(violations, issues...)
+		s.logger.Warn("Translation fails its checks, retrying", "lang", targetLang, "problems", strings.Join(hints.Problems, "; "))
 	}
 
-	// Cache the result
-	s.setInMemoryCache(cacheKey, translated)
-	s.setInDiskCache(cacheKey, translated)
+	// Cache the result, unless it still fails the quality checks, so the next run translates it again
+	if len(issues) == 0 {
+		s.setInMemoryCache(cacheKey, translated)
+		s.setInDiskCache(cacheKey, translated)
+		s.remember(ctx, text, translated, targetLang)
+	}
 
 	return translated, nil
 }
 
-// TranslateBatch translates multiple texts in parallel
+// hints returns the hints of a request translating text to targetLang, for the slide of ctx
+func (s *TranslationService) hints(ctx context.Context, text, targetLang string) translationHints {
+	slide := slideIndex(ctx)
+	return translationHints{
+		Terms:      s.glossary.Terms(text, targetLang),
+		Memory:     s.memoryMatches(text, targetLang),
+		Notes:      translatorNotes(ctx, slide),
+		Prompt:     s.prompt,
+		SourceLang: s.sourceLang,
+		Slide:      slide,
+	}
+}
+
+type translatorNotesKey struct{}
+
+// withTranslatorNotes returns a context translating the text of slide i with notes[i], the notes
+// for translators of the slide
+func withTranslatorNotes(ctx context.Context, notes []string) context.Context {
+	return context.WithValue(ctx, translatorNotesKey{}, notes)
+}
+
+// translatorNotes returns the notes for translators of the slide at index slide in ctx, "" for none
+func translatorNotes(ctx context.Context, slide int) string {
+	notes, _ := ctx.Value(translatorNotesKey{}).([]string)
+	if slide < 0 || slide >= len(notes) {
+		return ""
+	}
+	return notes[slide]
+}
+
+// fromMemory returns the translation of text, for the slide of ctx, from the translation memory: its
+// translation as a whole, or one assembled from the translations of its sentences. Only imported
+// translations and machine translations made with the settings of the slide are reused, and only
+// when the result respects the glossary.
+func (s *TranslationService) fromMemory(ctx context.Context, text, targetLang string) (string, bool) {
+	if s.memory == nil {
+		return "", false
+	}
+	settings := s.memorySettings(ctx, targetLang)
+	translated, ok := s.memory.Exact(s.sourceLang, targetLang, text, settings)
+	if !ok {
+		var out strings.Builder
+		for _, sentence := range splitSentences(text) {
+			translated, ok := s.memory.Exact(s.sourceLang, targetLang, sentence, settings)
+			if !ok {
+				return "", false
+			}
+			// The sentence keeps the space after it
+			out.WriteString(translated)
+			out.WriteString(sentence[len(strings.TrimRightFunc(sentence, unicode.IsSpace)):])
+		}
+		translated = out.String()
+	}
+	if violations := glossaryViolations(translated, s.glossary.Terms(text, targetLang)); len(violations) > 0 {
+		s.logger.Info("Translation memory hit breaks the glossary, translating the text", "lang", targetLang, "problems", strings.Join(violations, "; "))
+		return "", false
+	}
+	return translated, true
+}
+
+// memorySettings fingerprints the settings the machine translations of the slide of ctx to targetLang
+// are made with, as in their cache keys: the provider or deck mode, the notes of the slide and the prompt
+func (s *TranslationService) memorySettings(ctx context.Context, targetLang string) string {
+	return s.getCacheKey(ctx, "", targetLang)[:16]
+}
+
+// memoryMatches returns the translations of the sentences of text, or of similar sentences, in the
+// translation memory, most similar first
+func (s *TranslationService) memoryMatches(text, targetLang string) []MemoryMatch {
+	if s.memory == nil {
+		return nil
+	}
+	var matches []MemoryMatch
+	for _, sentence := range splitSentences(text) {
+		sentence = strings.TrimSpace(sentence)
+		if translated, ok := s.memory.Exact(s.sourceLang, targetLang, sentence, ""); ok {
+			matches = append(matches, MemoryMatch{Source: sentence, Target: translated, Similarity: 1})
+		} else if match, ok := s.memory.Fuzzy(s.sourceLang, targetLang, sentence, s.fuzzy); ok {
+			matches = append(matches, match)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Similarity > matches[j].Similarity })
+	if len(matches) > maxMemoryMatches {
+		matches = matches[:maxMemoryMatches]
+	}
+	return matches
+}
+
+// remember records the translation of text, for the slide of ctx, in the translation memory with the
+// settings it was made with. The text is kept as a whole: nothing tells which of its sentences
+// translates which, even when both have as many.
+func (s *TranslationService) remember(ctx context.Context, text, translated, targetLang string) {
+	if s.memory == nil {
+		return
+	}
+	s.memory.Add(MemorySegment{
+		SourceLang: s.sourceLang,
+		TargetLang: targetLang,
+		Source:     text,
+		Target:     translated,
+		Origin:     MemoryOriginMachine,
+		Settings:   s.memorySettings(ctx, targetLang),
+	})
+}
+
+// saveMemory writes the translation memory, if any, a failure only losing what it learned
+func (s *TranslationService) saveMemory() {
+	if s.memory == nil {
+		return
+	}
+	if err := s.memory.Save(); err != nil {
+		s.logger.Warn("Failed to save translation memory", "error", err)
+	}
+}
+
+// glossaryAttempts is how many times a text is translated until its translation respects the glossary
+const glossaryAttempts = 2
+
+// translationPrompt returns the user prompt sent to translate text to targetLang, following hints
+func translationPrompt(text, targetLang string, hints translationHints) (string, error) {
+	prompt, err := hints.Prompt.request(hints.Prompt.data(text, hints.SourceLang, targetLang, hints.Slide, hints.Notes))
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(text, placeholderOpen) {
+		prompt += fmt.Sprintf(" Keep every placeholder such as %s1%s exactly as it is, where it belongs in the translation.", placeholderOpen, placeholderClose)
+	}
+	prompt += glossaryInstructions(hints.Terms) + memoryInstructions(hints.Memory)
+	if len(hints.Problems) > 0 {
+		prompt += fmt.Sprintf(" A previous translation had these problems, avoid them: %s.", strings.Join(hints.Problems, "; "))
+	}
+	return prompt, nil
+}
+
+// memoryInstructions returns the instructions of a prompt offering the matches of the translation
+// memory as references, "" when none
+func memoryInstructions(matches []MemoryMatch) string {
+	if len(matches) == 0 {
+		return ""
+	}
+	references := make([]string, len(matches))
+	for i, match := range matches {
+		references[i] = fmt.Sprintf("%q as %q", match.Source, match.Target)
+	}
+	return fmt.Sprintf(" Approved translations of these sentences or similar ones, to reuse where they apply: %s.", strings.Join(references, ", "))
+}
+
+// TranslateBatch translates multiple texts in parallel, or in deck mode together. Empty texts,
+// of silent slides, stay empty. With quality checks, the translations failing them are flagged
+// in the report of ctx, or fail the batch when the checks are strict.
 func (s *TranslationService) TranslateBatch(ctx context.Context, texts []string, targetLang string) ([]string, error) {
+	// What the translations taught the memory is saved once the batch is done
+	defer s.saveMemory()
+	var results []string
+	var err error
+	if s.deck(targetLang) {
+		results, err = s.translateDeck(ctx, texts, targetLang)
+	} else {
+		results, err = s.translateParallel(ctx, texts, targetLang)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.review(ctx, texts, results, targetLang); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// translateParallel translates every text on its own, in parallel
+func (s *TranslationService) translateParallel(ctx context.Context, texts []string, targetLang string) ([]string, error) {
 	results := make([]string, len(texts))
 	errors := make([]error, len(texts))
 	var wg sync.WaitGroup
 
 	for i, text := range texts {
+		if isSilent(text) {
+			continue
+		}
 		wg.Add(1)
 		go func(idx int, txt string) {
 			defer wg.Done()
+			ctx := withSlideIndex(ctx, idx)
+			start := time.Now()
 			translated, err := s.Translate(ctx, txt, targetLang)
+			recordSlideStep(ctx, start, err)
 			if err != nil {
 				errors[idx] = err
 				return
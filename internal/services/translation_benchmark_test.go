@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"testing"
 
+	"gocreator/internal/interfaces"
 	"gocreator/internal/mocks"
 
 	"github.com/spf13/afero"
@@ -22,7 +23,7 @@ func BenchmarkTranslationService_Translate_NoCache(b *testing.B) {
 	ctx := context.Background()
 
 	// Mock API response
-	mockClient.On("ChatCompletion", mock.Anything, mock.Anything).
+	mockClient.On("ChatCompletion", mock.Anything, mock.Anything, interfaces.ChatOptions{}).
 		Return("Este es un texto de prueba para el benchmark de traducción", nil)
 
 	b.ResetTimer()
@@ -43,7 +44,7 @@ func BenchmarkTranslationService_Translate_WithCache(b *testing.B) {
 	ctx := context.Background()
 
 	// Mock API response (only called once to populate cache)
-	mockClient.On("ChatCompletion", mock.Anything, mock.Anything).
+	mockClient.On("ChatCompletion", mock.Anything, mock.Anything, interfaces.ChatOptions{}).
 		Return("Este es un texto de prueba para el benchmark de traducción", nil).Once()
 
 	// First call to populate cache
@@ -72,7 +73,7 @@ func BenchmarkTranslationService_TranslateBatch_5Texts(b *testing.B) {
 	ctx := context.Background()
 
 	// Mock API responses
-	mockClient.On("ChatCompletion", mock.Anything, mock.Anything).
+	mockClient.On("ChatCompletion", mock.Anything, mock.Anything, interfaces.ChatOptions{}).
 		Return("Traducción de texto", nil)
 
 	b.ResetTimer()
@@ -95,7 +96,7 @@ func BenchmarkTranslationService_TranslateBatch_10Texts(b *testing.B) {
 	ctx := context.Background()
 
 	// Mock API responses
-	mockClient.On("ChatCompletion", mock.Anything, mock.Anything).
+	mockClient.On("ChatCompletion", mock.Anything, mock.Anything, interfaces.ChatOptions{}).
 		Return("Traducción de texto", nil)
 
 	b.ResetTimer()
@@ -118,7 +119,7 @@ func BenchmarkTranslationService_TranslateBatch_20Texts(b *testing.B) {
 	ctx := context.Background()
 
 	// Mock API responses
-	mockClient.On("ChatCompletion", mock.Anything, mock.Anything).
+	mockClient.On("ChatCompletion", mock.Anything, mock.Anything, interfaces.ChatOptions{}).
 		Return("Traducción de texto", nil)
 
 	b.ResetTimer()
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"testing"
 
 	"gocreator/internal/mocks"
@@ -26,13 +27,13 @@ func FuzzTranslationService_getCacheKey(f *testing.F) {
 		service := NewTranslationService(mockClient, logger)
 
 		// getCacheKey should never panic
-		key1 := service.getCacheKey(text, targetLang)
+		key1 := service.getCacheKey(context.Background(), text, targetLang)
 		if key1 == "" {
 			t.Error("getCacheKey returned empty string")
 		}
 
 		// Cache key should be deterministic
-		key2 := service.getCacheKey(text, targetLang)
+		key2 := service.getCacheKey(context.Background(), text, targetLang)
 		if key1 != key2 {
 			t.Errorf("Cache key is not deterministic: %s != %s", key1, key2)
 		}
@@ -44,12 +45,12 @@ func FuzzTranslationService_getCacheKey(f *testing.F) {
 
 		// Different inputs should produce different cache keys
 		if text != "" || targetLang != "" {
-			differentKey := service.getCacheKey(text+"x", targetLang)
+			differentKey := service.getCacheKey(context.Background(), text+"x", targetLang)
 			if key1 == differentKey && text != text+"x" {
 				t.Errorf("Same cache key for different texts")
 			}
 
-			differentLangKey := service.getCacheKey(text, targetLang+"x")
+			differentLangKey := service.getCacheKey(context.Background(), text, targetLang+"x")
 			if key1 == differentLangKey && targetLang != targetLang+"x" {
 				t.Errorf("Same cache key for different languages")
 			}
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"testing"
 
+	"gocreator/internal/interfaces"
 	"gocreator/internal/mocks"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTranslationService_Translate(t *testing.T) {
@@ -58,7 +60,7 @@ func TestTranslationService_Translate(t *testing.T) {
 			service := NewTranslationService(mockClient, logger)
 
 			// Setup mock expectations
-			mockClient.On("ChatCompletion", mock.Anything, mock.AnythingOfType("[]openai.ChatCompletionMessageParamUnion")).
+			mockClient.On("ChatCompletion", mock.Anything, mock.AnythingOfType("[]openai.ChatCompletionMessageParamUnion"), interfaces.ChatOptions{}).
 				Return(tt.mockResponse, tt.mockError)
 
 			ctx := context.Background()
@@ -85,7 +87,7 @@ func TestTranslationService_TranslateBatch(t *testing.T) {
 		inputTexts := []string{"Hello", "Goodbye", "Thank you"}
 
 		// Setup mock to return a translation for any input (3 times)
-		mockClient.On("ChatCompletion", mock.Anything, mock.AnythingOfType("[]openai.ChatCompletionMessageParamUnion")).
+		mockClient.On("ChatCompletion", mock.Anything, mock.AnythingOfType("[]openai.ChatCompletionMessageParamUnion"), interfaces.ChatOptions{}).
 			Return("translated", nil).Times(3)
 
 		ctx := context.Background()
@@ -117,7 +119,7 @@ func TestTranslationService_TranslateBatch(t *testing.T) {
 		logger := &mockLogger{}
 		service := NewTranslationService(mockClient, logger)
 
-		mockClient.On("ChatCompletion", mock.Anything, mock.AnythingOfType("[]openai.ChatCompletionMessageParamUnion")).
+		mockClient.On("ChatCompletion", mock.Anything, mock.AnythingOfType("[]openai.ChatCompletionMessageParamUnion"), interfaces.ChatOptions{}).
 			Return("Hallo", nil).Once()
 
 		ctx := context.Background()
@@ -137,9 +139,9 @@ func TestTranslationService_TranslateBatch_WithError(t *testing.T) {
 	inputTexts := []string{"Hello", "Goodbye"}
 
 	// First translation succeeds, second fails
-	mockClient.On("ChatCompletion", mock.Anything, mock.AnythingOfType("[]openai.ChatCompletionMessageParamUnion")).
+	mockClient.On("ChatCompletion", mock.Anything, mock.AnythingOfType("[]openai.ChatCompletionMessageParamUnion"), interfaces.ChatOptions{}).
 		Return("Hola", nil).Once()
-	mockClient.On("ChatCompletion", mock.Anything, mock.AnythingOfType("[]openai.ChatCompletionMessageParamUnion")).
+	mockClient.On("ChatCompletion", mock.Anything, mock.AnythingOfType("[]openai.ChatCompletionMessageParamUnion"), interfaces.ChatOptions{}).
 		Return("", errors.New("API error")).Once()
 
 	ctx := context.Background()
@@ -164,3 +166,65 @@ func TestNewTranslationServiceWithCache(t *testing.T) {
 	assert.Equal(t, cacheDir, service.cacheDir)
 	assert.NotNil(t, service.memoryCache)
 }
+
+// fakeTranslationProvider translates by prefixing the text with its name
+type fakeTranslationProvider struct {
+	name  string
+	calls int
+}
+
+func (p *fakeTranslationProvider) Translate(ctx context.Context, text, targetLang string) (string, error) {
+	p.calls++
+	return p.name + ":" + text, nil
+}
+
+func TestTranslationService_WithProviders(t *testing.T) {
+	ctx := context.Background()
+	fs := afero.NewMemMapFs()
+	logger := &mockLogger{}
+	mockClient := new(mocks.MockOpenAIClient)
+	mockClient.On("ChatCompletion", mock.Anything, mock.Anything, interfaces.ChatOptions{}).Return("Bonjour", nil).Once()
+
+	base := NewTranslationServiceWithCache(mockClient, logger, fs, "/cache")
+	_, err := base.Translate(ctx, "Hello", "fr")
+	require.NoError(t, err)
+
+	deepl := &fakeTranslationProvider{name: "deepl"}
+	providers, err := NewTranslationProviders(map[string]interfaces.TranslationProvider{
+		ProviderOpenAI: NewOpenAITranslator(mockClient),
+		ProviderDeepL:  deepl,
+	}, ProviderOpenAI, map[string]string{"de": ProviderDeepL})
+	require.NoError(t, err)
+	service := base.WithProviders(providers)
+
+	// OpenAI translations keep their cache key, shared with the base service
+	translated, err := service.Translate(ctx, "Hello", "fr")
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", translated)
+	mockClient.AssertExpectations(t)
+
+	// Other providers are part of the key
+	assert.NotEqual(t, base.getCacheKey(context.Background(), "Hello", "de"), service.getCacheKey(context.Background(), "Hello", "de"))
+	translated, err = service.Translate(ctx, "Hello [pause 1s] world", "de")
+	require.NoError(t, err)
+	assert.Equal(t, "deepl:Hello [pause 1s] world", translated)
+	cached, ok := service.Cached(context.Background(), "Hello [pause 1s] world", "de")
+	assert.True(t, ok)
+	assert.Equal(t, translated, cached)
+	_, ok = base.Cached(context.Background(), "Hello [pause 1s] world", "de")
+	assert.False(t, ok)
+
+	_, err = service.Translate(ctx, "Hello [pause 1s] world", "de")
+	require.NoError(t, err)
+	assert.Equal(t, 1, deepl.calls)
+}
+
+func TestNewTranslationProviders(t *testing.T) {
+	providers := map[string]interfaces.TranslationProvider{ProviderOpenAI: &fakeTranslationProvider{name: "openai"}}
+
+	_, err := NewTranslationProviders(providers, "deepl", nil)
+	assert.EqualError(t, err, `unknown translation provider "deepl", expected one of openai`)
+
+	_, err = NewTranslationProviders(providers, ProviderOpenAI, map[string]string{"ja": "google"})
+	assert.EqualError(t, err, `language ja: unknown translation provider "google", expected one of openai`)
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// TranslationMemoryFile is the name of the translation memory, in the translation cache directory
+const TranslationMemoryFile = "memory.json"
+
+// DefaultFuzzyThreshold is the similarity, from 0 to 1, a sentence of the translation memory needs
+// to be offered to the translator as a fuzzy match
+const DefaultFuzzyThreshold = 0.75
+
+// maxMemoryMatches bounds the matches of the translation memory sent with one request
+const maxMemoryMatches = 10
+
+// Origins of the segments of the translation memory
+const (
+	MemoryOriginMachine = "machine" // Translated by a translation provider
+	MemoryOriginTMX     = "tmx"     // Imported from a TMX file
+)
+
+// MemorySegment is the translation of a sentence, or of a whole text, in the translation memory
+type MemorySegment struct {
+	SourceLang string    `json:"source_lang"`
+	TargetLang string    `json:"target_lang"`
+	Source     string    `json:"source"`
+	Target     string    `json:"target"`
+	Origin     string    `json:"origin"`
+	Settings   string    `json:"settings,omitempty"` // Fingerprint of the settings of a machine translation
+	Updated    time.Time `json:"updated"`
+}
+
+// MemoryMatch is a segment of the translation memory similar to a sentence being translated
+type MemoryMatch struct {
+	Source     string
+	Target     string
+	Similarity float64 // 1 for an exact match
+}
+
+// translationMemoryFile is the content of the translation memory file
+type translationMemoryFile struct {
+	Version  int              `json:"version"`
+	Segments []*MemorySegment `json:"segments"`
+}
+
+// translationMemoryVersion is the version of the translation memory file format
+const translationMemoryVersion = 1
+
+// TranslationMemory keeps the translation of every sentence translated or imported, per pair of languages,
+// so a translation can be reused when the text around it changes
+type TranslationMemory struct {
+	fs       afero.Fs
+	path     string
+	mu       sync.RWMutex
+	segments []*MemorySegment
+	bySource map[string][]*MemorySegment
+	dirty    bool
+}
+
+// OpenTranslationMemory loads the translation memory at path. A missing file is an empty memory.
+func OpenTranslationMemory(fs afero.Fs, path string) (*TranslationMemory, error) {
+	memory := &TranslationMemory{fs: fs, path: path, bySource: make(map[string][]*MemorySegment)}
+	data, err := afero.ReadFile(fs, path)
+	if errors.Is(err, os.ErrNotExist) {
+		return memory, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read translation memory: %w", err)
+	}
+
+	var file translationMemoryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse translation memory %s: %w", path, err)
+	}
+	if file.Version != translationMemoryVersion {
+		return nil, fmt.Errorf("translation memory %s has version %d, expected %d", path, file.Version, translationMemoryVersion)
+	}
+	for _, segment := range file.Segments {
+		memory.add(*segment)
+	}
+	memory.dirty = false
+	return memory, nil
+}
+
+// Len returns the number of segments of the memory
+func (m *TranslationMemory) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.segments)
+}
+
+// Add records the translation of a sentence, replacing its previous translation between the same languages
+func (m *TranslationMemory) Add(segment MemorySegment) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.add(segment)
+}
+
+func (m *TranslationMemory) add(segment MemorySegment) {
+	segment.SourceLang = normalizeLang(segment.SourceLang)
+	segment.TargetLang = normalizeLang(segment.TargetLang)
+	segment.Source = strings.TrimSpace(segment.Source)
+	segment.Target = strings.TrimSpace(segment.Target)
+	if segment.Source == "" || segment.Target == "" {
+		return
+	}
+	if segment.Updated.IsZero() {
+		segment.Updated = time.Now().UTC()
+	}
+
+	for _, existing := range m.bySource[segment.Source] {
+		if existing.SourceLang == segment.SourceLang && existing.TargetLang == segment.TargetLang {
+			// A machine translation never replaces an imported one
+			if existing.Origin == MemoryOriginTMX && segment.Origin == MemoryOriginMachine {
+				return
+			}
+			if existing.Target != segment.Target || existing.Origin != segment.Origin || existing.Settings != segment.Settings {
+				*existing = segment
+				m.dirty = true
+			}
+			return
+		}
+	}
+	stored := &segment
+	m.segments = append(m.segments, stored)
+	m.bySource[segment.Source] = append(m.bySource[segment.Source], stored)
+	m.dirty = true
+}
+
+// Exact returns the translation of sentence from sourceLang to targetLang, if the memory has one.
+// Unless settings is empty, machine translations are only returned when made with settings, while
+// imported ones always are.
+func (m *TranslationMemory) Exact(sourceLang, targetLang, sentence, settings string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var found *MemorySegment
+	for _, segment := range m.bySource[strings.TrimSpace(sentence)] {
+		if !langMatches(segment.SourceLang, sourceLang) || !langMatches(segment.TargetLang, targetLang) {
+			continue
+		}
+		if settings != "" && segment.Origin != MemoryOriginTMX && segment.Settings != settings {
+			continue
+		}
+		// A segment of the very language wins over one of a regional variant
+		if found == nil || segment.TargetLang == normalizeLang(targetLang) {
+			found = segment
+		}
+	}
+	if found == nil {
+		return "", false
+	}
+	return found.Target, true
+}
+
+// Fuzzy returns the segment from sourceLang to targetLang most similar to sentence, if any is at least
+// threshold similar. Exact matches are left to Exact.
+func (m *TranslationMemory) Fuzzy(sourceLang, targetLang, sentence string, threshold float64) (MemoryMatch, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sentence = strings.TrimSpace(sentence)
+	var best MemoryMatch
+	for _, segment := range m.segments {
+		if segment.Source == sentence || !langMatches(segment.SourceLang, sourceLang) || !langMatches(segment.TargetLang, targetLang) {
+			continue
+		}
+		if similarity := textSimilarity(sentence, segment.Source, threshold); similarity >= threshold && similarity > best.Similarity {
+			best = MemoryMatch{Source: segment.Source, Target: segment.Target, Similarity: similarity}
+		}
+	}
+	return best, best.Similarity > 0
+}
+
+// Segments returns the segments from sourceLang to targetLang, of every language when empty,
+// sorted by languages and source
+func (m *TranslationMemory) Segments(sourceLang, targetLang string) []MemorySegment {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var segments []MemorySegment
+	for _, segment := range m.segments {
+		if (sourceLang == "" || langMatches(segment.SourceLang, sourceLang)) && (targetLang == "" || langMatches(segment.TargetLang, targetLang)) {
+			segments = append(segments, *segment)
+		}
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		a, b := segments[i], segments[j]
+		if a.SourceLang != b.SourceLang {
+			return a.SourceLang < b.SourceLang
+		}
+		if a.TargetLang != b.TargetLang {
+			return a.TargetLang < b.TargetLang
+		}
+		return a.Source < b.Source
+	})
+	return segments
+}
+
+// Save writes the memory to its file, if anything was added since it was loaded or last saved
+func (m *TranslationMemory) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.dirty {
+		return nil
+	}
+
+	file := translationMemoryFile{Version: translationMemoryVersion, Segments: m.segments}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := m.fs.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("failed to create translation memory directory: %w", err)
+	}
+	if err := writeFileAtomic(m.fs, m.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write translation memory: %w", err)
+	}
+	m.dirty = false
+	return nil
+}
+
+// normalizeLang returns the language tag lang in lower case, with - between its subtags
+func normalizeLang(lang string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+}
+
+// langMatches reports whether the language tag of a segment is lang, or a regional variant of it:
+// fr-FR matches fr, but fr doesn't match fr-CA
+func langMatches(tag, lang string) bool {
+	tag, lang = normalizeLang(tag), normalizeLang(lang)
+	return tag == lang || strings.HasPrefix(tag, lang+"-")
+}
+
+// sentenceEnd matches the end of a sentence, its punctuation and the space after it, or a line break
+var sentenceEnd = regexp.MustCompile(`[.!?…]+["'”’»)]*[ \t]+|[ \t]*\n\s*`)
+
+// splitSentences splits text into its sentences, each with the space after it, so joining them gives text back
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for _, m := range sentenceEnd.FindAllStringIndex(text, -1) {
+		if strings.TrimSpace(text[start:m[1]]) == "" {
+			continue // Leading space stays with the next sentence
+		}
+		sentences = append(sentences, text[start:m[1]])
+		start = m[1]
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
+
+// textSimilarity returns the similarity of a and b from 0 to 1, one minus their edit distance over the
+// length of the longer, in runes. Pairs whose lengths differ too much to reach threshold score 0.
+func textSimilarity(a, b string, threshold float64) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	if float64(min(len(ra), len(rb)))/float64(longest) < threshold {
+		return 0
+	}
+
+	// Levenshtein distance, one row at a time
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gocreator/internal/interfaces"
+	"gocreator/internal/mocks"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello.", []string{"Hello."}},
+		{"Hello. How are you? Fine!", []string{"Hello. ", "How are you? ", "Fine!"}},
+		{"He said \"stop.\" Then he left.", []string{"He said \"stop.\" ", "Then he left."}},
+		{"First line\nSecond line", []string{"First line\n", "Second line"}},
+		{"Version 1.5 is out. [pause 1.5s] Try it.", []string{"Version 1.5 is out. ", "[pause 1.5s] Try it."}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := splitSentences(tt.text)
+		assert.Equal(t, tt.want, got, tt.text)
+		assert.Equal(t, tt.text, strings.Join(got, ""))
+	}
+}
+
+func TestTextSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, textSimilarity("Hello world", "Hello world", 0.75))
+	assert.InDelta(t, 1-1.0/11, textSimilarity("Hello world", "Helo world", 0.75), 1e-9)
+	assert.Equal(t, 0.0, textSimilarity("Hello", "Hello world, how are you today?", 0.75))
+}
+
+func TestTranslationMemory(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	memory, err := OpenTranslationMemory(fs, "/cache/memory.json")
+	require.NoError(t, err)
+	assert.Equal(t, 0, memory.Len())
+
+	memory.Add(MemorySegment{SourceLang: "en", TargetLang: "fr-FR", Source: " Welcome to the course. ", Target: "Bienvenue dans le cours.", Origin: MemoryOriginTMX})
+	memory.Add(MemorySegment{SourceLang: "en", TargetLang: "fr", Source: "Welcome to the course.", Target: "Bienvenue au cours.", Origin: MemoryOriginMachine})
+	memory.Add(MemorySegment{SourceLang: "en", TargetLang: "fr", Source: "Welcome to the course.", Target: "Bienvenue au cours.", Origin: MemoryOriginMachine})
+	assert.Equal(t, 2, memory.Len())
+	memory.Add(MemorySegment{SourceLang: "en", TargetLang: "fr-FR", Source: "Welcome to the course.", Target: "Bienvenue en cours.", Origin: MemoryOriginMachine})
+
+	// The segment of the very language wins over a regional variant, which still matches
+	translated, ok := memory.Exact("en", "fr", "Welcome to the course.", "")
+	assert.True(t, ok)
+	assert.Equal(t, "Bienvenue au cours.", translated)
+	translated, ok = memory.Exact("en", "fr-fr", "Welcome to the course.", "")
+	assert.True(t, ok)
+	assert.Equal(t, "Bienvenue dans le cours.", translated)
+	_, ok = memory.Exact("en", "de", "Welcome to the course.", "")
+	assert.False(t, ok)
+
+	match, ok := memory.Fuzzy("en", "fr", "Welcome to the courses.", DefaultFuzzyThreshold)
+	assert.True(t, ok)
+	assert.Equal(t, "Welcome to the course.", match.Source)
+	assert.Greater(t, match.Similarity, 0.9)
+	_, ok = memory.Fuzzy("en", "fr", "Something else entirely.", DefaultFuzzyThreshold)
+	assert.False(t, ok)
+
+	// Machine translations are only reused with the settings they were made with, imported ones always
+	translated, ok = memory.Exact("en", "fr", "Welcome to the course.", "prompt")
+	assert.True(t, ok)
+	assert.Equal(t, "Bienvenue dans le cours.", translated)
+	memory.Add(MemorySegment{SourceLang: "en", TargetLang: "de", Source: "Welcome.", Target: "Willkommen.", Origin: MemoryOriginMachine, Settings: "prompt"})
+	_, ok = memory.Exact("en", "de", "Welcome.", "other prompt")
+	assert.False(t, ok)
+	translated, ok = memory.Exact("en", "de", "Welcome.", "prompt")
+	assert.True(t, ok)
+	assert.Equal(t, "Willkommen.", translated)
+
+	require.NoError(t, memory.Save())
+	reopened, err := OpenTranslationMemory(fs, "/cache/memory.json")
+	require.NoError(t, err)
+	assert.Equal(t, memory.Segments("", ""), reopened.Segments("", ""))
+	assert.Len(t, reopened.Segments("en", "fr-fr"), 1)
+
+	require.NoError(t, afero.WriteFile(fs, "/cache/memory.json", []byte(`{"version":2}`), 0644))
+	_, err = OpenTranslationMemory(fs, "/cache/memory.json")
+	assert.EqualError(t, err, "translation memory /cache/memory.json has version 2, expected 1")
+}
+
+func TestTranslationService_TranslateMemory(t *testing.T) {
+	ctx := context.Background()
+	fs := afero.NewMemMapFs()
+	memory, err := OpenTranslationMemory(fs, "/cache/memory.json")
+	require.NoError(t, err)
+	mockClient := new(mocks.MockOpenAIClient)
+	service := NewTranslationServiceWithCache(mockClient, &mockLogger{}, fs, "/cache").WithMemory(memory, "en", 0)
+
+	// A machine translation is remembered as a whole, its sentences not being aligned
+	mockClient.On("ChatCompletion", mock.Anything, mock.Anything, interfaces.ChatOptions{}).Return("Bienvenue. Voici le plan.", nil).Once()
+	_, err = service.TranslateBatch(ctx, []string{"Welcome. Here is the plan."}, "fr")
+	require.NoError(t, err)
+	assert.Equal(t, 1, memory.Len())
+
+	// The memory is saved after the batch
+	saved, err := OpenTranslationMemory(fs, "/cache/memory.json")
+	require.NoError(t, err)
+	assert.Equal(t, 1, saved.Len())
+
+	// The same text needs no request on another slide, but it does with another prompt
+	translated, err := service.Translate(withSlideIndex(ctx, 4), "Welcome. Here is the plan.", "fr")
+	require.NoError(t, err)
+	assert.Equal(t, "Bienvenue. Voici le plan.", translated)
+	formal, err := service.WithPrompt(TranslationPrompt{Tone: "formal"}, "en")
+	require.NoError(t, err)
+	_, ok := formal.fromMemory(ctx, "Welcome. Here is the plan.", "fr")
+	assert.False(t, ok)
+
+	// A new text made of imported sentences needs no request
+	memory.Add(MemorySegment{SourceLang: "en", TargetLang: "fr", Source: "Welcome.", Target: "Bienvenue.", Origin: MemoryOriginTMX})
+	memory.Add(MemorySegment{SourceLang: "en", TargetLang: "fr", Source: "Here is the plan.", Target: "Voici le plan.", Origin: MemoryOriginTMX})
+	translated, err = service.Translate(ctx, "Here is the plan.\nWelcome.", "fr")
+	require.NoError(t, err)
+	assert.Equal(t, "Voici le plan.\nBienvenue.", translated)
+	mockClient.AssertExpectations(t)
+
+	// Unless the result breaks the glossary
+	glossary := &Glossary{translate: map[string][]GlossaryTerm{"fr": {{Source: "plan", Target: "programme"}}}}
+	_, ok = service.WithGlossary(glossary).fromMemory(ctx, "Here is the plan.", "fr")
+	assert.False(t, ok)
+
+	// Fixing a typo sends the approved translation of the sentence along
+	referenced := mock.MatchedBy(func(messages []openai.ChatCompletionMessageParamUnion) bool {
+		prompt := userPrompt(messages)
+		return strings.Contains(prompt, `"Welcome." as "Bienvenue."`) && strings.Contains(prompt, `"Here is the plan." as "Voici le plan."`)
+	})
+	mockClient.On("ChatCompletion", mock.Anything, referenced, interfaces.ChatOptions{}).Return("Bienvenue. Voici le plan !", nil).Once()
+	translated, err = service.Translate(ctx, "Welcome. Here is the plan!", "fr")
+	require.NoError(t, err)
+	assert.Equal(t, "Bienvenue. Voici le plan !", translated)
+	mockClient.AssertExpectations(t)
+}
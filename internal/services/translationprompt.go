@@ -0,0 +1,189 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"gocreator/internal/interfaces"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// TranslationPrompt is how translations are requested from OpenAI: the chat model and its
+// temperature, a system prompt, and the user prompt, a Go template executed with a PromptData.
+// Zero fields use the defaults: the model of the client, the temperature of the model, no
+// system prompt and the built-in user prompt. The instructions of the glossary, the translation
+// memory and speech markup are added after the user prompt, whichever it is.
+type TranslationPrompt struct {
+	Model       string   // Chat model, "" for the default (gpt-4o-mini)
+	Temperature *float64 // Sampling temperature, nil for the default of the model
+	System      string   // System prompt, "" for none
+	User        string   // Go template of the user prompt, "" for the built-in one
+	Audience    string   // Who the narration is for, told to the model when set
+	Tone        string   // Tone of the narration, told to the model when set
+
+	user *template.Template
+}
+
+// PromptData is what the template of the user prompt is executed with
+type PromptData struct {
+	Text           string // Narration to translate
+	SourceLanguage string // Language of the narration, "" when not configured
+	TargetLanguage string
+	Slide          int    // Number of the slide from 1, 0 when the text is not of a slide
+	Notes          string // Notes for translators of the slide, never narrated
+	Audience       string
+	Tone           string
+}
+
+// WithPrompt returns a translation service requesting translations from sourceLang as prompt says,
+// sharing the caches and scheduler of s. The template of the user prompt is checked here, so a
+// mistake in it fails before any translation.
+func (s *TranslationService) WithPrompt(prompt TranslationPrompt, sourceLang string) (*TranslationService, error) {
+	if prompt.User != "" {
+		user, err := template.New("user prompt").Parse(prompt.User)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user prompt template: %w", err)
+		}
+		if err := user.Execute(io.Discard, PromptData{Text: "Hello", SourceLanguage: sourceLang, TargetLanguage: "fr", Slide: 1}); err != nil {
+			return nil, fmt.Errorf("invalid user prompt template: %w", err)
+		}
+		prompt.user = user
+	}
+	withPrompt := *s
+	withPrompt.prompt = &prompt
+	withPrompt.sourceLang = sourceLang
+	return &withPrompt, nil
+}
+
+// isDefault reports whether p, possibly nil, requests translations as the built-in prompt does
+func (p *TranslationPrompt) isDefault() bool {
+	return p == nil || (p.Model == "" && p.Temperature == nil && p.System == "" && p.User == "" && p.Audience == "" && p.Tone == "")
+}
+
+// options returns the chat options of the requests of p, possibly nil
+func (p *TranslationPrompt) options() interfaces.ChatOptions {
+	if p == nil {
+		return interfaces.ChatOptions{}
+	}
+	return interfaces.ChatOptions{Model: p.Model, Temperature: p.Temperature}
+}
+
+// system returns the system prompt of p, possibly nil
+func (p *TranslationPrompt) system() string {
+	if p == nil {
+		return ""
+	}
+	return p.System
+}
+
+// request returns the user prompt translating data.Text, before the instructions of its hints
+func (p *TranslationPrompt) request(data PromptData) (string, error) {
+	if p == nil || p.user == nil {
+		prompt := fmt.Sprintf("Translate '%s' to %s and don't return anything else than the translation.", data.Text, data.TargetLanguage)
+		return prompt + audienceInstructions(data.Audience, data.Tone) + notesInstructions(data.Notes), nil
+	}
+	var out strings.Builder
+	if err := p.user.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render the user prompt: %w", err)
+	}
+	return out.String(), nil
+}
+
+// data returns what the user prompt of p, possibly nil, translating text to targetLang for the
+// slide at index slide (-1 for none), with its notes for translators, is executed with
+func (p *TranslationPrompt) data(text, sourceLang, targetLang string, slide int, notes string) PromptData {
+	data := PromptData{Text: text, SourceLanguage: sourceLang, TargetLanguage: targetLang, Slide: slide + 1, Notes: notes}
+	if p != nil {
+		data.Audience, data.Tone = p.Audience, p.Tone
+	}
+	return data
+}
+
+// audienceInstructions returns the instructions of a prompt about the audience and tone of the
+// narration, "" when neither is set
+func audienceInstructions(audience, tone string) string {
+	var instructions string
+	if audience != "" {
+		instructions += fmt.Sprintf(" The narration is for %s.", audience)
+	}
+	if tone != "" {
+		instructions += fmt.Sprintf(" Use a %s tone.", tone)
+	}
+	return instructions
+}
+
+// notesInstructions returns the instructions of a prompt giving the notes for translators of a slide,
+// "" when none
+func notesInstructions(notes string) string {
+	if notes == "" {
+		return ""
+	}
+	return fmt.Sprintf(" Notes for the translator, as context only, not to translate: %q.", notes)
+}
+
+// promptFingerprint returns what the OpenAI translation of text to targetLang, for the slide at index
+// slide with notes, depends on in the prompt, "" with the built-in prompt. A text whose request is unchanged by an
+// edit of the prompt, such as a branch of the template for another language, keeps its fingerprint.
+// Deck mode sends no user prompt, so only the model, temperature, system prompt, audience and tone count.
+func (s *TranslationService) promptFingerprint(text, targetLang string, slide int, notes string) string {
+	p := s.prompt
+	if s.deck(targetLang) && p != nil {
+		deck := *p
+		deck.User = ""
+		p = &deck
+	}
+	if p.isDefault() {
+		return ""
+	}
+	data := p.chatSettings()
+	if s.deck(targetLang) {
+		data += fmt.Sprintf("|deck|%s|%s", p.Audience, p.Tone)
+	} else {
+		request, err := p.request(p.data(text, s.sourceLang, targetLang, slide, notes))
+		if err != nil {
+			request = p.User // Fails when translating, not cached
+		}
+		data += "|" + request
+	}
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:8])
+}
+
+// chatSettings identifies the model, temperature and system prompt of p, possibly nil
+func (p *TranslationPrompt) chatSettings() string {
+	if p == nil {
+		return ""
+	}
+	temperature := "default"
+	if p.Temperature != nil {
+		temperature = fmt.Sprintf("%g", *p.Temperature)
+	}
+	return fmt.Sprintf("%s|%s|%s", p.Model, temperature, p.System)
+}
+
+// fingerprint identifies every setting of p, possibly nil, "" for the built-in prompt
+func (p *TranslationPrompt) fingerprint() string {
+	if p.isDefault() {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%s", p.chatSettings(), p.User, p.Audience, p.Tone)))
+	return hex.EncodeToString(hash[:8])
+}
+
+// translationMessages returns the messages sent to translate text to targetLang, following hints
+func translationMessages(text, targetLang string, hints translationHints) ([]openai.ChatCompletionMessageParamUnion, error) {
+	prompt, err := translationPrompt(text, targetLang, hints)
+	if err != nil {
+		return nil, err
+	}
+	var messages []openai.ChatCompletionMessageParamUnion
+	if system := hints.Prompt.system(); system != "" {
+		messages = append(messages, openai.SystemMessage(system))
+	}
+	return append(messages, openai.UserMessage(prompt)), nil
+}
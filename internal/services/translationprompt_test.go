@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gocreator/internal/interfaces"
+	"gocreator/internal/mocks"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslationService_WithPrompt(t *testing.T) {
+	base := NewTranslationService(new(mocks.MockOpenAIClient), &mockLogger{})
+
+	_, err := base.WithPrompt(TranslationPrompt{User: "Translate {{.Text"}, "en")
+	assert.ErrorContains(t, err, "invalid user prompt template")
+	_, err = base.WithPrompt(TranslationPrompt{User: "Translate {{.Txt}}"}, "en")
+	assert.ErrorContains(t, err, "invalid user prompt template")
+
+	// The built-in prompt keeps the keys of the translations
+	service, err := base.WithPrompt(TranslationPrompt{}, "en")
+	require.NoError(t, err)
+	assert.Equal(t, base.getCacheKey(context.Background(), "Hello", "fr"), service.getCacheKey(context.Background(), "Hello", "fr"))
+}
+
+func TestTranslationService_PromptCacheKey(t *testing.T) {
+	ctx := context.Background()
+	base := NewTranslationService(new(mocks.MockOpenAIClient), &mockLogger{})
+	withPrompt := func(prompt TranslationPrompt) *TranslationService {
+		t.Helper()
+		service, err := base.WithPrompt(prompt, "en")
+		require.NoError(t, err)
+		return service
+	}
+	temperature, colder := 0.7, 0.2
+
+	// Every setting of the prompt is part of the key
+	keys := map[string]bool{base.getCacheKey(context.Background(), "Hello", "fr"): true}
+	for _, prompt := range []TranslationPrompt{
+		{Model: "gpt-4o"},
+		{Temperature: &temperature},
+		{Temperature: &colder},
+		{System: "You translate software tutorials."},
+		{Audience: "developers"},
+		{Tone: "friendly"},
+		{User: "Translate {{.Text}} to {{.TargetLanguage}}."},
+	} {
+		key := withPrompt(prompt).getCacheKey(context.Background(), "Hello", "fr")
+		assert.False(t, keys[key], "prompt %+v", prompt)
+		keys[key] = true
+	}
+
+	// Only the texts whose request changes get another key
+	frenchOnly := `Translate {{.Text}} to {{.TargetLanguage}}.{{if eq .TargetLanguage "de"}} Use "Sie".{{end}}`
+	user := withPrompt(TranslationPrompt{User: "Translate {{.Text}} to {{.TargetLanguage}}."})
+	assert.Equal(t, user.getCacheKey(context.Background(), "Hello", "fr"), withPrompt(TranslationPrompt{User: frenchOnly}).getCacheKey(context.Background(), "Hello", "fr"))
+	assert.NotEqual(t, user.getCacheKey(context.Background(), "Hello", "de"), withPrompt(TranslationPrompt{User: frenchOnly}).getCacheKey(context.Background(), "Hello", "de"))
+	assert.Equal(t, user.getCacheKey(withSlideIndex(ctx, 0), "Hello", "fr"), user.getCacheKey(withSlideIndex(ctx, 3), "Hello", "fr"))
+	bySlide := withPrompt(TranslationPrompt{User: "Slide {{.Slide}}: translate {{.Text}} to {{.TargetLanguage}}."})
+	assert.NotEqual(t, bySlide.getCacheKey(withSlideIndex(ctx, 0), "Hello", "fr"), bySlide.getCacheKey(withSlideIndex(ctx, 3), "Hello", "fr"))
+
+	// Deck mode sends no user prompt
+	deck := base.WithDeck(0)
+	deckUser, err := deck.WithPrompt(TranslationPrompt{User: "Translate {{.Text}}."}, "en")
+	require.NoError(t, err)
+	assert.Equal(t, deck.getCacheKey(context.Background(), "Hello", "fr"), deckUser.getCacheKey(context.Background(), "Hello", "fr"))
+}
+
+func TestTranslationService_TranslatePrompt(t *testing.T) {
+	ctx := withSlideIndex(context.Background(), 2)
+	mockClient := new(mocks.MockOpenAIClient)
+	temperature := 0.2
+	service, err := NewTranslationService(mockClient, &mockLogger{}).WithPrompt(TranslationPrompt{
+		Model:       "gpt-4o",
+		Temperature: &temperature,
+		System:      "You translate software tutorials.",
+		User:        "Slide {{.Slide}} for {{.Audience}}, in a {{.Tone}} tone: translate {{printf \"%q\" .Text}} from {{.SourceLanguage}} to {{.TargetLanguage}}.",
+		Audience:    "developers",
+		Tone:        "friendly",
+	}, "en")
+	require.NoError(t, err)
+
+	requested := mock.MatchedBy(func(messages []openai.ChatCompletionMessageParamUnion) bool {
+		return len(messages) == 2 && messages[0].OfSystem != nil &&
+			messages[0].OfSystem.Content.OfString.Value == "You translate software tutorials." &&
+			userPrompt(messages) == `Slide 3 for developers, in a friendly tone: translate "Wait ⟦1⟧ here" from en to fr.`+
+				" Keep every placeholder such as ⟦1⟧ exactly as it is, where it belongs in the translation."
+	})
+	mockClient.On("ChatCompletion", mock.Anything, requested, interfaces.ChatOptions{Model: "gpt-4o", Temperature: &temperature}).
+		Return("Attendez ⟦1⟧ ici", nil).Once()
+
+	translated, err := service.Translate(ctx, "Wait [pause 1s] here", "fr")
+	require.NoError(t, err)
+	assert.Equal(t, "Attendez [pause 1s] ici", translated)
+	mockClient.AssertExpectations(t)
+}
+
+func TestTranslationService_TranslateBatchDeckPrompt(t *testing.T) {
+	mockClient := new(mocks.MockOpenAIClient)
+	service, err := NewTranslationService(mockClient, &mockLogger{}).WithDeck(0).WithPrompt(TranslationPrompt{
+		Model:    "gpt-4o",
+		System:   "You translate software tutorials.",
+		Audience: "developers",
+		Tone:     "friendly",
+	}, "en")
+	require.NoError(t, err)
+
+	requested := mock.MatchedBy(func(messages []openai.ChatCompletionMessageParamUnion) bool {
+		if len(messages) != 3 || messages[1].OfSystem == nil || messages[1].OfSystem.Content.OfString.Value != "You translate software tutorials." {
+			return false
+		}
+		var req deckRequest
+		return json.Unmarshal([]byte(userPrompt(messages)), &req) == nil && req.Audience == "developers" && req.Tone == "friendly"
+	})
+	mockClient.On("ChatCompletion", mock.Anything, requested, interfaces.ChatOptions{Model: "gpt-4o", Schema: &deckSchema}).
+		Return(`{"translations":[{"index":0,"text":"Bonjour"}]}`, nil).Once()
+
+	translated, err := service.TranslateBatch(context.Background(), []string{"Hello"}, "fr")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bonjour"}, translated)
+	mockClient.AssertExpectations(t)
+}
+
+func TestTranslationService_TranslatorNotes(t *testing.T) {
+	mockClient := new(mocks.MockOpenAIClient)
+	service := NewTranslationService(mockClient, &mockLogger{})
+	ctx := withTranslatorNotes(context.Background(), []string{"", "Spoken over a login form"})
+
+	// The notes of a slide are sent with its text and keyed with its translation
+	assert.Equal(t, service.getCacheKey(context.Background(), "Sign in", "fr"), service.getCacheKey(withSlideIndex(ctx, 0), "Sign in", "fr"))
+	assert.NotEqual(t, service.getCacheKey(context.Background(), "Sign in", "fr"), service.getCacheKey(withSlideIndex(ctx, 1), "Sign in", "fr"))
+	noted := mock.MatchedBy(func(messages []openai.ChatCompletionMessageParamUnion) bool {
+		return strings.Contains(userPrompt(messages), `Notes for the translator, as context only, not to translate: "Spoken over a login form".`)
+	})
+	mockClient.On("ChatCompletion", mock.Anything, noted, interfaces.ChatOptions{}).Return("Se connecter", nil).Once()
+	translated, err := service.Translate(withSlideIndex(ctx, 1), "Sign in", "fr")
+	require.NoError(t, err)
+	assert.Equal(t, "Se connecter", translated)
+	mockClient.AssertExpectations(t)
+
+	// So are they in deck mode, without changing the slides of the chunk
+	chunk := []deckSlide{{Index: 0, Text: "Hello"}, {Index: 1, Text: "Sign in"}}
+	request := service.WithDeck(0).newDeckRequest(ctx, chunk, []string{"Hello", "Sign in"}, "fr")
+	assert.Equal(t, []deckSlide{{Index: 0, Text: "Hello"}, {Index: 1, Text: "Sign in", Notes: "Spoken over a login form"}}, request.Slides)
+	assert.Empty(t, chunk[1].Notes)
+}
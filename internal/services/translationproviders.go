@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gocreator/internal/interfaces"
+)
+
+// Names of the translation providers
+const (
+	ProviderOpenAI         = "openai"
+	ProviderDeepL          = "deepl"
+	ProviderLibreTranslate = "libretranslate"
+)
+
+// openAITranslator translates with OpenAI chat completions
+type openAITranslator struct {
+	client interfaces.OpenAIClient
+}
+
+// NewOpenAITranslator creates the translation provider translating with OpenAI chat completions
+func NewOpenAITranslator(client interfaces.OpenAIClient) interfaces.TranslationProvider {
+	return &openAITranslator{client: client}
+}
+
+// Translate translates text to targetLang
+func (t *openAITranslator) Translate(ctx context.Context, text, targetLang string) (string, error) {
+	return t.translateWithHints(ctx, text, targetLang, translationHints{Slide: -1})
+}
+
+// translateWithHints translates text to targetLang, instructed to follow the hints
+func (t *openAITranslator) translateWithHints(ctx context.Context, text, targetLang string, hints translationHints) (string, error) {
+	messages, err := translationMessages(text, targetLang, hints)
+	if err != nil {
+		return "", err
+	}
+	return t.client.ChatCompletion(ctx, messages, hints.Prompt.options())
+}
+
+// translationHints is what a translation request says besides its text: the glossary terms of the
+// text, the translations of its sentences, or of similar ones, in the translation memory, the
+// problems of a previous translation of the text, the notes for translators of its slide, and
+// the prompt asking for the translation
+type translationHints struct {
+	Terms      []GlossaryTerm
+	Memory     []MemoryMatch
+	Problems   []string
+	Notes      string
+	Prompt     *TranslationPrompt // nil for the built-in prompt
+	SourceLang string
+	Slide      int // Index of the slide of the text, -1 for none
+}
+
+// hintedProvider is a translation provider following hints. Other providers only get the
+// glossary terms kept as is, protected as placeholders.
+type hintedProvider interface {
+	translateWithHints(ctx context.Context, text, targetLang string, hints translationHints) (string, error)
+}
+
+// TranslationProviders routes every language to the provider translating it
+type TranslationProviders struct {
+	providers map[string]interfaces.TranslationProvider
+	fallback  string
+	languages map[string]string
+}
+
+// NewTranslationProviders creates the routing of languages to providers, by name: languages
+// names the provider of a language, fallback the provider of the others. Every name must be in providers.
+func NewTranslationProviders(providers map[string]interfaces.TranslationProvider, fallback string, languages map[string]string) (*TranslationProviders, error) {
+	known := func(name string) error {
+		if _, ok := providers[name]; ok {
+			return nil
+		}
+		names := make([]string, 0, len(providers))
+		for name := range providers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown translation provider %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+
+	if err := known(fallback); err != nil {
+		return nil, err
+	}
+	for lang, name := range languages {
+		if err := known(name); err != nil {
+			return nil, fmt.Errorf("language %s: %w", lang, err)
+		}
+	}
+	return &TranslationProviders{providers: providers, fallback: fallback, languages: languages}, nil
+}
+
+// For returns the name and provider translating to lang
+func (p *TranslationProviders) For(lang string) (string, interfaces.TranslationProvider) {
+	name, ok := p.languages[lang]
+	if !ok {
+		name = p.fallback
+	}
+	return name, p.providers[name]
+}
@@ -0,0 +1,447 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Defaults of the translation quality checks
+const (
+	DefaultQAAttempts               = 2
+	DefaultMinLengthRatio           = 0.5
+	DefaultMaxLengthRatio           = 2.0
+	DefaultBackTranslationThreshold = 0.4
+)
+
+// Texts shorter than these are not checked for their length or language, which vary too much
+const (
+	minLengthCheckChars   = 20
+	minLanguageCheckWords = 5
+)
+
+// TranslationQA configures the quality checks of machine translations: their length against the
+// source, the numbers, URLs, speech markup and placeholders they keep, the language they are in
+// and, optionally, how close their translation back to the source language is to the source.
+type TranslationQA struct {
+	Attempts                 int     // Times a translation failing a check is translated, 0 for the default (2)
+	MinLengthRatio           float64 // Shortest translation, relative to the source, 0 for the default (0.5)
+	MaxLengthRatio           float64 // Longest translation, relative to the source, 0 for the default (2)
+	BackTranslation          bool    // Translate every translation back to compare it to the source
+	BackTranslationThreshold float64 // Least similarity of the back translation, 0 for the default (0.4)
+	Strict                   bool    // Fail the batch when a translation still fails a check, instead of flagging it
+}
+
+// withDefaults returns qa with the default of every setting left to 0
+func (qa TranslationQA) withDefaults() TranslationQA {
+	if qa.Attempts <= 0 {
+		qa.Attempts = DefaultQAAttempts
+	}
+	if qa.MinLengthRatio <= 0 {
+		qa.MinLengthRatio = DefaultMinLengthRatio
+	}
+	if qa.MaxLengthRatio <= 0 {
+		qa.MaxLengthRatio = DefaultMaxLengthRatio
+	}
+	if qa.BackTranslationThreshold <= 0 {
+		qa.BackTranslationThreshold = DefaultBackTranslationThreshold
+	}
+	return qa
+}
+
+// WithQA returns a translation service checking the quality of the translations from sourceLang,
+// retrying or flagging those failing a check, sharing the caches and scheduler of s
+func (s *TranslationService) WithQA(qa TranslationQA, sourceLang string) *TranslationService {
+	qa = qa.withDefaults()
+	withQA := *s
+	withQA.qa = &qa
+	withQA.sourceLang = sourceLang
+	return &withQA
+}
+
+// fingerprint identifies the checks of qa, possibly nil, that decide which translation is kept,
+// "" without checks. Strictness only fails the batch, so it is left out.
+func (qa *TranslationQA) fingerprint() string {
+	if qa == nil {
+		return ""
+	}
+	fingerprint := fmt.Sprintf("%d|%g|%g", qa.Attempts, qa.MinLengthRatio, qa.MaxLengthRatio)
+	if qa.BackTranslation {
+		fingerprint += fmt.Sprintf("|back=%g", qa.BackTranslationThreshold)
+	}
+	return fingerprint
+}
+
+// qaAttempts returns how many times a translation failing a check is translated
+func (s *TranslationService) qaAttempts() int {
+	if s.qa == nil {
+		return 1
+	}
+	return s.qa.Attempts
+}
+
+// qaIssues returns the problems the quality checks find in the translation of text to targetLang,
+// none when it passes them or there are no checks
+func (s *TranslationService) qaIssues(ctx context.Context, text, translated, targetLang string) []string {
+	if s.qa == nil {
+		return nil
+	}
+	var issues []string
+	if issue := checkLength(text, translated, s.qa.MinLengthRatio, s.qa.MaxLengthRatio); issue != "" {
+		issues = append(issues, issue)
+	}
+	issues = append(issues, checkNumbers(text, translated)...)
+	issues = append(issues, checkURLs(text, translated)...)
+	issues = append(issues, checkMarkup(text, translated)...)
+	if issue := checkLanguage(translated, targetLang); issue != "" {
+		issues = append(issues, issue)
+	}
+	if s.qa.BackTranslation {
+		if issue := s.checkBackTranslation(ctx, text, translated, targetLang); issue != "" {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// review runs the quality checks on the translations of a batch, in parallel, and flags the slides
+// failing them in the report of ctx. With strict checks, a failing slide fails the batch.
+func (s *TranslationService) review(ctx context.Context, texts, translated []string, targetLang string) error {
+	if s.qa == nil {
+		return nil
+	}
+	issues := make([][]string, len(texts))
+	var wg sync.WaitGroup
+	for i, text := range texts {
+		if isSilent(text) {
+			continue
+		}
+		wg.Add(1)
+		go func(idx int, txt string) {
+			defer wg.Done()
+			issues[idx] = s.qaIssues(withSlideIndex(ctx, idx), txt, translated[idx], targetLang)
+		}(i, text)
+	}
+	wg.Wait()
+
+	var failed []string
+	for i, problems := range issues {
+		if len(problems) == 0 {
+			continue
+		}
+		s.logger.Warn("Translation fails quality checks", "lang", targetLang, "text", i, "problems", strings.Join(problems, "; "))
+		recordInReport(withSlideIndex(ctx, i), func(c *SlideReport) { c.QA = problems })
+		failed = append(failed, fmt.Sprintf("text %d: %s", i, strings.Join(problems, "; ")))
+	}
+	if len(failed) > 0 && s.qa.Strict {
+		return fmt.Errorf("translation fails quality checks: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// checkLength reports a translation whose length is out of the ratio range of the source's, such as
+// a truncated answer or one chatting around the translation
+func checkLength(text, translated string, minRatio, maxRatio float64) string {
+	source := textLength(text)
+	if source < minLengthCheckChars {
+		return ""
+	}
+	ratio := float64(textLength(translated)) / float64(source)
+	if ratio < minRatio || ratio > maxRatio {
+		return fmt.Sprintf("length is %.2f times the source, expected %.2g to %.2g", ratio, minRatio, maxRatio)
+	}
+	return ""
+}
+
+// textLength returns the length of text without spaces, counting the ideographs and syllables
+// of Chinese, Japanese and Korean as the letters of the words they stand for
+func textLength(text string) int {
+	length := 0
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			length += 3
+		case unicode.Is(unicode.Hangul, r):
+			length += 2
+		default:
+			length++
+		}
+	}
+	return length
+}
+
+// numberPattern matches a number, with its thousands separators and decimals
+var numberPattern = regexp.MustCompile(`\d+(?:[.,\x{00A0}\x{202F}' ]\d{3}\b)*(?:[.,]\d+)?`)
+
+// checkNumbers reports the numbers of the source missing from the translation, and those added.
+// Numbers are compared by their digits, as languages separate thousands and decimals differently.
+func checkNumbers(text, translated string) []string {
+	digits := func(s string) []string {
+		numbers := numberPattern.FindAllString(s, -1)
+		for i, number := range numbers {
+			numbers[i] = strings.Map(func(r rune) rune {
+				if unicode.IsDigit(r) {
+					return r
+				}
+				return -1
+			}, number)
+		}
+		return numbers
+	}
+	return compareTokens("number", digits(text), digits(translated))
+}
+
+// urlPattern matches URLs and email addresses
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"'«»()\[\]]+|[\w.+-]+@[\w-]+(?:\.[\w-]+)+`)
+
+// checkURLs reports the URLs and email addresses of the source missing from the translation
+func checkURLs(text, translated string) []string {
+	urls := func(s string) []string {
+		matches := urlPattern.FindAllString(s, -1)
+		for i, match := range matches {
+			matches[i] = strings.TrimRight(match, ".,;:!?")
+		}
+		return matches
+	}
+	var issues []string
+	for _, issue := range compareTokens("URL", urls(text), urls(translated)) {
+		if strings.HasSuffix(issue, "missing") {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// placeholderPattern matches the placeholders of templates and of the translation of speech markup
+var placeholderPattern = regexp.MustCompile(placeholderOpen + `\d+` + placeholderClose + `|\{\{\s*[\w.]+\s*\}\}|\{[\w.]+\}|%(?:\(\w+\))?[sdf]`)
+
+// checkMarkup reports the speech markup and placeholders of the source missing from the
+// translation, and those added
+func checkMarkup(text, translated string) []string {
+	_, sourceMarkup := protectMarkup(text)
+	_, translatedMarkup := protectMarkup(translated)
+	issues := compareTokens("speech markup", sourceMarkup, translatedMarkup)
+	return append(issues, compareTokens("placeholder", placeholderPattern.FindAllString(text, -1), placeholderPattern.FindAllString(translated, -1))...)
+}
+
+// compareTokens reports the tokens of kind in source and not in translated, and the other way round,
+// counting repeated tokens
+func compareTokens(kind string, source, translated []string) []string {
+	counts := make(map[string]int)
+	for _, token := range source {
+		counts[token]++
+	}
+	for _, token := range translated {
+		counts[token]--
+	}
+	tokens := make([]string, 0, len(counts))
+	for token := range counts {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+
+	var issues []string
+	for _, token := range tokens {
+		switch {
+		case counts[token] > 0:
+			issues = append(issues, fmt.Sprintf("%s %s is missing", kind, token))
+		case counts[token] < 0:
+			issues = append(issues, fmt.Sprintf("%s %s was added", kind, token))
+		}
+	}
+	return issues
+}
+
+// scripts are the writing systems a translation is checked against
+var scripts = map[string]*unicode.RangeTable{
+	"Arabic":     unicode.Arabic,
+	"Armenian":   unicode.Armenian,
+	"Bengali":    unicode.Bengali,
+	"Cyrillic":   unicode.Cyrillic,
+	"Devanagari": unicode.Devanagari,
+	"Georgian":   unicode.Georgian,
+	"Greek":      unicode.Greek,
+	"Han":        unicode.Han,
+	"Hangul":     unicode.Hangul,
+	"Hebrew":     unicode.Hebrew,
+	"Hiragana":   unicode.Hiragana,
+	"Katakana":   unicode.Katakana,
+	"Latin":      unicode.Latin,
+	"Tamil":      unicode.Tamil,
+	"Thai":       unicode.Thai,
+}
+
+// languageScripts are the scripts of the languages not written in the Latin script
+var languageScripts = map[string][]string{
+	"ar": {"Arabic"}, "fa": {"Arabic"}, "ur": {"Arabic"},
+	"be": {"Cyrillic"}, "bg": {"Cyrillic"}, "kk": {"Cyrillic"}, "mk": {"Cyrillic"}, "ru": {"Cyrillic"}, "uk": {"Cyrillic"},
+	"sr": {"Cyrillic", "Latin"},
+	"bn": {"Bengali"},
+	"el": {"Greek"},
+	"he": {"Hebrew"}, "yi": {"Hebrew"},
+	"hi": {"Devanagari"}, "mr": {"Devanagari"}, "ne": {"Devanagari"},
+	"hy": {"Armenian"},
+	"ja": {"Han", "Hiragana", "Katakana"},
+	"ka": {"Georgian"},
+	"ko": {"Hangul", "Han"},
+	"ta": {"Tamil"},
+	"th": {"Thai"},
+	"zh": {"Han"},
+}
+
+// stopwords are frequent words telling apart languages written in the Latin script
+var stopwords = map[string]map[string]bool{
+	"en": wordSet("the and is are of to with this that you your for it be will we have on not what"),
+	"fr": wordSet("le les des est et une du pour vous nous dans avec pas sur ce cette qui sont au aux"),
+	"de": wordSet("der die das und ist nicht mit sie ein eine den dem zu auf für sind wir auch von ich"),
+	"es": wordSet("el los las y está una del por para con se su son como pero muy al más lo esto"),
+	"it": wordSet("il gli della di che è per sono non anche come questo alla nel più ma lo una delle"),
+	"pt": wordSet("o os uma do da dos das não para com é em você isso mais são no na ao"),
+	"nl": wordSet("het een van niet dat op te zijn voor met je ook wat dit maar hij er wordt"),
+}
+
+// wordSet returns the set of the words of a space separated list
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// checkLanguage reports a translation that doesn't look like targetLang: written mostly in another
+// script, or, in the Latin script, made of the frequent words of another language
+func checkLanguage(translated, targetLang string) string {
+	lang, _, _ := strings.Cut(normalizeLang(targetLang), "-")
+	text := urlPattern.ReplaceAllString(translated, "")
+
+	expected, ok := languageScripts[lang]
+	if !ok {
+		expected = []string{"Latin"}
+	}
+	letters, matching := 0, 0
+	counts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for name, table := range scripts {
+			if unicode.Is(table, r) {
+				counts[name]++
+			}
+		}
+	}
+	for _, name := range expected {
+		matching += counts[name]
+	}
+	if letters >= minLengthCheckChars/2 && matching*2 < letters {
+		dominant := ""
+		for name, count := range counts {
+			if dominant == "" || count > counts[dominant] || (count == counts[dominant] && name < dominant) {
+				dominant = name
+			}
+		}
+		return fmt.Sprintf("text is mostly in the %s script, expected %s for %s", dominant, strings.Join(expected, " or "), targetLang)
+	}
+	if ok {
+		return "" // No frequent words to tell languages of the same script apart
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) })
+	if len(words) < minLanguageCheckWords {
+		return ""
+	}
+	scores := make(map[string]int)
+	for _, word := range words {
+		for candidate, set := range stopwords {
+			if set[word] {
+				scores[candidate]++
+			}
+		}
+	}
+	best := ""
+	for candidate, score := range scores {
+		if best == "" || score > scores[best] || (score == scores[best] && candidate < best) {
+			best = candidate
+		}
+	}
+	// Another language's frequent words must clearly dominate
+	if best == "" || best == lang || scores[best] < 3 || scores[best] < 2*scores[lang] || scores[best]*5 < len(words) {
+		return ""
+	}
+	return fmt.Sprintf("text looks like %s, not %s", best, targetLang)
+}
+
+// checkBackTranslation reports a translation whose translation back to the source language is too
+// different from the source
+func (s *TranslationService) checkBackTranslation(ctx context.Context, text, translated, targetLang string) string {
+	back, err := s.backTranslate(ctx, translated, targetLang)
+	if err != nil {
+		s.logger.Warn("Back translation failed, skipping its check", "lang", targetLang, "error", err)
+		return ""
+	}
+	source, _ := protectMarkup(text)
+	if similarity := wordSimilarity(source, back); similarity < s.qa.BackTranslationThreshold {
+		return fmt.Sprintf("back translation is %.0f%% similar to the source, expected %.0f%%", similarity*100, s.qa.BackTranslationThreshold*100)
+	}
+	return ""
+}
+
+// backTranslate translates the translation to targetLang back to the source language, with the
+// provider of targetLang, caching the result
+func (s *TranslationService) backTranslate(ctx context.Context, translated, targetLang string) (string, error) {
+	name, provider := s.provider(targetLang)
+	hash := sha256.Sum256([]byte(fmt.Sprintf("back|%s|%s|%s|%s", translated, targetLang, s.sourceLang, name)))
+	cacheKey := hex.EncodeToString(hash[:])
+	if cached, ok := s.lookup(ctx, cacheKey); ok {
+		return cached, nil
+	}
+
+	// The markup is left out, only the words are compared
+	protected, _ := protectMarkup(translated)
+	var back string
+	err := s.scheduler.API(ctx, func() error {
+		var err error
+		recordAPICall(ctx)
+		back, err = provider.Translate(ctx, protected, s.sourceLang)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("back translation with %s failed: %w", name, err)
+	}
+	s.setInMemoryCache(cacheKey, back)
+	s.setInDiskCache(cacheKey, back)
+	return back, nil
+}
+
+// wordSimilarity returns the share of the words of a and b they have in common, from 0 to 1,
+// ignoring case and order
+func wordSimilarity(a, b string) float64 {
+	words := func(s string) []string {
+		return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return !unicode.IsLetter(r) })
+	}
+	aWords, bWords := words(a), words(b)
+	if len(aWords)+len(bWords) == 0 {
+		return 1
+	}
+	counts := make(map[string]int)
+	for _, word := range aWords {
+		counts[word]++
+	}
+	common := 0
+	for _, word := range bWords {
+		if counts[word] > 0 {
+			counts[word]--
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(aWords)+len(bWords))
+}
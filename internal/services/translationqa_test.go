@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gocreator/internal/interfaces"
+	"gocreator/internal/mocks"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLength(t *testing.T) {
+	source := "Open the settings and choose a new password."
+	assert.Empty(t, checkLength(source, "Ouvrez les réglages et choisissez un nouveau mot de passe.", 0.5, 2))
+	assert.Equal(t, "length is 0.19 times the source, expected 0.5 to 2", checkLength(source, "Ouvrez.", 0.5, 2))
+	assert.Contains(t, checkLength(source, "Here is the translation: Ouvrez les réglages et choisissez un nouveau mot de passe. Let me know if you need anything else!", 0.5, 2), "length is 2.")
+	// Ideographs stand for whole words
+	assert.Empty(t, checkLength(source, "設定を開いて新しいパスワードを選択してください。", 0.5, 2))
+	// Short texts are not compared
+	assert.Empty(t, checkLength("Hello", "Bonjour à toutes et à tous", 0.5, 2))
+}
+
+func TestCheckNumbers(t *testing.T) {
+	assert.Empty(t, checkNumbers("Save 1,500.75 dollars in 2024, at 10:30.", "Économisez 1 500,75 dollars en 2024, à 10h30."))
+	assert.Equal(t, []string{"number 2024 is missing", "number 2025 was added"}, checkNumbers("Released in 2024.", "Sorti en 2025."))
+	assert.Equal(t, []string{"number 3 is missing"}, checkNumbers("Step 3 of 3", "Étape 3 sur trois"))
+}
+
+func TestCheckURLs(t *testing.T) {
+	assert.Empty(t, checkURLs("See https://example.com/docs.", "Voir https://example.com/docs."))
+	assert.Equal(t, []string{"URL help@example.com is missing", "URL https://example.com/docs is missing"},
+		checkURLs("See https://example.com/docs or write to help@example.com", "Voir la documentation ou écrivez-nous"))
+}
+
+func TestCheckMarkup(t *testing.T) {
+	assert.Empty(t, checkMarkup("Hello [pause 1s] *world* {name}", "Bonjour [pause 1s] *le monde* {name}"))
+	assert.Equal(t, []string{"speech markup [pause 1s] is missing", "placeholder {name} is missing"},
+		checkMarkup("Hello [pause 1s] {name}", "Bonjour"))
+	assert.Equal(t, []string{"placeholder ⟦2⟧ was added"}, checkMarkup("Hello", "Bonjour ⟦2⟧"))
+}
+
+func TestCheckLanguage(t *testing.T) {
+	tests := []struct {
+		name       string
+		translated string
+		lang       string
+		want       string
+	}{
+		{"french", "Ouvrez les réglages et choisissez un nouveau mot de passe pour votre compte.", "fr", ""},
+		{"untranslated", "Open the settings and choose a new password for your account, it is easy.", "fr", "text looks like en, not fr"},
+		{"regional variant", "Öffnen Sie die Einstellungen und wählen Sie ein neues Passwort für das Konto.", "de-AT", ""},
+		{"language without frequent words", "Otwórz ustawienia i wybierz nowe hasło do swojego konta.", "pl", ""},
+		{"japanese", "設定を開いて新しいパスワードを選択してください。", "ja", ""},
+		{"latin instead of japanese", "Open the settings and choose a new password.", "ja", "text is mostly in the Latin script, expected Han or Hiragana or Katakana for ja"},
+		{"russian", "Откройте настройки и выберите новый пароль.", "ru", ""},
+		{"short", "OK", "ru", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, checkLanguage(tt.translated, tt.lang))
+		})
+	}
+}
+
+func TestWordSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, wordSimilarity("Open the settings", "open the Settings!"))
+	assert.InDelta(t, 0.5, wordSimilarity("Open the settings", "Go to the settings page"), 1e-9)
+	assert.Equal(t, 0.0, wordSimilarity("Open the settings", "Bonjour"))
+}
+
+func TestTranslationService_TranslateQA(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mocks.MockOpenAIClient)
+	service := NewTranslationService(mockClient, &mockLogger{}).WithQA(TranslationQA{}, "en")
+
+	source := "Version 2 ships on 12 March with 40 new features."
+	toldWhy := mock.MatchedBy(func(messages []openai.ChatCompletionMessageParamUnion) bool {
+		return strings.Contains(userPrompt(messages), "A previous translation had these problems, avoid them: number 40 is missing.")
+	})
+	mockClient.On("ChatCompletion", mock.Anything, toldWhy, interfaces.ChatOptions{}).Return("La version 2 sort le 12 mars avec 40 nouveautés.", nil).Once()
+	mockClient.On("ChatCompletion", mock.Anything, mock.Anything, interfaces.ChatOptions{}).Return("La version 2 sort le 12 mars avec des nouveautés.", nil).Once()
+
+	translated, err := service.Translate(ctx, source, "fr")
+	require.NoError(t, err)
+	assert.Equal(t, "La version 2 sort le 12 mars avec 40 nouveautés.", translated)
+	mockClient.AssertExpectations(t)
+}
+
+func TestTranslationService_TranslateBatchQA(t *testing.T) {
+	mockClient := new(mocks.MockOpenAIClient)
+	texts := []string{"Visit https://example.com to learn more about it.", "", "Thanks for watching this video until the end."}
+	about := func(text string) any {
+		return mock.MatchedBy(func(messages []openai.ChatCompletionMessageParamUnion) bool {
+			return strings.Contains(userPrompt(messages), text)
+		})
+	}
+	mockClient.On("ChatCompletion", mock.Anything, about(texts[0]), interfaces.ChatOptions{}).Return("Visitez notre site pour en savoir plus à ce sujet.", nil).Times(2)
+
+	// A translation still failing the checks is flagged in the report, and not cached
+	service := NewTranslationService(mockClient, &mockLogger{}).WithQA(TranslationQA{}, "en")
+	report := newLanguageReport("fr", "", 3)
+	ctx := withLanguageReport(context.Background(), report)
+	translated, err := service.TranslateBatch(ctx, texts[:1], "fr")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Visitez notre site pour en savoir plus à ce sujet."}, translated)
+	assert.Equal(t, []string{"URL https://example.com is missing"}, report.Slides[0].QA)
+	_, ok := service.Cached(context.Background(), texts[0], "fr")
+	assert.False(t, ok)
+	mockClient.AssertExpectations(t)
+
+	// Strict checks fail the batch, before anything is synthesized
+	mockClient.On("ChatCompletion", mock.Anything, about(texts[0]), interfaces.ChatOptions{}).Return("Visitez notre site pour en savoir plus à ce sujet.", nil).Once()
+	mockClient.On("ChatCompletion", mock.Anything, about(texts[2]), interfaces.ChatOptions{}).Return("Merci d'avoir regardé cette vidéo jusqu'au bout.", nil).Once()
+	strict := NewTranslationService(mockClient, &mockLogger{}).WithQA(TranslationQA{Attempts: 1, Strict: true}, "en")
+	_, err = strict.TranslateBatch(context.Background(), texts, "fr")
+	assert.EqualError(t, err, "translation fails quality checks: text 0: URL https://example.com is missing")
+	mockClient.AssertExpectations(t)
+
+	// The checks are part of the cache key
+	assert.NotEqual(t, service.getCacheKey(context.Background(), texts[2], "fr"), strict.getCacheKey(context.Background(), texts[2], "fr"))
+	_, ok = strict.Cached(context.Background(), texts[2], "fr")
+	assert.True(t, ok)
+}
+
+func TestTranslationService_BackTranslation(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(mocks.MockOpenAIClient)
+	service := NewTranslationService(mockClient, &mockLogger{}).WithQA(TranslationQA{Attempts: 1, BackTranslation: true}, "en")
+
+	toFrench := mock.MatchedBy(func(messages []openai.ChatCompletionMessageParamUnion) bool {
+		return strings.Contains(userPrompt(messages), "to fr")
+	})
+	toEnglish := mock.MatchedBy(func(messages []openai.ChatCompletionMessageParamUnion) bool {
+		return strings.Contains(userPrompt(messages), "to en")
+	})
+	mockClient.On("ChatCompletion", mock.Anything, toFrench, interfaces.ChatOptions{}).Return("Le chat dort sur le canapé.", nil).Once()
+	mockClient.On("ChatCompletion", mock.Anything, toEnglish, interfaces.ChatOptions{}).Return("The dog runs in a garden.", nil).Once()
+
+	report := newLanguageReport("fr", "", 1)
+	_, err := service.TranslateBatch(withLanguageReport(ctx, report), []string{"The cat sleeps on the sofa."}, "fr")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"back translation is 17% similar to the source, expected 40%"}, report.Slides[0].QA)
+	// The back translation is cached for the review after the batch
+	mockClient.AssertExpectations(t)
+	assert.Equal(t, 2, report.APICalls)
+}
@@ -0,0 +1,442 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gocreator/internal/interfaces"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/spf13/afero"
+)
+
+// MaxSpeechChars is the longest text the speech API accepts in one request
+const MaxSpeechChars = 4096
+
+// aspectTolerance is how far the aspect ratio of a slide may be from slide 1 before it is reported
+const aspectTolerance = 0.01
+
+// Severity tells whether a diagnostic stops a project from being created
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // The project can't be created as written
+	SeverityWarning Severity = "warning" // The project is created, maybe not as intended
+)
+
+// Diagnostic is one problem found in the inputs of a project, at a file and, when known, a line
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Path     string   `json:"path"`
+	Line     int      `json:"line,omitempty"` // 1-based, 0 for the whole file
+	Message  string   `json:"message"`
+}
+
+// String formats the diagnostic as path:line: severity: message
+func (d Diagnostic) String() string {
+	if d.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", d.Path, d.Line, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.Path, d.Severity, d.Message)
+}
+
+// Diagnostics are the problems found in a project, sorted by file and line
+type Diagnostics []Diagnostic
+
+// Count returns the number of diagnostics of severity
+func (d Diagnostics) Count(severity Severity) int {
+	count := 0
+	for _, diagnostic := range d {
+		if diagnostic.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// HasErrors reports whether any diagnostic stops the project from being created
+func (d Diagnostics) HasErrors() bool {
+	return d.Count(SeverityError) > 0
+}
+
+// narrationEntry is the narration of one slide, as spoken, and where it is written
+type narrationEntry struct {
+	text  string
+	line  int
+	slide string // Slide file named by the script, "" for the slide at the same position
+	timed bool   // The script sets how long the slide is shown
+}
+
+// ProjectValidator checks the narration and slides of a local project before anything is generated
+type ProjectValidator struct {
+	fs     afero.Fs
+	logger interfaces.Logger
+	// probe returns the dimensions of a video slide
+	probe func(ctx context.Context, path string) (int, int, error)
+}
+
+// NewProjectValidator creates a new project validator
+func NewProjectValidator(fs afero.Fs, logger interfaces.Logger) *ProjectValidator {
+	return &ProjectValidator{
+		fs:     fs,
+		logger: logger,
+		probe:  probeVideoDimensions,
+	}
+}
+
+// Validate checks the project whose inputs are in dataDir, reading its narration the way create does:
+// data/script.yaml, then data/script.md, then data/texts.txt. The error is only set when the
+// project can't be read at all.
+func (v *ProjectValidator) Validate(ctx context.Context, dataDir string) (Diagnostics, error) {
+	var diags Diagnostics
+	report := func(severity Severity, path string, line int, format string, args ...any) {
+		diags = append(diags, Diagnostic{Severity: severity, Path: path, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	narrationPath, entries, named, err := v.loadNarration(dataDir, report)
+	if err != nil {
+		return nil, err
+	}
+
+	slidesDir := filepath.Join(dataDir, "slides")
+	dirSlides, err := v.listSlides(slidesDir, report)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pair the narration with the slides the way loadLocalInputs does
+	var slides []string
+	for i, entry := range entries {
+		switch {
+		case entry.slide != "":
+			path := filepath.Join(slidesDir, entry.slide)
+			if exists, _ := afero.Exists(v.fs, path); !exists {
+				report(SeverityError, narrationPath, entry.line, "slide %d: slide file %s not found in the slides directory", i+1, entry.slide)
+				continue
+			}
+			slides = append(slides, path)
+		case i < len(dirSlides):
+			slides = append(slides, dirSlides[i])
+		default:
+			report(SeverityError, narrationPath, entry.line, "narration %d has no slide, there are only %d slides", i+1, len(dirSlides))
+		}
+
+		if isSilent(entry.text) {
+			if !entry.timed {
+				report(SeverityWarning, narrationPath, entry.line, "slide %d has no narration and is shown in silence", i+1)
+			}
+			continue
+		}
+		for _, part := range parseSpeechMarkup(entry.text) {
+			if chars := utf8.RuneCountInString(part.Text); chars > MaxSpeechChars {
+				report(SeverityError, narrationPath, entry.line, "narration of slide %d has %d characters in one request, over the %d the speech API accepts: split it with a [pause]", i+1, chars, MaxSpeechChars)
+			}
+		}
+	}
+	if !named && narrationPath != "" {
+		for i := len(entries); i < len(dirSlides); i++ {
+			report(SeverityError, dirSlides[i], 0, "slide %d has no narration, there are only %d narrations", i+1, len(entries))
+			slides = append(slides, dirSlides[i])
+		}
+	}
+
+	if narrationPath != "" {
+		if err := v.checkHumanTranslations(dataDir, filepath.Base(narrationPath) == MarkdownScriptFile, len(entries), report); err != nil {
+			return nil, err
+		}
+	}
+
+	// Media: every slide must be readable, and match the shape of the first one
+	checked := make(map[string]bool, len(slides))
+	refWidth, refHeight := 0, 0
+	for i, slide := range slides {
+		if checked[slide] {
+			continue
+		}
+		checked[slide] = true
+
+		width, height, ok := v.checkMedia(ctx, slide, report)
+		if !ok || width == 0 || height == 0 {
+			continue
+		}
+		if i == 0 {
+			refWidth, refHeight = width, height
+			continue
+		}
+		if refHeight > 0 && math.Abs(float64(width)/float64(height)-float64(refWidth)/float64(refHeight)) > aspectTolerance {
+			report(SeverityWarning, slide, 0, "aspect ratio %dx%d differs from slide 1 (%dx%d), the slide is letterboxed", width, height, refWidth, refHeight)
+		}
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Path != diags[j].Path {
+			return diags[i].Path < diags[j].Path
+		}
+		return diags[i].Line < diags[j].Line
+	})
+	return diags, nil
+}
+
+// humanTranslationName matches the name of a translation supplied by a translator, such as texts.fr.txt
+var humanTranslationName = regexp.MustCompile(`^(?:texts|script)\.([A-Za-z][\w-]*)\.(?:txt|md)$`)
+
+// checkHumanTranslations reports the human translations in dataDir with more blocks than the project has
+// slides, and those in a format the project doesn't read
+func (v *ProjectValidator) checkHumanTranslations(dataDir string, markdown bool, slideCount int, report func(Severity, string, int, string, ...any)) error {
+	infos, err := afero.ReadDir(v.fs, dataDir)
+	if err != nil {
+		return fmt.Errorf("failed to read data directory: %w", err)
+	}
+	var script *Script
+	if markdown {
+		script = &Script{markdown: true}
+	}
+	for _, info := range infos {
+		match := humanTranslationName.FindStringSubmatch(info.Name())
+		if info.IsDir() || match == nil {
+			continue
+		}
+		path := filepath.Join(dataDir, info.Name())
+		if expected := humanTranslationFile(match[1], script); info.Name() != expected {
+			report(SeverityWarning, path, 0, "ignored: the narration is in %s, its translation to %s goes in %s", script.textsFile(), match[1], expected)
+			continue
+		}
+
+		data, err := afero.ReadFile(v.fs, path)
+		if err != nil {
+			report(SeverityError, path, 0, "unreadable: %v", err)
+			continue
+		}
+		var blocks []textBlock
+		if markdown {
+			blocks = parseMarkdownBlocks(string(data))
+		} else if blocks, err = parseTextBlocks(bytes.NewReader(data)); err != nil {
+			report(SeverityError, path, 0, "unreadable: %v", err)
+			continue
+		}
+		if len(blocks) > slideCount {
+			report(SeverityError, path, blocks[slideCount].Line, "%d blocks for %d slides: write one block per slide, in order, and leave a block empty to machine translate it", len(blocks), slideCount)
+		}
+	}
+	return nil
+}
+
+// loadNarration reads the narration of the project, with the line of every slide. It returns the
+// path of the narration, "" when there is none, and whether the script names slide files itself.
+func (v *ProjectValidator) loadNarration(dataDir string, report func(Severity, string, int, string, ...any)) (string, []narrationEntry, bool, error) {
+	scriptPath := filepath.Join(dataDir, ScriptFile)
+	if exists, err := afero.Exists(v.fs, scriptPath); err != nil {
+		return "", nil, false, fmt.Errorf("failed to check script: %w", err)
+	} else if exists {
+		entries, err := v.loadScriptNarration(scriptPath, report)
+		return scriptPath, entries, true, err
+	}
+
+	markdownPath := filepath.Join(dataDir, MarkdownScriptFile)
+	if exists, err := afero.Exists(v.fs, markdownPath); err != nil {
+		return "", nil, false, fmt.Errorf("failed to check script: %w", err)
+	} else if exists {
+		data, err := afero.ReadFile(v.fs, markdownPath)
+		if err != nil {
+			report(SeverityError, markdownPath, 0, "unreadable: %v", err)
+			return markdownPath, nil, false, nil
+		}
+		blocks := parseMarkdownBlocks(string(data))
+		if len(blocks) == 0 {
+			report(SeverityError, markdownPath, 0, "script has no slides")
+		}
+		entries := make([]narrationEntry, len(blocks))
+		for i, block := range blocks {
+			entries[i] = narrationEntry{text: markdownSpeech(block.Text), line: block.Line}
+		}
+		return markdownPath, entries, false, nil
+	}
+
+	textsPath := filepath.Join(dataDir, "texts.txt")
+	data, err := afero.ReadFile(v.fs, textsPath)
+	if errors.Is(err, os.ErrNotExist) {
+		report(SeverityError, textsPath, 0, "no narration: write it in texts.txt, %s or %s", MarkdownScriptFile, ScriptFile)
+		return "", nil, false, nil
+	}
+	if err != nil {
+		report(SeverityError, textsPath, 0, "unreadable: %v", err)
+		return textsPath, nil, false, nil
+	}
+	blocks, err := parseTextBlocks(bytes.NewReader(data))
+	if err != nil {
+		report(SeverityError, textsPath, 0, "unreadable: %v", err)
+		return textsPath, nil, false, nil
+	}
+	var entries []narrationEntry
+	for _, block := range blocks {
+		if block.empty {
+			report(SeverityWarning, textsPath, block.Line, "empty narration block is skipped, the narration after it moves to the previous slide")
+			continue
+		}
+		slide, err := textSlide(block.Text)
+		var metadataErr *textMetadataError
+		if errors.As(err, &metadataErr) {
+			report(SeverityError, textsPath, block.Line+metadataErr.Line-1, "slide %d: %v", len(entries)+1, metadataErr.Err)
+		} else if err := slide.Validate(); err != nil {
+			report(SeverityError, textsPath, block.Line, "slide %d: %v", len(entries)+1, err)
+		}
+		entries = append(entries, narrationEntry{text: textSpeech(block.Text), line: block.Line, timed: slide.Duration > 0 || slide.MinDuration > 0})
+	}
+	return textsPath, entries, false, nil
+}
+
+// loadScriptNarration reads the slides of a script.yaml, reporting the invalid settings of each at its line
+func (v *ProjectValidator) loadScriptNarration(path string, report func(Severity, string, int, string, ...any)) ([]narrationEntry, error) {
+	data, err := afero.ReadFile(v.fs, path)
+	if err != nil {
+		report(SeverityError, path, 0, "unreadable: %v", err)
+		return nil, nil
+	}
+
+	var script Script
+	if err := yaml.UnmarshalWithOptions(data, &script, yaml.Strict()); err != nil {
+		report(SeverityError, path, 0, "invalid script: %v", err)
+		return nil, nil
+	}
+	if len(script.Slides) == 0 {
+		report(SeverityError, path, 0, "script has no slides")
+		return nil, nil
+	}
+
+	lines := scriptSlideLines(data)
+	entries := make([]narrationEntry, len(script.Slides))
+	for i, slide := range script.Slides {
+		line := 0
+		if i < len(lines) {
+			line = lines[i]
+		}
+		if err := slide.Validate(); err != nil {
+			report(SeverityError, path, line, "slide %d: %v", i+1, err)
+		}
+		entries[i] = narrationEntry{text: slide.Narration, line: line, slide: slide.Slide, timed: slide.Duration > 0 || slide.MinDuration > 0}
+	}
+	return entries, nil
+}
+
+// scriptSlideLines returns the line every slide of a script.yaml starts on, nil when it can't tell
+func scriptSlideLines(data []byte) []int {
+	file, err := parser.ParseBytes(data, 0)
+	if err != nil {
+		return nil
+	}
+	path, err := yaml.PathString("$.slides")
+	if err != nil {
+		return nil
+	}
+	node, err := path.FilterFile(file)
+	if err != nil {
+		return nil
+	}
+	sequence, ok := node.(*ast.SequenceNode)
+	if !ok {
+		return nil
+	}
+	lines := make([]int, len(sequence.Values))
+	for i, value := range sequence.Values {
+		lines[i] = value.GetToken().Position.Line
+	}
+	return lines
+}
+
+// listSlides returns the slides LoadSlides reads from dir, reporting the files it skips
+func (v *ProjectValidator) listSlides(dir string, report func(Severity, string, int, string, ...any)) ([]string, error) {
+	exists, err := afero.DirExists(v.fs, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check directory: %w", err)
+	}
+	if !exists {
+		report(SeverityError, dir, 0, "slides directory not found")
+		return nil, nil
+	}
+
+	files, err := afero.ReadDir(v.fs, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var slides []string
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		if !isSlideFile(file.Name()) {
+			report(SeverityWarning, path, 0, "unsupported extension %q, the file is skipped", filepath.Ext(file.Name()))
+			continue
+		}
+		slides = append(slides, path)
+	}
+	return slides, nil
+}
+
+// checkMedia reports a slide that can't be read, and returns its dimensions when they are known
+func (v *ProjectValidator) checkMedia(ctx context.Context, path string, report func(Severity, string, int, string, ...any)) (int, int, bool) {
+	info, err := v.fs.Stat(path)
+	if err != nil {
+		report(SeverityError, path, 0, "unreadable: %v", err)
+		return 0, 0, false
+	}
+	if info.Size() == 0 {
+		report(SeverityError, path, 0, "file is empty")
+		return 0, 0, false
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".jpg", ".jpeg":
+		file, err := v.fs.Open(path)
+		if err != nil {
+			report(SeverityError, path, 0, "unreadable: %v", err)
+			return 0, 0, false
+		}
+		defer func() { _ = file.Close() }()
+		config, _, err := image.DecodeConfig(file)
+		if err != nil {
+			report(SeverityError, path, 0, "unreadable image: %v", err)
+			return 0, 0, false
+		}
+		return config.Width, config.Height, true
+	default:
+		width, height, err := v.probe(ctx, path)
+		if errors.Is(err, exec.ErrNotFound) {
+			v.logger.Debug("ffprobe not found, video slides are not checked", "path", path)
+			return 0, 0, true
+		}
+		if err != nil {
+			report(SeverityError, path, 0, "unreadable video: %v", err)
+			return 0, 0, false
+		}
+		return width, height, true
+	}
+}
+
+// probeVideoDimensions returns the dimensions of the first video stream of path
+func probeVideoDimensions(ctx context.Context, path string) (int, int, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe", "-v", "error", "-select_streams", "v:0",
+		"-show_entries", "stream=width,height", "-of", "csv=s=x:p=0", path)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, 0, subprocessError(ctx, err)
+	}
+	var width, height int
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(output)), "%dx%d", &width, &height); err != nil {
+		return 0, 0, fmt.Errorf("no video stream")
+	}
+	return width, height, nil
+}
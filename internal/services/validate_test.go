@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectValidator_Validate(t *testing.T) {
+	ctx := context.Background()
+	logger := &mockLogger{}
+
+	t.Run("valid project", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		writeTestPNG(t, fs, "/p/data/slides/1.png", 1920, 1080)
+		writeTestPNG(t, fs, "/p/data/slides/2.png", 1280, 720)
+		require.NoError(t, afero.WriteFile(fs, "/p/data/texts.txt", []byte("One\n-\nTwo"), 0644))
+
+		diags, err := NewProjectValidator(fs, logger).Validate(ctx, "/p/data")
+		require.NoError(t, err)
+		assert.Empty(t, diags)
+	})
+
+	t.Run("texts.txt problems", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		writeTestPNG(t, fs, "/p/data/slides/1.png", 1920, 1080)
+		writeTestPNG(t, fs, "/p/data/slides/2.png", 1024, 768)
+		writeTestPNG(t, fs, "/p/data/slides/3.png", 1920, 1080)
+		require.NoError(t, afero.WriteFile(fs, "/p/data/slides/4.png", nil, 0644))
+		require.NoError(t, afero.WriteFile(fs, "/p/data/slides/5.gif", []byte("gif"), 0644))
+		require.NoError(t, afero.WriteFile(fs, "/p/data/slides/.DS_Store", []byte("x"), 0644))
+		long := strings.Repeat("a", MaxSpeechChars+1)
+		require.NoError(t, afero.WriteFile(fs, "/p/data/texts.txt", []byte("One\n-\n-\n"+long+"\n-\n\nThree"), 0644))
+
+		diags, err := NewProjectValidator(fs, logger).Validate(ctx, "/p/data")
+		require.NoError(t, err)
+		var lines []string
+		for _, d := range diags {
+			lines = append(lines, d.String())
+		}
+		assert.Equal(t, []string{
+			"/p/data/slides/2.png: warning: aspect ratio 1024x768 differs from slide 1 (1920x1080), the slide is letterboxed",
+			"/p/data/slides/4.png: error: slide 4 has no narration, there are only 3 narrations",
+			"/p/data/slides/4.png: error: file is empty",
+			`/p/data/slides/5.gif: warning: unsupported extension ".gif", the file is skipped`,
+			"/p/data/texts.txt:3: warning: empty narration block is skipped, the narration after it moves to the previous slide",
+			"/p/data/texts.txt:4: error: narration of slide 2 has 4097 characters in one request, over the 4096 the speech API accepts: split it with a [pause]",
+		}, lines)
+		assert.True(t, diags.HasErrors())
+		assert.Equal(t, 3, diags.Count(SeverityWarning))
+	})
+
+	t.Run("texts.txt metadata at its line", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		writeTestPNG(t, fs, "/p/data/slides/1.png", 1920, 1080)
+		writeTestPNG(t, fs, "/p/data/slides/2.png", 1920, 1080)
+		writeTestPNG(t, fs, "/p/data/slides/3.png", 1920, 1080)
+		texts := "# title slide\n@duration: 4s\n-\n@voice: nova\n@speed: 9\nTwo\n-\nThree\n@pause: 1"
+		require.NoError(t, afero.WriteFile(fs, "/p/data/texts.txt", []byte(texts), 0644))
+
+		diags, err := NewProjectValidator(fs, logger).Validate(ctx, "/p/data")
+		require.NoError(t, err)
+		require.Len(t, diags, 2)
+		assert.Equal(t, Diagnostic{Severity: SeverityError, Path: "/p/data/texts.txt", Line: 4, Message: "slide 2: speed must be between 0.25 and 4.0, got 9"}, diags[0])
+		assert.Equal(t, 9, diags[1].Line)
+		assert.Contains(t, diags[1].Message, "slide 3: unknown metadata @pause")
+	})
+
+	t.Run("human translations", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		writeTestPNG(t, fs, "/p/data/slides/1.png", 1920, 1080)
+		require.NoError(t, afero.WriteFile(fs, "/p/data/texts.txt", []byte("One"), 0644))
+		require.NoError(t, afero.WriteFile(fs, "/p/data/texts.fr.txt", []byte("Un\n-\nDeux"), 0644))
+		require.NoError(t, afero.WriteFile(fs, "/p/data/texts.de.txt", []byte("-\n"), 0644))
+		require.NoError(t, afero.WriteFile(fs, "/p/data/script.es.md", []byte("## Uno"), 0644))
+
+		diags, err := NewProjectValidator(fs, logger).Validate(ctx, "/p/data")
+		require.NoError(t, err)
+		assert.Equal(t, Diagnostics{
+			{Severity: SeverityWarning, Path: "/p/data/script.es.md", Message: "ignored: the narration is in texts.txt, its translation to es goes in texts.es.txt"},
+			{Severity: SeverityError, Path: "/p/data/texts.fr.txt", Line: 3, Message: "2 blocks for 1 slides: write one block per slide, in order, and leave a block empty to machine translate it"},
+		}, diags)
+	})
+
+	t.Run("script.yaml problems at their line", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		writeTestPNG(t, fs, "/p/data/slides/1.png", 1920, 1080)
+		script := "slides:\n  - narration: Hi\n    speed: 9\n  - slide: missing.png\n    narration: There\n  - slide: 1.png\n  - slide: 1.png\n    duration: 3\n  - narration: Bye\n"
+		require.NoError(t, afero.WriteFile(fs, "/p/data/script.yaml", []byte(script), 0644))
+
+		diags, err := NewProjectValidator(fs, logger).Validate(ctx, "/p/data")
+		require.NoError(t, err)
+		assert.Equal(t, Diagnostics{
+			{Severity: SeverityError, Path: "/p/data/script.yaml", Line: 2, Message: "slide 1: speed must be between 0.25 and 4.0, got 9"},
+			{Severity: SeverityError, Path: "/p/data/script.yaml", Line: 4, Message: "slide 2: slide file missing.png not found in the slides directory"},
+			{Severity: SeverityWarning, Path: "/p/data/script.yaml", Line: 6, Message: "slide 3 has no narration and is shown in silence"},
+			{Severity: SeverityError, Path: "/p/data/script.yaml", Line: 9, Message: "narration 5 has no slide, there are only 1 slides"},
+		}, diags)
+	})
+
+	t.Run("markdown script and unreadable video", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		writeTestPNG(t, fs, "/p/data/slides/1.png", 1920, 1080)
+		require.NoError(t, afero.WriteFile(fs, "/p/data/slides/2.mp4", []byte("mp4"), 0644))
+		require.NoError(t, afero.WriteFile(fs, "/p/data/script.md", []byte("# Title\n\n## Intro\n\nHello\n\n## Demo\n"), 0644))
+
+		validator := NewProjectValidator(fs, logger)
+		validator.probe = func(ctx context.Context, path string) (int, int, error) {
+			return 0, 0, errors.New("moov atom not found")
+		}
+		diags, err := validator.Validate(ctx, "/p/data")
+		require.NoError(t, err)
+		assert.Equal(t, Diagnostics{
+			{Severity: SeverityWarning, Path: "/p/data/script.md", Line: 7, Message: "slide 2 has no narration and is shown in silence"},
+			{Severity: SeverityError, Path: "/p/data/slides/2.mp4", Message: "unreadable video: moov atom not found"},
+		}, diags)
+	})
+
+	t.Run("no narration", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, fs.MkdirAll("/p/data/slides", 0755))
+
+		diags, err := NewProjectValidator(fs, logger).Validate(ctx, "/p/data")
+		require.NoError(t, err)
+		require.Len(t, diags, 1)
+		assert.Contains(t, diags[0].String(), "/p/data/texts.txt: error: no narration")
+	})
+}
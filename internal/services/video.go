@@ -6,51 +6,93 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"image"
+	_ "image/jpeg" // Registers JPEG for reading slide dimensions
+	_ "image/png"  // Registers PNG for reading slide dimensions
+	"math"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"gocreator/internal/interfaces"
+	"gocreator/internal/timeline"
 
 	"github.com/spf13/afero"
 )
 
 // VideoService handles video generation
 type VideoService struct {
-	fs         afero.Fs
-	logger     interfaces.Logger
-	transition TransitionConfig
+	fs        afero.Fs
+	logger    interfaces.Logger
+	scheduler *Scheduler
+	manifest  *Manifest
 }
 
 // NewVideoService creates a new video service
 func NewVideoService(fs afero.Fs, logger interfaces.Logger) *VideoService {
 	return &VideoService{
-		fs:         fs,
-		logger:     logger,
-		transition: TransitionConfig{Type: TransitionNone}, // Default: no transitions
+		fs:        fs,
+		logger:    logger,
+		scheduler: NewScheduler(0, 0),
 	}
 }
 
-// SetTransition sets the transition configuration
-func (s *VideoService) SetTransition(transition TransitionConfig) {
-	s.transition = transition
+// SetManifest sets the manifest recording every segment of the run
+func (s *VideoService) SetManifest(manifest *Manifest) {
+	s.manifest = manifest
 }
 
-// GenerateFromSlides generates videos from slides and audio
+// SetScheduler sets the scheduler bounding concurrent ffmpeg jobs
+func (s *VideoService) SetScheduler(scheduler *Scheduler) {
+	s.scheduler = scheduler
+}
+
+// GenerateFromSlides generates a video from slides and audio with no per-slide settings
 func (s *VideoService) GenerateFromSlides(ctx context.Context, slides, audioPaths []string, outputPath string) error {
-	if len(slides) != len(audioPaths) {
-		return fmt.Errorf("slides and audio count mismatch: %d vs %d", len(slides), len(audioPaths))
+	tl, err := timeline.FromSlides(slides, audioPaths)
+	if err != nil {
+		return err
+	}
+	return s.GenerateFromTimeline(ctx, tl, outputPath)
+}
+
+// GenerateFromTimeline renders a timeline to a video file
+func (s *VideoService) GenerateFromTimeline(ctx context.Context, tl timeline.Timeline, outputPath string) error {
+	indices := make([]int, len(tl.Segments))
+	for i := range indices {
+		indices[i] = i
+	}
+	return s.render(ctx, tl, indices, outputPath, outputPath)
+}
+
+// GeneratePreview renders a timeline made of the slides at indices of the video at fullOutputPath.
+// Segments are shared with the segment cache of that video, the video itself is left untouched.
+func (s *VideoService) GeneratePreview(ctx context.Context, tl timeline.Timeline, indices []int, fullOutputPath, outputPath string) error {
+	if len(indices) != len(tl.Segments) {
+		return fmt.Errorf("preview has %d segments but %d slide indices", len(tl.Segments), len(indices))
 	}
+	return s.render(ctx, tl, indices, fullOutputPath, outputPath)
+}
 
-	if len(slides) == 0 {
-		return fmt.Errorf("no slides provided")
+// render renders tl to outputPath. Segment i shows slide indices[i] and is cached as
+// that slide in the segment cache of cacheOutputPath.
+func (s *VideoService) render(ctx context.Context, tl timeline.Timeline, indices []int, cacheOutputPath, outputPath string) error {
+	if err := tl.Validate(); err != nil {
+		return fmt.Errorf("invalid timeline: %w", err)
 	}
 
-	// Get dimensions from first slide
-	width, height, err := s.getMediaDimensions(slides[0])
+	// Get dimensions from first segment
+	var width, height int
+	err := s.scheduler.Media(ctx, func() error {
+		var err error
+		width, height, err = s.getMediaDimensions(ctx, tl.Segments[0].Visual.Path)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to get media dimensions: %w", err)
 	}
@@ -69,26 +111,52 @@ func (s *VideoService) GenerateFromSlides(ctx context.Context, slides, audioPath
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Create temporary video directory
-	tempDir := filepath.Join(outputDir, ".temp")
-	if err := s.fs.MkdirAll(tempDir, 0755); err != nil {
+	// Segments are cached per output so outputs rendered in parallel never share files
+	name := outputName(outputPath)
+	segmentDir := segmentCacheDir(cacheOutputPath)
+	if err := s.fs.MkdirAll(segmentDir, 0755); err != nil {
 		return fmt.Errorf("failed to create temp directory: %w", err)
 	}
 
+	// Everything is rendered in a per-run workspace and published once complete
+	ws, err := NewWorkspace(s.fs, filepath.Join(outputDir, ".work"), name)
+	if err != nil {
+		return err
+	}
+	succeeded := false
+	defer func() {
+		// Interrupted runs leave nothing behind, failed runs are kept for inspection
+		if !succeeded && ctx.Err() == nil {
+			s.logger.Warn("Keeping workspace of failed run for debugging", "path", ws.Dir)
+			return
+		}
+		if err := ws.Remove(); err != nil {
+			s.logger.Warn("Failed to remove workspace", "path", ws.Dir, "error", err)
+		}
+	}()
+
 	// Generate individual videos
-	videoFiles := make([]string, len(slides))
-	errors := make([]error, len(slides))
+	videoFiles := make([]string, len(tl.Segments))
+	errors := make([]error, len(tl.Segments))
 	var wg sync.WaitGroup
 
-	for i := 0; i < len(slides); i++ {
+	for i := range tl.Segments {
 		wg.Add(1)
 		go func(idx int) {
 			defer wg.Done()
+			ctx := withSlideIndex(ctx, indices[idx])
+			start := time.Now()
 
-			videoPath := filepath.Join(tempDir, fmt.Sprintf("video_%d.mp4", idx))
+			videoPath := segmentCachePath(cacheOutputPath, indices[idx])
+			segmentName := filepath.Base(videoPath)
 			videoFiles[idx] = videoPath
 
-			if err := s.generateSingleVideo(slides[idx], audioPaths[idx], videoPath, width, height); err != nil {
+			err := s.scheduler.Media(ctx, func() error {
+				return s.generateSingleVideo(ctx, tl.Segments[idx], videoPath, ws.Path(segmentName), width, height)
+			})
+			recordSlideStep(ctx, start, err)
+			s.recordSegment(videoPath, err)
+			if err != nil {
 				errors[idx] = fmt.Errorf("failed to generate video %d: %w", idx, err)
 			}
 		}(i)
@@ -104,169 +172,422 @@ func (s *VideoService) GenerateFromSlides(ctx context.Context, slides, audioPath
 	}
 
 	// Concatenate videos
-	if err := s.concatenateVideos(videoFiles, outputPath); err != nil {
+	err = s.scheduler.Media(ctx, func() error {
+		return s.concatenateVideos(ctx, tl, videoFiles, outputPath, ws.Path(filepath.Base(outputPath)))
+	})
+	if err != nil {
 		return fmt.Errorf("failed to concatenate videos: %w", err)
 	}
 
+	succeeded = true
 	s.logger.Info("Video created successfully", "path", outputPath)
 	return nil
 }
 
-func (s *VideoService) generateSingleVideo(slidePath, audioPath, outputPath string, targetWidth, targetHeight int) error {
+// CachedTimeline reports which segments of tl, and whether the final video, GenerateFromTimeline
+// would reuse from cache given the files as they are now. Nothing is rendered: the output size is
+// read from the header of the first slide, so it fails for a timeline starting with a video clip.
+func (s *VideoService) CachedTimeline(tl timeline.Timeline, outputPath string) ([]bool, bool, error) {
+	indices := make([]int, len(tl.Segments))
+	for i := range indices {
+		indices[i] = i
+	}
+	return s.cachedRender(tl, indices, outputPath, outputPath)
+}
+
+// CachedPreview reports which segments of tl, and whether the preview at outputPath, GeneratePreview
+// would reuse from cache, segment i showing slide indices[i] of the full video at fullOutputPath
+func (s *VideoService) CachedPreview(tl timeline.Timeline, indices []int, fullOutputPath, outputPath string) ([]bool, bool, error) {
+	if len(indices) != len(tl.Segments) {
+		return nil, false, fmt.Errorf("preview has %d segments but %d slide indices", len(tl.Segments), len(indices))
+	}
+	return s.cachedRender(tl, indices, fullOutputPath, outputPath)
+}
+
+// cachedRender reports what render would reuse from cache, given the files as they are now
+func (s *VideoService) cachedRender(tl timeline.Timeline, indices []int, cacheOutputPath, outputPath string) ([]bool, bool, error) {
+	if err := tl.Validate(); err != nil {
+		return nil, false, fmt.Errorf("invalid timeline: %w", err)
+	}
+
+	width, height, err := s.imageDimensions(tl.Segments[0].Visual.Path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	segments := make([]bool, len(tl.Segments))
+	videoFiles := make([]string, len(tl.Segments))
+	allCached := true
+	for i, seg := range tl.Segments {
+		videoFiles[i] = segmentCachePath(cacheOutputPath, indices[i])
+		cached, err := s.checkSegmentCache(seg, videoFiles[i], width, height)
+		if err != nil {
+			s.logger.Debug("Failed to check segment cache", "path", videoFiles[i], "error", err)
+		}
+		segments[i] = cached
+		allCached = allCached && cached
+	}
+
+	// The final video is only reused when none of its segments change
+	if !allCached {
+		return segments, false, nil
+	}
+	final, err := s.checkFinalVideoCache(tl, videoFiles, outputPath)
+	if err != nil {
+		s.logger.Debug("Failed to check final video cache", "path", outputPath, "error", err)
+	}
+	return segments, final, nil
+}
+
+// imageDimensions reads the output size GenerateFromTimeline would use for an image slide, without ffmpeg
+func (s *VideoService) imageDimensions(path string) (int, int, error) {
+	file, err := s.fs.Open(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open slide: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot read dimensions of %s without ffmpeg: %w", path, err)
+	}
+
+	// Same even rounding as for rendering
+	return config.Width - config.Width%2, config.Height - config.Height%2, nil
+}
+
+// outputName returns the name of an output without directory and extension, e.g. output-en
+func outputName(outputPath string) string {
+	return strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+}
+
+// segmentCacheDir returns the directory caching the segments of an output
+func segmentCacheDir(outputPath string) string {
+	return filepath.Join(filepath.Dir(outputPath), ".temp", outputName(outputPath))
+}
+
+// segmentCachePath returns the cached segment idx of an output
+func segmentCachePath(outputPath string, idx int) string {
+	return filepath.Join(segmentCacheDir(outputPath), fmt.Sprintf("video_%d.mp4", idx))
+}
+
+// publish replaces dst with the rendered file src and records its cache hash.
+// The previous hash is removed first, so a crash can never pair a new file with a stale hash.
+func (s *VideoService) publish(src, dst string, saveHash func() error) error {
+	if err := s.fs.Remove(dst + ".hash"); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale hash: %w", err)
+	}
+	if err := publishFile(s.fs, src, dst); err != nil {
+		return err
+	}
+	if err := saveHash(); err != nil {
+		s.logger.Warn("Failed to save hash", "path", dst, "error", err)
+		// Don't fail the operation if hash saving fails
+	}
+	return nil
+}
+
+// recordSegment records the outcome of a segment in the manifest.
+// The inputs hash of a built segment is the one saved next to it for the cache.
+func (s *VideoService) recordSegment(outputPath string, err error) {
+	if s.manifest == nil {
+		return
+	}
+
+	var inputsHash string
+	if err == nil {
+		if data, readErr := afero.ReadFile(s.fs, outputPath+".hash"); readErr == nil {
+			inputsHash = string(data)
+		}
+	}
+	status, message := artifactStatus(err)
+	recordArtifact(s.logger, s.manifest, Artifact{Kind: ArtifactSegment, InputsHash: inputsHash, Output: outputPath, Status: status, Error: message})
+}
+
+// generateSingleVideo renders a segment to workPath and publishes it to outputPath,
+// unless outputPath already holds a segment rendered from the same inputs.
+// It runs in a media job slot, which its ffmpeg and ffprobe probes share.
+func (s *VideoService) generateSingleVideo(ctx context.Context, seg timeline.Segment, outputPath, workPath string, targetWidth, targetHeight int) error {
 	// Check segment cache first
-	cached, err := s.checkSegmentCache(slidePath, audioPath, outputPath, targetWidth, targetHeight)
+	cached, err := s.checkSegmentCache(seg, outputPath, targetWidth, targetHeight)
 	if err != nil {
 		s.logger.Warn("Failed to check segment cache", "error", err)
 	}
 	if cached {
 		s.logger.Info("Using cached video segment", "path", outputPath)
+		recordCacheHit(ctx)
 		return nil
 	}
-	
+
+	slidePath := seg.Visual.Path
+
 	// Check if the slide is actually a video
-	isVideo, err := s.isVideoFile(slidePath)
+	isVideo, err := s.isVideoFile(ctx, slidePath)
 	if err != nil {
 		s.logger.Warn("Failed to check if file is video, treating as image", "path", slidePath, "error", err)
 		isVideo = false
 	}
 
 	// Get slide/video dimensions
-	iw, ih, err := s.getMediaDimensions(slidePath)
+	iw, ih, err := s.getMediaDimensions(ctx, slidePath)
 	if err != nil {
 		return err
 	}
 
-	var cmd *exec.Cmd
+	// Duration of the rendered segment, 0 lets the narration decide
+	duration := seg.Duration
+	// Time the last frame of a video is held past the end of the clip
+	var hold float64
 
 	if isVideo {
 		// For video input: use video duration, align audio at beginning
 		// Video determines the duration, audio is aligned at the start
 		s.logger.Debug("Processing video input", "path", slidePath)
 
-		// Get video duration to use as the final duration
-		videoDuration, err := s.getVideoDuration(slidePath)
+		// Get video duration, trimmed to the in and out points
+		videoDuration, err := s.getVideoDuration(ctx, slidePath)
 		if err != nil {
 			return fmt.Errorf("failed to get video duration: %w", err)
 		}
+		if seg.Out > 0 && seg.Out < videoDuration {
+			videoDuration = seg.Out
+		}
+		clip := videoDuration - seg.In
+
+		if duration == 0 {
+			duration = clip
+
+			// Pauses after the narration and minimum durations hold the last frame past the clip
+			if seg.PadEnd > 0 || seg.MinDuration > 0 {
+				audioEnd, err := s.audioEnd(ctx, seg)
+				if err != nil {
+					return err
+				}
+				duration = max(duration, audioEnd+seg.PadEnd, seg.MinDuration)
+			}
+		}
+		hold = max(duration-clip, 0)
 
 		// Get audio duration and warn if significantly shorter than video
-		audioDuration, err := s.getVideoDuration(audioPath)
-		if err != nil {
-			s.logger.Warn("Failed to get audio duration, proceeding anyway", "path", audioPath, "error", err)
-		} else if audioDuration < videoDuration*0.8 { // Audio is less than 80% of video duration
-			s.logger.Warn("Audio is significantly shorter than video, remainder will be silent",
-				"video_duration", videoDuration,
-				"audio_duration", audioDuration,
-				"video_path", slidePath)
-		}
-
-		if targetWidth != iw || targetHeight != ih {
-			// Need to scale and pad video
-			scaleFilter := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease", targetWidth, targetHeight)
-			padFilter := fmt.Sprintf("pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1", targetWidth, targetHeight)
-			filterComplex := fmt.Sprintf("[0:v]%s,%s[v]", scaleFilter, padFilter)
-
-			// Use video duration as primary, trim audio to match if longer
-			cmd = exec.Command("ffmpeg", "-y",
-				"-i", slidePath,
-				"-i", audioPath,
-				"-filter_complex", filterComplex,
-				"-map", "[v]", "-map", "1:a:0",
-				"-c:v", "libx264",
-				"-c:a", "mp3", "-b:a", "192k",
-				"-pix_fmt", "yuv420p",
-				"-t", fmt.Sprintf("%.2f", videoDuration),
-				outputPath)
-		} else {
-			// No scaling needed for video
-			cmd = exec.Command("ffmpeg", "-y",
-				"-i", slidePath,
-				"-i", audioPath,
-				"-map", "0:v:0", "-map", "1:a:0",
-				"-c:v", "libx264",
-				"-c:a", "mp3", "-b:a", "192k",
-				"-pix_fmt", "yuv420p",
-				"-t", fmt.Sprintf("%.2f", videoDuration),
-				outputPath)
+		if len(seg.Audio) > 0 {
+			audioDuration, err := s.getVideoDuration(ctx, seg.Audio[0].Path)
+			if err != nil {
+				s.logger.Warn("Failed to get audio duration, proceeding anyway", "path", seg.Audio[0].Path, "error", err)
+			} else if audioDuration+seg.Audio[0].Offset < duration*0.8 { // Audio is less than 80% of video duration
+				s.logger.Warn("Audio is significantly shorter than video, remainder will be silent",
+					"video_duration", duration,
+					"audio_duration", audioDuration,
+					"video_path", slidePath)
+			}
 		}
 	} else {
 		// For image input: use audio duration (current behavior)
 		s.logger.Debug("Processing image input", "path", slidePath)
 
-		if targetWidth != iw || targetHeight != ih {
-			// Need to scale and pad
-			scaleFilter := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease", targetWidth, targetHeight)
-			padFilter := fmt.Sprintf("pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1", targetWidth, targetHeight)
-			filterComplex := fmt.Sprintf("%s,%s", scaleFilter, padFilter)
-
-			cmd = exec.Command("ffmpeg", "-y", "-loop", "1", "-i", slidePath, "-i", audioPath,
-				"-vf", filterComplex,
-				"-c:v", "libx264", "-tune", "stillimage",
-				"-c:a", "mp3", "-b:a", "192k",
-				"-pix_fmt", "yuv420p", "-shortest",
-				outputPath)
-		} else {
-			// No scaling needed
-			cmd = exec.Command("ffmpeg", "-y", "-loop", "1", "-i", slidePath, "-i", audioPath,
-				"-c:v", "libx264", "-tune", "stillimage",
-				"-c:a", "mp3", "-b:a", "192k",
-				"-pix_fmt", "yuv420p", "-shortest",
-				outputPath)
+		// Pauses after the narration and minimum durations hold the image past its audio
+		if duration == 0 && (seg.PadEnd > 0 || seg.MinDuration > 0) {
+			audioEnd, err := s.audioEnd(ctx, seg)
+			if err != nil {
+				return err
+			}
+			duration = max(audioEnd+seg.PadEnd, seg.MinDuration)
+		}
+	}
+
+	// A fixed duration shorter than the narration cuts it
+	if seg.Duration > 0 && len(seg.Audio) > 0 {
+		if audioEnd, err := s.audioEnd(ctx, seg); err == nil && audioEnd > seg.Duration {
+			s.logger.Warn("Narration is longer than the fixed duration of the slide, cutting it",
+				"path", slidePath, "duration", seg.Duration, "narration", audioEnd)
 		}
 	}
 
+	scale := targetWidth != iw || targetHeight != ih
+	cmd := exec.CommandContext(ctx, "ffmpeg", segmentArgs(seg, isVideo, scale, targetWidth, targetHeight, duration, hold, workPath)...)
+
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
 	s.logger.Debug("Running ffmpeg", "command", cmd.String())
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("ffmpeg error: %w, stderr: %s", err, stderr.String())
+		return fmt.Errorf("ffmpeg error: %w, stderr: %s", subprocessError(ctx, err), stderr.String())
 	}
 
-	// Save segment hash for future cache hits
-	if err := s.saveSegmentHash(slidePath, audioPath, outputPath, targetWidth, targetHeight); err != nil {
-		s.logger.Warn("Failed to save segment hash", "error", err)
-		// Don't fail the operation if hash saving fails
+	// Publish the segment with its hash for future cache hits
+	return s.publish(workPath, outputPath, func() error {
+		return s.saveSegmentHash(seg, outputPath, targetWidth, targetHeight)
+	})
+}
+
+// audioEnd returns when the last audio track of seg ends, in seconds, 0 for a silent segment
+func (s *VideoService) audioEnd(ctx context.Context, seg timeline.Segment) (float64, error) {
+	var end float64
+	for _, track := range seg.Audio {
+		duration, err := s.getVideoDuration(ctx, track.Path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get audio duration: %w", err)
+		}
+		end = max(end, track.Offset+duration)
 	}
+	return end, nil
+}
 
-	return nil
+// segmentArgs builds the ffmpeg arguments rendering one segment.
+// Input 0 is the visual, followed by the audio tracks, or generated silence for a silent
+// segment, and then the overlays. A duration of 0 ends the segment with its shortest stream.
+// A video holds its last frame for hold seconds.
+func segmentArgs(seg timeline.Segment, isVideo, scale bool, width, height int, duration, hold float64, outputPath string) []string {
+	args := []string{"-y"}
+	if isVideo {
+		if seg.In > 0 {
+			args = append(args, "-ss", fmt.Sprintf("%.2f", seg.In))
+		}
+		args = append(args, "-i", seg.Visual.Path)
+	} else {
+		args = append(args, "-loop", "1", "-i", seg.Visual.Path)
+	}
+	for _, track := range seg.Audio {
+		args = append(args, "-i", track.Path)
+	}
+	audioInputs := len(seg.Audio)
+	if audioInputs == 0 {
+		args = append(args, "-f", "lavfi", "-i", "anullsrc=r=44100:cl=stereo")
+		audioInputs = 1
+	}
+	for _, overlay := range seg.Overlays {
+		args = append(args, "-i", overlay.Path)
+	}
+
+	var filters []string
+
+	// Video chain: hold the last frame, scale and pad to the target size, then composite overlays
+	videoMap := "0:v:0"
+	label := "[0:v]"
+	if hold > 0 {
+		filters = append(filters, fmt.Sprintf("%stpad=stop_mode=clone:stop_duration=%.2f[held]", label, hold))
+		label = "[held]"
+	}
+	if scale {
+		scaleFilter := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease", width, height)
+		padFilter := fmt.Sprintf("pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1", width, height)
+		filters = append(filters, fmt.Sprintf("%s%s,%s[base]", label, scaleFilter, padFilter))
+		label = "[base]"
+	}
+	overlayInput := 1 + audioInputs
+	for i, overlay := range seg.Overlays {
+		x, y := overlay.X, overlay.Y
+		if x == "" {
+			x = "0"
+		}
+		if y == "" {
+			y = "0"
+		}
+		filter := fmt.Sprintf("%s[%d:v]overlay=%s:%s", label, overlayInput+i, x, y)
+		if overlay.End > 0 {
+			filter += fmt.Sprintf(":enable='between(t,%.2f,%.2f)'", overlay.Start, overlay.End)
+		} else if overlay.Start > 0 {
+			filter += fmt.Sprintf(":enable='gte(t,%.2f)'", overlay.Start)
+		}
+		label = fmt.Sprintf("[ov%d]", i)
+		filters = append(filters, filter+label)
+	}
+	if label != "[0:v]" {
+		videoMap = label
+	}
+
+	// Audio chain: delay tracks by their offset, mix them, and pad to a fixed duration.
+	// Generated silence lasts as long as needed.
+	audioMap := "1:a:0"
+	pad := seg.Duration > 0 || hold > 0 || (!isVideo && duration > 0)
+	if len(seg.Audio) > 0 && (len(seg.Audio) > 1 || seg.Audio[0].Offset > 0 || pad) {
+		labels := make([]string, len(seg.Audio))
+		for i, track := range seg.Audio {
+			labels[i] = fmt.Sprintf("[%d:a]", i+1)
+			if track.Offset > 0 {
+				delayed := fmt.Sprintf("[ad%d]", i)
+				filters = append(filters, fmt.Sprintf("%sadelay=%d:all=1%s", labels[i], int(track.Offset*1000), delayed))
+				labels[i] = delayed
+			}
+		}
+		audio := labels[0]
+		if len(labels) > 1 {
+			filters = append(filters, fmt.Sprintf("%samix=inputs=%d:duration=longest:normalize=0[mix]", strings.Join(labels, ""), len(labels)))
+			audio = "[mix]"
+		}
+		if pad {
+			filters = append(filters, fmt.Sprintf("%sapad[padded]", audio))
+			audio = "[padded]"
+		}
+		audioMap = audio
+	}
+
+	if len(filters) > 0 {
+		args = append(args, "-filter_complex", strings.Join(filters, ";"))
+	}
+	args = append(args, "-map", videoMap, "-map", audioMap)
+
+	args = append(args, "-c:v", "libx264")
+	if !isVideo {
+		args = append(args, "-tune", "stillimage")
+	}
+	args = append(args,
+		"-c:a", "mp3", "-b:a", "192k",
+		"-pix_fmt", "yuv420p")
+	if duration > 0 {
+		args = append(args, "-t", fmt.Sprintf("%.2f", duration))
+	} else {
+		args = append(args, "-shortest")
+	}
+	return append(args, outputPath)
 }
 
-func (s *VideoService) concatenateVideos(videoFiles []string, outputPath string) error {
+// concatenateVideos joins the segments in workPath and publishes the result to outputPath,
+// unless outputPath already holds a video concatenated from the same segments
+func (s *VideoService) concatenateVideos(ctx context.Context, tl timeline.Timeline, videoFiles []string, outputPath, workPath string) error {
 	// Check final video cache first
-	cached, err := s.checkFinalVideoCache(videoFiles, outputPath)
+	cached, err := s.checkFinalVideoCache(tl, videoFiles, outputPath)
 	if err != nil {
 		s.logger.Warn("Failed to check final video cache", "error", err)
 	}
 	if cached {
 		s.logger.Info("Using cached final video", "path", outputPath)
+		recordCacheHit(ctx)
 		return nil
 	}
-	
-	// If transitions are disabled or only one video, use simple concatenation
-	if !s.transition.IsEnabled() || len(videoFiles) == 1 {
-		if err := s.concatenateVideosSimple(videoFiles, outputPath); err != nil {
+
+	// Chapters are added in a second pass over the concatenated video
+	concatPath := workPath
+	if tl.HasChapters() {
+		concatPath = workPath + ".concat" + filepath.Ext(workPath)
+	}
+
+	// If no segment transitions out or only one video, use simple concatenation
+	if !tl.HasTransitions() || len(videoFiles) == 1 {
+		if err := s.concatenateVideosSimple(ctx, videoFiles, concatPath); err != nil {
 			return err
 		}
 	} else {
 		// Use transitions with xfade filter
-		if err := s.concatenateVideosWithTransitions(videoFiles, outputPath); err != nil {
+		if err := s.concatenateVideosWithTransitions(ctx, tl, videoFiles, concatPath); err != nil {
 			return err
 		}
 	}
-	
-	// Save final video hash for future cache hits
-	if err := s.saveFinalVideoHash(videoFiles, outputPath); err != nil {
-		s.logger.Warn("Failed to save final video hash", "error", err)
-		// Don't fail the operation if hash saving fails
+
+	if tl.HasChapters() {
+		if err := s.addChapters(ctx, tl, videoFiles, concatPath, workPath); err != nil {
+			return err
+		}
 	}
-	
-	return nil
+
+	// Publish the final video with its hash for future cache hits
+	return s.publish(workPath, outputPath, func() error {
+		return s.saveFinalVideoHash(tl, videoFiles, outputPath)
+	})
 }
 
 // concatenateVideosSimple concatenates videos without transitions
-func (s *VideoService) concatenateVideosSimple(videoFiles []string, outputPath string) error {
+func (s *VideoService) concatenateVideosSimple(ctx context.Context, videoFiles []string, outputPath string) error {
 	args := []string{"-y"}
 
 	for _, video := range videoFiles {
@@ -282,25 +603,28 @@ func (s *VideoService) concatenateVideosSimple(videoFiles []string, outputPath s
 	args = append(args, "-filter_complex", filterComplex.String())
 	args = append(args, "-map", "[outv]", "-map", "[outa]", outputPath)
 
-	cmd := exec.Command("ffmpeg", args...)
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
 	s.logger.Debug("Concatenating videos (no transitions)", "command", cmd.String())
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("ffmpeg concat error: %w, stderr: %s", err, stderr.String())
+		return fmt.Errorf("ffmpeg concat error: %w, stderr: %s", subprocessError(ctx, err), stderr.String())
 	}
 
 	return nil
 }
 
-// concatenateVideosWithTransitions concatenates videos with transition effects
-func (s *VideoService) concatenateVideosWithTransitions(videoFiles []string, outputPath string) error {
+// concatenateVideosWithTransitions concatenates videos with each segment's transition into the next
+func (s *VideoService) concatenateVideosWithTransitions(ctx context.Context, tl timeline.Timeline, videoFiles []string, outputPath string) error {
 	// Guard: This function requires at least 2 videos for transitions
 	if len(videoFiles) < 2 {
 		return fmt.Errorf("concatenateVideosWithTransitions requires at least 2 videos, got %d", len(videoFiles))
 	}
+	if len(videoFiles) != len(tl.Segments) {
+		return fmt.Errorf("segment and video count mismatch: %d vs %d", len(tl.Segments), len(videoFiles))
+	}
 
 	args := []string{"-y"}
 
@@ -309,62 +633,35 @@ func (s *VideoService) concatenateVideosWithTransitions(videoFiles []string, out
 		args = append(args, "-i", video)
 	}
 
-	// Build complex filter for transitions
-	var filterComplex strings.Builder
-	var audioMix strings.Builder
-
-	transitionName := s.transition.GetFFmpegTransitionName()
-	transitionDuration := s.transition.Duration
-
 	// Get duration of each video segment for offset calculation
 	durations := make([]float64, len(videoFiles))
 	for i, video := range videoFiles {
-		duration, err := s.getVideoDuration(video)
+		duration, err := s.getVideoDuration(ctx, video)
 		if err != nil {
 			s.logger.Warn("Failed to get video duration, using default", "video", video, "error", err)
 			duration = 5.0 // Default fallback
 		}
 		durations[i] = duration
-		
-		// Warn if transition duration exceeds video duration
-		if transitionDuration >= duration {
-			s.logger.Warn("Transition duration meets or exceeds video duration, may cause unexpected behavior",
-				"video", video,
-				"video_duration", duration,
-				"transition_duration", transitionDuration)
-		}
 	}
 
-	// Generate xfade transitions between consecutive videos
-	currentVideoLabel := "[0:v]"
-	offset := 0.0
+	filterComplex := transitionFilter(tl, durations)
 
+	// Warn if a transition exceeds the segment it leaves
 	for i := 0; i < len(videoFiles)-1; i++ {
-		nextVideoLabel := fmt.Sprintf("[%d:v]", i+1)
-		outputLabel := fmt.Sprintf("[v%d]", i)
-
-		// Calculate offset: accumulated duration minus transition duration
-		offset += durations[i] - transitionDuration
-
-		// Add xfade filter
-		filterComplex.WriteString(fmt.Sprintf(
-			"%s%sxfade=transition=%s:duration=%.2f:offset=%.2f%s",
-			currentVideoLabel, nextVideoLabel,
-			transitionName, transitionDuration, offset,
-			outputLabel,
-		))
-
-		if i < len(videoFiles)-2 {
-			filterComplex.WriteString(";")
+		transition := transitionFromTimeline(tl.Segments[i].TransitionOut)
+		if transition.IsEnabled() && transition.Duration >= durations[i] {
+			s.logger.Warn("Transition duration meets or exceeds video duration, may cause unexpected behavior",
+				"video", videoFiles[i],
+				"video_duration", durations[i],
+				"transition_duration", transition.Duration)
 		}
-
-		currentVideoLabel = outputLabel
 	}
 
 	// Final video output label
 	finalVideoLabel := fmt.Sprintf("[v%d]", len(videoFiles)-2)
 
 	// Mix audio streams
+	var audioMix strings.Builder
 	audioMix.WriteString(";")
 	for i := range videoFiles {
 		audioMix.WriteString(fmt.Sprintf("[%d:a]", i))
@@ -372,31 +669,160 @@ func (s *VideoService) concatenateVideosWithTransitions(videoFiles []string, out
 	audioMix.WriteString(fmt.Sprintf("concat=n=%d:v=0:a=1[outa]", len(videoFiles)))
 
 	// Combine video and audio filters
-	fullFilter := filterComplex.String() + audioMix.String()
+	fullFilter := filterComplex + audioMix.String()
 	args = append(args, "-filter_complex", fullFilter)
 	args = append(args, "-map", finalVideoLabel, "-map", "[outa]", outputPath)
 
-	cmd := exec.Command("ffmpeg", args...)
-	s.logger.Debug("Concatenating videos with transitions",
-		"transition", transitionName,
-		"duration", transitionDuration,
-		"command", cmd.String())
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	s.logger.Debug("Concatenating videos with transitions", "command", cmd.String())
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("ffmpeg concat with transitions error: %w, stderr: %s", err, stderr.String())
+		return fmt.Errorf("ffmpeg concat with transitions error: %w, stderr: %s", subprocessError(ctx, err), stderr.String())
+	}
+
+	return nil
+}
+
+// transitionFilter builds the video filter joining consecutive segments.
+// Boundaries with a transition use xfade, the others a plain concat.
+// The output of boundary i is labelled [v<i>].
+func transitionFilter(tl timeline.Timeline, durations []float64) string {
+	var filterComplex strings.Builder
+
+	currentVideoLabel := "[0:v]"
+	chainDuration := durations[0]
+
+	for i := 0; i < len(durations)-1; i++ {
+		nextVideoLabel := fmt.Sprintf("[%d:v]", i+1)
+		outputLabel := fmt.Sprintf("[v%d]", i)
+
+		transition := transitionFromTimeline(tl.Segments[i].TransitionOut)
+		if transition.IsEnabled() {
+			// Offset: duration of the chain so far minus the transition duration
+			offset := chainDuration - transition.Duration
+			filterComplex.WriteString(fmt.Sprintf(
+				"%s%sxfade=transition=%s:duration=%.2f:offset=%.2f%s",
+				currentVideoLabel, nextVideoLabel,
+				transition.GetFFmpegTransitionName(), transition.Duration, offset,
+				outputLabel,
+			))
+			chainDuration += durations[i+1] - transition.Duration
+		} else {
+			filterComplex.WriteString(fmt.Sprintf("%s%sconcat=n=2:v=1:a=0%s", currentVideoLabel, nextVideoLabel, outputLabel))
+			chainDuration += durations[i+1]
+		}
+
+		if i < len(durations)-2 {
+			filterComplex.WriteString(";")
+		}
+
+		currentVideoLabel = outputLabel
+	}
+
+	return filterComplex.String()
+}
+
+// addChapters copies the video at inputPath to outputPath with a chapter at every segment with a title
+func (s *VideoService) addChapters(ctx context.Context, tl timeline.Timeline, videoFiles []string, inputPath, outputPath string) error {
+	durations := make([]float64, len(videoFiles))
+	for i, video := range videoFiles {
+		duration, err := s.getVideoDuration(ctx, video)
+		if err != nil {
+			return fmt.Errorf("failed to get duration of %s for chapters: %w", video, err)
+		}
+		durations[i] = duration
+	}
+
+	metadataPath := outputPath + ".chapters.txt"
+	if err := afero.WriteFile(s.fs, metadataPath, []byte(chapterMetadata(tl, durations)), 0644); err != nil {
+		return fmt.Errorf("failed to write chapters: %w", err)
 	}
 
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-y", "-i", inputPath, "-i", metadataPath,
+		"-map", "0", "-map_metadata", "1", "-map_chapters", "1", "-c", "copy", outputPath)
+	s.logger.Debug("Adding chapters", "command", cmd.String())
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg chapters error: %w, stderr: %s", subprocessError(ctx, err), stderr.String())
+	}
 	return nil
 }
 
-func (s *VideoService) getMediaDimensions(mediaPath string) (int, int, error) {
-	cmd := exec.Command("ffmpeg", "-i", mediaPath, "-vf", "scale", "-vframes", "1", "-f", "null", "-")
+// segmentStarts returns when every segment starts in the concatenated video, in seconds.
+// A segment with a transition out overlaps the next one by the transition duration.
+func segmentStarts(tl timeline.Timeline, durations []float64) []float64 {
+	starts := make([]float64, len(durations))
+	end := 0.0
+	for i, duration := range durations {
+		if i > 0 {
+			starts[i] = end
+			if transition := transitionFromTimeline(tl.Segments[i-1].TransitionOut); transition.IsEnabled() {
+				starts[i] -= transition.Duration
+			}
+		}
+		end = starts[i] + duration
+	}
+	return starts
+}
+
+// chapterMetadata builds the ffmpeg metadata file with a chapter for every segment with a title,
+// lasting until the next one
+func chapterMetadata(tl timeline.Timeline, durations []float64) string {
+	starts := segmentStarts(tl, durations)
+	end := starts[len(starts)-1] + durations[len(durations)-1]
+
+	var metadata strings.Builder
+	metadata.WriteString(";FFMETADATA1\n")
+	for i, seg := range tl.Segments {
+		if seg.Title() == "" {
+			continue
+		}
+		chapterEnd := end
+		for j := i + 1; j < len(tl.Segments); j++ {
+			if tl.Segments[j].Title() != "" {
+				chapterEnd = starts[j]
+				break
+			}
+		}
+		fmt.Fprintf(&metadata, "\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			int64(math.Round(starts[i]*1000)), int64(math.Round(chapterEnd*1000)), escapeMetadata(seg.Title()))
+	}
+	return metadata.String()
+}
+
+// escapeMetadata escapes the characters with a meaning in ffmpeg metadata files
+func escapeMetadata(value string) string {
+	var escaped strings.Builder
+	for _, r := range value {
+		switch r {
+		case '=', ';', '#', '\\', '\n':
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
+
+// subprocessError returns the cancellation of ctx when that is what stopped a media
+// subprocess, so callers can tell an interrupted run from a failed one
+func subprocessError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+func (s *VideoService) getMediaDimensions(ctx context.Context, mediaPath string) (int, int, error) {
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-i", mediaPath, "-vf", "scale", "-vframes", "1", "-f", "null", "-")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return 0, 0, fmt.Errorf("ffmpeg dimension check failed: %w", err)
+		return 0, 0, fmt.Errorf("ffmpeg dimension check failed: %w", subprocessError(ctx, err))
 	}
 
 	outputStr := string(output)
@@ -415,12 +841,12 @@ func (s *VideoService) getMediaDimensions(mediaPath string) (int, int, error) {
 }
 
 // isVideoFile checks if a file is a video (not a static image)
-func (s *VideoService) isVideoFile(filePath string) (bool, error) {
-	cmd := exec.Command("ffprobe", "-v", "error", "-select_streams", "v:0",
+func (s *VideoService) isVideoFile(ctx context.Context, filePath string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe", "-v", "error", "-select_streams", "v:0",
 		"-show_entries", "stream=codec_type,duration", "-of", "default=noprint_wrappers=1", filePath)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return false, fmt.Errorf("ffprobe check failed: %w", err)
+		return false, fmt.Errorf("ffprobe check failed: %w", subprocessError(ctx, err))
 	}
 
 	outputStr := string(output)
@@ -448,12 +874,12 @@ func (s *VideoService) isVideoFile(filePath string) (bool, error) {
 }
 
 // getVideoDuration gets the duration of a video file in seconds
-func (s *VideoService) getVideoDuration(videoPath string) (float64, error) {
-	cmd := exec.Command("ffprobe", "-v", "error", "-show_entries",
+func (s *VideoService) getVideoDuration(ctx context.Context, videoPath string) (float64, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe", "-v", "error", "-show_entries",
 		"format=duration", "-of", "default=noprint_wrappers=1:nokey=1", videoPath)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return 0, fmt.Errorf("ffprobe duration check failed: %w", err)
+		return 0, fmt.Errorf("ffprobe duration check failed: %w", subprocessError(ctx, err))
 	}
 
 	var duration float64
@@ -465,32 +891,52 @@ func (s *VideoService) getVideoDuration(videoPath string) (float64, error) {
 }
 
 // computeSegmentHash computes a cache key for a video segment
-func (s *VideoService) computeSegmentHash(slidePath, audioPath string, width, height int) (string, error) {
+func (s *VideoService) computeSegmentHash(seg timeline.Segment, width, height int) (string, error) {
 	// Read slide file
-	slideData, err := afero.ReadFile(s.fs, slidePath)
+	slideData, err := afero.ReadFile(s.fs, seg.Visual.Path)
 	if err != nil {
 		return "", fmt.Errorf("failed to read slide file: %w", err)
 	}
-	
-	// Read audio file
-	audioData, err := afero.ReadFile(s.fs, audioPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read audio file: %w", err)
-	}
-	
-	// Compute hash of slide + audio + dimensions
+
 	hasher := sha256.New()
 	hasher.Write(slideData)
-	hasher.Write(audioData)
+
+	// Read audio files
+	for _, track := range seg.Audio {
+		audioData, err := afero.ReadFile(s.fs, track.Path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read audio file: %w", err)
+		}
+		hasher.Write(audioData)
+	}
+
+	// Compute hash of slide + audio + dimensions
 	if _, err := fmt.Fprintf(hasher, "%dx%d", width, height); err != nil {
 		return "", fmt.Errorf("failed to write dimensions to hash: %w", err)
 	}
-	
+
+	// Segment settings are only hashed when used, so plain slide segments
+	// keep the cache keys they had before timelines existed
+	if !seg.IsSimple() {
+		hasher.Write([]byte(fmt.Sprintf("|in=%.3f|out=%.3f|duration=%.3f|pad_end=%.3f|min_duration=%.3f", seg.In, seg.Out, seg.Duration, seg.PadEnd, seg.MinDuration)))
+		for _, track := range seg.Audio {
+			hasher.Write([]byte(fmt.Sprintf("|offset=%.3f", track.Offset)))
+		}
+		for _, overlay := range seg.Overlays {
+			overlayData, err := afero.ReadFile(s.fs, overlay.Path)
+			if err != nil {
+				return "", fmt.Errorf("failed to read overlay file: %w", err)
+			}
+			hasher.Write(overlayData)
+			hasher.Write([]byte(fmt.Sprintf("|overlay=%s:%s:%.3f:%.3f", overlay.X, overlay.Y, overlay.Start, overlay.End)))
+		}
+	}
+
 	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
 // checkSegmentCache checks if a cached video segment exists and is valid
-func (s *VideoService) checkSegmentCache(slidePath, audioPath, outputPath string, width, height int) (bool, error) {
+func (s *VideoService) checkSegmentCache(seg timeline.Segment, outputPath string, width, height int) (bool, error) {
 	// Check if output file exists
 	exists, err := afero.Exists(s.fs, outputPath)
 	if err != nil {
@@ -499,7 +945,7 @@ func (s *VideoService) checkSegmentCache(slidePath, audioPath, outputPath string
 	if !exists {
 		return false, nil
 	}
-	
+
 	// Check if hash file exists
 	hashPath := outputPath + ".hash"
 	hashExists, err := afero.Exists(s.fs, hashPath)
@@ -509,37 +955,37 @@ func (s *VideoService) checkSegmentCache(slidePath, audioPath, outputPath string
 	if !hashExists {
 		return false, nil
 	}
-	
+
 	// Read stored hash
 	storedHash, err := afero.ReadFile(s.fs, hashPath)
 	if err != nil {
 		return false, err
 	}
-	
+
 	// Compute current hash
-	currentHash, err := s.computeSegmentHash(slidePath, audioPath, width, height)
+	currentHash, err := s.computeSegmentHash(seg, width, height)
 	if err != nil {
 		return false, err
 	}
-	
+
 	return string(storedHash) == currentHash, nil
 }
 
 // saveSegmentHash saves the hash for a video segment
-func (s *VideoService) saveSegmentHash(slidePath, audioPath, outputPath string, width, height int) error {
-	hash, err := s.computeSegmentHash(slidePath, audioPath, width, height)
+func (s *VideoService) saveSegmentHash(seg timeline.Segment, outputPath string, width, height int) error {
+	hash, err := s.computeSegmentHash(seg, width, height)
 	if err != nil {
 		return err
 	}
-	
+
 	hashPath := outputPath + ".hash"
-	return afero.WriteFile(s.fs, hashPath, []byte(hash), 0644)
+	return writeFileAtomic(s.fs, hashPath, []byte(hash), 0644)
 }
 
 // computeFinalVideoHash computes a cache key for the final concatenated video
-func (s *VideoService) computeFinalVideoHash(videoFiles []string) (string, error) {
+func (s *VideoService) computeFinalVideoHash(tl timeline.Timeline, videoFiles []string) (string, error) {
 	hasher := sha256.New()
-	
+
 	// Hash each video segment file
 	for _, videoFile := range videoFiles {
 		data, err := afero.ReadFile(s.fs, videoFile)
@@ -548,17 +994,33 @@ func (s *VideoService) computeFinalVideoHash(videoFiles []string) (string, error
 		}
 		hasher.Write(data)
 	}
-	
-	// Include transition configuration in hash
-	if _, err := fmt.Fprintf(hasher, "%s:%.2f", s.transition.Type, s.transition.Duration); err != nil {
-		return "", fmt.Errorf("failed to write transition config to hash: %w", err)
+
+	// Include transitions in hash
+	if !tl.HasTransitions() {
+		if _, err := fmt.Fprintf(hasher, "%s:%.2f", TransitionNone, 0.0); err != nil {
+			return "", fmt.Errorf("failed to write transition config to hash: %w", err)
+		}
+	} else {
+		for i := 0; i < len(tl.Segments)-1; i++ {
+			transition := transitionFromTimeline(tl.Segments[i].TransitionOut)
+			if _, err := fmt.Fprintf(hasher, "%d:%s:%.2f;", i, transition.Type, transition.Duration); err != nil {
+				return "", fmt.Errorf("failed to write transition config to hash: %w", err)
+			}
+		}
 	}
-	
+
+	// Chapters are only hashed when present, so videos without keep their cache keys
+	for i, seg := range tl.Segments {
+		if seg.Title() != "" {
+			fmt.Fprintf(hasher, "|chapter %d=%s", i, seg.Title())
+		}
+	}
+
 	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
 // checkFinalVideoCache checks if a cached final video exists and is valid
-func (s *VideoService) checkFinalVideoCache(videoFiles []string, outputPath string) (bool, error) {
+func (s *VideoService) checkFinalVideoCache(tl timeline.Timeline, videoFiles []string, outputPath string) (bool, error) {
 	// Check if output file exists
 	exists, err := afero.Exists(s.fs, outputPath)
 	if err != nil {
@@ -567,7 +1029,7 @@ func (s *VideoService) checkFinalVideoCache(videoFiles []string, outputPath stri
 	if !exists {
 		return false, nil
 	}
-	
+
 	// Check if hash file exists
 	hashPath := outputPath + ".hash"
 	hashExists, err := afero.Exists(s.fs, hashPath)
@@ -577,29 +1039,29 @@ func (s *VideoService) checkFinalVideoCache(videoFiles []string, outputPath stri
 	if !hashExists {
 		return false, nil
 	}
-	
+
 	// Read stored hash
 	storedHash, err := afero.ReadFile(s.fs, hashPath)
 	if err != nil {
 		return false, err
 	}
-	
+
 	// Compute current hash
-	currentHash, err := s.computeFinalVideoHash(videoFiles)
+	currentHash, err := s.computeFinalVideoHash(tl, videoFiles)
 	if err != nil {
 		return false, err
 	}
-	
+
 	return string(storedHash) == currentHash, nil
 }
 
 // saveFinalVideoHash saves the hash for the final video
-func (s *VideoService) saveFinalVideoHash(videoFiles []string, outputPath string) error {
-	hash, err := s.computeFinalVideoHash(videoFiles)
+func (s *VideoService) saveFinalVideoHash(tl timeline.Timeline, videoFiles []string, outputPath string) error {
+	hash, err := s.computeFinalVideoHash(tl, videoFiles)
 	if err != nil {
 		return err
 	}
-	
+
 	hashPath := outputPath + ".hash"
-	return afero.WriteFile(s.fs, hashPath, []byte(hash), 0644)
+	return writeFileAtomic(s.fs, hashPath, []byte(hash), 0644)
 }
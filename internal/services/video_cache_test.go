@@ -1,8 +1,11 @@
 package services
 
 import (
+	"context"
 	"testing"
 
+	"gocreator/internal/timeline"
+
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,27 +19,31 @@ func TestVideoService_computeSegmentHash(t *testing.T) {
 	// Create test files
 	slidePath := "/test/slide.png"
 	audioPath := "/test/audio.mp3"
+	seg := timeline.Segment{
+		Visual: timeline.VisualSource{Path: slidePath},
+		Audio:  []timeline.AudioTrack{{Path: audioPath}},
+	}
 	require.NoError(t, afero.WriteFile(fs, slidePath, []byte("slide data"), 0644))
 	require.NoError(t, afero.WriteFile(fs, audioPath, []byte("audio data"), 0644))
 
 	// Compute hash
-	hash1, err := service.computeSegmentHash(slidePath, audioPath, 1920, 1080)
+	hash1, err := service.computeSegmentHash(seg, 1920, 1080)
 	require.NoError(t, err)
 	assert.NotEmpty(t, hash1)
 
 	// Same inputs should produce same hash
-	hash2, err := service.computeSegmentHash(slidePath, audioPath, 1920, 1080)
+	hash2, err := service.computeSegmentHash(seg, 1920, 1080)
 	require.NoError(t, err)
 	assert.Equal(t, hash1, hash2)
 
 	// Different dimensions should produce different hash
-	hash3, err := service.computeSegmentHash(slidePath, audioPath, 1280, 720)
+	hash3, err := service.computeSegmentHash(seg, 1280, 720)
 	require.NoError(t, err)
 	assert.NotEqual(t, hash1, hash3)
 
 	// Different slide content should produce different hash
 	require.NoError(t, afero.WriteFile(fs, slidePath, []byte("different slide"), 0644))
-	hash4, err := service.computeSegmentHash(slidePath, audioPath, 1920, 1080)
+	hash4, err := service.computeSegmentHash(seg, 1920, 1080)
 	require.NoError(t, err)
 	assert.NotEqual(t, hash1, hash4)
 }
@@ -48,6 +55,10 @@ func TestVideoService_checkSegmentCache(t *testing.T) {
 
 	slidePath := "/test/slide.png"
 	audioPath := "/test/audio.mp3"
+	seg := timeline.Segment{
+		Visual: timeline.VisualSource{Path: slidePath},
+		Audio:  []timeline.AudioTrack{{Path: audioPath}},
+	}
 	outputPath := "/test/output.mp4"
 
 	// Create test files
@@ -55,29 +66,29 @@ func TestVideoService_checkSegmentCache(t *testing.T) {
 	require.NoError(t, afero.WriteFile(fs, audioPath, []byte("audio data"), 0644))
 
 	t.Run("cache miss when output doesn't exist", func(t *testing.T) {
-		cached, err := service.checkSegmentCache(slidePath, audioPath, outputPath, 1920, 1080)
+		cached, err := service.checkSegmentCache(seg, outputPath, 1920, 1080)
 		require.NoError(t, err)
 		assert.False(t, cached)
 	})
 
 	t.Run("cache miss when hash file doesn't exist", func(t *testing.T) {
 		require.NoError(t, afero.WriteFile(fs, outputPath, []byte("video data"), 0644))
-		cached, err := service.checkSegmentCache(slidePath, audioPath, outputPath, 1920, 1080)
+		cached, err := service.checkSegmentCache(seg, outputPath, 1920, 1080)
 		require.NoError(t, err)
 		assert.False(t, cached)
 	})
 
 	t.Run("cache miss when hash doesn't match", func(t *testing.T) {
 		require.NoError(t, afero.WriteFile(fs, outputPath+".hash", []byte("wrong hash"), 0644))
-		cached, err := service.checkSegmentCache(slidePath, audioPath, outputPath, 1920, 1080)
+		cached, err := service.checkSegmentCache(seg, outputPath, 1920, 1080)
 		require.NoError(t, err)
 		assert.False(t, cached)
 	})
 
 	t.Run("cache hit when hash matches", func(t *testing.T) {
 		// Save correct hash
-		require.NoError(t, service.saveSegmentHash(slidePath, audioPath, outputPath, 1920, 1080))
-		cached, err := service.checkSegmentCache(slidePath, audioPath, outputPath, 1920, 1080)
+		require.NoError(t, service.saveSegmentHash(seg, outputPath, 1920, 1080))
+		cached, err := service.checkSegmentCache(seg, outputPath, 1920, 1080)
 		require.NoError(t, err)
 		assert.True(t, cached)
 	})
@@ -85,7 +96,7 @@ func TestVideoService_checkSegmentCache(t *testing.T) {
 	t.Run("cache miss when input changes", func(t *testing.T) {
 		// Modify slide
 		require.NoError(t, afero.WriteFile(fs, slidePath, []byte("modified slide"), 0644))
-		cached, err := service.checkSegmentCache(slidePath, audioPath, outputPath, 1920, 1080)
+		cached, err := service.checkSegmentCache(seg, outputPath, 1920, 1080)
 		require.NoError(t, err)
 		assert.False(t, cached)
 	})
@@ -98,6 +109,10 @@ func TestVideoService_saveSegmentHash(t *testing.T) {
 
 	slidePath := "/test/slide.png"
 	audioPath := "/test/audio.mp3"
+	seg := timeline.Segment{
+		Visual: timeline.VisualSource{Path: slidePath},
+		Audio:  []timeline.AudioTrack{{Path: audioPath}},
+	}
 	outputPath := "/test/output.mp4"
 
 	// Create test files
@@ -105,7 +120,7 @@ func TestVideoService_saveSegmentHash(t *testing.T) {
 	require.NoError(t, afero.WriteFile(fs, audioPath, []byte("audio data"), 0644))
 
 	// Save hash
-	err := service.saveSegmentHash(slidePath, audioPath, outputPath, 1920, 1080)
+	err := service.saveSegmentHash(seg, outputPath, 1920, 1080)
 	require.NoError(t, err)
 
 	// Verify hash file was created
@@ -131,36 +146,39 @@ func TestVideoService_computeFinalVideoHash(t *testing.T) {
 	require.NoError(t, afero.WriteFile(fs, video2, []byte("video2 data"), 0644))
 
 	videoFiles := []string{video1, video2}
+	tl, err := timeline.FromSlides([]string{"/test/slide1.png", "/test/slide2.png"}, []string{"/test/audio1.mp3", "/test/audio2.mp3"})
+	require.NoError(t, err)
 
 	t.Run("same inputs produce same hash", func(t *testing.T) {
-		hash1, err := service.computeFinalVideoHash(videoFiles)
+		hash1, err := service.computeFinalVideoHash(tl, videoFiles)
 		require.NoError(t, err)
 		assert.NotEmpty(t, hash1)
 
-		hash2, err := service.computeFinalVideoHash(videoFiles)
+		hash2, err := service.computeFinalVideoHash(tl, videoFiles)
 		require.NoError(t, err)
 		assert.Equal(t, hash1, hash2)
 	})
 
 	t.Run("different transition config produces different hash", func(t *testing.T) {
-		service.SetTransition(TransitionConfig{Type: TransitionNone})
-		hash1, err := service.computeFinalVideoHash(videoFiles)
+		hash1, err := service.computeFinalVideoHash(tl, videoFiles)
 		require.NoError(t, err)
 
-		service.SetTransition(TransitionConfig{Type: TransitionFade, Duration: 0.5})
-		hash2, err := service.computeFinalVideoHash(videoFiles)
+		faded := tl
+		faded.Segments = append([]timeline.Segment(nil), tl.Segments...)
+		TransitionConfig{Type: TransitionFade, Duration: 0.5}.ApplyTo(&faded)
+		hash2, err := service.computeFinalVideoHash(faded, videoFiles)
 		require.NoError(t, err)
 
 		assert.NotEqual(t, hash1, hash2)
 	})
 
 	t.Run("different video content produces different hash", func(t *testing.T) {
-		hash1, err := service.computeFinalVideoHash(videoFiles)
+		hash1, err := service.computeFinalVideoHash(tl, videoFiles)
 		require.NoError(t, err)
 
 		// Modify video content
 		require.NoError(t, afero.WriteFile(fs, video1, []byte("modified video1"), 0644))
-		hash2, err := service.computeFinalVideoHash(videoFiles)
+		hash2, err := service.computeFinalVideoHash(tl, videoFiles)
 		require.NoError(t, err)
 
 		assert.NotEqual(t, hash1, hash2)
@@ -181,31 +199,33 @@ func TestVideoService_checkFinalVideoCache(t *testing.T) {
 	require.NoError(t, afero.WriteFile(fs, video2, []byte("video2 data"), 0644))
 
 	videoFiles := []string{video1, video2}
+	tl, err := timeline.FromSlides([]string{"/test/slide1.png", "/test/slide2.png"}, []string{"/test/audio1.mp3", "/test/audio2.mp3"})
+	require.NoError(t, err)
 
 	t.Run("cache miss when output doesn't exist", func(t *testing.T) {
-		cached, err := service.checkFinalVideoCache(videoFiles, outputPath)
+		cached, err := service.checkFinalVideoCache(tl, videoFiles, outputPath)
 		require.NoError(t, err)
 		assert.False(t, cached)
 	})
 
 	t.Run("cache miss when hash file doesn't exist", func(t *testing.T) {
 		require.NoError(t, afero.WriteFile(fs, outputPath, []byte("final video"), 0644))
-		cached, err := service.checkFinalVideoCache(videoFiles, outputPath)
+		cached, err := service.checkFinalVideoCache(tl, videoFiles, outputPath)
 		require.NoError(t, err)
 		assert.False(t, cached)
 	})
 
 	t.Run("cache miss when hash doesn't match", func(t *testing.T) {
 		require.NoError(t, afero.WriteFile(fs, outputPath+".hash", []byte("wrong hash"), 0644))
-		cached, err := service.checkFinalVideoCache(videoFiles, outputPath)
+		cached, err := service.checkFinalVideoCache(tl, videoFiles, outputPath)
 		require.NoError(t, err)
 		assert.False(t, cached)
 	})
 
 	t.Run("cache hit when hash matches", func(t *testing.T) {
 		// Save correct hash
-		require.NoError(t, service.saveFinalVideoHash(videoFiles, outputPath))
-		cached, err := service.checkFinalVideoCache(videoFiles, outputPath)
+		require.NoError(t, service.saveFinalVideoHash(tl, videoFiles, outputPath))
+		cached, err := service.checkFinalVideoCache(tl, videoFiles, outputPath)
 		require.NoError(t, err)
 		assert.True(t, cached)
 	})
@@ -213,7 +233,7 @@ func TestVideoService_checkFinalVideoCache(t *testing.T) {
 	t.Run("cache miss when segment changes", func(t *testing.T) {
 		// Modify segment
 		require.NoError(t, afero.WriteFile(fs, video1, []byte("modified video1"), 0644))
-		cached, err := service.checkFinalVideoCache(videoFiles, outputPath)
+		cached, err := service.checkFinalVideoCache(tl, videoFiles, outputPath)
 		require.NoError(t, err)
 		assert.False(t, cached)
 	})
@@ -221,17 +241,18 @@ func TestVideoService_checkFinalVideoCache(t *testing.T) {
 	t.Run("cache miss when transition config changes", func(t *testing.T) {
 		// Reset video content and save hash
 		require.NoError(t, afero.WriteFile(fs, video1, []byte("video1 data"), 0644))
-		service.SetTransition(TransitionConfig{Type: TransitionNone})
-		require.NoError(t, service.saveFinalVideoHash(videoFiles, outputPath))
+		require.NoError(t, service.saveFinalVideoHash(tl, videoFiles, outputPath))
 
 		// Cache hit with same config
-		cached, err := service.checkFinalVideoCache(videoFiles, outputPath)
+		cached, err := service.checkFinalVideoCache(tl, videoFiles, outputPath)
 		require.NoError(t, err)
 		assert.True(t, cached)
 
 		// Change transition config - cache miss
-		service.SetTransition(TransitionConfig{Type: TransitionFade, Duration: 0.5})
-		cached, err = service.checkFinalVideoCache(videoFiles, outputPath)
+		faded := tl
+		faded.Segments = append([]timeline.Segment(nil), tl.Segments...)
+		TransitionConfig{Type: TransitionFade, Duration: 0.5}.ApplyTo(&faded)
+		cached, err = service.checkFinalVideoCache(faded, videoFiles, outputPath)
 		require.NoError(t, err)
 		assert.False(t, cached)
 	})
@@ -251,9 +272,11 @@ func TestVideoService_saveFinalVideoHash(t *testing.T) {
 	require.NoError(t, afero.WriteFile(fs, video2, []byte("video2 data"), 0644))
 
 	videoFiles := []string{video1, video2}
+	tl, err := timeline.FromSlides([]string{"/test/slide1.png", "/test/slide2.png"}, []string{"/test/audio1.mp3", "/test/audio2.mp3"})
+	require.NoError(t, err)
 
 	// Save hash
-	err := service.saveFinalVideoHash(videoFiles, outputPath)
+	err = service.saveFinalVideoHash(tl, videoFiles, outputPath)
 	require.NoError(t, err)
 
 	// Verify hash file was created
@@ -271,7 +294,6 @@ func TestVideoService_concatenateVideosWithTransitions_GuardSingleVideo(t *testi
 fs := afero.NewMemMapFs()
 logger := &mockLogger{}
 service := NewVideoService(fs, logger)
-service.SetTransition(TransitionConfig{Type: TransitionFade, Duration: 0.5})
 
 video1 := "/test/video1.mp4"
 outputPath := "/test/final.mp4"
@@ -280,9 +302,11 @@ outputPath := "/test/final.mp4"
 require.NoError(t, afero.WriteFile(fs, video1, []byte("video1 data"), 0644))
 
 videoFiles := []string{video1}
+tl, err := timeline.FromSlides([]string{"/test/slide1.png"}, []string{"/test/audio1.mp3"})
+require.NoError(t, err)
 
 // Should return error when called with single video
-err := service.concatenateVideosWithTransitions(videoFiles, outputPath)
+err = service.concatenateVideosWithTransitions(context.Background(), tl, videoFiles, outputPath)
 require.Error(t, err)
 assert.Contains(t, err.Error(), "requires at least 2 videos")
 }
@@ -1,10 +1,17 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
+
+	"gocreator/internal/timeline"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewVideoService(t *testing.T) {
@@ -22,3 +29,191 @@ func TestNewVideoService(t *testing.T) {
 // all depend on ffmpeg/ffprobe being installed and available.
 // These functions are tested in integration tests but cannot be easily unit tested
 // without mocking the exec.Command functionality or having ffmpeg installed.
+
+func TestSegmentArgs(t *testing.T) {
+	seg := timeline.Segment{
+		Visual: timeline.VisualSource{Path: "/slide.png"},
+		Audio:  []timeline.AudioTrack{{Path: "/audio.mp3"}},
+	}
+
+	t.Run("plain image segment ends with narration", func(t *testing.T) {
+		args := segmentArgs(seg, false, false, 1920, 1080, 0, 0, "/out.mp4")
+
+		assert.Equal(t, []string{"-y", "-loop", "1", "-i", "/slide.png", "-i", "/audio.mp3",
+			"-map", "0:v:0", "-map", "1:a:0",
+			"-c:v", "libx264", "-tune", "stillimage",
+			"-c:a", "mp3", "-b:a", "192k",
+			"-pix_fmt", "yuv420p", "-shortest", "/out.mp4"}, args)
+	})
+
+	t.Run("scaled image segment", func(t *testing.T) {
+		args := strings.Join(segmentArgs(seg, false, true, 1280, 720, 0, 0, "/out.mp4"), " ")
+
+		assert.Contains(t, args, "[0:v]scale=1280:720:force_original_aspect_ratio=decrease,pad=1280:720:(ow-iw)/2:(oh-ih)/2,setsar=1[base]")
+		assert.Contains(t, args, "-map [base] -map 1:a:0")
+	})
+
+	t.Run("trimmed video with delayed music and overlay", func(t *testing.T) {
+		rich := seg
+		rich.Visual.Path = "/clip.mp4"
+		rich.In = 2
+		rich.Audio = []timeline.AudioTrack{{Path: "/audio.mp3", Offset: 0.5}, {Path: "/music.mp3"}}
+		rich.Overlays = []timeline.Overlay{{Path: "/logo.png", X: "W-w-20", Start: 1, End: 3}}
+
+		args := strings.Join(segmentArgs(rich, true, false, 1920, 1080, 4, 0, "/out.mp4"), " ")
+
+		assert.Contains(t, args, "-ss 2.00 -i /clip.mp4 -i /audio.mp3 -i /music.mp3 -i /logo.png")
+		assert.Contains(t, args, "[0:v][3:v]overlay=W-w-20:0:enable='between(t,1.00,3.00)'[ov0]")
+		assert.Contains(t, args, "[1:a]adelay=500:all=1[ad0]")
+		assert.Contains(t, args, "[ad0][2:a]amix=inputs=2:duration=longest:normalize=0[mix]")
+		assert.Contains(t, args, "-map [ov0] -map [mix]")
+		assert.Contains(t, args, "-t 4.00")
+		assert.NotContains(t, args, "stillimage")
+	})
+
+	t.Run("fixed duration pads narration", func(t *testing.T) {
+		fixed := seg
+		fixed.Duration = 3
+
+		args := strings.Join(segmentArgs(fixed, false, false, 1920, 1080, 3, 0, "/out.mp4"), " ")
+
+		assert.Contains(t, args, "[1:a]apad[padded]")
+		assert.Contains(t, args, "-map 0:v:0 -map [padded]")
+		assert.Contains(t, args, "-t 3.00")
+		assert.NotContains(t, args, "-shortest")
+	})
+
+	t.Run("image held past its narration pads audio", func(t *testing.T) {
+		held := seg
+		held.Audio = []timeline.AudioTrack{{Path: "/audio.mp3", Offset: 0.5}}
+		held.PadEnd = 1
+
+		args := strings.Join(segmentArgs(held, false, false, 1920, 1080, 4.5, 0, "/out.mp4"), " ")
+
+		assert.Contains(t, args, "[1:a]adelay=500:all=1[ad0];[ad0]apad[padded]")
+		assert.Contains(t, args, "-t 4.50")
+		assert.NotContains(t, args, "-shortest")
+	})
+
+	t.Run("silent slide plays generated silence", func(t *testing.T) {
+		silent := seg
+		silent.Audio = nil
+		silent.MinDuration = 3
+		silent.Overlays = []timeline.Overlay{{Path: "/logo.png"}}
+
+		args := strings.Join(segmentArgs(silent, false, false, 1920, 1080, 3, 0, "/out.mp4"), " ")
+
+		assert.Contains(t, args, "-loop 1 -i /slide.png -f lavfi -i anullsrc=r=44100:cl=stereo -i /logo.png")
+		assert.Contains(t, args, "[0:v][2:v]overlay=0:0[ov0]")
+		assert.Contains(t, args, "-map [ov0] -map 1:a:0")
+		assert.NotContains(t, args, "apad")
+		assert.Contains(t, args, "-t 3.00")
+	})
+
+	t.Run("video held past its end", func(t *testing.T) {
+		held := seg
+		held.Visual.Path = "/clip.mp4"
+		held.MinDuration = 6
+
+		args := strings.Join(segmentArgs(held, true, true, 1280, 720, 6, 2, "/out.mp4"), " ")
+
+		assert.Contains(t, args, "[0:v]tpad=stop_mode=clone:stop_duration=2.00[held];[held]scale=1280:720")
+		assert.Contains(t, args, "[1:a]apad[padded]")
+		assert.Contains(t, args, "-map [base] -map [padded]")
+		assert.Contains(t, args, "-t 6.00")
+	})
+}
+
+func TestTransitionFilter(t *testing.T) {
+	tl, err := timeline.FromSlides(
+		[]string{"/1.png", "/2.png", "/3.png"},
+		[]string{"/1.mp3", "/2.mp3", "/3.mp3"},
+	)
+	assert.NoError(t, err)
+
+	// Fade out of the first slide, hard cut out of the second
+	tl.Segments[0].TransitionOut = &timeline.Transition{Type: "fade", Duration: 0.5}
+
+	filter := transitionFilter(tl, []float64{4, 3, 2})
+
+	assert.Equal(t,
+		"[0:v][1:v]xfade=transition=fade:duration=0.50:offset=3.50[v0];[v0][2:v]concat=n=2:v=1:a=0[v1]",
+		filter)
+}
+
+func TestChapterMetadata(t *testing.T) {
+	tl, err := timeline.FromSlides([]string{"/s/1.png", "/s/2.png", "/s/3.png"}, []string{"/a/0.mp3", "/a/1.mp3", "/a/2.mp3"})
+	require.NoError(t, err)
+	tl.Segments[0].SetTitle("Intro")
+	tl.Segments[2].SetTitle("Q&A; wrap=up")
+	tl.Segments[1].TransitionOut = &timeline.Transition{Type: "fade", Duration: 0.5}
+
+	// Slide 2 belongs to the intro chapter, slide 3 starts half a second early for the fade
+	expected := ";FFMETADATA1\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=0\nEND=6500\ntitle=Intro\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=6500\nEND=8500\ntitle=Q&A\\; wrap\\=up\n"
+	assert.Equal(t, expected, chapterMetadata(tl, []float64{3, 4, 2}))
+}
+
+func TestVideoService_GenerateFromTimeline_Canceled(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	service := NewVideoService(fs, &mockLogger{})
+
+	tl, err := timeline.FromSlides([]string{"/slides/1.png"}, []string{"/audio/0.mp3"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// No subprocess is started once the run is canceled
+	err = service.GenerateFromTimeline(ctx, tl, "/out/output-en.mp4")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestVideoService_GenerateFromTimeline_ProbeWaitsForSlot(t *testing.T) {
+	service := NewVideoService(afero.NewMemMapFs(), &mockLogger{})
+	scheduler := NewScheduler(1, 1)
+	service.SetScheduler(scheduler)
+
+	tl, err := timeline.FromSlides([]string{"/slides/1.png"}, []string{"/audio/0.mp3"})
+	require.NoError(t, err)
+
+	// Hold the only media slot
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = scheduler.Media(context.Background(), func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	defer close(release)
+
+	// The dimension probe is a media job too, it waits for the slot instead of starting ffmpeg
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = service.GenerateFromTimeline(ctx, tl, "/out/output-en.mp4")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestVideoService_GeneratePreview_IndexMismatch(t *testing.T) {
+	service := NewVideoService(afero.NewMemMapFs(), &mockLogger{})
+
+	tl, err := timeline.FromSlides([]string{"/slides/2.png"}, []string{"/audio/1.mp3"})
+	require.NoError(t, err)
+
+	err = service.GeneratePreview(context.Background(), tl, []int{1, 2}, "/out/output-en.mp4", "/out/preview/preview-en.mp4")
+	assert.ErrorContains(t, err, "1 segments but 2 slide indices")
+}
+
+func TestSubprocessError(t *testing.T) {
+	killed := errors.New("signal: killed")
+	assert.Equal(t, killed, subprocessError(context.Background(), killed))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, subprocessError(ctx, killed), context.Canceled)
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"gocreator/internal/interfaces"
+
+	"github.com/spf13/afero"
+)
+
+// fileStamp identifies a version of a file without reading it
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// FileWatcher polls files and directory trees for changes.
+// Polling works on any afero filesystem and on network shares, where change notifications are unreliable.
+type FileWatcher struct {
+	fs       afero.Fs
+	paths    []string
+	interval time.Duration
+	debounce time.Duration
+	logger   interfaces.Logger
+}
+
+// NewFileWatcher creates a watcher of paths, which may be files or directories and need not exist yet.
+// Changes are polled every interval and reported once nothing changed for debounce.
+func NewFileWatcher(fs afero.Fs, paths []string, interval, debounce time.Duration, logger interfaces.Logger) *FileWatcher {
+	return &FileWatcher{
+		fs:       fs,
+		paths:    paths,
+		interval: interval,
+		debounce: debounce,
+		logger:   logger,
+	}
+}
+
+// Watch calls onChange with the paths changed since the previous call, until ctx is done.
+// onChange runs on the watching goroutine: changes made while it runs are reported once it returns.
+func (w *FileWatcher) Watch(ctx context.Context, onChange func(changed []string)) error {
+	last, err := w.snapshot()
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	pending := make(map[string]bool)
+	var lastChange time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		current, err := w.snapshot()
+		if err != nil {
+			w.logger.Warn("Failed to scan watched files", "error", err)
+			continue
+		}
+		changed := diffSnapshots(last, current)
+		last = current
+		if len(changed) > 0 {
+			for _, path := range changed {
+				pending[path] = true
+			}
+			lastChange = time.Now()
+			continue
+		}
+
+		// Rapid edits are reported together once they settle
+		if len(pending) == 0 || time.Since(lastChange) < w.debounce {
+			continue
+		}
+		paths := make([]string, 0, len(pending))
+		for path := range pending {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		pending = make(map[string]bool)
+		onChange(paths)
+	}
+}
+
+// snapshot stamps every file under the watched paths
+func (w *FileWatcher) snapshot() (map[string]fileStamp, error) {
+	stamps := make(map[string]fileStamp)
+	for _, root := range w.paths {
+		err := afero.Walk(w.fs, root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if !info.IsDir() {
+				stamps[path] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+		}
+	}
+	return stamps, nil
+}
+
+// diffSnapshots returns the sorted paths added, removed or modified between two snapshots
+func diffSnapshots(before, after map[string]fileStamp) []string {
+	var changed []string
+	for path, stamp := range after {
+		if previous, ok := before[path]; !ok || previous.size != stamp.size || !previous.modTime.Equal(stamp.modTime) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gocreator/internal/interfaces"
+	"gocreator/internal/mocks"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	now := time.Now()
+	before := map[string]fileStamp{
+		"/data/texts.txt":    {size: 10, modTime: now},
+		"/data/slides/1.png": {size: 100, modTime: now},
+		"/data/slides/2.png": {size: 100, modTime: now},
+	}
+	after := map[string]fileStamp{
+		"/data/texts.txt":    {size: 10, modTime: now.Add(time.Second)},
+		"/data/slides/1.png": {size: 100, modTime: now},
+		"/data/slides/3.png": {size: 100, modTime: now},
+	}
+
+	assert.Equal(t, []string{"/data/slides/2.png", "/data/slides/3.png", "/data/texts.txt"}, diffSnapshots(before, after))
+	assert.Empty(t, diffSnapshots(after, after))
+}
+
+func TestFileWatcher_Watch(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/data/texts.txt", []byte("Hello"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/data/slides/1.png", []byte("png"), 0644))
+
+	// The config file does not exist yet, watching it must not fail
+	watcher := NewFileWatcher(fs, []string{"/data/slides", "/data/texts.txt", "/gocreator.yaml"},
+		5*time.Millisecond, 50*time.Millisecond, &mockLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan []string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- watcher.Watch(ctx, func(changed []string) { changes <- changed })
+	}()
+
+	// Rapid edits of several files are reported together once they settle
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, afero.WriteFile(fs, "/data/texts.txt", []byte("Hello world"), 0644))
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, afero.WriteFile(fs, "/data/slides/2.png", []byte("png"), 0644))
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, afero.WriteFile(fs, "/gocreator.yaml", []byte("input:\n  lang: en\n"), 0644))
+
+	select {
+	case changed := <-changes:
+		assert.Equal(t, []string{"/data/slides/2.png", "/data/texts.txt", "/gocreator.yaml"}, changed)
+	case <-time.After(2 * time.Second):
+		t.Fatal("change was not reported")
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Empty(t, changes)
+}
+
+func TestFileWatcher_Watch_SourceEdit(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	mockClient := new(mocks.MockOpenAIClient)
+	translator := NewTranslationService(mockClient, &mockLogger{})
+	mockClient.On("ChatCompletion", mock.Anything, requestAbout("'Hello'"), interfaces.ChatOptions{}).Return("Bonjour", nil).Once()
+	mockClient.On("ChatCompletion", mock.Anything, requestAbout("'Goodbye'"), interfaces.ChatOptions{}).Return("Au revoir", nil).Once()
+	assert.Equal(t, []string{"Bonjour", "Au revoir"}, translatedRun(t, fs, translator, []string{"Hello", "Goodbye"}))
+
+	watcher := NewFileWatcher(fs, []string{"/test/data/texts.txt"}, 5*time.Millisecond, 20*time.Millisecond, &mockLogger{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan []string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- watcher.Watch(ctx, func(changed []string) { changes <- changed })
+	}()
+
+	// Editing the narration of a slide reaches its translated output, the other slide comes from the saved translation
+	time.Sleep(20 * time.Millisecond)
+	edited := []string{"Hello", "Good night"}
+	require.NoError(t, NewTextService(fs, &mockLogger{}).Save(context.Background(), "/test/data/texts.txt", edited))
+	select {
+	case changed := <-changes:
+		assert.Equal(t, []string{"/test/data/texts.txt"}, changed)
+	case <-time.After(2 * time.Second):
+		t.Fatal("change was not reported")
+	}
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	mockClient.On("ChatCompletion", mock.Anything, requestAbout("'Good night'"), interfaces.ChatOptions{}).Return("Bonne nuit", nil).Once()
+	assert.Equal(t, []string{"Bonjour", "Bonne nuit"}, translatedRun(t, fs, translator, edited))
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Workspace is a scratch directory for one run of one output.
+// Intermediate files are rendered there and only published to their final
+// location once complete. It is removed when the run succeeds and kept when
+// it fails, so the partial files can be inspected.
+type Workspace struct {
+	fs  afero.Fs
+	Dir string
+}
+
+// NewWorkspace creates a workspace under root for the named output
+func NewWorkspace(fs afero.Fs, root, name string) (*Workspace, error) {
+	dir := filepath.Join(root, fmt.Sprintf("%s-%s", name, newRunID()))
+	if err := fs.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	return &Workspace{fs: fs, Dir: dir}, nil
+}
+
+// Path returns the path of a file inside the workspace
+func (w *Workspace) Path(name string) string {
+	return filepath.Join(w.Dir, name)
+}
+
+// Remove deletes the workspace and everything in it
+func (w *Workspace) Remove() error {
+	return w.fs.RemoveAll(w.Dir)
+}
+
+// newRunID returns an identifier unique to one run, sortable by start time
+func newRunID() string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return time.Now().Format("20060102-150405.000000")
+	}
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// publishFile moves a complete file to its final path.
+// The rename is atomic, so readers see either the old file or the new one.
+func publishFile(fs afero.Fs, src, dst string) error {
+	if err := fs.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := fs.Rename(src, dst); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", src, dst, err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place
+func writeFileAtomic(fs afero.Fs, path string, data []byte, perm os.FileMode) error {
+	file, err := afero.TempFile(fs, filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := file.Name()
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fs.Chmod(tmpPath, perm)
+	}
+	if err != nil {
+		_ = fs.Remove(tmpPath)
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	return fs.Rename(tmpPath, path)
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspace(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	ws, err := NewWorkspace(fs, "/out/.work", "output-en")
+	require.NoError(t, err)
+	assert.Equal(t, "/out/.work", filepath.Dir(ws.Dir))
+	assert.True(t, strings.HasPrefix(filepath.Base(ws.Dir), "output-en-"))
+	assert.Equal(t, filepath.Join(ws.Dir, "video_0.mp4"), ws.Path("video_0.mp4"))
+
+	exists, err := afero.DirExists(fs, ws.Dir)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Concurrent runs of the same output never share a workspace
+	other, err := NewWorkspace(fs, "/out/.work", "output-en")
+	require.NoError(t, err)
+	assert.NotEqual(t, ws.Dir, other.Dir)
+
+	require.NoError(t, afero.WriteFile(fs, ws.Path("video_0.mp4"), []byte("partial"), 0644))
+	require.NoError(t, ws.Remove())
+	exists, err = afero.DirExists(fs, ws.Dir)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestPublishFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/work/video.mp4", []byte("new"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/out/video.mp4", []byte("old"), 0644))
+
+	require.NoError(t, publishFile(fs, "/work/video.mp4", "/out/video.mp4"))
+
+	data, err := afero.ReadFile(fs, "/out/video.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+
+	exists, err := afero.Exists(fs, "/work/video.mp4")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	t.Run("creates the destination directory", func(t *testing.T) {
+		require.NoError(t, afero.WriteFile(fs, "/work/a.mp4", []byte("a"), 0644))
+		require.NoError(t, publishFile(fs, "/work/a.mp4", "/new/dir/a.mp4"))
+		exists, err := afero.Exists(fs, "/new/dir/a.mp4")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		err := publishFile(fs, "/work/missing.mp4", "/out/missing.mp4")
+		assert.Error(t, err)
+	})
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, fs.MkdirAll("/cache", 0755))
+	require.NoError(t, afero.WriteFile(fs, "/cache/video.mp4.hash", []byte("old"), 0644))
+
+	require.NoError(t, writeFileAtomic(fs, "/cache/video.mp4.hash", []byte("new"), 0644))
+
+	data, err := afero.ReadFile(fs, "/cache/video.mp4.hash")
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+
+	entries, err := afero.ReadDir(fs, "/cache")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary file should be renamed into place")
+}
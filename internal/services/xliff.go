@@ -0,0 +1,190 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// States of the segments of an XLIFF 2.0 file
+const (
+	xliffStateInitial    = "initial"
+	xliffStateTranslated = "translated"
+	xliffStateReviewed   = "reviewed"
+	xliffStateFinal      = "final"
+)
+
+// xliffStateRank orders the states of segments, from initial, the default, to final
+func xliffStateRank(state string) int {
+	switch state {
+	case xliffStateTranslated:
+		return 1
+	case xliffStateReviewed:
+		return 2
+	case xliffStateFinal:
+		return 3
+	}
+	return 0
+}
+
+// xliffDocument is an XLIFF 2.0 file
+type xliffDocument struct {
+	XMLName xml.Name    `xml:"urn:oasis:names:tc:xliff:document:2.0 xliff"`
+	Version string      `xml:"version,attr"`
+	SrcLang string      `xml:"srcLang,attr"`
+	TrgLang string      `xml:"trgLang,attr,omitempty"`
+	Files   []xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	ID    string      `xml:"id,attr"`
+	Units []xliffUnit `xml:"unit"`
+}
+
+type xliffUnit struct {
+	ID    string      `xml:"id,attr"`
+	Name  string      `xml:"name,attr,omitempty"`
+	Notes []xliffNote `xml:"notes>note"`
+	Parts []xliffPart `xml:",any"` // Segments, and the ignorable whitespace between them, in order
+}
+
+type xliffNote struct {
+	Category string `xml:"category,attr,omitempty"`
+	Text     string `xml:",chardata"`
+}
+
+// xliffPart is a segment or an ignorable of a unit, told apart by XMLName
+type xliffPart struct {
+	XMLName xml.Name
+	State   string     `xml:"state,attr,omitempty"`
+	Source  xliffText  `xml:"source"`
+	Target  *xliffText `xml:"target"`
+}
+
+// xliffText is the content of a source or target, which may hold inline markup
+type xliffText struct {
+	Inner string `xml:",innerxml"`
+}
+
+// text returns the text of the content: the text of inline elements such as pc and mrk is kept,
+// and cp elements are replaced by their code point
+func (t xliffText) text() (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader("<text>" + t.Inner + "</text>"))
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return text.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "cp" {
+				continue
+			}
+			for _, attr := range t.Attr {
+				if attr.Name.Local == "hex" {
+					code, err := strconv.ParseUint(attr.Value, 16, 32)
+					if err != nil {
+						return "", fmt.Errorf("invalid code point %q", attr.Value)
+					}
+					text.WriteRune(rune(code))
+				}
+			}
+		case xml.CharData:
+			text.Write(t)
+		}
+	}
+}
+
+// newXLIFFText returns the content of text, escaped
+func newXLIFFText(text string) (*xliffText, error) {
+	var escaped bytes.Buffer
+	if err := xml.EscapeText(&escaped, []byte(text)); err != nil {
+		return nil, err
+	}
+	return &xliffText{Inner: escaped.String()}, nil
+}
+
+// newXLIFFSegment returns a segment translating source to target, without a target when it is empty
+func newXLIFFSegment(source, target, state string) (xliffPart, error) {
+	segment := xliffPart{XMLName: xml.Name{Local: "segment"}, State: state}
+	sourceText, err := newXLIFFText(source)
+	if err != nil {
+		return segment, err
+	}
+	segment.Source = *sourceText
+	if target != "" {
+		if segment.Target, err = newXLIFFText(target); err != nil {
+			return segment, err
+		}
+	}
+	return segment, nil
+}
+
+// texts returns the source and target of the unit, joining its segments, and the least advanced
+// state of its segments with a target, initial when none has one
+func (u xliffUnit) texts() (string, string, string, error) {
+	var source, target strings.Builder
+	state := ""
+	for _, part := range u.Parts {
+		if part.XMLName.Local != "segment" && part.XMLName.Local != "ignorable" {
+			continue
+		}
+		text, err := part.Source.text()
+		if err != nil {
+			return "", "", "", fmt.Errorf("unit %s: %w", u.ID, err)
+		}
+		source.WriteString(text)
+		if part.Target == nil {
+			if part.XMLName.Local == "ignorable" {
+				target.WriteString(text) // Whitespace between segments is kept as is
+			}
+			continue
+		}
+		text, err = part.Target.text()
+		if err != nil {
+			return "", "", "", fmt.Errorf("unit %s: %w", u.ID, err)
+		}
+		target.WriteString(text)
+		if part.XMLName.Local == "segment" && (state == "" || xliffStateRank(part.State) < xliffStateRank(state)) {
+			state = part.State
+		}
+	}
+	if xliffStateRank(state) == 0 {
+		state = xliffStateInitial
+	}
+	return source.String(), target.String(), state, nil
+}
+
+// readXLIFF parses an XLIFF 2 file
+func readXLIFF(r io.Reader) (*xliffDocument, error) {
+	var doc xliffDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse XLIFF 2.0: %w", err)
+	}
+	if !strings.HasPrefix(doc.Version, "2.") {
+		return nil, fmt.Errorf("unsupported XLIFF version %q, expected 2.0", doc.Version)
+	}
+	return &doc, nil
+}
+
+// writeXLIFF writes doc as an XLIFF 2.0 file
+func writeXLIFF(w io.Writer, doc *xliffDocument) error {
+	doc.Version = "2.0"
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write XLIFF: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,207 @@
+package timeline
+
+import "fmt"
+
+// MetadataTitle is the segment metadata key of the chapter title of the segment
+const MetadataTitle = "title"
+
+// Timeline describes a video as an ordered list of segments.
+// It is built by the creator and rendered by the video generator, so every
+// per-slide setting lives here rather than on the renderer.
+type Timeline struct {
+	Segments []Segment
+	Metadata map[string]string
+}
+
+// Segment is one slide of the timeline
+type Segment struct {
+	// Visual is the image or video shown during the segment
+	Visual VisualSource
+
+	// Audio lists the tracks mixed together for the segment. A segment without audio is silent,
+	// and needs a duration or a minimum duration.
+	Audio []AudioTrack
+
+	// In and Out trim a video source, in seconds. Out of 0 means the end of the source.
+	In  float64
+	Out float64
+
+	// Duration forces the segment length in seconds, padding the audio with silence or cutting it.
+	// 0 derives it from the sources.
+	Duration float64
+
+	// PadEnd holds the segment past the end of its audio, in seconds
+	PadEnd float64
+
+	// MinDuration is the shortest length of the segment in seconds, padded with silence
+	MinDuration float64
+
+	// TransitionOut is the transition into the next segment, nil for a hard cut
+	TransitionOut *Transition
+
+	// Overlays are images composited on top of the visual
+	Overlays []Overlay
+
+	Metadata map[string]string
+}
+
+// VisualSource is the image or video file shown by a segment
+type VisualSource struct {
+	Path string
+}
+
+// AudioTrack is an audio file played during a segment
+type AudioTrack struct {
+	Path string
+
+	// Offset delays the start of the track, in seconds
+	Offset float64
+}
+
+// Transition describes the effect between two segments
+type Transition struct {
+	Type     string
+	Duration float64
+}
+
+// Overlay is an image composited on top of a segment
+type Overlay struct {
+	Path string
+
+	// X and Y position the overlay, as ffmpeg overlay expressions (e.g. "W-w-20")
+	X string
+	Y string
+
+	// Start and End restrict the overlay to a time window, in seconds. End of 0 means the end of the segment.
+	Start float64
+	End   float64
+}
+
+// FromSlides builds a timeline with one segment per slide and its narration.
+// A slide whose audio path is empty has no narration and gets a silent segment.
+func FromSlides(slides, audioPaths []string) (Timeline, error) {
+	if len(slides) != len(audioPaths) {
+		return Timeline{}, fmt.Errorf("slides and audio count mismatch: %d vs %d", len(slides), len(audioPaths))
+	}
+
+	segments := make([]Segment, len(slides))
+	for i := range slides {
+		segments[i] = Segment{Visual: VisualSource{Path: slides[i]}}
+		if audioPaths[i] != "" {
+			segments[i].Audio = []AudioTrack{{Path: audioPaths[i]}}
+		}
+	}
+
+	return Timeline{Segments: segments}, nil
+}
+
+// Validate checks that the timeline can be rendered
+func (t Timeline) Validate() error {
+	if len(t.Segments) == 0 {
+		return fmt.Errorf("timeline has no segments")
+	}
+
+	for i, seg := range t.Segments {
+		if err := seg.Validate(); err != nil {
+			return fmt.Errorf("segment %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that the segment can be rendered
+func (s Segment) Validate() error {
+	if s.Visual.Path == "" {
+		return fmt.Errorf("missing visual source")
+	}
+	if len(s.Audio) == 0 && s.Duration == 0 && s.MinDuration == 0 {
+		return fmt.Errorf("missing audio track: a silent segment needs a duration or minimum duration")
+	}
+	for _, track := range s.Audio {
+		if track.Path == "" {
+			return fmt.Errorf("audio track has no path")
+		}
+		if track.Offset < 0 {
+			return fmt.Errorf("audio offset must be non-negative, got %f", track.Offset)
+		}
+	}
+	if s.In < 0 {
+		return fmt.Errorf("in point must be non-negative, got %f", s.In)
+	}
+	if s.Out != 0 && s.Out <= s.In {
+		return fmt.Errorf("out point %f must be after in point %f", s.Out, s.In)
+	}
+	if s.Duration < 0 {
+		return fmt.Errorf("duration must be non-negative, got %f", s.Duration)
+	}
+	if s.PadEnd < 0 {
+		return fmt.Errorf("end padding must be non-negative, got %f", s.PadEnd)
+	}
+	if s.MinDuration < 0 {
+		return fmt.Errorf("minimum duration must be non-negative, got %f", s.MinDuration)
+	}
+	for _, overlay := range s.Overlays {
+		if overlay.Path == "" {
+			return fmt.Errorf("overlay has no path")
+		}
+		if overlay.End != 0 && overlay.End <= overlay.Start {
+			return fmt.Errorf("overlay end %f must be after start %f", overlay.End, overlay.Start)
+		}
+	}
+	return nil
+}
+
+// Visuals returns the visual source path of every segment
+func (t Timeline) Visuals() []string {
+	paths := make([]string, len(t.Segments))
+	for i, seg := range t.Segments {
+		paths[i] = seg.Visual.Path
+	}
+	return paths
+}
+
+// HasTransitions reports whether any segment transitions into the next one
+func (t Timeline) HasTransitions() bool {
+	for i := 0; i < len(t.Segments)-1; i++ {
+		if t.Segments[i].TransitionOut != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// HasChapters reports whether any segment has a chapter title
+func (t Timeline) HasChapters() bool {
+	for _, seg := range t.Segments {
+		if seg.Title() != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Title returns the chapter title of the segment, "" when it starts no chapter
+func (s Segment) Title() string {
+	return s.Metadata[MetadataTitle]
+}
+
+// SetTitle sets the chapter title of the segment, removing it when empty
+func (s *Segment) SetTitle(title string) {
+	if title == "" {
+		delete(s.Metadata, MetadataTitle)
+		return
+	}
+	if s.Metadata == nil {
+		s.Metadata = make(map[string]string)
+	}
+	s.Metadata[MetadataTitle] = title
+}
+
+// IsSimple reports whether the segment uses a single untrimmed, undelayed
+// audio track and no overlays, i.e. only the visual and narration matter
+func (s Segment) IsSimple() bool {
+	return len(s.Audio) == 1 && s.Audio[0].Offset == 0 &&
+		s.In == 0 && s.Out == 0 && s.Duration == 0 && s.PadEnd == 0 && s.MinDuration == 0 &&
+		len(s.Overlays) == 0
+}
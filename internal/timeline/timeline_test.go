@@ -0,0 +1,128 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromSlides(t *testing.T) {
+	t.Run("one segment per slide", func(t *testing.T) {
+		tl, err := FromSlides([]string{"/s/1.png", "/s/2.mp4"}, []string{"/a/0.mp3", "/a/1.mp3"})
+		require.NoError(t, err)
+
+		require.Len(t, tl.Segments, 2)
+		assert.Equal(t, "/s/1.png", tl.Segments[0].Visual.Path)
+		assert.Equal(t, []AudioTrack{{Path: "/a/1.mp3"}}, tl.Segments[1].Audio)
+		assert.Nil(t, tl.Segments[0].TransitionOut)
+		assert.Equal(t, []string{"/s/1.png", "/s/2.mp4"}, tl.Visuals())
+	})
+
+	t.Run("slide without narration", func(t *testing.T) {
+		tl, err := FromSlides([]string{"/s/1.png"}, []string{""})
+		require.NoError(t, err)
+		assert.Empty(t, tl.Segments[0].Audio)
+	})
+
+	t.Run("count mismatch", func(t *testing.T) {
+		_, err := FromSlides([]string{"/s/1.png"}, []string{"/a/0.mp3", "/a/1.mp3"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "slides and audio count mismatch")
+	})
+}
+
+func TestTimeline_Validate(t *testing.T) {
+	valid := Segment{
+		Visual: VisualSource{Path: "/s/1.png"},
+		Audio:  []AudioTrack{{Path: "/a/0.mp3"}},
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(seg *Segment)
+		wantErr string
+	}{
+		{name: "valid segment", modify: func(seg *Segment) {}},
+		{name: "missing visual", modify: func(seg *Segment) { seg.Visual.Path = "" }, wantErr: "missing visual source"},
+		{name: "missing audio", modify: func(seg *Segment) { seg.Audio = nil }, wantErr: "missing audio track"},
+		{name: "silent with duration", modify: func(seg *Segment) { seg.Audio = nil; seg.MinDuration = 3 }},
+		{name: "negative offset", modify: func(seg *Segment) { seg.Audio[0].Offset = -1 }, wantErr: "audio offset"},
+		{name: "out before in", modify: func(seg *Segment) { seg.In, seg.Out = 3, 2 }, wantErr: "out point"},
+		{name: "negative duration", modify: func(seg *Segment) { seg.Duration = -1 }, wantErr: "duration"},
+		{name: "negative end padding", modify: func(seg *Segment) { seg.PadEnd = -1 }, wantErr: "end padding"},
+		{name: "negative minimum duration", modify: func(seg *Segment) { seg.MinDuration = -1 }, wantErr: "minimum duration"},
+		{name: "overlay without path", modify: func(seg *Segment) { seg.Overlays = []Overlay{{}} }, wantErr: "overlay has no path"},
+		{name: "overlay window reversed", modify: func(seg *Segment) {
+			seg.Overlays = []Overlay{{Path: "/o.png", Start: 2, End: 1}}
+		}, wantErr: "overlay end"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seg := valid
+			seg.Audio = append([]AudioTrack(nil), valid.Audio...)
+			tt.modify(&seg)
+
+			err := Timeline{Segments: []Segment{seg}}.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "segment 0")
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	t.Run("empty timeline", func(t *testing.T) {
+		err := Timeline{}.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no segments")
+	})
+}
+
+func TestTimeline_HasTransitions(t *testing.T) {
+	tl, err := FromSlides([]string{"/s/1.png", "/s/2.png"}, []string{"/a/0.mp3", "/a/1.mp3"})
+	require.NoError(t, err)
+	assert.False(t, tl.HasTransitions())
+
+	// A transition out of the last segment leads nowhere
+	tl.Segments[1].TransitionOut = &Transition{Type: "fade", Duration: 0.5}
+	assert.False(t, tl.HasTransitions())
+
+	tl.Segments[0].TransitionOut = &Transition{Type: "fade", Duration: 0.5}
+	assert.True(t, tl.HasTransitions())
+}
+
+func TestSegment_Title(t *testing.T) {
+	tl, err := FromSlides([]string{"/s/1.png", "/s/2.png"}, []string{"/a/0.mp3", "/a/1.mp3"})
+	require.NoError(t, err)
+	assert.False(t, tl.HasChapters())
+
+	tl.Segments[1].SetTitle("Summary")
+	assert.Equal(t, "Summary", tl.Segments[1].Title())
+	assert.True(t, tl.HasChapters())
+	assert.True(t, tl.Segments[1].IsSimple(), "titles do not affect the segment itself")
+
+	tl.Segments[1].SetTitle("")
+	assert.False(t, tl.HasChapters())
+}
+
+func TestSegment_IsSimple(t *testing.T) {
+	seg := Segment{
+		Visual: VisualSource{Path: "/s/1.png"},
+		Audio:  []AudioTrack{{Path: "/a/0.mp3"}},
+	}
+	assert.True(t, seg.IsSimple())
+
+	seg.TransitionOut = &Transition{Type: "fade", Duration: 0.5}
+	assert.True(t, seg.IsSimple(), "transitions do not affect the segment itself")
+
+	padded := seg
+	padded.PadEnd = 1
+	assert.False(t, padded.IsSimple())
+
+	seg.Audio = append(seg.Audio, AudioTrack{Path: "/a/music.mp3", Offset: 1})
+	assert.False(t, seg.IsSimple())
+}
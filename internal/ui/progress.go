@@ -16,7 +16,10 @@ type ProgressModel struct {
 	width        int
 	height       int
 	quitting     bool
+	interrupted  bool
 	startTime    time.Time
+	run          int    // number of the current watch run, 0 outside watch mode
+	status       string // what the watcher is doing, shown under the title
 }
 
 // Stage represents a stage in the video creation process
@@ -100,18 +103,23 @@ var (
 // NewProgressModel creates a new progress model
 func NewProgressModel() *ProgressModel {
 	return &ProgressModel{
-		stages: []Stage{
-			{Name: "Loading", Status: StatusPending},
-			{Name: "Translation", Status: StatusPending},
-			{Name: "Audio Generation", Status: StatusPending},
-			{Name: "Video Assembly", Status: StatusPending},
-		},
+		stages:    newStages(),
 		width:     80,
 		height:    24,
 		startTime: time.Now(),
 	}
 }
 
+// newStages returns the stages of a run, all pending
+func newStages() []Stage {
+	return []Stage{
+		{Name: "Loading", Status: StatusPending},
+		{Name: "Translation", Status: StatusPending},
+		{Name: "Audio Generation", Status: StatusPending},
+		{Name: "Video Assembly", Status: StatusPending},
+	}
+}
+
 // Init initializes the model
 func (m *ProgressModel) Init() tea.Cmd {
 	return nil
@@ -124,6 +132,7 @@ func (m *ProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "q", "ctrl+c":
 			m.quitting = true
+			m.interrupted = true
 			return m, tea.Quit
 		}
 
@@ -137,6 +146,16 @@ func (m *ProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case StageCompleteMsg:
 		m.completeStage(msg)
 
+	case RunStartMsg:
+		m.stages = newStages()
+		m.currentStage = 0
+		m.startTime = time.Now()
+		m.run = msg.Run
+		m.status = msg.Reason
+
+	case WatchStatusMsg:
+		m.status = msg.Message
+
 	case CompleteMsg:
 		m.quitting = true
 		return m, tea.Quit
@@ -145,6 +164,12 @@ func (m *ProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// Interrupted reports whether the user quit the UI before the run completed.
+// The terminal is in raw mode while the UI runs, so Ctrl-C arrives here rather than as a signal.
+func (m *ProgressModel) Interrupted() bool {
+	return m.interrupted
+}
+
 // View renders the UI
 func (m *ProgressModel) View() string {
 	if m.quitting {
@@ -156,8 +181,15 @@ func (m *ProgressModel) View() string {
 	// Title
 	elapsed := time.Since(m.startTime).Round(time.Second)
 	title := fmt.Sprintf("GoCreator - Video Generation (Elapsed: %s)", elapsed)
+	if m.run > 0 {
+		title = fmt.Sprintf("GoCreator - Watching, run #%d (Elapsed: %s)", m.run, elapsed)
+	}
 	b.WriteString(titleStyle.Render(title))
 	b.WriteString("\n\n")
+	if m.status != "" {
+		b.WriteString(stageStyle.Render(m.status))
+		b.WriteString("\n")
+	}
 
 	// Stages
 	for i, stage := range m.stages {
@@ -172,7 +204,11 @@ func (m *ProgressModel) View() string {
 	b.WriteString("\n\n")
 
 	// Footer
-	b.WriteString("Press q to quit (will not stop video generation)\n")
+	if m.run > 0 {
+		b.WriteString("Press q to stop watching\n")
+	} else {
+		b.WriteString("Press q to quit (will not stop video generation)\n")
+	}
 
 	return b.String()
 }
@@ -359,5 +395,16 @@ type StageCompleteMsg struct {
 	Message   string
 }
 
+// RunStartMsg resets the stages for a new run of watch mode
+type RunStartMsg struct {
+	Run    int
+	Reason string
+}
+
+// WatchStatusMsg shows what watch mode is doing between runs
+type WatchStatusMsg struct {
+	Message string
+}
+
 // CompleteMsg signals completion
 type CompleteMsg struct{}
@@ -149,3 +149,32 @@ func TestProgressModel_Update_KeyMsg(t *testing.T) {
 	_, cmd := model.Update(keyMsg)
 	assert.NotNil(t, cmd)
 }
+
+func TestProgressModel_Interrupted(t *testing.T) {
+	model := NewProgressModel()
+	assert.False(t, model.Interrupted())
+
+	// Completing the run quits without interrupting it
+	model.Update(CompleteMsg{})
+	assert.False(t, model.Interrupted())
+
+	model.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	assert.True(t, model.Interrupted())
+}
+
+func TestProgressModel_Update_RunStartMsg(t *testing.T) {
+	model := NewProgressModel()
+	model.Update(StageCompleteMsg{StageName: "Loading", Message: "Loaded 3 slides"})
+	model.Update(WatchStatusMsg{Message: "Waiting for changes"})
+	assert.Contains(t, model.View(), "Waiting for changes")
+
+	// A new watch run starts from pending stages
+	model.Update(RunStartMsg{Run: 2, Reason: "Changed: data/texts.txt"})
+	assert.Equal(t, StatusPending, model.stages[0].Status)
+	assert.Empty(t, model.stages[0].Message)
+
+	view := model.View()
+	assert.Contains(t, view, "run #2")
+	assert.Contains(t, view, "Changed: data/texts.txt")
+	assert.Contains(t, view, "Press q to stop watching")
+}